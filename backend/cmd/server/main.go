@@ -1,18 +1,27 @@
 // main.go は家計簿APIサーバーのエントリーポイントです。
 // Echoサーバーを起動し、CORSを設定してフロントエンドからのリクエストを受け付けます。
 // 環境変数 DATABASE_URL が設定されている場合は PostgreSQL を使用します。
+// 収支の添付ファイルは環境変数 ATTACHMENT_DIR（未設定時は data/attachments）のディレクトリに保存します。
+// 削除した収支はゴミ箱に移し、環境変数 TRASH_RETENTION_DAYS（未設定時は30）日を過ぎると完全に削除します。
+// ヘルスチェックとユーザー登録・ログイン以外の /api はログインが必要です。
+// 収支・カテゴリ・予算・定期収支・仕分けルールはリクエストで指定した（なければユーザーがいま利用している）家計簿のものを扱い、
+// 変更には家計簿での editor 以上の役割が必要です。
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"kakeibo-app/backend/internal/domain"
 	"kakeibo-app/backend/internal/handler"
 	"kakeibo-app/backend/internal/repository"
+	"kakeibo-app/backend/internal/scheduler"
+	"kakeibo-app/backend/internal/storage"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -36,45 +45,165 @@ func main() {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     corsOrigins,
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, handler.HouseholdIdHeader},
 		AllowCredentials: true,
 	}))
 
+	// /api/households/{id}/… で家計簿を指定したリクエストを /api/… にルーティング
+	e.Pre(handler.SelectHouseholdByPath())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
 	var repo repository.TransactionRepository
+	var budgetRepo repository.BudgetRepository
+	var recurringRepo repository.RecurringRuleRepository
+	var ruleRepo repository.CategoryRuleRepository
+	var userRepo repository.UserRepository
+	var householdRepo repository.HouseholdRepository
 	useMemory := os.Getenv("DATABASE_URL") == ""
 	if useMemory {
 		repo = repository.NewTransactionRepository()
+		budgetRepo = repository.NewBudgetRepository()
+		recurringRepo = repository.NewRecurringRuleRepository()
+		ruleRepo = repository.NewCategoryRuleRepository()
+		userRepo = repository.NewUserRepository()
+		householdRepo = repository.NewHouseholdRepository()
+		repository.LinkMemoryRepositories(repo, recurringRepo, ruleRepo, budgetRepo)
 		log.Println("メモリストアを使用しています（DATABASE_URL 未設定）")
 	} else {
-		var err error
-		repo, err = repository.NewPostgresTransactionRepository(os.Getenv("DATABASE_URL"))
+		db, err := repository.OpenPostgres(os.Getenv("DATABASE_URL"))
 		if err != nil {
 			log.Fatalf("PostgreSQL接続失敗: %v", err)
 		}
+		defer db.Close()
+		repo = repository.NewPostgresTransactionRepository(db)
+		budgetRepo = repository.NewPostgresBudgetRepository(db)
+		recurringRepo = repository.NewPostgresRecurringRuleRepository(db)
+		ruleRepo = repository.NewPostgresCategoryRuleRepository(db)
+		userRepo = repository.NewPostgresUserRepository(db)
+		householdRepo = repository.NewPostgresHouseholdRepository(db)
 		log.Println("PostgreSQL に接続しました")
 	}
 
-	th := handler.NewTransactionHandler(repo)
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "data/attachments" // 開発用デフォルト
+	}
+	blobs := storage.NewLocalBlobStore(attachmentDir)
+
+	retentionDays := domain.DefaultTrashRetentionDays
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatalf("TRASH_RETENTION_DAYS は1以上の整数で指定してください: %q", v)
+		}
+		retentionDays = n
+	}
+
+	th := handler.NewTransactionHandler(repo, ruleRepo)
+	ch := handler.NewCategoryHandler(repo, recurringRepo)
+	bh := handler.NewBudgetHandler(budgetRepo, repo)
+	rh := handler.NewRecurringHandler(recurringRepo, repo)
+	ah := handler.NewAccountHandler(repo)
+	tgh := handler.NewTagHandler(repo)
+	ih := handler.NewImportHandler(repo, ruleRepo)
+	rlh := handler.NewRuleHandler(ruleRepo, repo)
+	pyh := handler.NewPayeeHandler(repo)
+	ath := handler.NewAttachmentHandler(repo, blobs)
+	ith := handler.NewItemHandler(repo)
+	trh := handler.NewTrashHandler(repo, retentionDays)
+	uh := handler.NewAuthHandler(userRepo, householdRepo, repo, budgetRepo, recurringRepo, ruleRepo)
+	hh := handler.NewHouseholdHandler(householdRepo, userRepo, repo)
 
-	e.GET("/api/categories", th.GetCategories)
-	e.GET("/api/transactions", th.GetTransactions)
-	e.POST("/api/transactions", th.CreateTransaction)
-	e.PUT("/api/transactions/:id", th.UpdateTransaction)
-	e.DELETE("/api/transactions/:id", th.DeleteTransaction)
+	// ログイン不要
+	e.POST("/api/auth/register", uh.Register)
+	e.POST("/api/auth/login", uh.Login)
 	e.GET("/api/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	})
 
-	// メモリストア時のみサンプルデータを投入
+	// ログイン必須（参加している家計簿がなくても使える）
+	api := e.Group("/api", handler.RequireAuth(userRepo))
+	api.POST("/auth/logout", uh.Logout)
+	api.GET("/auth/me", uh.Me)
+	api.GET("/households", hh.GetHouseholds)
+	api.POST("/households", hh.CreateHousehold)
+	api.PUT("/households/current", hh.SelectHousehold)
+	api.POST("/invitations/accept", hh.AcceptInvitation)
+
+	// 家計簿のメンバーであることが必須（収支・カテゴリ・予算・定期収支は対象の家計簿のものだけを扱う）
+	// 対象の家計簿は /api/households/{id}/… のパスか X-Household-Id ヘッダーで指定し、なければいま利用している家計簿
+	book := api.Group("", handler.RequireMembership(householdRepo, userRepo))
+	editor := handler.RequireRole(domain.RoleEditor)
+	owner := handler.RequireRole(domain.RoleOwner)
+	book.GET("/household", hh.GetHousehold)
+	book.PUT("/household", hh.UpdateHousehold, owner)
+	book.PUT("/household/members/:userId", hh.UpdateMember, owner)
+	book.DELETE("/household/members/:userId", hh.RemoveMember) // 自分が抜ける場合は owner 以外も可
+	book.GET("/household/invitations", hh.GetInvitations, owner)
+	book.POST("/household/invitations", hh.CreateInvitation, owner)
+	book.DELETE("/household/invitations/:id", hh.DeleteInvitation, owner)
+	book.GET("/categories", ch.GetCategories)
+	book.POST("/categories", ch.CreateCategory, editor)
+	book.PUT("/categories/order", ch.ReorderCategories, editor)
+	book.PUT("/categories/:id", ch.UpdateCategory, editor)
+	book.DELETE("/categories/:id", ch.DeleteCategory, editor)
+	book.GET("/accounts", ah.GetAccounts)
+	book.POST("/accounts", ah.CreateAccount, editor)
+	book.PUT("/accounts/:id", ah.UpdateAccount, editor)
+	book.DELETE("/accounts/:id", ah.DeleteAccount, editor)
+	book.GET("/transactions", th.GetTransactions)
+	book.GET("/transactions/search", th.SearchTransactions)
+	book.GET("/export", th.ExportTransactions)
+	book.POST("/transactions", th.CreateTransaction, editor)
+	book.PUT("/transactions/:id", th.UpdateTransaction, editor)
+	book.DELETE("/transactions/:id", th.DeleteTransaction, editor)
+	book.POST("/transactions/:id/restore", trh.RestoreTransaction, editor)
+	book.GET("/trash", trh.GetTrash)
+	book.GET("/transactions/:id/attachments", ath.GetAttachments)
+	book.POST("/transactions/:id/attachments", ath.UploadAttachment, editor)
+	book.GET("/attachments/:id", ath.DownloadAttachment)
+	book.GET("/attachments/:id/thumbnail", ath.GetThumbnail)
+	book.DELETE("/attachments/:id", ath.DeleteAttachment, editor)
+	book.GET("/summary/monthly", th.GetMonthlySummary)
+	book.GET("/tags", tgh.GetTags)
+	book.GET("/reports/tags", tgh.GetTagReport)
+	book.POST("/import/csv", ih.ImportCSV, editor)
+	book.POST("/import/zaim", ih.ImportZaim, editor)
+	book.POST("/import/moneyforward", ih.ImportMoneyForward, editor)
+	book.POST("/import/ledger", ih.ImportLedger, editor)
+	book.GET("/import/mappings/:source", ih.GetCategoryMappings)
+	book.PUT("/import/mappings/:source", ih.UpdateCategoryMappings, editor)
+	book.GET("/rules", rlh.GetRules)
+	book.POST("/rules", rlh.CreateRule, editor)
+	book.POST("/rules/test", rlh.TestRule)
+	book.POST("/rules/apply", rlh.ApplyRules, editor)
+	book.PUT("/rules/:id", rlh.UpdateRule, editor)
+	book.DELETE("/rules/:id", rlh.DeleteRule, editor)
+	book.GET("/payees", pyh.GetPayees)
+	book.PUT("/payees/:id", pyh.UpdatePayee, editor)
+	book.POST("/payees/:id/merge", pyh.MergePayees, editor)
+	book.GET("/reports/payees", pyh.GetPayeeReport)
+	book.GET("/reports/prices", ith.GetPriceHistory)
+	book.GET("/budgets", bh.GetBudgets)
+	book.GET("/budgets/status", bh.GetBudgetStatus)
+	book.POST("/budgets", bh.CreateBudget, editor)
+	book.PUT("/budgets/:id", bh.UpdateBudget, editor)
+	book.DELETE("/budgets/:id", bh.DeleteBudget, editor)
+	book.GET("/recurring", rh.GetRecurringRules)
+	book.GET("/recurring/upcoming", rh.GetUpcoming)
+	book.POST("/recurring", rh.CreateRecurringRule, editor)
+	book.PUT("/recurring/:id", rh.UpdateRecurringRule, editor)
+	book.DELETE("/recurring/:id", rh.DeleteRecurringRule, editor)
+
+	// メモリストア時のみサンプルデータを投入（最初に登録したユーザーの家計簿のものになる）
 	if useMemory {
-		sampleCategory := domain.Category{ID: 1, Name: "食費"}
+		sampleCategory, _ := repo.FindCategoryById(11) // 食費 > 外食
 		sample := domain.Transaction{
 			Date:       parseDate("2025-01-15"),
 			Type:       "expense",
 			CategoryId: sampleCategory.ID,
+			AccountId:  domain.DefaultAccountId,
 			Amount:     -1500,
 			Memo:       "サンプル：昼食",
 			Category:   sampleCategory,
@@ -82,6 +211,14 @@ func main() {
 		_ = repo.Save(&sample)
 	}
 
+	// 定期収支: 起動時と1時間ごとに予定日が来た収支を登録
+	poster := scheduler.NewRecurringPoster(recurringRepo, repo)
+	go poster.Run(context.Background(), time.Hour)
+
+	// ゴミ箱: 起動時と1時間ごとに保存期間を過ぎた収支を完全に削除
+	purger := scheduler.NewTrashPurger(repo, blobs, time.Duration(retentionDays)*24*time.Hour)
+	go purger.Run(context.Background(), time.Hour)
+
 	addr := ":8080"
 	log.Printf("サーバー起動: http://localhost%s", addr)
 	if err := e.Start(addr); err != nil {
@@ -0,0 +1,55 @@
+package domain
+
+// Account は現金・銀行口座・クレジットカード・電子マネーなど、お金の置き場所を表すドメインモデルです。
+// 残高は開始残高に、その口座の収支の金額（支出は負の値）を足したものです。
+// 口座は家計簿ごとに分かれています（HouseholdId が0のものは家計簿の導入前の口座です）。
+type Account struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`            // "cash" / "bank" / "credit_card" / "e_money"
+	OpeningBalance int    `json:"opening_balance"` // 記録を始めた時点の残高（クレジットカードは未払額を負の値で）
+	DisplayOrder   int    `json:"display_order"`
+	Archived       bool   `json:"archived"`
+	HouseholdId    int    `json:"-"`
+}
+
+// 口座の種別です。
+const (
+	AccountKindCash       = "cash"
+	AccountKindBank       = "bank"
+	AccountKindCreditCard = "credit_card"
+	AccountKindEMoney     = "e_money"
+)
+
+// DefaultAccountId は初期データの「現金」口座のIDです。最初に作成された家計簿の口座になります。
+const DefaultAccountId = 1
+
+// DefaultAccount は新しい家計簿に用意する口座を返します。
+// account_id を省略した収支は、家計簿の口座のうち表示順で先頭のアーカイブしていない口座に登録します。
+func DefaultAccount() Account {
+	return Account{Name: "現金", Kind: AccountKindCash, DisplayOrder: 1}
+}
+
+// IsValidAccountKind は kind が口座の種別として有効かを判定します。
+func IsValidAccountKind(kind string) bool {
+	switch kind {
+	case AccountKindCash, AccountKindBank, AccountKindCreditCard, AccountKindEMoney:
+		return true
+	}
+	return false
+}
+
+// AccountBalance は口座と現在の残高です。
+type AccountBalance struct {
+	Account
+	Balance int `json:"balance"`
+}
+
+// AccountRequest は口座の作成・更新時のリクエストボディです。
+type AccountRequest struct {
+	Name           string `json:"name"`
+	Kind           string `json:"kind"`
+	OpeningBalance int    `json:"opening_balance"`
+	DisplayOrder   int    `json:"display_order"` // 0 の場合は末尾に追加（作成時）
+	Archived       bool   `json:"archived"`
+}
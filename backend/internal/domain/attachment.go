@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+// Attachment は収支に添付したレシート・書類（画像またはPDF）です。
+// ファイルの中身は BlobKey をキーにしてファイル置き場（storage.BlobStore）に保存し、ここではその情報だけを持ちます。
+// 画像の場合は縮小画像（サムネイル）を ThumbnailKey() に保存します。収支を削除すると添付ファイルも削除します。
+type Attachment struct {
+	ID            int       `json:"id"`
+	TransactionId int       `json:"transaction_id"`
+	FileName      string    `json:"file_name"`    // アップロード時のファイル名
+	ContentType   string    `json:"content_type"` // ファイルの中身から判定したMIMEタイプ
+	Size          int64     `json:"size"`         // バイト数
+	BlobKey       string    `json:"-"`
+	HasThumbnail  bool      `json:"has_thumbnail"`
+	CreatedBy     int       `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ThumbnailKey はサムネイルを保存するキーです。
+func (a Attachment) ThumbnailKey() string {
+	return a.BlobKey + ".thumb.jpg"
+}
+
+// MaxAttachmentSize は添付ファイルの大きさの上限（バイト）です。
+const MaxAttachmentSize = 10 << 20
+
+// MaxAttachmentFileNameLength は添付ファイルのファイル名の長さ（文字数）の上限です。
+const MaxAttachmentFileNameLength = 100
+
+// AttachmentContentTypes は添付できるファイルのMIMEタイプです。
+var AttachmentContentTypes = []string{
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+}
+
+// IsAttachmentContentType は contentType のファイルを添付できるかを判定します。
+func IsAttachmentContentType(contentType string) bool {
+	for _, t := range AttachmentContentTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
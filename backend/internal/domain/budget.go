@@ -0,0 +1,77 @@
+package domain
+
+import "time"
+
+// Budget はカテゴリごとの月次予算を表すドメインモデルです。
+// Month は "2006-01" 形式で、家計簿ごとに同じカテゴリ・同じ月の予算は1件だけです。
+type Budget struct {
+	ID          int       `json:"id"`
+	CategoryId  int       `json:"category_id"`
+	Month       string    `json:"month"`
+	Amount      int       `json:"amount"`
+	CreatedAt   time.Time `json:"created_at"`
+	HouseholdId int       `json:"-"`
+}
+
+// BudgetRequest は予算の登録・更新時のリクエストボディです。
+type BudgetRequest struct {
+	CategoryId int    `json:"category_id"`
+	Month      string `json:"month"` // "2006-01" 形式
+	Amount     int    `json:"amount"`
+}
+
+// BudgetStatus は1件の予算に対する実績です。
+// Actual は子カテゴリの支出を含む実績で、Remaining が負の場合は予算超過です。
+// Projected は月末時点の支出見込みで、当月は経過日数からの日割り、過去の月は実績そのものです。
+type BudgetStatus struct {
+	BudgetId      int     `json:"budget_id"`
+	CategoryId    int     `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	Month         string  `json:"month"`
+	Budget        int     `json:"budget"`
+	Actual        int     `json:"actual"`
+	Remaining     int     `json:"remaining"`
+	PercentUsed   float64 `json:"percent_used"`
+	Projected     int     `json:"projected"`
+	OverBudget    bool    `json:"over_budget"`
+	ProjectedOver bool    `json:"projected_over"`
+}
+
+// NewBudgetStatus は予算と支出実績から BudgetStatus を組み立てます。
+// asOf は見込みの計算基準日で、予算の月の途中なら経過日数から日割りで見込み、それ以外は実績を見込みとします。
+func NewBudgetStatus(b Budget, categoryName string, actual int, asOf time.Time) BudgetStatus {
+	status := BudgetStatus{
+		BudgetId:     b.ID,
+		CategoryId:   b.CategoryId,
+		CategoryName: categoryName,
+		Month:        b.Month,
+		Budget:       b.Amount,
+		Actual:       actual,
+		Remaining:    b.Amount - actual,
+		OverBudget:   actual > b.Amount,
+		Projected:    actual,
+	}
+	if b.Amount > 0 {
+		// 小数第1位までに丸める
+		status.PercentUsed = float64(actual*1000/b.Amount) / 10
+	}
+
+	start, err := time.Parse("2006-01", b.Month)
+	if err == nil {
+		end := start.AddDate(0, 1, 0)
+		day := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+		if day.Before(end) && !day.Before(start) {
+			daysInMonth := int(end.Sub(start).Hours() / 24)
+			elapsed := day.Day()
+			status.Projected = actual * daysInMonth / elapsed
+		}
+	}
+	status.ProjectedOver = status.Projected > b.Amount
+	return status
+}
+
+// BudgetStatusReport は指定月の予算と実績の一覧です。
+type BudgetStatusReport struct {
+	Month string         `json:"month"`
+	Items []BudgetStatus `json:"items"`
+}
@@ -0,0 +1,188 @@
+package domain
+
+// Category は収支の分類を表すドメインモデルです。
+// Archived のカテゴリは登録フォームに表示しませんが、既存の収支からは参照されたままです。
+// ParentId が0でないカテゴリは子カテゴリ（例: 食費 > 外食）で、階層は2段までです。
+// カテゴリは家計簿ごとに分かれています（HouseholdId が0のものは家計簿の導入前のカテゴリです）。
+type Category struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Kind         string     `json:"kind"` // "income" / "expense" / "both"
+	ParentId     int        `json:"parent_id"`
+	DisplayOrder int        `json:"display_order"`
+	Archived     bool       `json:"archived"`
+	Children     []Category `json:"children,omitempty"`
+	HouseholdId  int        `json:"-"`
+}
+
+// DefaultCategories は新しい家計簿に用意する初期カテゴリを返します。
+// ID と ParentId はこの一覧の中での番号で、保存するときに振り直します。
+func DefaultCategories() []Category {
+	return []Category{
+		{ID: 1, Name: "食費", Kind: CategoryKindExpense, DisplayOrder: 1},
+		{ID: 2, Name: "交通費", Kind: CategoryKindExpense, DisplayOrder: 2},
+		{ID: 3, Name: "住居費", Kind: CategoryKindExpense, DisplayOrder: 3},
+		{ID: 4, Name: "光熱費", Kind: CategoryKindExpense, DisplayOrder: 4},
+		{ID: 5, Name: "通信費", Kind: CategoryKindExpense, DisplayOrder: 5},
+		{ID: 6, Name: "娯楽費", Kind: CategoryKindExpense, DisplayOrder: 6},
+		{ID: 7, Name: "医療費", Kind: CategoryKindExpense, DisplayOrder: 7},
+		{ID: 8, Name: "教育費", Kind: CategoryKindExpense, DisplayOrder: 8},
+		{ID: 9, Name: "その他", Kind: CategoryKindBoth, DisplayOrder: 9},
+		{ID: 10, Name: "給与", Kind: CategoryKindIncome, DisplayOrder: 10},
+		{ID: 11, Name: "外食", Kind: CategoryKindExpense, ParentId: 1, DisplayOrder: 11},
+		{ID: 12, Name: "食材", Kind: CategoryKindExpense, ParentId: 1, DisplayOrder: 12},
+		{ID: 13, Name: "電気", Kind: CategoryKindExpense, ParentId: 4, DisplayOrder: 13},
+		{ID: 14, Name: "ガス", Kind: CategoryKindExpense, ParentId: 4, DisplayOrder: 14},
+		{ID: 15, Name: "水道", Kind: CategoryKindExpense, ParentId: 4, DisplayOrder: 15},
+	}
+}
+
+// カテゴリの種別です。収支の Type と一致するカテゴリ（または both）だけを使えます。
+const (
+	CategoryKindIncome  = "income"
+	CategoryKindExpense = "expense"
+	CategoryKindBoth    = "both"
+)
+
+// IsValidCategoryKind は kind がカテゴリの種別として有効かを判定します。
+func IsValidCategoryKind(kind string) bool {
+	return kind == CategoryKindIncome || kind == CategoryKindExpense || kind == CategoryKindBoth
+}
+
+// AllowsType はカテゴリが収支の種別（"income" / "expense"）に使えるかを判定します。
+func (c Category) AllowsType(transactionType string) bool {
+	return c.Kind == CategoryKindBoth || c.Kind == transactionType
+}
+
+// CreateCategoryRequest はカテゴリ新規作成時のリクエストボディです。
+type CreateCategoryRequest struct {
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`          // 省略時は親カテゴリの種別、親がなければ "both"
+	ParentId     int    `json:"parent_id"`     // 0 の場合は最上位
+	DisplayOrder int    `json:"display_order"` // 0 の場合は末尾に追加
+}
+
+// UpdateCategoryRequest はカテゴリ更新時のリクエストボディです。
+type UpdateCategoryRequest struct {
+	Name         string `json:"name"`
+	Kind         string `json:"kind"`
+	ParentId     int    `json:"parent_id"`
+	DisplayOrder int    `json:"display_order"`
+	Archived     bool   `json:"archived"`
+}
+
+// ReorderCategoriesRequest はカテゴリの表示順を一括で変更するリクエストボディです。
+// IDs の並び順がそのまま表示順になります。
+type ReorderCategoriesRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// BuildCategoryTree は表示順に並んだカテゴリの一覧から、最上位カテゴリの Children に
+// 子カテゴリを入れた木を組み立てます。親が一覧にない子カテゴリは最上位として扱います。
+func BuildCategoryTree(categories []Category) []Category {
+	present := map[int]bool{}
+	for _, c := range categories {
+		present[c.ID] = true
+	}
+
+	children := map[int][]Category{}
+	for _, c := range categories {
+		if c.ParentId != 0 && present[c.ParentId] {
+			children[c.ParentId] = append(children[c.ParentId], c)
+		}
+	}
+
+	tree := []Category{}
+	for _, c := range categories {
+		if c.ParentId != 0 && present[c.ParentId] {
+			continue
+		}
+		c.Children = children[c.ID]
+		tree = append(tree, c)
+	}
+	return tree
+}
+
+// CategoryWithDescendants は id とその子孫カテゴリのIDを返します。
+// 親カテゴリでの絞り込みに子カテゴリの収支を含めるために使います。
+func CategoryWithDescendants(categories []Category, id int) []int {
+	ids := []int{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range categories {
+			if c.ParentId == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// RollUpCategoryTotals はカテゴリ単位の合計を親カテゴリへ集約します。
+// 戻り値は最上位カテゴリの合計（子カテゴリ分を含む）をカテゴリの表示順に並べたもので、
+// 子カテゴリの合計は各要素の Children に入ります。
+// categories にないカテゴリの合計は最上位として末尾に残します。
+func RollUpCategoryTotals(totals []CategoryTotal, categories []Category) []CategoryTotal {
+	byId := map[int]Category{}
+	for _, c := range categories {
+		byId[c.ID] = c
+	}
+	rootOf := func(id int) int {
+		for {
+			c, ok := byId[id]
+			if !ok || c.ParentId == 0 {
+				return id
+			}
+			if _, ok := byId[c.ParentId]; !ok {
+				return id
+			}
+			id = c.ParentId
+		}
+	}
+
+	roots := map[int]*CategoryTotal{}
+	var unknown []int
+	for _, t := range totals {
+		rootId := rootOf(t.CategoryId)
+		root, ok := roots[rootId]
+		if !ok {
+			root = &CategoryTotal{CategoryId: rootId, CategoryName: t.CategoryName}
+			if c, ok := byId[rootId]; ok {
+				root.CategoryName = c.Name
+			} else {
+				unknown = append(unknown, rootId)
+			}
+			roots[rootId] = root
+		}
+		root.Income += t.Income
+		root.Expense += t.Expense
+		root.Count += t.Count
+		if rootId != t.CategoryId {
+			root.Children = append(root.Children, t)
+		}
+	}
+
+	result := []CategoryTotal{}
+	for _, c := range categories {
+		root, ok := roots[c.ID]
+		if !ok {
+			continue
+		}
+		// 子カテゴリの内訳もカテゴリの表示順に並べる
+		ordered := make([]CategoryTotal, 0, len(root.Children))
+		for _, child := range categories {
+			for _, t := range root.Children {
+				if t.CategoryId == child.ID {
+					ordered = append(ordered, t)
+				}
+			}
+		}
+		if len(ordered) > 0 {
+			root.Children = ordered
+		}
+		result = append(result, *root)
+	}
+	for _, id := range unknown {
+		result = append(result, *roots[id])
+	}
+	return result
+}
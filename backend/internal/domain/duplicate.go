@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeMemo は重複の判定で比較に使う形（NFKC 正規化・小文字化・連続する空白を1つに）にメモを変換します。
+// 検索と違って一致した位置を求めないため、半角カナの濁点なども合成した文字列全体で正規化します。
+func NormalizeMemo(memo string) string {
+	return strings.Join(strings.Fields(strings.ToLower(norm.NFKC.String(memo))), " ")
+}
+
+// Fingerprint は重複の判定に使う収支の指紋です。日付・金額（符号付き）・正規化したメモ・口座が同じ収支は同じ指紋になります。
+func Fingerprint(date time.Time, amount int, memo string, accountId int) string {
+	return date.Format("2006-01-02") + "|" + strconv.Itoa(amount) + "|" + strconv.Itoa(accountId) + "|" + NormalizeMemo(memo)
+}
+
+// Fingerprint は収支の重複の判定に使う指紋を返します。
+func (t Transaction) Fingerprint() string {
+	return Fingerprint(t.Date, t.Amount, t.Memo, t.AccountId)
+}
+
+// DuplicateIndex は登録済みの収支を指紋ごとにまとめた索引です。
+// 同じ指紋の収支が複数ある場合は、その件数までを重複とみなします（同じ日に同じ金額の買い物を2回した場合など）。
+// 振替は出金側の行だけを、収入・支出とは別に索引に入れます。
+type DuplicateIndex map[string][]int
+
+// duplicateKey は索引での t の鍵を返します。振替の入金側の行は索引に入れないため "" です。
+func duplicateKey(t Transaction) string {
+	if t.Type != TransactionTypeTransfer {
+		return t.Fingerprint()
+	}
+	if t.Amount > 0 {
+		return ""
+	}
+	return TransactionTypeTransfer + "|" + t.Fingerprint()
+}
+
+// NewDuplicateIndex は transactions の索引を作ります。
+func NewDuplicateIndex(transactions []Transaction) DuplicateIndex {
+	index := DuplicateIndex{}
+	for _, t := range transactions {
+		if key := duplicateKey(t); key != "" {
+			index[key] = append(index[key], t.ID)
+		}
+	}
+	return index
+}
+
+// Take は t と同じ指紋の登録済みの収支のうち、まだ重複として使っていないもののIDを返し、使用済みにします。
+// 見つからない場合は0です。振替は出金側の行で探します。
+func (index DuplicateIndex) Take(t Transaction) int {
+	key := duplicateKey(t)
+	ids := index[key]
+	if key == "" || len(ids) == 0 {
+		return 0
+	}
+	index[key] = ids[1:]
+	return ids[0]
+}
+
+// DuplicateWarning は登録済みの収支と重複する収支を登録しようとした場合のレスポンスです。
+// ?allow_duplicate=true を付けて送り直すと、重複していても登録します。
+type DuplicateWarning struct {
+	Error      string        `json:"error"`
+	Duplicates []Transaction `json:"duplicates"`
+}
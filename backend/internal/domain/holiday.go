@@ -0,0 +1,100 @@
+package domain
+
+import "time"
+
+// IsHoliday は日付が日本の国民の祝日（振替休日・国民の休日を含む）かを判定します。
+// 2007年以降の祝日法に基づき、2000〜2099年の範囲で使う想定です。
+func IsHoliday(d time.Time) bool {
+	d = dateOnly(d)
+	if isNamedHoliday(d) {
+		return true
+	}
+	// 振替休日: 日曜の祝日の後、最初の祝日でない日
+	if d.Year() >= 2007 {
+		for prev := d.AddDate(0, 0, -1); isNamedHoliday(prev); prev = prev.AddDate(0, 0, -1) {
+			if prev.Weekday() == time.Sunday {
+				return true
+			}
+		}
+	}
+	// 国民の休日: 前日と翌日が祝日である平日
+	if d.Weekday() != time.Sunday && isNamedHoliday(d.AddDate(0, 0, -1)) && isNamedHoliday(d.AddDate(0, 0, 1)) {
+		return true
+	}
+	return false
+}
+
+// IsBusinessDay は土日・祝日以外の日かを判定します。
+func IsBusinessDay(d time.Time) bool {
+	wd := d.Weekday()
+	return wd != time.Saturday && wd != time.Sunday && !IsHoliday(d)
+}
+
+// isNamedHoliday は振替休日・国民の休日を除いた、名前のある祝日かを判定します。
+func isNamedHoliday(d time.Time) bool {
+	y, m, day := d.Year(), d.Month(), d.Day()
+
+	switch m {
+	case time.January:
+		return day == 1 || day == nthMonday(y, m, 2) // 元日・成人の日
+	case time.February:
+		return day == 11 || (y >= 2020 && day == 23) // 建国記念の日・天皇誕生日
+	case time.March:
+		return day == vernalEquinoxDay(y) // 春分の日
+	case time.April:
+		return day == 29 || (y == 2019 && day == 30) // 昭和の日・2019年の休日
+	case time.May:
+		return day == 3 || day == 4 || day == 5 || (y == 2019 && (day == 1 || day == 2)) // 憲法記念日・みどりの日・こどもの日・即位の日
+	case time.July:
+		switch y {
+		case 2020:
+			return day == 23 || day == 24 // 海の日・スポーツの日（東京五輪特例）
+		case 2021:
+			return day == 22 || day == 23
+		}
+		return day == nthMonday(y, m, 3) // 海の日
+	case time.August:
+		switch {
+		case y == 2020:
+			return day == 10
+		case y == 2021:
+			return day == 8
+		case y >= 2016:
+			return day == 11 // 山の日
+		}
+	case time.September:
+		return day == nthMonday(y, m, 3) || day == autumnalEquinoxDay(y) // 敬老の日・秋分の日
+	case time.October:
+		if y == 2020 || y == 2021 {
+			return false
+		}
+		return day == nthMonday(y, m, 2) || (y == 2019 && day == 22) // スポーツの日・即位礼正殿の儀
+	case time.November:
+		return day == 3 || day == 23 // 文化の日・勤労感謝の日
+	case time.December:
+		return y <= 2018 && day == 23 // 天皇誕生日（平成）
+	}
+	return false
+}
+
+// nthMonday は y 年 m 月の第 n 月曜日の日にちを返します。
+func nthMonday(y int, m time.Month, n int) int {
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	offset := (int(time.Monday) - int(first) + 7) % 7
+	return 1 + offset + (n-1)*7
+}
+
+// vernalEquinoxDay は春分の日（3月）の日にちを近似式で求めます（1980〜2099年）。
+func vernalEquinoxDay(y int) int {
+	return int(20.8431+0.242194*float64(y-1980)) - (y-1980)/4
+}
+
+// autumnalEquinoxDay は秋分の日（9月）の日にちを近似式で求めます（1980〜2099年）。
+func autumnalEquinoxDay(y int) int {
+	return int(23.2488+0.242194*float64(y-1980)) - (y-1980)/4
+}
+
+// dateOnly は時刻部分を切り捨てた UTC の日付を返します。
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
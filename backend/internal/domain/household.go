@@ -0,0 +1,103 @@
+package domain
+
+import "time"
+
+// Household は家族などで共有する家計簿を表すドメインモデルです。
+// 収支・カテゴリ・予算・定期収支は家計簿ごとに分かれ、メンバーだけが参照・変更できます。
+type Household struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// 家計簿のメンバーの役割です。
+// owner はメンバーの招待・役割の変更もでき、editor は収支などを変更でき、viewer は参照だけができます。
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// roleRanks は役割の強さです。数字が大きいほど多くの操作ができます。
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+
+// IsValidRole は role がメンバーの役割として有効かを判定します。
+func IsValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAtLeast は role が min 以上の操作をできる役割かを判定します。
+func RoleAtLeast(role, min string) bool {
+	return IsValidRole(role) && roleRanks[role] >= roleRanks[min]
+}
+
+// HouseholdMember は家計簿のメンバーです。Email と Name は一覧表示用にユーザーから引き継ぎます。
+type HouseholdMember struct {
+	HouseholdId int       `json:"household_id"`
+	UserId      int       `json:"user_id"`
+	Email       string    `json:"email"`
+	Name        string    `json:"name"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// HouseholdMembership はユーザーが参加している家計簿とその役割です。
+type HouseholdMembership struct {
+	Household
+	Role string `json:"role"`
+}
+
+// HouseholdDetail は利用中の家計簿と、その家計簿での自分の役割・メンバー一覧です。
+type HouseholdDetail struct {
+	Household
+	Role    string            `json:"role"`
+	Members []HouseholdMember `json:"members"`
+}
+
+// Invitation は家計簿への招待です。
+// 招待されたメールアドレスのユーザーが、Token を使って参加します。
+// TokenHash はトークンの SHA-256（16進文字列）で、トークンは作成時のレスポンスでだけ返します。
+type Invitation struct {
+	ID          int       `json:"id"`
+	HouseholdId int       `json:"household_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"` // "editor" / "viewer"
+	InvitedBy   int       `json:"invited_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	Token       string    `json:"token,omitempty"`
+	TokenHash   string    `json:"-"`
+}
+
+// CreateHouseholdRequest は家計簿を作成するリクエストボディです。
+// CopyCategoriesFrom に参加している家計簿のIDを指定すると、その家計簿のカテゴリをコピーします（0 の場合は初期カテゴリ）。
+type CreateHouseholdRequest struct {
+	Name               string `json:"name"`
+	CopyCategoriesFrom int    `json:"copy_categories_from,omitempty"`
+}
+
+// UpdateHouseholdRequest は家計簿の名前を変更するリクエストボディです。
+type UpdateHouseholdRequest struct {
+	Name string `json:"name"`
+}
+
+// InvitationRequest は家計簿へ招待するリクエストボディです。
+type InvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // 省略時は "editor"
+}
+
+// AcceptInvitationRequest は招待を受けて家計簿に参加するリクエストボディです。
+type AcceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// UpdateMemberRequest はメンバーの役割を変更するリクエストボディです。
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// SelectHouseholdRequest は利用する家計簿を切り替えるリクエストボディです。
+type SelectHouseholdRequest struct {
+	HouseholdId int `json:"household_id"`
+}
@@ -0,0 +1,117 @@
+package domain
+
+// CSV の文字コードです。
+const (
+	EncodingAuto     = "auto"      // BOM と UTF-8 として正しいかで判定（既定）
+	EncodingUTF8     = "utf-8"     // UTF-8（先頭の BOM は読み飛ばす）
+	EncodingShiftJIS = "shift_jis" // Shift_JIS（Windows-31J）
+)
+
+// CSVImportProfile は銀行・カードの明細 CSV の列と収支の対応（マッピング）です。
+//
+// 列は見出し行の列名（HasHeader が true の場合）か、"1" から始まる列番号の文字列で指定します。
+// 金額は AmountColumn（符号付きの1列）か、DebitColumn・CreditColumn（出金・入金の2列）のどちらかで指定します。
+type CSVImportProfile struct {
+	Encoding  string `json:"encoding"`   // EncodingAuto（既定） / EncodingUTF8 / EncodingShiftJIS
+	Delimiter string `json:"delimiter"`  // 区切り文字（既定 ","。"\t" でタブ区切り）
+	SkipRows  int    `json:"skip_rows"`  // 先頭で読み飛ばす行数（見出し行より前の口座名などの行）
+	HasHeader bool   `json:"has_header"` // 読み飛ばした後の1行目が見出し行か
+
+	DateColumn string `json:"date_column"`
+	DateFormat string `json:"date_format"` // "YYYY/MM/DD" など。省略時はよく使われる形式を順に試す
+
+	AmountColumn   string `json:"amount_column"`
+	AmountPositive string `json:"amount_positive"` // AmountColumn が正の値の行の種別: "income"（既定） / "expense"
+	DebitColumn    string `json:"debit_column"`    // 出金（支出）の列
+	CreditColumn   string `json:"credit_column"`   // 入金（収入）の列
+
+	MemoColumns []string `json:"memo_columns"` // 複数指定すると空白でつなげる
+	PayeeColumn string   `json:"payee_column"` // 支払先の列（省略時は支払先を設定しない）
+
+	ExpenseCategoryId int `json:"expense_category_id"` // 支出の行のカテゴリ（0 は仕分けルールで設定する）
+	IncomeCategoryId  int `json:"income_category_id"`  // 収入の行のカテゴリ（0 は仕分けルールで設定する）
+	AccountId         int `json:"account_id"`          // 省略時は家計簿の既定の口座
+}
+
+// ImportRow は取り込むファイルの1行（複式簿記の仕訳では1件の取引）を収支に変換した結果です。Amount は正の値です。
+// 変換や検証に失敗した行と、取り込まない行（他の家計簿アプリの振替など）は Error に理由を持ち、登録しません。
+type ImportRow struct {
+	Line           int    `json:"line"` // ファイル上の行番号（1始まり）
+	Date           string `json:"date,omitempty"`
+	Type           string `json:"type,omitempty"` // "income" / "expense" / "transfer"（振替は ledger のみ）
+	CategoryId     int    `json:"category_id,omitempty"`
+	Amount         int    `json:"amount,omitempty"`
+	Memo           string `json:"memo,omitempty"`
+	Payee          string `json:"payee,omitempty"`           // 支払先の名前（登録時に domain.PayeeName で正規化）
+	SourceCategory string `json:"source_category,omitempty"` // 取り込み元のカテゴリ名（Zaim・Money Forward ME・ledger）
+	TransactionId  int    `json:"transaction_id,omitempty"`  // 登録した収支のID（登録時のみ）
+	DuplicateOf    int    `json:"duplicate_of,omitempty"`    // 重複する登録済みの収支のID
+	RuleIds        []int  `json:"rule_ids,omitempty"`        // 一致した仕分けルールのID（適用した順）
+	Error          string `json:"error,omitempty"`
+
+	// 行ごとの口座です（ledger のみ）。AccountId が0の行はリクエストで指定した口座に登録します。
+	// 振替では SourceAccount・AccountId が振替元、SourceToAccount・ToAccountId が振替先です。
+	SourceAccount   string `json:"source_account,omitempty"`
+	SourceToAccount string `json:"source_to_account,omitempty"`
+	AccountId       int    `json:"account_id,omitempty"`
+	ToAccountId     int    `json:"to_account_id,omitempty"`
+
+	Splits []ImportSplit `json:"splits,omitempty"` // 内訳（ledger で1件の取引に複数のカテゴリがある場合）
+	Tags   []string      `json:"tags,omitempty"`
+}
+
+// ImportSplit は取り込む収支の内訳1行です。Amount は正の値です。
+type ImportSplit struct {
+	SourceCategory string `json:"source_category"`
+	CategoryId     int    `json:"category_id,omitempty"`
+	Amount         int    `json:"amount"`
+	Memo           string `json:"memo,omitempty"`
+}
+
+// ImportResult は取り込みの結果です。DryRun の場合は登録せずに変換結果だけを返します。
+type ImportResult struct {
+	DryRun     bool        `json:"dry_run"`
+	Total      int         `json:"total"`      // データ行の数
+	Valid      int         `json:"valid"`      // 登録できる（登録した）行の数
+	Skipped    int         `json:"skipped"`    // エラーのため登録しない行の数
+	Imported   int         `json:"imported"`   // 登録した収支の数（DryRun では0）
+	Duplicates int         `json:"duplicates"` // 登録済みの収支と重複する行の数
+	Rows       []ImportRow `json:"rows"`
+
+	// 対応するカテゴリが見つからなかった取り込み元のカテゴリ名です（Zaim・Money Forward ME のみ）。
+	Unmapped []UnmappedCategory `json:"unmapped,omitempty"`
+}
+
+// 他の家計簿アプリの取り込み元です。
+const (
+	ImportSourceZaim         = "zaim"
+	ImportSourceMoneyForward = "moneyforward"
+	ImportSourceLedger       = "ledger" // beancount・hledger の仕訳帳
+)
+
+// IsValidImportSource は source がカテゴリの対応を持つ取り込み元かを判定します。
+func IsValidImportSource(source string) bool {
+	return source == ImportSourceZaim || source == ImportSourceMoneyForward || source == ImportSourceLedger
+}
+
+// CategoryMapping は取り込み元のカテゴリ名と、この家計簿のカテゴリの対応です。
+// Name は "大項目/中項目"（中項目がない場合は大項目だけ）で、大項目だけの対応はその大項目のすべての中項目に使います。
+type CategoryMapping struct {
+	Source      string `json:"source"`
+	Name        string `json:"name"`
+	CategoryId  int    `json:"category_id"`
+	HouseholdId int    `json:"-"`
+}
+
+// CategoryMappingRequest はカテゴリの対応1件の更新リクエストです。
+type CategoryMappingRequest struct {
+	Name       string `json:"name"`
+	CategoryId int    `json:"category_id"`
+}
+
+// UnmappedCategory は対応するカテゴリが見つからなかった取り込み元のカテゴリ名と、その行数です。
+type UnmappedCategory struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"` // "income" / "expense"
+	Count int    `json:"count"`
+}
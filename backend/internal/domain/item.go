@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// LineItem は支出の品目（レシートの1行）です。「牛乳」「卵」のような品名と数量・単価を持ち、
+// 同じ品目の単価がお店や月によってどう変わったかを調べるのに使います。
+// 品目のある支出では、品目の金額（数量 × 単価）の合計が支出の金額と一致します。
+type LineItem struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice int    `json:"unit_price"` // 1個あたりの税込の金額（正の値）
+}
+
+// Total は品目の金額（数量 × 単価）です。
+func (i LineItem) Total() int {
+	return i.Quantity * i.UnitPrice
+}
+
+// LineItemRequest は品目1行のリクエストです。
+type LineItemRequest struct {
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"` // 省略時は1
+	UnitPrice int    `json:"unit_price"`
+}
+
+// MaxLineItems は1件の収支に持たせられる品目の最大数です。
+const MaxLineItems = 100
+
+// MaxLineItemNameLength は品名の長さ（文字数）の上限です。
+const MaxLineItemNameLength = 50
+
+// ItemPurchase は品目の1回の購入です（価格の推移の元データ）。
+type ItemPurchase struct {
+	TransactionId int       `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	PayeeId       int       `json:"payee_id,omitempty"`
+	PayeeName     string    `json:"payee_name,omitempty"`
+	Name          string    `json:"name"`
+	Quantity      int       `json:"quantity"`
+	UnitPrice     int       `json:"unit_price"`
+}
+
+// ItemPriceMonth は品目の1か月・1支払先の単価です。
+// AverageUnitPrice は数量で重み付けした平均（合計金額 ÷ 数量、1円未満は四捨五入）です。
+type ItemPriceMonth struct {
+	Month            string `json:"month"`                // YYYY-MM
+	PayeeId          int    `json:"payee_id,omitempty"`   // 支払先のない支出は0
+	PayeeName        string `json:"payee_name,omitempty"` // 支払先のない支出は空
+	Count            int    `json:"count"`                // 購入の回数
+	Quantity         int    `json:"quantity"`
+	AverageUnitPrice int    `json:"average_unit_price"`
+	MinUnitPrice     int    `json:"min_unit_price"`
+	MaxUnitPrice     int    `json:"max_unit_price"`
+}
+
+// ItemPriceHistory は品名に検索語を含む品目の、期間内の購入と月・支払先ごとの単価です。
+type ItemPriceHistory struct {
+	Item      string           `json:"item"`
+	From      string           `json:"from"` // YYYY-MM-DD
+	To        string           `json:"to"`   // YYYY-MM-DD
+	Purchases []ItemPurchase   `json:"purchases"`
+	Months    []ItemPriceMonth `json:"months"`
+}
+
+// SummarizeItemPrices は購入を月・支払先ごとにまとめます。月の古い順、同じ月は支払先の名前順に並べます。
+func SummarizeItemPrices(purchases []ItemPurchase) []ItemPriceMonth {
+	type groupKey struct {
+		month   string
+		payeeId int
+	}
+	index := map[groupKey]int{}
+	totals := []int{}
+	result := []ItemPriceMonth{}
+	for _, p := range purchases {
+		key := groupKey{p.Date.Format("2006-01"), p.PayeeId}
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, ItemPriceMonth{
+				Month: key.month, PayeeId: p.PayeeId, PayeeName: p.PayeeName,
+				MinUnitPrice: p.UnitPrice, MaxUnitPrice: p.UnitPrice,
+			})
+			totals = append(totals, 0)
+		}
+		m := &result[i]
+		m.Count++
+		m.Quantity += p.Quantity
+		m.MinUnitPrice = min(m.MinUnitPrice, p.UnitPrice)
+		m.MaxUnitPrice = max(m.MaxUnitPrice, p.UnitPrice)
+		totals[i] += p.Quantity * p.UnitPrice
+	}
+	for i := range result {
+		if q := result[i].Quantity; q > 0 {
+			result[i].AverageUnitPrice = (2*totals[i] + q) / (2 * q)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Month != result[j].Month {
+			return result[i].Month < result[j].Month
+		}
+		return result[i].PayeeName < result[j].PayeeName
+	})
+	return result
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Payee は収支の支払先・入金元（お店など）です。家計簿ごとに持ち、収支の PayeeId から参照します。
+//
+// 明細のメモは同じお店でも「ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店」「セブン-イレブン」のように書き方が揺れるため、
+// PayeeKey で正規化した名前（Key）が同じ支払先は同じものとして扱います。
+// 統合（マージ）した支払先は MergedInto に統合先のIDを持って残り、その Key の収支は統合先に登録します。
+type Payee struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"` // 表示名
+	Key         string    `json:"key"`  // PayeeKey で正規化した名前（家計簿の中で一意）
+	MergedInto  int       `json:"merged_into,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	HouseholdId int       `json:"-"`
+
+	// 支払先の一覧でのみ設定します。
+	Count    int        `json:"count"`               // 支払先の収支の件数
+	LastDate *time.Time `json:"last_date,omitempty"` // 最後の収支の日付
+}
+
+// MaxPayeeNameLength は支払先の名前の長さ（文字数）の上限です。
+const MaxPayeeNameLength = 50
+
+// PayeeRequest は支払先の名前の変更時のリクエストボディです。
+type PayeeRequest struct {
+	Name string `json:"name"`
+}
+
+// PayeeMergeRequest は支払先の統合時のリクエストボディです。PayeeIds の支払先をパスで指定した支払先に統合します。
+type PayeeMergeRequest struct {
+	PayeeIds []int `json:"payee_ids"`
+}
+
+// payeeCompanyMarks は支払先の名前から取り除く法人の種類の表記です（NFKC 正規化後の形）。
+var payeeCompanyMarks = strings.NewReplacer(
+	"株式会社", "", "有限会社", "", "合同会社", "", "(株)", "", "(有)", "", "(同)", "",
+)
+
+// payeeBranchPattern は名前の末尾の店舗名（「新宿店」「渋谷駅前支店」「3号店」など）です。
+var payeeBranchPattern = regexp.MustCompile(`^\S*(店|支店|号店|営業所|店舗)$`)
+
+// payeeParenPattern は名前の末尾の括弧書き（「(新宿店)」など）です。
+var payeeParenPattern = regexp.MustCompile(`\s*\([^()]*\)$`)
+
+// PayeeName はメモや明細の内容から支払先の表示名を作ります。
+// NFKC 正規化で半角カナを全角に、全角英数字を半角にそろえ、法人の種類の表記と、
+// 空白で区切った末尾の店舗名・括弧書きを取り除きます（取り除くと空になる場合は残します）。
+// 長さは MaxPayeeNameLength 文字までに切り詰めます。
+func PayeeName(text string) string {
+	name := strings.Join(strings.Fields(norm.NFKC.String(text)), " ")
+	if stripped := strings.TrimSpace(payeeCompanyMarks.Replace(name)); stripped != "" {
+		name = strings.Join(strings.Fields(stripped), " ")
+	}
+	for {
+		if stripped := payeeParenPattern.ReplaceAllString(name, ""); stripped != name && stripped != "" {
+			name = stripped
+			continue
+		}
+		fields := strings.Fields(name)
+		if len(fields) > 1 && payeeBranchPattern.MatchString(fields[len(fields)-1]) {
+			name = strings.Join(fields[:len(fields)-1], " ")
+			continue
+		}
+		break
+	}
+	if runes := []rune(name); len(runes) > MaxPayeeNameLength {
+		name = strings.TrimSpace(string(runes[:MaxPayeeNameLength]))
+	}
+	return name
+}
+
+// PayeeKey は支払先が同じかを比べるための名前を返します。
+// PayeeName で表示名にした後、小文字化し、空白と記号（「-」「・」など。長音符「ー」は残す）を取り除きます。
+func PayeeKey(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(PayeeName(text)))
+}
+
+// PayeeMonth は支払先の1か月分の収入・支出の合計（支出は正の値）と件数です。
+type PayeeMonth struct {
+	Month   string `json:"month"` // YYYY-MM
+	Income  int    `json:"income"`
+	Expense int    `json:"expense"`
+	Count   int    `json:"count"`
+}
+
+// PayeeTotal は期間内の支払先別の収入・支出の合計と件数、月ごとの内訳です。
+// 振替は集計に含めません。Months は収支のある月だけを古い順に持ちます。
+type PayeeTotal struct {
+	PayeeId   int          `json:"payee_id"`
+	PayeeName string       `json:"payee_name"`
+	Income    int          `json:"income"`
+	Expense   int          `json:"expense"`
+	Count     int          `json:"count"`
+	FirstDate time.Time    `json:"first_date"`
+	LastDate  time.Time    `json:"last_date"`
+	Months    []PayeeMonth `json:"months"`
+
+	// 期間内で収支のあった月あたりの平均の件数と、収支の間隔（日数）の平均です（2件以上の場合のみ）。
+	CountPerMonth   float64  `json:"count_per_month"`
+	AverageInterval *float64 `json:"average_interval_days,omitempty"`
+}
+
+// PayeeReport は期間内の支払先別の合計です。
+type PayeeReport struct {
+	From   string       `json:"from"` // YYYY-MM-DD
+	To     string       `json:"to"`   // YYYY-MM-DD
+	Payees []PayeeTotal `json:"payees"`
+}
+
+// Add は収支 t を支払先の合計に加えます。収支は日付の古い順に加える必要があります。
+func (p *PayeeTotal) Add(t Transaction) {
+	month := t.Date.Format("2006-01")
+	if n := len(p.Months); n == 0 || p.Months[n-1].Month != month {
+		p.Months = append(p.Months, PayeeMonth{Month: month})
+	}
+	m := &p.Months[len(p.Months)-1]
+	if t.Type == TransactionTypeIncome {
+		m.Income += t.Amount
+		p.Income += t.Amount
+	} else {
+		m.Expense -= t.Amount
+		p.Expense -= t.Amount
+	}
+	m.Count++
+	if p.Count == 0 {
+		p.FirstDate = t.Date
+	}
+	p.Count++
+	p.LastDate = t.Date
+	p.CountPerMonth = float64(p.Count) / float64(len(p.Months))
+	if p.Count > 1 {
+		interval := p.LastDate.Sub(p.FirstDate).Hours() / 24 / float64(p.Count-1)
+		p.AverageInterval = &interval
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import "time"
+
+// RecurringRule は家賃・給与・通信費などの定期的な収支のテンプレートです。
+// スケジューラが予定日ごとに収支を1件ずつ自動登録します。
+//
+// 予定日は StartDate を起点に Frequency と Interval で決まります。
+// monthly / yearly では DayOfMonth（0 の場合は StartDate の日）の日に発生し、
+// 月末より大きい日や EndOfMonth が true の場合はその月の末日になります。
+// 予定日が土日・祝日の場合は Adjustment に従って前後の営業日へずらします。
+type RecurringRule struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"` // "income" または "expense"
+	CategoryId    int        `json:"category_id"`
+	AccountId     int        `json:"account_id"`
+	Amount        int        `json:"amount"` // 支出は負の値で保持
+	Memo          string     `json:"memo"`
+	Frequency     string     `json:"frequency"`    // "daily" / "weekly" / "monthly" / "yearly"
+	Interval      int        `json:"interval"`     // 何日・週・月・年ごとか（1以上）
+	DayOfMonth    int        `json:"day_of_month"` // monthly / yearly の日（0 は StartDate の日）
+	EndOfMonth    bool       `json:"end_of_month"`
+	Adjustment    string     `json:"adjustment"` // "none" / "previous"（前営業日） / "next"（翌営業日）
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date"`
+	PostedThrough *time.Time `json:"posted_through"` // 登録済みの最後の予定日（調整前）
+	CreatedAt     time.Time  `json:"created_at"`
+	HouseholdId   int        `json:"-"` // ルールを持つ家計簿。登録する収支もこの家計簿のものになる
+}
+
+// 定期収支の頻度と休日調整の種類です。
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+
+	AdjustmentNone     = "none"
+	AdjustmentPrevious = "previous"
+	AdjustmentNext     = "next"
+)
+
+// RecurringRuleRequest は定期収支の登録・更新時のリクエストボディです。
+type RecurringRuleRequest struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"` // "income" または "expense"
+	CategoryId int    `json:"category_id"`
+	AccountId  int    `json:"account_id"` // 省略時は家計簿の既定の口座
+	Amount     int    `json:"amount"`
+	Memo       string `json:"memo"`
+	Frequency  string `json:"frequency"`
+	Interval   int    `json:"interval"` // 省略時は 1
+	DayOfMonth int    `json:"day_of_month"`
+	EndOfMonth bool   `json:"end_of_month"`
+	Adjustment string `json:"adjustment"` // 省略時は "none"
+	StartDate  string `json:"start_date"` // "2006-01-02" 形式
+	EndDate    string `json:"end_date"`   // "2006-01-02" 形式（省略時は無期限）
+}
+
+// Occurrence は定期収支の1回分の発生です。
+// ScheduledDate は調整前の予定日、Date は休日調整後の実際の登録日です。
+type Occurrence struct {
+	RuleId        int       `json:"rule_id"`
+	Name          string    `json:"name"`
+	ScheduledDate time.Time `json:"scheduled_date"`
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"`
+	CategoryId    int       `json:"category_id"`
+	CategoryName  string    `json:"category_name"`
+	AccountId     int       `json:"account_id"`
+	Amount        int       `json:"amount"`
+	Memo          string    `json:"memo"`
+}
+
+// ScheduledDate は n 回目（0始まり）の調整前の予定日を返します。
+func (r RecurringRule) ScheduledDate(n int) time.Time {
+	start := dateOnly(r.StartDate)
+	interval := max(r.Interval, 1)
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return start.AddDate(0, 0, n*interval)
+	case FrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*interval)
+	case FrequencyYearly:
+		return r.dayInMonth(start.Year()+n*interval, start.Month())
+	default:
+		months := int(start.Month()) - 1 + n*interval
+		return r.dayInMonth(start.Year()+months/12, time.Month(months%12+1))
+	}
+}
+
+// dayInMonth は monthly / yearly の予定日を、その月の末日を超えないように求めます。
+func (r RecurringRule) dayInMonth(y int, m time.Month) time.Time {
+	last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	day := r.DayOfMonth
+	if day == 0 {
+		day = r.StartDate.Day()
+	}
+	if r.EndOfMonth || day > last {
+		day = last
+	}
+	return time.Date(y, m, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Adjust は予定日を休日調整した登録日を返します。
+func (r RecurringRule) Adjust(d time.Time) time.Time {
+	switch r.Adjustment {
+	case AdjustmentPrevious:
+		for !IsBusinessDay(d) {
+			d = d.AddDate(0, 0, -1)
+		}
+	case AdjustmentNext:
+		for !IsBusinessDay(d) {
+			d = d.AddDate(0, 0, 1)
+		}
+	}
+	return d
+}
+
+// Occurrences は予定日が after より後で、登録日が until 以前の発生を古い順に最大 limit 件返します。
+// after がゼロ値の場合は最初の予定日から数えます。終了日より後の予定日は含みません。
+func (r RecurringRule) Occurrences(after, until time.Time, limit int) []Occurrence {
+	var result []Occurrence
+	for n := 0; len(result) < limit; n++ {
+		scheduled := r.ScheduledDate(n)
+		if r.EndDate != nil && scheduled.After(dateOnly(*r.EndDate)) {
+			break
+		}
+		if !after.IsZero() && !scheduled.After(dateOnly(after)) {
+			continue
+		}
+		date := r.Adjust(scheduled)
+		if date.After(dateOnly(until)) {
+			break
+		}
+		result = append(result, Occurrence{
+			RuleId:        r.ID,
+			Name:          r.Name,
+			ScheduledDate: scheduled,
+			Date:          date,
+			Type:          r.Type,
+			CategoryId:    r.CategoryId,
+			AccountId:     r.AccountId,
+			Amount:        r.Amount,
+			Memo:          r.Memo,
+		})
+	}
+	return result
+}
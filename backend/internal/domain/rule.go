@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CategoryRule は収支のカテゴリ・タグ・メモを自動で設定する仕分けルールです。
+// 取り込んだ行と、?apply_rules=true を付けて登録する収支に優先度の順で適用します。
+//
+// 条件は指定したものすべてに一致する収入・支出にだけルールを適用します（振替には適用しません）。
+// 一致したルールはすべて適用し、カテゴリとメモの書き換えは先に適用したルールのものを、タグはすべてのルールのものを使います。
+type CategoryRule struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"` // 小さいほど先に適用する
+	Enabled  bool   `json:"enabled"`
+
+	// 条件です。
+	Type         string `json:"type"`          // "income" / "expense"（空はどちらも）
+	MemoContains string `json:"memo_contains"` // メモの部分一致（NormalizeMemo で正規化して比べる）
+	MemoPattern  string `json:"memo_pattern"`  // メモの正規表現（Go の regexp 構文）
+	MinAmount    *int   `json:"min_amount"`    // 金額（絶対値）の下限
+	MaxAmount    *int   `json:"max_amount"`    // 金額（絶対値）の上限
+	AccountId    int    `json:"account_id"`    // 口座（0 はすべての口座）
+	Weekdays     []int  `json:"weekdays"`      // 曜日（0 が日曜日〜6 が土曜日。空はすべての曜日）
+
+	// 一致した収支に対する動作です。
+	CategoryId  int      `json:"category_id"`  // 設定するカテゴリ（0 は変更しない）
+	Tags        []string `json:"tags"`         // 追加するタグ
+	MemoRewrite string   `json:"memo_rewrite"` // 書き換え後のメモ（空は変更しない）。MemoPattern のグループを $1 などで使える
+
+	CreatedAt   time.Time `json:"created_at"`
+	HouseholdId int       `json:"-"`
+}
+
+// CategoryRuleRequest は仕分けルールの登録・更新・試用時のリクエストボディです。
+type CategoryRuleRequest struct {
+	Name         string   `json:"name"`
+	Priority     int      `json:"priority"`
+	Enabled      *bool    `json:"enabled"` // 省略時は true
+	Type         string   `json:"type"`
+	MemoContains string   `json:"memo_contains"`
+	MemoPattern  string   `json:"memo_pattern"`
+	MinAmount    *int     `json:"min_amount"`
+	MaxAmount    *int     `json:"max_amount"`
+	AccountId    int      `json:"account_id"`
+	Weekdays     []int    `json:"weekdays"`
+	CategoryId   int      `json:"category_id"`
+	Tags         []string `json:"tags"`
+	MemoRewrite  string   `json:"memo_rewrite"`
+}
+
+// RuleApplyRequest は仕分けルールを登録済みの収支に適用する POST /api/rules/apply のリクエストボディです。
+type RuleApplyRequest struct {
+	From    string `json:"from"`     // YYYY-MM-DD
+	To      string `json:"to"`       // YYYY-MM-DD
+	RuleIds []int  `json:"rule_ids"` // 適用するルール（省略時は有効なすべてのルール）
+	DryRun  bool   `json:"dry_run"`
+}
+
+// RuleChange は収支に仕分けルールを適用した結果です。Transaction は適用前の収支です。
+type RuleChange struct {
+	Transaction Transaction `json:"transaction"`
+	CategoryId  int         `json:"category_id"` // 適用後のカテゴリ
+	Memo        string      `json:"memo"`        // 適用後のメモ
+	Tags        []string    `json:"tags"`        // 適用後のタグ
+	RuleIds     []int       `json:"rule_ids"`    // 一致したルール（適用した順）
+	Changed     bool        `json:"changed"`     // カテゴリ・メモ・タグのいずれかが変わるか
+}
+
+// RuleApplyResult は仕分けルールの試用・適用の結果です。DryRun の場合は収支を更新しません。
+type RuleApplyResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Checked int          `json:"checked"` // 対象の収支の数
+	Matched int          `json:"matched"` // いずれかのルールに一致した収支の数
+	Updated int          `json:"updated"` // 更新した（DryRun では更新する）収支の数
+	Changes []RuleChange `json:"changes"`
+}
+
+// RuleSet は優先度の順に並べた有効な仕分けルールです。NewRuleSet で作ります。
+type RuleSet struct {
+	rules      []compiledRule
+	categories map[int]Category
+}
+
+// compiledRule は条件を比べやすい形にした仕分けルールです。
+type compiledRule struct {
+	CategoryRule
+	contains string         // 正規化した MemoContains
+	pattern  *regexp.Regexp // MemoPattern（空の場合は nil）
+	weekdays map[time.Weekday]bool
+}
+
+// NewRuleSet は rules のうち有効なルールを優先度（同じ場合はID）の順に並べた RuleSet を作ります。
+// categories はルールが設定するカテゴリの確認に使い、見つからない・アーカイブ済みのカテゴリは設定しません。
+// 正規表現を解釈できないルールは使いません（登録時に検証するため通常はありません）。
+func NewRuleSet(rules []CategoryRule, categories []Category) *RuleSet {
+	set := &RuleSet{categories: make(map[int]Category, len(categories))}
+	for _, c := range categories {
+		set.categories[c.ID] = c
+	}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		compiled := compiledRule{CategoryRule: rule, contains: NormalizeMemo(rule.MemoContains)}
+		if rule.MemoPattern != "" {
+			pattern, err := regexp.Compile(rule.MemoPattern)
+			if err != nil {
+				continue
+			}
+			compiled.pattern = pattern
+		}
+		if len(rule.Weekdays) > 0 {
+			compiled.weekdays = map[time.Weekday]bool{}
+			for _, d := range rule.Weekdays {
+				compiled.weekdays[time.Weekday(d)] = true
+			}
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	sort.SliceStable(set.rules, func(i, j int) bool {
+		if set.rules[i].Priority != set.rules[j].Priority {
+			return set.rules[i].Priority < set.rules[j].Priority
+		}
+		return set.rules[i].ID < set.rules[j].ID
+	})
+	return set
+}
+
+// Len はルールの数を返します。
+func (s *RuleSet) Len() int {
+	return len(s.rules)
+}
+
+// matches は収支 t がルールの条件すべてに一致するかを判定します。
+func (r compiledRule) matches(t Transaction) bool {
+	if r.Type != "" && r.Type != t.Type {
+		return false
+	}
+	if r.contains != "" && !strings.Contains(NormalizeMemo(t.Memo), r.contains) {
+		return false
+	}
+	if r.pattern != nil && !r.pattern.MatchString(t.Memo) {
+		return false
+	}
+	amount := t.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	if r.MinAmount != nil && amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && amount > *r.MaxAmount {
+		return false
+	}
+	if r.AccountId != 0 && r.AccountId != t.AccountId {
+		return false
+	}
+	if r.weekdays != nil && !r.weekdays[t.Date.Weekday()] {
+		return false
+	}
+	return true
+}
+
+// rewriteMemo はメモ memo をルールの MemoRewrite で書き換えます。MemoPattern がある場合は $1 などを一致したグループに置き換えます。
+func (r compiledRule) rewriteMemo(memo string) string {
+	if r.pattern == nil {
+		return r.MemoRewrite
+	}
+	match := r.pattern.FindStringSubmatchIndex(memo)
+	return string(r.pattern.ExpandString(nil, r.MemoRewrite, memo, match))
+}
+
+// Apply は収支 t に一致するルールを優先度の順に適用し、一致したルールのIDを返します。
+// 条件はすべて適用前の収支と比べます。カテゴリは、内訳のない収支に、種別に使えてアーカイブされていない最初のカテゴリを設定します。
+// メモは最初に書き換えるルールのもので書き換え、タグは一致したすべてのルールのものを加えます。
+func (s *RuleSet) Apply(t *Transaction) []int {
+	if t.Type != TransactionTypeIncome && t.Type != TransactionTypeExpense {
+		return nil
+	}
+	original := *t
+	var ids []int
+	categorySet, memoSet := false, false
+	tags := append([]string(nil), t.Tags...)
+	for _, rule := range s.rules {
+		if !rule.matches(original) {
+			continue
+		}
+		ids = append(ids, rule.ID)
+		if !categorySet && rule.CategoryId != 0 && len(t.Splits) == 0 {
+			if category, ok := s.categories[rule.CategoryId]; ok && !category.Archived && category.AllowsType(t.Type) {
+				t.CategoryId, t.Category = category.ID, category
+				categorySet = true
+			}
+		}
+		if !memoSet && rule.MemoRewrite != "" {
+			t.Memo = rule.rewriteMemo(original.Memo)
+			memoSet = true
+		}
+		tags = append(tags, rule.Tags...)
+	}
+	if len(ids) > 0 {
+		t.Tags = NormalizeTags(tags)
+		if len(t.Tags) > MaxTagsPerTransaction {
+			t.Tags = t.Tags[:MaxTagsPerTransaction]
+		}
+	}
+	return ids
+}
+
+// Change は収支 t にルールを適用した結果を返します。t は変更しません。
+func (s *RuleSet) Change(t Transaction) RuleChange {
+	applied := t
+	applied.Tags = append([]string(nil), t.Tags...)
+	ids := s.Apply(&applied)
+	return RuleChange{
+		Transaction: t,
+		CategoryId:  applied.CategoryId,
+		Memo:        applied.Memo,
+		Tags:        applied.Tags,
+		RuleIds:     ids,
+		Changed: applied.CategoryId != t.CategoryId || applied.Memo != t.Memo ||
+			strings.Join(applied.Tags, "\n") != strings.Join(t.Tags, "\n"),
+	}
+}
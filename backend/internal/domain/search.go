@@ -0,0 +1,355 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 収支の全文検索は、メモ・カテゴリ名・内訳・タグを文字の2-gram（バイグラム）で照合します。
+// 日本語は単語の区切りがないため、単語ではなく連続する2文字の組で一致を数えます。
+// 比較の前に NFKC 正規化と小文字化を行い、全角英数字と半角英数字、大文字と小文字を区別しません。
+//
+// 検索語は空白で区切った語ごとに採点します。語がそのまま含まれていれば 0.6〜1（フィールド全体に
+// 占める語の割合が大きいほど高く、完全に一致すれば 1）、そうでなければ語とフィールドのバイグラムの
+// 一致度（Dice 係数）に 0.6 を掛けた値を点数とし、各語の最もよく一致したフィールドの点数
+// （フィールドごとの重みを掛けたもの）の平均が SearchMinScore 以上の収支を検索結果とします。
+
+// SearchMinScore は検索結果に含める収支の点数の下限です。
+const SearchMinScore = 0.3
+
+// 検索対象のフィールドです。
+const (
+	SearchFieldMemo     = "memo"     // 収支のメモ
+	SearchFieldCategory = "category" // 収支または内訳のカテゴリ名
+	SearchFieldTag      = "tag"      // タグ
+	SearchFieldSplit    = "split"    // 内訳のメモ
+)
+
+// searchFieldWeights はフィールドごとの点数の重みです。メモとタグの一致を最も重く扱います。
+var searchFieldWeights = map[string]float64{
+	SearchFieldMemo:     1.0,
+	SearchFieldTag:      1.0,
+	SearchFieldSplit:    0.9,
+	SearchFieldCategory: 0.8,
+}
+
+// searchFragmentLength は長いメモの一致箇所の前後を切り出すときの断片の長さ（文字数）です。
+const searchFragmentLength = 40
+
+// SearchRange は断片の中で一致した範囲です。Start・End は文字（rune）単位の位置で、End は含みません。
+type SearchRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SearchHighlight は一致したフィールドの断片と、その中の一致箇所です。
+// 長いメモは一致箇所の前後だけを切り出し、省略した側に "…" を付けます。
+type SearchHighlight struct {
+	Field  string        `json:"field"`
+	Text   string        `json:"text"`
+	Ranges []SearchRange `json:"ranges"`
+}
+
+// SearchResult は検索に一致した収支と、その点数・一致箇所です。
+type SearchResult struct {
+	Transaction Transaction       `json:"transaction"`
+	Score       float64           `json:"score"`
+	Highlights  []SearchHighlight `json:"highlights"`
+}
+
+// SearchResponse は全文検索の結果です。Total は件数で絞る前の一致件数です。
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+}
+
+// searchText は正規化した文字列と、その各文字が元の文字列のどの範囲から来たかです。
+// start・end は元の文字列での文字（rune）単位の位置で、end は含みません。
+type searchText struct {
+	runes []rune
+	start []int
+	end   []int
+}
+
+// normalizeSearchText は s 全体を NFKC 正規化・小文字化します。
+// 半角カナと濁点（ｾﾌﾞﾝ → セブン）のように複数の文字が1文字にまとまるため、1文字ずつではなく
+// 文字列全体を正規化し、その区切り（基底の文字と後に続く結合文字の並び）ごとに元の範囲を記録します。
+// 区切りの文字数が正規化の前後で同じなら1文字ずつ、違えば区切り全体を各文字の元の範囲とします。
+func normalizeSearchText(s string) searchText {
+	var st searchText
+	var it norm.Iter
+	it.InitString(norm.NFKC, s)
+	pos := 0
+	for !it.Done() {
+		from := it.Pos()
+		segment := []rune(string(it.Next()))
+		n := utf8.RuneCountInString(s[from:it.Pos()])
+		for i, r := range segment {
+			start, end := pos, pos+n
+			if len(segment) == n {
+				start, end = pos+i, pos+i+1
+			}
+			st.runes = append(st.runes, unicode.ToLower(r))
+			st.start = append(st.start, start)
+			st.end = append(st.end, end)
+		}
+		pos += n
+	}
+	return st
+}
+
+// NormalizeSearchString は検索で比較に使う形（NFKC 正規化・小文字化）に s を変換します。
+func NormalizeSearchString(s string) string {
+	return string(normalizeSearchText(s).runes)
+}
+
+// searchTerms は検索語を空白で区切り、正規化した語の一覧を返します。
+func searchTerms(query string) [][]rune {
+	var terms [][]rune
+	for _, term := range strings.Fields(NormalizeSearchString(query)) {
+		terms = append(terms, []rune(term))
+	}
+	return terms
+}
+
+// bigrams は文字列のバイグラムの集合を返します。1文字の場合はその文字だけを返します。
+func bigrams(runes []rune) map[string]bool {
+	grams := map[string]bool{}
+	if len(runes) == 1 {
+		grams[string(runes)] = true
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		grams[string(runes[i:i+2])] = true
+	}
+	return grams
+}
+
+// SearchGrams は検索語のバイグラム（1文字の語はその文字）を重複なく返します。
+// リポジトリはこのいずれかを含む収支を候補として返し、採点は SearchTransaction で行います。
+func SearchGrams(query string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, term := range searchTerms(query) {
+		for gram := range bigrams(term) {
+			if !seen[gram] {
+				seen[gram] = true
+				result = append(result, gram)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// searchField は検索対象の1つのフィールドです。
+type searchField struct {
+	name string
+	text string
+}
+
+// searchFields は収支の検索対象のフィールドを返します。
+func (t Transaction) searchFields() []searchField {
+	var fields []searchField
+	if t.Memo != "" {
+		fields = append(fields, searchField{SearchFieldMemo, t.Memo})
+	}
+	seen := map[string]bool{}
+	for _, line := range t.CategoryLines() {
+		if name := line.Category.Name; name != "" && !seen[name] {
+			seen[name] = true
+			fields = append(fields, searchField{SearchFieldCategory, name})
+		}
+	}
+	for _, split := range t.Splits {
+		if split.Memo != "" {
+			fields = append(fields, searchField{SearchFieldSplit, split.Memo})
+		}
+	}
+	for _, tag := range t.Tags {
+		fields = append(fields, searchField{SearchFieldTag, tag})
+	}
+	return fields
+}
+
+// SearchString は収支の検索対象のフィールドを正規化して改行でつないだ文字列です。
+// メモリ上のリポジトリが SearchGrams の候補を探すときに使います。
+func (t Transaction) SearchString() string {
+	var texts []string
+	for _, f := range t.searchFields() {
+		texts = append(texts, NormalizeSearchString(f.text))
+	}
+	return strings.Join(texts, "\n")
+}
+
+// SearchIndexGrams は収支のメモと内訳のメモを正規化した文字とバイグラムを、空白を含むものを除いて重複なく返します。
+// データベースのリポジトリはこれを全文検索の索引として収支ごとに保存し、SearchGrams のいずれかを持つ収支を候補にします。
+// カテゴリ名とタグは件数が少ないため索引に含めず、リポジトリがカテゴリ・タグの名前と直接照合します。
+func (t Transaction) SearchIndexGrams() []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, f := range t.searchFields() {
+		if f.name != SearchFieldMemo && f.name != SearchFieldSplit {
+			continue
+		}
+		runes := normalizeSearchText(f.text).runes
+		for i := range runes {
+			for _, gram := range []string{string(runes[i]), string(runes[i:min(i+2, len(runes))])} {
+				if !seen[gram] && !strings.ContainsFunc(gram, unicode.IsSpace) {
+					seen[gram] = true
+					result = append(result, gram)
+				}
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// containScore は語がフィールドにそのまま含まれる場合の点数の下限で、部分的な一致の点数の上限です。
+const containScore = 0.6
+
+// termScore は正規化した語 term がフィールドの文字列 text にどれだけ一致するかを0〜1で返します。
+func termScore(term []rune, text searchText) float64 {
+	if strings.Contains(string(text.runes), string(term)) {
+		return containScore + (1-containScore)*float64(len(term))/float64(len(text.runes))
+	}
+	if len(term) < 2 || len(text.runes) < 2 {
+		return 0
+	}
+	termGrams, textGrams := bigrams(term), bigrams(text.runes)
+	common := 0
+	for gram := range termGrams {
+		if textGrams[gram] {
+			common++
+		}
+	}
+	return containScore * 2 * float64(common) / float64(len(termGrams)+len(textGrams))
+}
+
+// matchRanges は text の中で語そのもの、または語のバイグラムに一致する範囲を、重なりをまとめて返します。
+// 範囲は正規化後の文字の位置です。
+func matchRanges(terms [][]rune, text searchText) []SearchRange {
+	covered := make([]bool, len(text.runes))
+	mark := func(pattern []rune) {
+		for i := 0; i+len(pattern) <= len(text.runes); i++ {
+			if string(text.runes[i:i+len(pattern)]) == string(pattern) {
+				for j := i; j < i+len(pattern); j++ {
+					covered[j] = true
+				}
+			}
+		}
+	}
+	for _, term := range terms {
+		mark(term)
+		if len(term) >= 2 {
+			for gram := range bigrams(term) {
+				mark([]rune(gram))
+			}
+		}
+	}
+
+	var ranges []SearchRange
+	for i := 0; i < len(covered); i++ {
+		if !covered[i] {
+			continue
+		}
+		start := i
+		for i < len(covered) && covered[i] {
+			i++
+		}
+		ranges = append(ranges, SearchRange{Start: start, End: i})
+	}
+	return ranges
+}
+
+// highlight は正規化後の一致範囲を元の文字列の位置に戻し、長い文字列は一致箇所の前後を切り出します。
+func highlight(field searchField, text searchText, ranges []SearchRange) SearchHighlight {
+	original := []rune(field.text)
+	mapped := make([]SearchRange, 0, len(ranges))
+	for _, r := range ranges {
+		start, end := text.start[r.Start], text.end[r.End-1]
+		if n := len(mapped); n > 0 && mapped[n-1].End >= start {
+			mapped[n-1].End = max(mapped[n-1].End, end)
+			continue
+		}
+		mapped = append(mapped, SearchRange{Start: start, End: end})
+	}
+
+	if len(original) <= searchFragmentLength {
+		return SearchHighlight{Field: field.name, Text: field.text, Ranges: mapped}
+	}
+	from := max(0, mapped[0].Start-searchFragmentLength/4)
+	to := min(len(original), from+searchFragmentLength)
+	from = max(0, to-searchFragmentLength)
+
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = "…"
+	}
+	if to < len(original) {
+		suffix = "…"
+	}
+	offset := len([]rune(prefix)) - from
+	var clipped []SearchRange
+	for _, r := range mapped {
+		if r.End <= from || r.Start >= to {
+			continue
+		}
+		clipped = append(clipped, SearchRange{Start: max(r.Start, from) + offset, End: min(r.End, to) + offset})
+	}
+	return SearchHighlight{Field: field.name, Text: prefix + string(original[from:to]) + suffix, Ranges: clipped}
+}
+
+// SearchTransaction は収支を検索語 query で採点し、一致箇所を求めます。
+// 点数が SearchMinScore 未満の場合は2つ目の戻り値が false です。
+func SearchTransaction(t Transaction, query string) (SearchResult, bool) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return SearchResult{}, false
+	}
+	fields := t.searchFields()
+	texts := make([]searchText, len(fields))
+	for i, f := range fields {
+		texts[i] = normalizeSearchText(f.text)
+	}
+
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		for i, f := range fields {
+			best = max(best, searchFieldWeights[f.name]*termScore(term, texts[i]))
+		}
+		total += best
+	}
+	score := total / float64(len(terms))
+	if score < SearchMinScore {
+		return SearchResult{}, false
+	}
+
+	result := SearchResult{Transaction: t, Score: float64(int(score*1000+0.5)) / 1000, Highlights: []SearchHighlight{}}
+	for i, f := range fields {
+		if ranges := matchRanges(terms, texts[i]); len(ranges) > 0 {
+			result.Highlights = append(result.Highlights, highlight(f, texts[i], ranges))
+		}
+	}
+	return result, true
+}
+
+// SortSearchResults は検索結果を点数の高い順、同点は日付の新しい順に並べます。
+func SortSearchResults(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.Transaction.Date.Equal(b.Transaction.Date) {
+			return a.Transaction.Date.After(b.Transaction.Date)
+		}
+		return a.Transaction.ID > b.Transaction.ID
+	})
+}
@@ -0,0 +1,82 @@
+package domain
+
+import "strings"
+
+// Tag は収支に付けたタグと、そのタグが付いた収支の件数です。
+// タグはカテゴリと違って事前に定義せず、収支の登録時に自由に付けます（例: 旅行2025、子ども、経費精算）。
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// 1件の収支に付けられるタグの数と、タグ1つの長さ（文字数）の上限です。
+const (
+	MaxTagsPerTransaction = 20
+	MaxTagLength          = 30
+)
+
+// タグでの絞り込みの一致方法です。
+const (
+	TagMatchAny = "any" // いずれかのタグが付いている
+	TagMatchAll = "all" // すべてのタグが付いている
+)
+
+// NormalizeTags はタグの前後の空白と先頭の "#" を取り除き、空のタグと重複を除いて返します。
+// 並び順は最初に現れた順のままです。タグがない場合は nil を返します。
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#＃"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// HasTag は収支に tag のタグが付いているかを判定します。
+func (t Transaction) HasTag(tag string) bool {
+	for _, name := range t.Tags {
+		if name == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesTags は収支が tags の条件に一致するかを判定します。
+// match が TagMatchAll の場合はすべてのタグ、それ以外はいずれかのタグが付いていれば一致します。
+func (t Transaction) MatchesTags(tags []string, match string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		has := t.HasTag(tag)
+		if match == TagMatchAll && !has {
+			return false
+		}
+		if match != TagMatchAll && has {
+			return true
+		}
+	}
+	return match == TagMatchAll
+}
+
+// TagTotal はタグ別の収入・支出の合計です（支出は正の値）。
+// 複数のタグが付いた収支は、それぞれのタグの合計に数えます。
+type TagTotal struct {
+	Tag     string `json:"tag"`
+	Income  int    `json:"income"`
+	Expense int    `json:"expense"`
+	Count   int    `json:"count"`
+}
+
+// TagReport は期間内のタグ別の合計です。
+type TagReport struct {
+	From string     `json:"from"` // YYYY-MM-DD
+	To   string     `json:"to"`   // YYYY-MM-DD
+	Tags []TagTotal `json:"tags"`
+}
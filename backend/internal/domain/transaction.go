@@ -4,36 +4,202 @@ import "time"
 
 // Transaction は収支データを表すドメインモデルです。
 // 家計簿の1件の収入または支出を保持します。
+//
+// 口座間の振替（Type が "transfer"）は、振替元の口座から出金する行（負の金額）と
+// 振替先の口座へ入金する行（正の金額）の2件で表し、両方に同じ TransferId を持たせます。
+// 振替にはカテゴリがなく（CategoryId は0）、収入・支出の集計には含めません。
+//
+// 1件の収支を複数のカテゴリに分ける場合は Splits に内訳を持たせます。
+// 内訳の金額の合計は Amount と一致し、CategoryId は先頭の内訳のカテゴリです。
+// カテゴリ別の集計では、内訳のある収支は内訳ごとにそれぞれのカテゴリへ数えます。
 type Transaction struct {
 	ID         int       `json:"id"`
 	Date       time.Time `json:"date"`
-	Type       string    `json:"type"` // "income" または "expense"
+	Type       string    `json:"type"` // "income" / "expense" / "transfer"
 	CategoryId int       `json:"category_id"`
+	AccountId  int       `json:"account_id"`
 	Amount     int       `json:"amount"`
 	Memo       string    `json:"memo"`
 	CreatedAt  time.Time `json:"created_at"`
 	Category   Category  `json:"category"`
+
+	// 定期収支から自動登録された場合の元ルールと予定日（調整前）です。
+	// 同じルール・予定日の収支は1件しか登録できません。
+	RecurringRuleId int        `json:"recurring_rule_id,omitempty"`
+	RecurringDate   *time.Time `json:"-"`
+
+	// 振替の組を表すIDです（出金側の行のID）。振替以外は0です。
+	TransferId int `json:"transfer_id,omitempty"`
+
+	// 収支を持つ家計簿のIDです。家計簿の導入前に登録された収支は0です。
+	HouseholdId int `json:"-"`
+
+	// 収支を登録したメンバーのユーザーIDです。定期収支から自動登録された収支などは0です。
+	CreatedBy int `json:"created_by,omitempty"`
+
+	// 内訳です。内訳のない収支は空です。
+	Splits []Split `json:"splits,omitempty"`
+
+	// 収支に付けたタグです（NormalizeTags で正規化済み）。振替には付けられません。
+	Tags []string `json:"tags,omitempty"`
+
+	// 支払先です。PayeeId が0で PayeeName がある収支は、登録時に PayeeKey が同じ支払先（なければ新しい支払先）に結び付けます。
+	// 振替には付けられません。
+	PayeeId   int    `json:"payee_id,omitempty"`
+	PayeeName string `json:"payee_name,omitempty"`
+
+	// 品目です（支出のみ）。品目の金額の合計は Amount の絶対値と一致します。品目のない収支は空です。
+	Items []LineItem `json:"items,omitempty"`
+
+	// ゴミ箱へ移した日時です。ゴミ箱にない収支は nil です。
+	// ゴミ箱の収支は一覧・集計に含めず、保存期間を過ぎると完全に削除します。
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Split は収支の内訳1行です。Amount は親の収支と同じ符号で保持します（支出は負の値）。
+type Split struct {
+	ID         int      `json:"id"`
+	CategoryId int      `json:"category_id"`
+	Amount     int      `json:"amount"`
+	Memo       string   `json:"memo"`
+	Category   Category `json:"category"`
+}
+
+// CategoryLines はカテゴリ別の集計に使う行を返します。
+// 内訳があれば内訳を、なければ収支全体を1行として返します。
+func (t Transaction) CategoryLines() []Split {
+	if len(t.Splits) > 0 {
+		return t.Splits
+	}
+	return []Split{{CategoryId: t.CategoryId, Amount: t.Amount, Memo: t.Memo, Category: t.Category}}
 }
 
-type Category struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+// HasCategory は収支または内訳のいずれかが categoryId のカテゴリかを判定します。
+func (t Transaction) HasCategory(categoryId int) bool {
+	for _, line := range t.CategoryLines() {
+		if line.CategoryId == categoryId {
+			return true
+		}
+	}
+	return false
 }
 
+// 収支の種別です。
+const (
+	TransactionTypeIncome   = "income"
+	TransactionTypeExpense  = "expense"
+	TransactionTypeTransfer = "transfer"
+)
+
 // CreateTransactionRequest は新規収支登録時のリクエストボディです。
 type CreateTransactionRequest struct {
-	Date       string `json:"date"` // "2006-01-02" 形式
-	Type       string `json:"type"` // "income" または "expense"
-	CategoryId int    `json:"category_id"`
-	Amount     int    `json:"amount"`
-	Memo       string `json:"memo"`
+	Date        string            `json:"date"`          // "2006-01-02" 形式
+	Type        string            `json:"type"`          // "income" / "expense" / "transfer"
+	CategoryId  int               `json:"category_id"`   // 振替では指定しない。内訳がある場合は省略可
+	AccountId   int               `json:"account_id"`    // 省略時は家計簿の既定の口座。振替では振替元
+	ToAccountId int               `json:"to_account_id"` // 振替先（振替のみ）
+	Amount      int               `json:"amount"`
+	Memo        string            `json:"memo"`
+	Splits      []SplitRequest    `json:"splits"` // 内訳（2行以上。振替では指定しない）
+	Tags        []string          `json:"tags"`   // タグ（振替では指定しない）
+	Payee       string            `json:"payee"`  // 支払先の名前（振替では指定しない）
+	Items       []LineItemRequest `json:"items"`  // 品目（支出のみ。金額の合計は amount と一致）
 }
 
 // UpdateTransactionRequest は収支更新時のリクエストボディです。
+// 内訳・品目は送った内容で置き換わり、省略すると内訳・品目のない収支になります。
 type UpdateTransactionRequest struct {
-	Date       string `json:"date"` // "2006-01-02" 形式
-	Type       string `json:"type"` // "income" または "expense"
+	Date        string            `json:"date"`          // "2006-01-02" 形式
+	Type        string            `json:"type"`          // "income" / "expense" / "transfer"
+	CategoryId  int               `json:"category_id"`   // 振替では指定しない。内訳がある場合は省略可
+	AccountId   int               `json:"account_id"`    // 省略時は家計簿の既定の口座。振替では振替元
+	ToAccountId int               `json:"to_account_id"` // 振替先（振替のみ）
+	Amount      int               `json:"amount"`
+	Memo        string            `json:"memo"`
+	Splits      []SplitRequest    `json:"splits"` // 内訳（2行以上。振替では指定しない）
+	Tags        []string          `json:"tags"`   // タグ（振替では指定しない）
+	Payee       string            `json:"payee"`  // 支払先の名前（振替では指定しない）
+	Items       []LineItemRequest `json:"items"`  // 品目（支出のみ。金額の合計は amount と一致）
+}
+
+// SplitRequest は収支の内訳1行のリクエストです。amount は親の amount と同じく正の値で指定します。
+type SplitRequest struct {
 	CategoryId int    `json:"category_id"`
 	Amount     int    `json:"amount"`
 	Memo       string `json:"memo"`
-}
\ No newline at end of file
+}
+
+// Transfer は振替1件分を、出金・入金の2行をまとめた形で表します。
+type Transfer struct {
+	ID            int           `json:"id"` // TransferId
+	Date          time.Time     `json:"date"`
+	FromAccountId int           `json:"from_account_id"`
+	ToAccountId   int           `json:"to_account_id"`
+	Amount        int           `json:"amount"` // 正の値
+	Memo          string        `json:"memo"`
+	Transactions  []Transaction `json:"transactions"` // 出金・入金の順
+}
+
+// NewTransfer は振替の出金側・入金側の行から Transfer を組み立てます。
+func NewTransfer(out, in Transaction) Transfer {
+	return Transfer{
+		ID:            out.TransferId,
+		Date:          out.Date,
+		FromAccountId: out.AccountId,
+		ToAccountId:   in.AccountId,
+		Amount:        in.Amount,
+		Memo:          out.Memo,
+		Transactions:  []Transaction{out, in},
+	}
+}
+
+// TransactionFilter は収支一覧の絞り込み・並び替え・ページング条件です。
+// ゼロ値のフィールドは条件なしとして扱います。
+type TransactionFilter struct {
+	From        *time.Time // この日付以降（当日を含む）
+	To          *time.Time // この日付以前（当日を含む）
+	Type        string     // "income" / "expense" / "transfer"
+	CategoryIds []int      // いずれかに一致
+	AccountId   int        // 口座ID
+	MinAmount   *int       // 金額の絶対値の下限
+	MaxAmount   *int       // 金額の絶対値の上限
+	Memo        string     // メモの部分一致
+	Tags        []string   // タグ
+	PayeeId     int        // 支払先ID
+	TagMatch    string     // "any"（いずれか、既定） / "all"（すべて）
+	SortBy      string     // "date" / "amount" / "created_at"（既定: "date"）
+	SortOrder   string     // "asc" / "desc"（既定: "desc"）
+	Page        int        // 1始まり
+	Limit       int        // 0以下の場合はページングしない
+}
+
+// TransactionPage は絞り込み結果の1ページ分と、条件に一致した総件数です。
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	Total        int           `json:"total"`
+	Page         int           `json:"page"`
+	Limit        int           `json:"limit"`
+}
+
+// MonthlySummary は1か月分の収支の集計結果です。
+// Expense は支出合計を正の値で保持します。
+type MonthlySummary struct {
+	Year       int             `json:"year"`
+	Month      int             `json:"month"`
+	Income     int             `json:"income"`
+	Expense    int             `json:"expense"`
+	Balance    int             `json:"balance"`
+	Count      int             `json:"count"`
+	Categories []CategoryTotal `json:"categories"`
+}
+
+// CategoryTotal はカテゴリ別の収入・支出の合計です（支出は正の値）。
+// 親カテゴリの合計には子カテゴリの合計を含み、内訳を Children に保持します。
+type CategoryTotal struct {
+	CategoryId   int             `json:"category_id"`
+	CategoryName string          `json:"category_name"`
+	Income       int             `json:"income"`
+	Expense      int             `json:"expense"`
+	Count        int             `json:"count"`
+	Children     []CategoryTotal `json:"children,omitempty"`
+}
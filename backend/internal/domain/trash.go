@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// DefaultTrashRetentionDays はゴミ箱の収支を完全に削除するまでの日数の既定値です。
+const DefaultTrashRetentionDays = 30
+
+// TrashedTransaction はゴミ箱の収支です。PurgeAt を過ぎると完全に削除します。
+type TrashedTransaction struct {
+	Transaction
+	PurgeAt time.Time `json:"purge_at"`
+}
+
+// Trash はゴミ箱の収支の一覧です。
+type Trash struct {
+	RetentionDays int                  `json:"retention_days"`
+	Transactions  []TrashedTransaction `json:"transactions"` // ゴミ箱へ移した日時の新しい順
+}
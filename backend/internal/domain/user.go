@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// User はAPIを利用するユーザーを表すドメインモデルです。
+// パスワードは bcrypt でハッシュ化した値だけを保持し、JSON には出力しません。
+// HouseholdId はいま利用している家計簿で、登録時に作られた自分の家計簿か、招待を受けて参加した家計簿です。
+type User struct {
+	ID           int       `json:"id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	HouseholdId  int       `json:"household_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RegisterRequest はユーザー登録時のリクエストボディです。
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+// LoginRequest はログイン時のリクエストボディです。
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// AuthResponse はユーザー登録・ログインのレスポンスです。
+// Token は Authorization: Bearer ヘッダーで送るセッショントークンで、同じ値を Cookie にも設定します。
+type AuthResponse struct {
+	User      User      `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Session はログイン中のセッションです。
+// TokenHash はクライアントに渡したトークンの SHA-256（16進文字列）で、トークンそのものは保存しません。
+type Session struct {
+	TokenHash string
+	UserId    int
+	ExpiresAt time.Time
+}
+
+// NormalizeEmail はメールアドレスの前後の空白を除き、小文字にそろえます。
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,157 @@
+// Package exporter は収支を CSV・JSON などのファイル形式で書き出します。
+// 収支は1件ずつ Writer に渡し、すべての収支をメモリに載せずに書き出せるようにします。
+package exporter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+// 書き出しの形式です。
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Writer は収支を1件ずつ書き出します。Close で書き出しを終えます（w は閉じません）。
+type Writer interface {
+	Write(t domain.Transaction) error
+	Close() error
+}
+
+// nopCloser は閉じる必要のない io.Writer に Close を加えます。
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// encodeWriter は文字コード enc で書き出す io.WriteCloser を返します。
+// Shift_JIS で表せない文字（絵文字など）は "?" に置き換えます。Close は w を閉じません。
+func encodeWriter(w io.Writer, enc string) (io.WriteCloser, error) {
+	switch enc {
+	case "", domain.EncodingUTF8:
+		return nopCloser{w}, nil
+	case domain.EncodingShiftJIS:
+		check := japanese.ShiftJIS.NewEncoder()
+		replace := runes.Map(func(r rune) rune {
+			if r < utf8.RuneSelf {
+				return r
+			}
+			if _, err := check.String(string(r)); err != nil {
+				return '?'
+			}
+			return r
+		})
+		return transform.NewWriter(w, transform.Chain(replace, japanese.ShiftJIS.NewEncoder())), nil
+	default:
+		return nil, fmt.Errorf("encodingは %s / %s のいずれかを指定してください", domain.EncodingUTF8, domain.EncodingShiftJIS)
+	}
+}
+
+// csvHeader は CSV の見出し行です。
+var csvHeader = []string{"ID", "日付", "種別", "カテゴリ", "口座", "金額", "メモ", "タグ", "内訳"}
+
+// typeLabels は種別の表示名です。
+var typeLabels = map[string]string{
+	domain.TransactionTypeIncome:   "収入",
+	domain.TransactionTypeExpense:  "支出",
+	domain.TransactionTypeTransfer: "振替",
+}
+
+// csvWriter は収支を CSV の1行ずつ書き出します。
+type csvWriter struct {
+	out        io.WriteCloser
+	w          *csv.Writer
+	accounts   map[int]string
+	categories map[int]string
+}
+
+// NewCSVWriter は w に CSV を書き出す Writer を返し、見出し行を書き出します。
+// 金額は支出・振替元を負の値とし、タグは空白で、内訳は "カテゴリ 金額" を " / " でつなげます。
+// 改行は CRLF です。accounts・categories は口座・カテゴリのIDと名前の対応です。
+func NewCSVWriter(w io.Writer, enc string, accounts, categories map[int]string) (Writer, error) {
+	out, err := encodeWriter(w, enc)
+	if err != nil {
+		return nil, err
+	}
+	cw := &csvWriter{out: out, w: csv.NewWriter(out), accounts: accounts, categories: categories}
+	cw.w.UseCRLF = true
+	if err := cw.w.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(t domain.Transaction) error {
+	var splits []string
+	for _, s := range t.Splits {
+		splits = append(splits, cw.categories[s.CategoryId]+" "+strconv.Itoa(s.Amount))
+	}
+	return cw.w.Write([]string{
+		strconv.Itoa(t.ID),
+		t.Date.Format("2006-01-02"),
+		typeLabels[t.Type],
+		cw.categories[t.CategoryId],
+		cw.accounts[t.AccountId],
+		strconv.Itoa(t.Amount),
+		t.Memo,
+		strings.Join(t.Tags, " "),
+		strings.Join(splits, " / "),
+	})
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		return err
+	}
+	return cw.out.Close()
+}
+
+// jsonWriter は収支を JSON の配列として1件ずつ書き出します。
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+// NewJSONWriter は w に収支の JSON の配列を書き出す Writer を返します。
+// 各要素は一覧取得（GET /api/transactions）の収支と同じ形です。
+func NewJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+func (jw *jsonWriter) Write(t domain.Transaction) error {
+	sep := ",\n"
+	if jw.count == 0 {
+		sep = "[\n"
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	jw.count++
+	if _, err := io.WriteString(jw.w, sep); err != nil {
+		return err
+	}
+	_, err = jw.w.Write(data)
+	return err
+}
+
+func (jw *jsonWriter) Close() error {
+	end := "\n]\n"
+	if jw.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(jw.w, end)
+	return err
+}
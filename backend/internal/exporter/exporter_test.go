@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// exporter_test.go は収支の CSV・JSON の書き出しの単体テストです。
+
+var categoryNames = map[int]string{9: "その他", 10: "給与", 12: "食材"}
+
+func sampleTransactions() []domain.Transaction {
+	return []domain.Transaction{
+		{
+			ID: 1, Date: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 12, AccountId: 1,
+			Amount: -4200, Memo: "スーパー, 駅前", Category: domain.Category{Name: "食材"}, Tags: []string{"旅行2025", "子ども"},
+			Splits: []domain.Split{
+				{CategoryId: 12, Amount: -3000, Category: domain.Category{Name: "食材"}},
+				{CategoryId: 9, Amount: -1200, Category: domain.Category{Name: "その他"}},
+			},
+		},
+		{ID: 2, Date: time.Date(2025, 8, 25, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, AccountId: 2,
+			Amount: 250000, Memo: "給与🎉", Category: domain.Category{Name: "給与"}},
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, "", map[int]string{1: "現金", 2: "銀行"}, categoryNames)
+	if err != nil {
+		t.Fatalf("NewCSVWriter: unexpected error: %v", err)
+	}
+	for _, tx := range sampleTransactions() {
+		if err := w.Write(tx); err != nil {
+			t.Fatalf("Write: unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	want := "ID,日付,種別,カテゴリ,口座,金額,メモ,タグ,内訳\r\n" +
+		"1,2025-08-01,支出,食材,現金,-4200,\"スーパー, 駅前\",旅行2025 子ども,食材 -3000 / その他 -1200\r\n" +
+		"2,2025-08-25,収入,給与,銀行,250000,給与🎉,,\r\n"
+	if buf.String() != want {
+		t.Errorf("CSVWriter: got\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestCSVWriter_ShiftJIS(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, domain.EncodingShiftJIS, map[int]string{1: "現金", 2: "銀行"}, categoryNames)
+	if err != nil {
+		t.Fatalf("NewCSVWriter: unexpected error: %v", err)
+	}
+	for _, tx := range sampleTransactions() {
+		if err := w.Write(tx); err != nil {
+			t.Fatalf("Write: unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	text, err := japanese.ShiftJIS.NewDecoder().String(buf.String())
+	if err != nil {
+		t.Fatalf("decode: unexpected error: %v", err)
+	}
+	// Shift_JIS で表せない文字は置き換える
+	if !strings.HasPrefix(text, "ID,日付,種別") || !strings.Contains(text, "2,2025-08-25,収入,給与,銀行,250000,給与?,,") {
+		t.Errorf("CSVWriter(shift_jis): unexpected text %q", text)
+	}
+
+	if _, err := NewCSVWriter(&buf, "euc-jp", nil, nil); err == nil {
+		t.Error("NewCSVWriter: expected error for unsupported encoding")
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("JSONWriter: expected empty array, got %q", buf.String())
+	}
+
+	buf.Reset()
+	w = NewJSONWriter(&buf)
+	for _, tx := range sampleTransactions() {
+		if err := w.Write(tx); err != nil {
+			t.Fatalf("Write: unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	var got []domain.Transaction
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("JSONWriter: invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 || got[0].Memo != "スーパー, 駅前" || len(got[0].Splits) != 2 || got[1].Amount != 250000 {
+		t.Errorf("JSONWriter: unexpected transactions: %+v", got)
+	}
+}
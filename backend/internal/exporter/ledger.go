@@ -0,0 +1,261 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// ledger.go は複式簿記の仕訳帳（beancount・hledger）の書き出しです。
+// 口座は資産（Assets:、クレジットカードは負債 Liabilities:）、カテゴリは費用（Expenses:）・収益（Income:）の勘定科目とし、
+// 収支1件を1件の取引（口座とカテゴリの仕訳）として書き出します。金額の単位は JPY です。
+
+// 仕訳帳の形式です。
+const (
+	FormatBeancount = "beancount"
+	FormatHledger   = "hledger"
+)
+
+// 勘定科目の最上位の名前です。
+const (
+	LedgerAssets      = "Assets"
+	LedgerLiabilities = "Liabilities"
+	LedgerEquity      = "Equity"
+	LedgerIncome      = "Income"
+	LedgerExpenses    = "Expenses"
+)
+
+// 口座・カテゴリ以外の勘定科目です。
+const (
+	ledgerOpeningBalances = LedgerEquity + ":Opening-Balances" // 口座の開始残高の相手
+	ledgerTransfers       = LedgerEquity + ":Transfers"        // 組の相手を書き出さない振替の相手
+)
+
+// ledgerOpenDate は勘定科目を開く日と、開始残高の取引の日付です。
+const ledgerOpenDate = "1970-01-01"
+
+// ledgerWriter は収支を仕訳帳の取引として1件ずつ書き出します。
+type ledgerWriter struct {
+	w        io.Writer
+	format   string
+	accounts map[int]string // 口座IDと勘定科目名
+	expenses map[int]string // カテゴリIDと費用の勘定科目名
+	incomes  map[int]string // カテゴリIDと収益の勘定科目名
+
+	pending      map[int]domain.Transaction // 組の相手を待っている振替（TransferId ごと）
+	pendingOrder []int
+}
+
+// NewLedgerWriter は w に format（FormatBeancount / FormatHledger）の仕訳帳を書き出す Writer を返し、
+// 勘定科目の宣言と口座の開始残高の取引を書き出します。
+//
+// beancount では勘定科目名の各階層が大文字か数字で始まる必要があるため、口座・カテゴリの名前の前に
+// ID を付けます（例: Expenses:1-食費:11-外食）。hledger では名前をそのまま使います（例: Expenses:食費:外食）。
+// 振替は出金側・入金側の2行を1件の取引にまとめ、組の相手が書き出す範囲にない場合は Equity:Transfers を相手にします。
+func NewLedgerWriter(w io.Writer, format string, accounts []domain.Account, categories []domain.Category) (Writer, error) {
+	if format != FormatBeancount && format != FormatHledger {
+		return nil, fmt.Errorf("formatは %s / %s のいずれかを指定してください", FormatBeancount, FormatHledger)
+	}
+	lw := &ledgerWriter{
+		w:        w,
+		format:   format,
+		accounts: make(map[int]string, len(accounts)),
+		expenses: map[int]string{},
+		incomes:  map[int]string{},
+		pending:  map[int]domain.Transaction{},
+	}
+
+	var names []string
+	for _, a := range accounts {
+		root := LedgerAssets
+		if a.Kind == domain.AccountKindCreditCard {
+			root = LedgerLiabilities
+		}
+		lw.accounts[a.ID] = root + ":" + lw.component(a.ID, a.Name)
+		names = append(names, lw.accounts[a.ID])
+	}
+	byId := make(map[int]domain.Category, len(categories))
+	for _, c := range categories {
+		byId[c.ID] = c
+	}
+	for _, c := range categories {
+		path := lw.component(c.ID, c.Name)
+		if parent, ok := byId[c.ParentId]; ok {
+			path = lw.component(parent.ID, parent.Name) + ":" + path
+		}
+		if c.Kind != domain.CategoryKindIncome {
+			lw.expenses[c.ID] = LedgerExpenses + ":" + path
+			names = append(names, lw.expenses[c.ID])
+		}
+		if c.Kind != domain.CategoryKindExpense {
+			lw.incomes[c.ID] = LedgerIncome + ":" + path
+			names = append(names, lw.incomes[c.ID])
+		}
+	}
+	names = append(names, ledgerOpeningBalances, ledgerTransfers)
+
+	var b strings.Builder
+	b.WriteString("; 家計簿から書き出した仕訳帳です。金額の単位は円（JPY）です。\n")
+	if format == FormatBeancount {
+		b.WriteString("option \"operating_currency\" \"JPY\"\n\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s open %s JPY\n", ledgerOpenDate, name)
+		}
+	} else {
+		b.WriteString("\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "account %s\n", name)
+		}
+	}
+
+	opening := 0
+	var postings []string
+	for _, a := range accounts {
+		if a.OpeningBalance != 0 {
+			postings = append(postings, lw.posting(lw.accounts[a.ID], a.OpeningBalance))
+			opening += a.OpeningBalance
+		}
+	}
+	if len(postings) > 0 {
+		b.WriteString("\n" + lw.header(ledgerOpenDate, "開始残高", nil))
+		for _, p := range postings {
+			b.WriteString(p)
+		}
+		b.WriteString(lw.posting(ledgerOpeningBalances, -opening))
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return nil, err
+	}
+	return lw, nil
+}
+
+// component は勘定科目名の1階層を作ります。勘定科目名に使えない文字は "-" に置き換えます。
+func (lw *ledgerWriter) component(id int, name string) string {
+	if lw.format == FormatBeancount {
+		return strconv.Itoa(id) + "-" + strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+				return r
+			}
+			return '-'
+		}, name)
+	}
+	// hledger は ":" で階層を区切り、2つ以上続く空白で金額と区切るため、":" と ";" を置き換えて空白を1つにまとめる
+	name = strings.Join(strings.Fields(name), " ")
+	return strings.NewReplacer(":", "-", ";", "-").Replace(name)
+}
+
+// header は取引の1行目（とタグ）を作ります。
+func (lw *ledgerWriter) header(date, memo string, tags []string) string {
+	memo = strings.Join(strings.Fields(memo), " ")
+	if lw.format == FormatBeancount {
+		line := fmt.Sprintf("%s * %s\n", date, quoteBeancount(memo))
+		if len(tags) > 0 {
+			line += fmt.Sprintf("  tags: %s\n", quoteBeancount(strings.Join(tags, ",")))
+		}
+		return line
+	}
+	line := strings.TrimRight(date+" * "+strings.ReplaceAll(memo, ";", "；"), " ")
+	if len(tags) > 0 {
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = hledgerTagName(tag) + ":"
+		}
+		line += "  ; " + strings.Join(names, ", ")
+	}
+	return line + "\n"
+}
+
+// posting は勘定科目 account に amount 円を記入する行を作ります。
+func (lw *ledgerWriter) posting(account string, amount int) string {
+	return fmt.Sprintf("  %s  %d JPY\n", account, amount)
+}
+
+// splitMemo は内訳のメモを内訳の行に付けます。
+func (lw *ledgerWriter) splitMemo(memo string) string {
+	memo = strings.Join(strings.Fields(memo), " ")
+	if memo == "" {
+		return ""
+	}
+	if lw.format == FormatBeancount {
+		return fmt.Sprintf("    memo: %s\n", quoteBeancount(memo))
+	}
+	return "    ; " + memo + "\n"
+}
+
+func (lw *ledgerWriter) Write(t domain.Transaction) error {
+	if t.Type == domain.TransactionTypeTransfer {
+		other, ok := lw.pending[t.TransferId]
+		if !ok {
+			lw.pending[t.TransferId] = t
+			lw.pendingOrder = append(lw.pendingOrder, t.TransferId)
+			return nil
+		}
+		delete(lw.pending, t.TransferId)
+		return lw.writeTransfer(other, t)
+	}
+
+	categories := lw.expenses
+	if t.Type == domain.TransactionTypeIncome {
+		categories = lw.incomes
+	}
+	var b strings.Builder
+	b.WriteString("\n" + lw.header(t.Date.Format("2006-01-02"), t.Memo, t.Tags))
+	for _, line := range t.CategoryLines() {
+		b.WriteString(lw.posting(categories[line.CategoryId], -line.Amount))
+		if len(t.Splits) > 0 {
+			b.WriteString(lw.splitMemo(line.Memo))
+		}
+	}
+	b.WriteString(lw.posting(lw.accounts[t.AccountId], t.Amount))
+	_, err := io.WriteString(lw.w, b.String())
+	return err
+}
+
+// writeTransfer は振替の2行 a・b（順不同）を1件の取引として書き出します。
+func (lw *ledgerWriter) writeTransfer(a, b domain.Transaction) error {
+	out, in := a, b
+	if out.Amount > 0 {
+		out, in = b, a
+	}
+	return lw.writeTransferTo(out, lw.accounts[in.AccountId])
+}
+
+// writeTransferTo は振替の1行 t と、その相手の勘定科目 other を1件の取引として書き出します。
+func (lw *ledgerWriter) writeTransferTo(t domain.Transaction, other string) error {
+	_, err := io.WriteString(lw.w, "\n"+lw.header(t.Date.Format("2006-01-02"), t.Memo, nil)+
+		lw.posting(lw.accounts[t.AccountId], t.Amount)+
+		lw.posting(other, -t.Amount))
+	return err
+}
+
+// Close は組の相手を書き出さなかった振替を Equity:Transfers を相手にして書き出します。
+func (lw *ledgerWriter) Close() error {
+	for _, id := range lw.pendingOrder {
+		if t, ok := lw.pending[id]; ok {
+			if err := lw.writeTransferTo(t, ledgerTransfers); err != nil {
+				return err
+			}
+		}
+	}
+	lw.pending, lw.pendingOrder = map[int]domain.Transaction{}, nil
+	return nil
+}
+
+// quoteBeancount は beancount の文字列として s を引用符で囲みます。
+func quoteBeancount(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// hledgerTagName は hledger のタグ名として使えない文字（空白・","・":"）を "_" に置き換えます。
+func hledgerTagName(tag string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == ',' || r == ':' {
+			return '_'
+		}
+		return r
+	}, tag)
+}
@@ -0,0 +1,141 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// ledger_test.go は仕訳帳（beancount・hledger）の書き出しの単体テストです。
+
+var (
+	ledgerAccounts = []domain.Account{
+		{ID: 1, Name: "現金", Kind: domain.AccountKindCash},
+		{ID: 2, Name: "みずほ 普通", Kind: domain.AccountKindBank, OpeningBalance: 100000},
+		{ID: 3, Name: "楽天カード", Kind: domain.AccountKindCreditCard},
+	}
+	ledgerCategories = []domain.Category{
+		{ID: 1, Name: "食費", Kind: domain.CategoryKindExpense},
+		{ID: 9, Name: "その他", Kind: domain.CategoryKindBoth},
+		{ID: 10, Name: "給与", Kind: domain.CategoryKindIncome},
+		{ID: 12, Name: "食材", Kind: domain.CategoryKindExpense, ParentId: 1},
+	}
+)
+
+// ledgerTransactions は sampleTransactions に振替（出金側・入金側）と組の相手のない振替を加えたものです。
+func ledgerTransactions() []domain.Transaction {
+	date := time.Date(2025, 8, 26, 0, 0, 0, 0, time.UTC)
+	return append(sampleTransactions(),
+		domain.Transaction{ID: 3, Date: date, Type: "transfer", AccountId: 2, Amount: -30000, Memo: "カード引き落とし", TransferId: 3},
+		domain.Transaction{ID: 4, Date: date, Type: "transfer", AccountId: 3, Amount: 30000, Memo: "カード引き落とし", TransferId: 3},
+		domain.Transaction{ID: 6, Date: date, Type: "transfer", AccountId: 1, Amount: 5000, Memo: "引き出し", TransferId: 5},
+	)
+}
+
+func writeLedger(t *testing.T, format string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewLedgerWriter(&buf, format, ledgerAccounts, ledgerCategories)
+	if err != nil {
+		t.Fatalf("NewLedgerWriter: unexpected error: %v", err)
+	}
+	for _, tx := range ledgerTransactions() {
+		if err := w.Write(tx); err != nil {
+			t.Fatalf("Write: unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	return buf.String()
+}
+
+func TestLedgerWriter_Beancount(t *testing.T) {
+	want := `; 家計簿から書き出した仕訳帳です。金額の単位は円（JPY）です。
+option "operating_currency" "JPY"
+
+1970-01-01 open Assets:1-現金 JPY
+1970-01-01 open Assets:2-みずほ-普通 JPY
+1970-01-01 open Liabilities:3-楽天カード JPY
+1970-01-01 open Expenses:1-食費 JPY
+1970-01-01 open Expenses:9-その他 JPY
+1970-01-01 open Income:9-その他 JPY
+1970-01-01 open Income:10-給与 JPY
+1970-01-01 open Expenses:1-食費:12-食材 JPY
+1970-01-01 open Equity:Opening-Balances JPY
+1970-01-01 open Equity:Transfers JPY
+
+1970-01-01 * "開始残高"
+  Assets:2-みずほ-普通  100000 JPY
+  Equity:Opening-Balances  -100000 JPY
+
+2025-08-01 * "スーパー, 駅前"
+  tags: "旅行2025,子ども"
+  Expenses:1-食費:12-食材  3000 JPY
+  Expenses:9-その他  1200 JPY
+  Assets:1-現金  -4200 JPY
+
+2025-08-25 * "給与🎉"
+  Income:10-給与  -250000 JPY
+  Assets:2-みずほ-普通  250000 JPY
+
+2025-08-26 * "カード引き落とし"
+  Assets:2-みずほ-普通  -30000 JPY
+  Liabilities:3-楽天カード  30000 JPY
+
+2025-08-26 * "引き出し"
+  Assets:1-現金  5000 JPY
+  Equity:Transfers  -5000 JPY
+`
+	if got := writeLedger(t, FormatBeancount); got != want {
+		t.Errorf("LedgerWriter(beancount): got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLedgerWriter_Hledger(t *testing.T) {
+	want := `; 家計簿から書き出した仕訳帳です。金額の単位は円（JPY）です。
+
+account Assets:現金
+account Assets:みずほ 普通
+account Liabilities:楽天カード
+account Expenses:食費
+account Expenses:その他
+account Income:その他
+account Income:給与
+account Expenses:食費:食材
+account Equity:Opening-Balances
+account Equity:Transfers
+
+1970-01-01 * 開始残高
+  Assets:みずほ 普通  100000 JPY
+  Equity:Opening-Balances  -100000 JPY
+
+2025-08-01 * スーパー, 駅前  ; 旅行2025:, 子ども:
+  Expenses:食費:食材  3000 JPY
+  Expenses:その他  1200 JPY
+  Assets:現金  -4200 JPY
+
+2025-08-25 * 給与🎉
+  Income:給与  -250000 JPY
+  Assets:みずほ 普通  250000 JPY
+
+2025-08-26 * カード引き落とし
+  Assets:みずほ 普通  -30000 JPY
+  Liabilities:楽天カード  30000 JPY
+
+2025-08-26 * 引き出し
+  Assets:現金  5000 JPY
+  Equity:Transfers  -5000 JPY
+`
+	if got := writeLedger(t, FormatHledger); got != want {
+		t.Errorf("LedgerWriter(hledger): got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLedgerWriter_InvalidFormat(t *testing.T) {
+	if _, err := NewLedgerWriter(&bytes.Buffer{}, "ledger", nil, nil); err == nil {
+		t.Error("NewLedgerWriter: expected error for unknown format")
+	}
+}
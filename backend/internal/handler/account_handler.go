@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccountHandler は口座（現金・銀行口座・クレジットカードなど）関連のHTTPリクエストを処理するハンドラです。
+// 口座は家計簿ごとに分かれ、ほかの家計簿の口座は見つからないものとして扱います。
+type AccountHandler struct {
+	repo repository.TransactionRepository
+}
+
+// NewAccountHandler はAccountHandlerを生成します。
+func NewAccountHandler(repo repository.TransactionRepository) *AccountHandler {
+	return &AccountHandler{repo: repo}
+}
+
+// 口座名の最大文字数です（accounts.name の VARCHAR(50) に合わせています）。
+const maxAccountNameLength = 50
+
+// GetAccounts は口座一覧を現在の残高付きで取得するGET /api/accountsのハンドラです。
+// アーカイブ済みの口座は include_archived=true を指定した場合のみ含めます。
+func (h *AccountHandler) GetAccounts(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	balances, err := repo.FindAccountBalances()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	includeArchived := c.QueryParam("include_archived") == "true"
+	result := []domain.AccountBalance{}
+	for _, b := range balances {
+		if b.Archived && !includeArchived {
+			continue
+		}
+		result = append(result, b)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// CreateAccount は口座を新規作成するPOST /api/accountsのハンドラです。
+func (h *AccountHandler) CreateAccount(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.AccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	account, err := validateAccount(repo, req, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := repo.SaveAccount(&account); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の保存に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, account)
+}
+
+// UpdateAccount は口座の名前・種別・開始残高・表示順の変更とアーカイブを行うPUT /api/accounts/{id}のハンドラです。
+func (h *AccountHandler) UpdateAccount(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if _, err := repo.FindAccountById(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	var req domain.AccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	account, err := validateAccount(repo, req, id)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	account.ID = id
+	if err := repo.UpdateAccount(&account); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, account)
+}
+
+// DeleteAccount は口座を削除するDELETE /api/accounts/{id}のハンドラです。
+// 収支・定期収支・仕分けルールから参照されている場合は 409 を返します。
+func (h *AccountHandler) DeleteAccount(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if _, err := repo.FindAccountById(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := repo.DeleteAccount(id); err != nil {
+		if errors.Is(err, repository.ErrAccountInUse) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "この口座を使用している収支・定期収支・仕分けルールがあるため削除できません。アーカイブしてください",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の削除に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "口座が削除されました",
+	})
+}
+
+// validateAccount は口座のリクエストを検証し、口座を組み立てます。
+// 名前は前後の空白を除き、repo の家計簿の excludeId 以外の口座と重複する場合はエラーにします。
+func validateAccount(repo repository.TransactionRepository, req domain.AccountRequest, excludeId int) (domain.Account, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return domain.Account{}, errors.New("nameを指定してください")
+	}
+	if utf8.RuneCountInString(name) > maxAccountNameLength {
+		return domain.Account{}, fmt.Errorf("nameは%d文字以内で指定してください", maxAccountNameLength)
+	}
+	if !domain.IsValidAccountKind(req.Kind) {
+		return domain.Account{}, errors.New("kindは cash / bank / credit_card / e_money のいずれかを指定してください")
+	}
+	if req.DisplayOrder < 0 {
+		return domain.Account{}, errors.New("display_orderは0以上の整数で指定してください")
+	}
+
+	accounts, err := repo.FindAllAccounts()
+	if err != nil {
+		return domain.Account{}, errors.New("口座の取得に失敗しました: " + err.Error())
+	}
+	for _, account := range accounts {
+		if account.ID != excludeId && account.Name == name {
+			return domain.Account{}, errors.New("同じ名前の口座が既に存在します: " + name)
+		}
+	}
+
+	return domain.Account{
+		Name:           name,
+		Kind:           req.Kind,
+		OpeningBalance: req.OpeningBalance,
+		DisplayOrder:   req.DisplayOrder,
+		Archived:       req.Archived,
+	}, nil
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// account_handler_test.go は AccountHandler の HTTP ハンドラテストです。
+
+func TestGetAccounts_Balances(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewAccountHandler(repo)
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 開始残高10万円の銀行口座を作り、現金と銀行に1件ずつ支出を登録
+	req := httptest.NewRequest(http.MethodPost, "/api/accounts",
+		bytes.NewBufferString(`{"name":"銀行","kind":"bank","opening_balance":100000}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateAccount(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateAccount: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateAccount: expected status 201, got %d", rec.Code)
+	}
+	for _, body := range []string{
+		`{"date":"2025-01-15","type":"expense","category_id":12,"amount":1500}`,
+		`{"date":"2025-01-27","type":"expense","category_id":3,"account_id":2,"amount":80000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := th.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+	rec = httptest.NewRecorder()
+	if err := h.GetAccounts(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetAccounts: unexpected error: %v", err)
+	}
+	var balances []domain.AccountBalance
+	if err := json.Unmarshal(rec.Body.Bytes(), &balances); err != nil {
+		t.Fatalf("GetAccounts: invalid JSON: %v", err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("GetAccounts: expected 2 accounts, got %+v", balances)
+	}
+	if balances[0].Name != "現金" || balances[0].Balance != -1500 {
+		t.Errorf("GetAccounts: expected 現金 balance -1500, got %+v", balances[0])
+	}
+	if balances[1].Name != "銀行" || balances[1].Balance != 20000 {
+		t.Errorf("GetAccounts: expected 銀行 balance 20000, got %+v", balances[1])
+	}
+}
+
+func TestCreateAccount_Validation(t *testing.T) {
+	h := NewAccountHandler(repository.NewTransactionRepository())
+	e := echo.New()
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"PayPay","kind":"e_money"}`, http.StatusCreated},
+		{`{"name":"PayPay","kind":"e_money"}`, http.StatusBadRequest}, // 名前の重複
+		{`{"name":"","kind":"cash"}`, http.StatusBadRequest},
+		{`{"name":"財布","kind":"wallet"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/accounts", bytes.NewBufferString(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if err := h.CreateAccount(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateAccount: unexpected error: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("CreateAccount(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestDeleteAccount_InUse(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewAccountHandler(repo)
+	e := echo.New()
+
+	tx := &domain.Transaction{Type: "expense", CategoryId: 1, AccountId: 1, Amount: -500}
+	if err := repo.Save(tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/accounts/1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	if err := h.DeleteAccount(c); err != nil {
+		t.Fatalf("DeleteAccount: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("DeleteAccount: expected status 409, got %d", rec.Code)
+	}
+}
+
+func TestDeleteAccount_UsedByRules(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	recurring := repository.NewRecurringRuleRepository()
+	rules := repository.NewCategoryRuleRepository()
+	repository.LinkMemoryRepositories(repo, recurring, rules, nil)
+	h := NewAccountHandler(repo)
+	e := echo.New()
+
+	salary := &domain.Account{Name: "給与口座", Kind: domain.AccountKindBank}
+	card := &domain.Account{Name: "カード", Kind: domain.AccountKindCreditCard}
+	for _, a := range []*domain.Account{salary, card} {
+		if err := repo.SaveAccount(a); err != nil {
+			t.Fatalf("SaveAccount: unexpected error: %v", err)
+		}
+	}
+	if err := recurring.Save(&domain.RecurringRule{Name: "給与", Type: "income", CategoryId: 10, AccountId: salary.ID, Amount: 250000}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if err := rules.Save(&domain.CategoryRule{Name: "カード", Enabled: true, AccountId: card.ID, CategoryId: 12}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 定期収支ルール・仕分けルールだけが使っている口座も削除できない
+	for _, a := range []*domain.Account{salary, card} {
+		id := strconv.Itoa(a.ID)
+		req := httptest.NewRequest(http.MethodDelete, "/api/accounts/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if err := h.DeleteAccount(c); err != nil {
+			t.Fatalf("DeleteAccount: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusConflict {
+			t.Errorf("DeleteAccount(%s): expected status 409, got %d", a.Name, rec.Code)
+		}
+	}
+	if _, err := repo.FindAccountById(salary.ID); err != nil {
+		t.Errorf("FindAccountById: expected the account to remain, got %v", err)
+	}
+}
+
+func TestCreateTransaction_Transfer(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewAccountHandler(repo)
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	if err := repo.SaveAccount(&domain.Account{Name: "銀行", Kind: domain.AccountKindBank, OpeningBalance: 100000}); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"date":"2025-03-05","type":"transfer","account_id":2,"to_account_id":1,"amount":30000,"memo":"ATM"}`, http.StatusCreated},
+		{`{"date":"2025-03-05","type":"transfer","account_id":2,"amount":30000}`, http.StatusBadRequest},                   // 振替先なし
+		{`{"date":"2025-03-05","type":"transfer","account_id":1,"to_account_id":1,"amount":30000}`, http.StatusBadRequest}, // 同じ口座
+		{`{"date":"2025-03-05","type":"transfer","account_id":2,"to_account_id":9,"amount":30000}`, http.StatusBadRequest}, // 存在しない口座
+		{`{"date":"2025-03-05","type":"transfer","account_id":2,"to_account_id":1,"category_id":12,"amount":30000}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := th.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("CreateTransaction(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+		if tc.want != http.StatusCreated {
+			continue
+		}
+		var transfer domain.Transfer
+		if err := json.Unmarshal(rec.Body.Bytes(), &transfer); err != nil {
+			t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+		}
+		if transfer.FromAccountId != 2 || transfer.ToAccountId != 1 || transfer.Amount != 30000 || len(transfer.Transactions) != 2 {
+			t.Errorf("CreateTransaction: unexpected transfer %+v", transfer)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/accounts", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetAccounts(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetAccounts: unexpected error: %v", err)
+	}
+	var balances []domain.AccountBalance
+	if err := json.Unmarshal(rec.Body.Bytes(), &balances); err != nil {
+		t.Fatalf("GetAccounts: invalid JSON: %v", err)
+	}
+	if len(balances) != 2 || balances[0].Balance != 30000 || balances[1].Balance != 70000 {
+		t.Errorf("GetAccounts: expected balances 30000 and 70000 after transfer, got %+v", balances)
+	}
+}
+
+func TestUpdateTransaction_TransferTypeChange(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	if err := repo.SaveAccount(&domain.Account{Name: "銀行", Kind: domain.AccountKindBank}); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+	out := &domain.Transaction{Type: domain.TransactionTypeTransfer, AccountId: 1, Amount: -5000}
+	in := &domain.Transaction{Type: domain.TransactionTypeTransfer, AccountId: 2, Amount: 5000}
+	if err := repo.SaveTransfer(out, in); err != nil {
+		t.Fatalf("SaveTransfer: unexpected error: %v", err)
+	}
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"date":"2025-03-06","type":"expense","category_id":12,"amount":5000}`, http.StatusBadRequest},
+		{`{"date":"2025-03-06","type":"transfer","account_id":1,"to_account_id":2,"amount":8000}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPut, "/api/transactions/2", bytes.NewBufferString(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		if err := th.UpdateTransaction(c); err != nil {
+			t.Fatalf("UpdateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("UpdateTransaction(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+
+	pair, err := repo.FindTransfer(out.TransferId)
+	if err != nil {
+		t.Fatalf("FindTransfer: unexpected error: %v", err)
+	}
+	if pair[0].Amount != -8000 || pair[1].Amount != 8000 || pair[1].AccountId != 2 {
+		t.Errorf("UpdateTransaction: expected both rows updated to 8000, got %+v", pair)
+	}
+}
+
+// getAccountBalances はログイン中のユーザーが利用している家計簿の口座を返します。
+func getAccountBalances(t *testing.T, e *echo.Echo, token string) []domain.AccountBalance {
+	t.Helper()
+	rec := doJSON(e, http.MethodGet, "/api/accounts", token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetAccounts: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var balances []domain.AccountBalance
+	if err := json.Unmarshal(rec.Body.Bytes(), &balances); err != nil {
+		t.Fatalf("GetAccounts: invalid JSON: %v", err)
+	}
+	return balances
+}
+
+func TestAccounts_PerHousehold(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	taro := registerUser(t, e, "taro@example.com")
+	hanako := registerUser(t, e, "hanako@example.com")
+
+	// 2人目のユーザーの家計簿には別の現金口座が用意され、最初の家計簿の口座は見えない
+	taroAccounts := getAccountBalances(t, e, taro)
+	hanakoAccounts := getAccountBalances(t, e, hanako)
+	if len(taroAccounts) != 1 || len(hanakoAccounts) != 1 {
+		t.Fatalf("GetAccounts: expected one account per household, got %+v and %+v", taroAccounts, hanakoAccounts)
+	}
+	taroCash, hanakoCash := taroAccounts[0], hanakoAccounts[0]
+	if taroCash.ID == hanakoCash.ID || hanakoCash.Name != "現金" {
+		t.Fatalf("GetAccounts: expected a separate 現金 account for household 2, got %+v", hanakoCash)
+	}
+
+	// 他の家計簿の口座は更新・削除できない
+	path := "/api/accounts/" + strconv.Itoa(taroCash.ID)
+	if rec := doJSON(e, http.MethodPut, path, hanako, `{"name":"乗っ取り","kind":"cash"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("UpdateAccount(other household's account): expected status 404, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodDelete, path, hanako, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("DeleteAccount(other household's account): expected status 404, got %d", rec.Code)
+	}
+
+	// 他の家計簿の口座には収支・振替を登録できず、account_id を省略すると自分の家計簿の口座になる
+	food := findCategoryByName(t, e, hanako, "食材")
+	for _, body := range []string{
+		`{"date":"2025-01-10","type":"expense","category_id":` + strconv.Itoa(food.ID) + `,"account_id":` + strconv.Itoa(taroCash.ID) + `,"amount":800}`,
+		`{"date":"2025-01-10","type":"transfer","to_account_id":` + strconv.Itoa(taroCash.ID) + `,"amount":800}`,
+	} {
+		if rec := doJSON(e, http.MethodPost, "/api/transactions", hanako, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+	rec := doJSON(e, http.MethodPost, "/api/transactions", hanako,
+		`{"date":"2025-01-10","type":"expense","category_id":`+strconv.Itoa(food.ID)+`,"amount":800}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Transaction
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.AccountId != hanakoCash.ID {
+		t.Errorf("CreateTransaction: expected household 2's account %d, got %d", hanakoCash.ID, created.AccountId)
+	}
+
+	if got := getAccountBalances(t, e, taro); len(got) != 1 || got[0].Name != "現金" || got[0].Balance != 0 {
+		t.Errorf("GetAccounts(taro): expected the first household's account unchanged, got %+v", got)
+	}
+}
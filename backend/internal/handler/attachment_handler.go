@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+	"kakeibo-app/backend/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AttachmentHandler は収支の添付ファイル（レシート・書類）のHTTPリクエストを処理するハンドラです。
+// ファイルの中身は blobs に保存し、情報はリポジトリに保存します。
+type AttachmentHandler struct {
+	repo  repository.TransactionRepository
+	blobs storage.BlobStore
+}
+
+// NewAttachmentHandler はAttachmentHandlerを生成します。
+func NewAttachmentHandler(repo repository.TransactionRepository, blobs storage.BlobStore) *AttachmentHandler {
+	return &AttachmentHandler{repo: repo, blobs: blobs}
+}
+
+// GetAttachments は収支の添付ファイル一覧を添付した順に取得するGET /api/transactions/{id}/attachmentsのハンドラです。
+func (h *AttachmentHandler) GetAttachments(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	attachments, err := repo.FindAttachments(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "添付ファイルの取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment は収支にファイルを添付するPOST /api/transactions/{id}/attachmentsのハンドラです。
+// multipart/form-data の file に画像（JPEG・PNG・GIF・WebP）かPDFを指定します（domain.MaxAttachmentSize まで）。
+// ファイルの種類はファイル名やヘッダーではなく中身から判定し、JPEG・PNG・GIF はサムネイルも作ります。
+func (h *AttachmentHandler) UploadAttachment(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "fileに添付するファイルを指定してください",
+		})
+	}
+	tooLarge := fmt.Sprintf("ファイルは%dMBまでです", domain.MaxAttachmentSize>>20)
+	if header.Size > domain.MaxAttachmentSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": tooLarge,
+		})
+	}
+	f, err := header.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "ファイルを開けません: " + err.Error(),
+		})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, domain.MaxAttachmentSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "ファイルを読み込めません: " + err.Error(),
+		})
+	}
+	if len(data) > domain.MaxAttachmentSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": tooLarge,
+		})
+	}
+	if len(data) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "空のファイルは添付できません",
+		})
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if !domain.IsAttachmentContentType(contentType) {
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"error": "添付できるのは画像（JPEG・PNG・GIF・WebP）とPDFだけです",
+		})
+	}
+	if _, err := repo.FindById(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	key, err := storage.NewKey(fmt.Sprintf("attachments/%d", currentHouseholdId(c)))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ファイルの保存に失敗しました: " + err.Error(),
+		})
+	}
+	attachment := domain.Attachment{
+		TransactionId: id,
+		FileName:      attachmentFileName(header.Filename, contentType),
+		ContentType:   contentType,
+		Size:          int64(len(data)),
+		BlobKey:       key,
+		CreatedBy:     currentUserId(c),
+	}
+	if err := h.blobs.Put(attachment.BlobKey, bytes.NewReader(data)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ファイルの保存に失敗しました: " + err.Error(),
+		})
+	}
+	// サムネイルを作れない画像（WebP や壊れた画像など）はサムネイルなしで添付する
+	if thumbnail, err := storage.Thumbnail(data); err == nil {
+		if err := h.blobs.Put(attachment.ThumbnailKey(), bytes.NewReader(thumbnail)); err == nil {
+			attachment.HasThumbnail = true
+		}
+	}
+	if err := repo.SaveAttachment(&attachment); err != nil {
+		storage.DeleteAttachments(h.blobs, []domain.Attachment{attachment})
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "添付ファイルの登録に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment は添付ファイルの中身を返すGET /api/attachments/{id}のハンドラです。
+// ブラウザで表示できるよう inline で返します。download=true の場合はダウンロードさせます。
+func (h *AttachmentHandler) DownloadAttachment(c echo.Context) error {
+	attachment, ok, err := h.findAttachment(c)
+	if !ok {
+		return err
+	}
+	disposition := "inline"
+	if c.QueryParam("download") == "true" {
+		disposition = "attachment"
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}); v != "" {
+		disposition = v
+	}
+	return h.sendBlob(c, attachment.BlobKey, attachment.ContentType, disposition)
+}
+
+// GetThumbnail は画像の添付ファイルのサムネイル（JPEG）を返すGET /api/attachments/{id}/thumbnailのハンドラです。
+// サムネイルのない添付ファイル（PDF など）は404です。
+func (h *AttachmentHandler) GetThumbnail(c echo.Context) error {
+	attachment, ok, err := h.findAttachment(c)
+	if !ok {
+		return err
+	}
+	if !attachment.HasThumbnail {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "この添付ファイルにはサムネイルがありません",
+		})
+	}
+	return h.sendBlob(c, attachment.ThumbnailKey(), "image/jpeg", "inline")
+}
+
+// DeleteAttachment は添付ファイルを削除するDELETE /api/attachments/{id}のハンドラです。
+func (h *AttachmentHandler) DeleteAttachment(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	attachment, ok, err := h.findAttachment(c)
+	if !ok {
+		return err
+	}
+	if err := repo.DeleteAttachment(attachment.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "添付ファイルの削除に失敗しました: " + err.Error(),
+		})
+	}
+	storage.DeleteAttachments(h.blobs, []domain.Attachment{attachment})
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "添付ファイルが削除されました",
+	})
+}
+
+// findAttachment はパスの id の添付ファイルを返します。
+// 見つからない場合などは ok が false で、err はエラーのレスポンスを書いた結果です。
+func (h *AttachmentHandler) findAttachment(c echo.Context) (domain.Attachment, bool, error) {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return domain.Attachment{}, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	attachment, err := repo.FindAttachmentById(id)
+	if err != nil {
+		return domain.Attachment{}, false, c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return attachment, true, nil
+}
+
+// sendBlob はファイル置き場の key のファイルを contentType で返します。
+func (h *AttachmentHandler) sendBlob(c echo.Context, key, contentType, disposition string) error {
+	f, err := h.blobs.Open(key)
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "ファイルが見つかりません",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ファイルの取得に失敗しました: " + err.Error(),
+		})
+	}
+	defer f.Close()
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentDisposition, disposition)
+	res.Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+	res.Header().Set(echo.HeaderCacheControl, "private, max-age=86400")
+	return c.Stream(http.StatusOK, contentType, f)
+}
+
+// attachmentFileName はアップロード時のファイル名から保存するファイル名を作ります。
+// ディレクトリ部分と制御文字を取り除いて長さを切り詰め、空の場合は "attachment" と種類に合う拡張子にします。
+func attachmentFileName(name, contentType string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" {
+		name = "attachment" + attachmentExtensions[contentType]
+	}
+	if runes := []rune(name); len(runes) > domain.MaxAttachmentFileNameLength {
+		ext := []rune(filepath.Ext(name))
+		if len(ext) >= domain.MaxAttachmentFileNameLength {
+			ext = nil
+		}
+		name = string(runes[:domain.MaxAttachmentFileNameLength-len(ext)]) + string(ext)
+	}
+	return name
+}
+
+// attachmentExtensions は添付できるファイルの種類ごとの拡張子です。
+var attachmentExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
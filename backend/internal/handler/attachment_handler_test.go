@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+	"kakeibo-app/backend/internal/storage"
+
+	"github.com/labstack/echo/v4"
+)
+
+// attachment_handler_test.go は AttachmentHandler（収支の添付ファイル）の HTTP ハンドラテストです。
+
+// newTestBlobStore はテストの一時ディレクトリに保存するファイル置き場を返します。
+func newTestBlobStore(t *testing.T) *storage.LocalBlobStore {
+	t.Helper()
+	return storage.NewLocalBlobStore(t.TempDir())
+}
+
+// testPNG は width×height の PNG 画像を返します。
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("png.Encode: unexpected error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// uploadAttachment は収支 transactionId に filename の data を添付し、レスポンスを返します。
+func uploadAttachment(t *testing.T, h *AttachmentHandler, e *echo.Echo, transactionId int, filename string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: unexpected error: %v", err)
+	}
+	fw.Write(data)
+	w.Close()
+	id := strconv.Itoa(transactionId)
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions/"+id+"/attachments", &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+	if err := h.UploadAttachment(c); err != nil {
+		t.Fatalf("UploadAttachment: unexpected error: %v", err)
+	}
+	return rec
+}
+
+// callAttachment はパスの id で fn を呼び出し、レスポンスを返します。
+func callAttachment(t *testing.T, fn echo.HandlerFunc, e *echo.Echo, method, target string, id int) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(id))
+	if err := fn(c); err != nil {
+		t.Fatalf("%s %s: unexpected error: %v", method, target, err)
+	}
+	return rec
+}
+
+// saveAttachmentTarget は添付先の収支を登録して返します。
+func saveAttachmentTarget(t *testing.T, repo repository.TransactionRepository) domain.Transaction {
+	t.Helper()
+	tx := &domain.Transaction{Type: "expense", CategoryId: 1, AccountId: 1, Amount: -5800, Memo: "病院"}
+	if err := repo.Save(tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	return *tx
+}
+
+func TestUploadAttachment(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewAttachmentHandler(repo, newTestBlobStore(t))
+	e := echo.New()
+	tx := saveAttachmentTarget(t, repo)
+
+	// 画像はサムネイルを作り、ファイル名のディレクトリ部分は取り除く
+	photo := testPNG(t, 800, 1200)
+	rec := uploadAttachment(t, h, e, tx.ID, `C:\Users\me\領収書.png`, photo)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("UploadAttachment: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var receipt domain.Attachment
+	json.Unmarshal(rec.Body.Bytes(), &receipt)
+	if receipt.FileName != "領収書.png" || receipt.ContentType != "image/png" || receipt.Size != int64(len(photo)) || !receipt.HasThumbnail {
+		t.Errorf("UploadAttachment: unexpected attachment: %+v", receipt)
+	}
+
+	// PDF はファイル名の拡張子によらず中身で判定し、サムネイルは作らない
+	rec = uploadAttachment(t, h, e, tx.ID, "warranty.png", []byte("%PDF-1.7\n保証書"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("UploadAttachment(PDF): expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var warranty domain.Attachment
+	json.Unmarshal(rec.Body.Bytes(), &warranty)
+	if warranty.ContentType != "application/pdf" || warranty.HasThumbnail {
+		t.Errorf("UploadAttachment(PDF): unexpected attachment: %+v", warranty)
+	}
+
+	rec = callAttachment(t, h.GetAttachments, e, http.MethodGet, "/api/transactions/1/attachments", tx.ID)
+	var attachments []domain.Attachment
+	json.Unmarshal(rec.Body.Bytes(), &attachments)
+	if rec.Code != http.StatusOK || len(attachments) != 2 || attachments[0].ID != receipt.ID || attachments[1].ID != warranty.ID {
+		t.Errorf("GetAttachments: unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = callAttachment(t, h.DownloadAttachment, e, http.MethodGet, "/api/attachments/1", receipt.ID)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), photo) {
+		t.Errorf("DownloadAttachment: unexpected response %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+	if got := rec.Header().Get(echo.HeaderContentType); got != "image/png" {
+		t.Errorf("DownloadAttachment: expected Content-Type image/png, got %q", got)
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); !strings.HasPrefix(got, "inline; filename*=utf-8''") {
+		t.Errorf("DownloadAttachment: unexpected Content-Disposition %q", got)
+	}
+
+	rec = callAttachment(t, h.GetThumbnail, e, http.MethodGet, "/api/attachments/1/thumbnail", receipt.ID)
+	thumbnail, err := png.DecodeConfig(bytes.NewReader(rec.Body.Bytes()))
+	if err == nil {
+		t.Errorf("GetThumbnail: expected JPEG, got PNG %+v", thumbnail)
+	}
+	if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderContentType) != "image/jpeg" {
+		t.Errorf("GetThumbnail: unexpected response %d: %s", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+	if rec := callAttachment(t, h.GetThumbnail, e, http.MethodGet, "/api/attachments/2/thumbnail", warranty.ID); rec.Code != http.StatusNotFound {
+		t.Errorf("GetThumbnail(PDF): expected status 404, got %d", rec.Code)
+	}
+}
+
+func TestUploadAttachment_Invalid(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewAttachmentHandler(repo, newTestBlobStore(t))
+	e := echo.New()
+	tx := saveAttachmentTarget(t, repo)
+
+	tests := []struct {
+		name          string
+		transactionId int
+		data          []byte
+		want          int
+	}{
+		{"画像・PDF以外", tx.ID, []byte("<html><script>alert(1)</script></html>"), http.StatusUnsupportedMediaType},
+		{"空のファイル", tx.ID, []byte{}, http.StatusBadRequest},
+		{"大きすぎる", tx.ID, append([]byte("%PDF-1.7\n"), make([]byte, domain.MaxAttachmentSize)...), http.StatusRequestEntityTooLarge},
+		{"存在しない収支", tx.ID + 100, testPNG(t, 10, 10), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := uploadAttachment(t, h, e, tt.transactionId, "file", tt.data)
+			if rec.Code != tt.want {
+				t.Errorf("UploadAttachment: expected status %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+		})
+	}
+	if attachments, _ := repo.FindAttachments(tx.ID); len(attachments) != 0 {
+		t.Errorf("UploadAttachment: expected no attachments, got %+v", attachments)
+	}
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	blobs := newTestBlobStore(t)
+	h := NewAttachmentHandler(repo, blobs)
+	e := echo.New()
+	tx := saveAttachmentTarget(t, repo)
+
+	var uploaded domain.Attachment
+	json.Unmarshal(uploadAttachment(t, h, e, tx.ID, "receipt.png", testPNG(t, 10, 10)).Body.Bytes(), &uploaded)
+	receipt, _ := repo.FindAttachmentById(uploaded.ID) // BlobKey はレスポンスに含まれない
+
+	if rec := callAttachment(t, h.DeleteAttachment, e, http.MethodDelete, "/api/attachments/1", receipt.ID); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteAttachment: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := blobs.Open(receipt.BlobKey); !errors.Is(err, storage.ErrBlobNotFound) {
+		t.Errorf("DeleteAttachment: expected blob to be deleted, got %v", err)
+	}
+	if rec := callAttachment(t, h.DownloadAttachment, e, http.MethodGet, "/api/attachments/1", receipt.ID); rec.Code != http.StatusNotFound {
+		t.Errorf("DownloadAttachment: expected status 404 after delete, got %d", rec.Code)
+	}
+}
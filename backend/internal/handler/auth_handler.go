@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionCookieName はセッショントークンを入れる Cookie の名前です。
+const sessionCookieName = "kakeibo_session"
+
+// sessionTTL はログインしてからセッションが切れるまでの期間です。
+const sessionTTL = 30 * 24 * time.Hour
+
+// パスワードの長さの制限です。bcrypt は72バイトを超える部分を無視するため上限を設けます。
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// AuthHandler はユーザー登録・ログイン・ログアウトのHTTPリクエストを処理するハンドラです。
+// 登録したユーザーには自分が所有者の家計簿を作ります。
+// 最初の家計簿には家計簿の導入前に登録された収支・カテゴリ・予算・定期収支を割り当て、
+// それ以外の家計簿には初期カテゴリと既定の口座を用意します。
+type AuthHandler struct {
+	users      repository.UserRepository
+	households repository.HouseholdRepository
+	repo       repository.TransactionRepository
+	owned      []repository.UnownedAssigner
+	now        func() time.Time
+	bcryptCost int
+}
+
+// NewAuthHandler はAuthHandlerを生成します。
+// owned には、repo のほかに最初の家計簿へ既存データを割り当てるリポジトリを渡します。
+func NewAuthHandler(users repository.UserRepository, households repository.HouseholdRepository, repo repository.TransactionRepository, owned ...repository.UnownedAssigner) *AuthHandler {
+	return &AuthHandler{
+		users:      users,
+		households: households,
+		repo:       repo,
+		owned:      append([]repository.UnownedAssigner{repo}, owned...),
+		now:        time.Now,
+		bcryptCost: bcrypt.DefaultCost,
+	}
+}
+
+// Register はユーザーを登録するPOST /api/auth/registerのハンドラです。
+// 登録後はそのままログインした状態になり、セッショントークンを返します。
+func (h *AuthHandler) Register(c echo.Context) error {
+	var req domain.RegisterRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	email := domain.NormalizeEmail(req.Email)
+	if !strings.Contains(email, "@") || len(email) > 254 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "emailには有効なメールアドレスを指定してください",
+		})
+	}
+	if utf8.RuneCountInString(req.Password) < minPasswordLength || len(req.Password) > maxPasswordBytes {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "passwordは8文字以上72バイト以内で指定してください",
+		})
+	}
+	name := strings.TrimSpace(req.Name)
+	if utf8.RuneCountInString(name) > 50 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "nameは50文字以内で指定してください",
+		})
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.bcryptCost)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ユーザーの登録に失敗しました: " + err.Error(),
+		})
+	}
+	user := domain.User{Email: email, Name: name, PasswordHash: string(hash)}
+	if err := h.users.Save(&user); err != nil {
+		if errors.Is(err, repository.ErrEmailTaken) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ユーザーの登録に失敗しました: " + err.Error(),
+		})
+	}
+
+	if err := h.createHousehold(&user); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の作成に失敗しました: " + err.Error(),
+		})
+	}
+	return h.startSession(c, http.StatusCreated, user)
+}
+
+// createHousehold はユーザーが所有者の家計簿を作り、いま利用している家計簿にします。
+// 最初の家計簿であれば所有者のいないデータを割り当て、そうでなければ初期カテゴリと口座を用意します。
+func (h *AuthHandler) createHousehold(user *domain.User) error {
+	name := "マイ家計簿"
+	if user.Name != "" {
+		name = user.Name + "の家計簿"
+	}
+	household := domain.Household{Name: name}
+	if err := h.households.Save(&household, user.ID); err != nil {
+		return err
+	}
+	if err := h.users.UpdateHousehold(user.ID, household.ID); err != nil {
+		return err
+	}
+	user.HouseholdId = household.ID
+
+	n, err := h.households.Count()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return prepareHousehold(h.repo, household.ID, domain.DefaultCategories())
+	}
+	for _, repo := range h.owned {
+		if err := repo.AssignUnowned(household.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Login はメールアドレスとパスワードでログインするPOST /api/auth/loginのハンドラです。
+func (h *AuthHandler) Login(c echo.Context) error {
+	var req domain.LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	user, err := h.users.FindByEmail(domain.NormalizeEmail(req.Email))
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ログインに失敗しました: " + err.Error(),
+		})
+	}
+	// ユーザーがいない場合とパスワードが違う場合は区別せずに返す
+	if err != nil || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "メールアドレスまたはパスワードが正しくありません",
+		})
+	}
+	return h.startSession(c, http.StatusOK, user)
+}
+
+// startSession はセッションを作成し、トークンを Cookie とレスポンスボディの両方で返します。
+func (h *AuthHandler) startSession(c echo.Context, status int, user domain.User) error {
+	token, err := newSessionToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "セッションの作成に失敗しました: " + err.Error(),
+		})
+	}
+	expiresAt := h.now().Add(sessionTTL)
+	session := domain.Session{TokenHash: hashSessionToken(token), UserId: user.ID, ExpiresAt: expiresAt}
+	if err := h.users.SaveSession(session); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "セッションの作成に失敗しました: " + err.Error(),
+		})
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/api",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.JSON(status, domain.AuthResponse{User: user, Token: token, ExpiresAt: expiresAt})
+}
+
+// Logout は現在のセッションを削除するPOST /api/auth/logoutのハンドラです。
+func (h *AuthHandler) Logout(c echo.Context) error {
+	if token := sessionToken(c); token != "" {
+		if err := h.users.DeleteSession(hashSessionToken(token)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "ログアウトに失敗しました: " + err.Error(),
+			})
+		}
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/api",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "ログアウトしました",
+	})
+}
+
+// Me はログイン中のユーザーを返すGET /api/auth/meのハンドラです。
+func (h *AuthHandler) Me(c echo.Context) error {
+	user, ok := currentUser(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "ログインが必要です",
+		})
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// newSessionToken は推測できないランダムなセッショントークンを生成します。
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSessionToken は保存用にトークンの SHA-256 を16進文字列で返します。
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
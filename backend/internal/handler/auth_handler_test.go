@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// auth_handler_test.go は AuthHandler と RequireAuth の HTTP ハンドラテストです。
+// ルーターを通して、ユーザーごとに作られる家計簿で収支が分かれることを検証します。
+
+// newAuthTestServer は本番と同じルーティングのうち、認証・家計簿・カテゴリ・口座・収支のルートだけを持つ Echo を返します。
+func newAuthTestServer(users repository.UserRepository, repo repository.TransactionRepository) *echo.Echo {
+	households := repository.NewHouseholdRepository()
+	ah := NewAuthHandler(users, households, repo)
+	ah.bcryptCost = bcrypt.MinCost
+	hh := NewHouseholdHandler(households, users, repo)
+	ch := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	ach := NewAccountHandler(repo)
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+
+	e := echo.New()
+	e.Pre(SelectHouseholdByPath())
+	e.POST("/api/auth/register", ah.Register)
+	e.POST("/api/auth/login", ah.Login)
+	api := e.Group("/api", RequireAuth(users))
+	api.POST("/auth/logout", ah.Logout)
+	api.GET("/auth/me", ah.Me)
+	api.GET("/households", hh.GetHouseholds)
+	api.POST("/households", hh.CreateHousehold)
+	api.PUT("/households/current", hh.SelectHousehold)
+	api.POST("/invitations/accept", hh.AcceptInvitation)
+
+	book := api.Group("", RequireMembership(households, users))
+	editor := RequireRole(domain.RoleEditor)
+	owner := RequireRole(domain.RoleOwner)
+	book.GET("/household", hh.GetHousehold)
+	book.PUT("/household", hh.UpdateHousehold, owner)
+	book.PUT("/household/members/:userId", hh.UpdateMember, owner)
+	book.DELETE("/household/members/:userId", hh.RemoveMember)
+	book.GET("/household/invitations", hh.GetInvitations, owner)
+	book.POST("/household/invitations", hh.CreateInvitation, owner)
+	book.DELETE("/household/invitations/:id", hh.DeleteInvitation, owner)
+	book.GET("/categories", ch.GetCategories)
+	book.POST("/categories", ch.CreateCategory, editor)
+	book.GET("/accounts", ach.GetAccounts)
+	book.POST("/accounts", ach.CreateAccount, editor)
+	book.PUT("/accounts/:id", ach.UpdateAccount, editor)
+	book.DELETE("/accounts/:id", ach.DeleteAccount, editor)
+	book.GET("/transactions", th.GetTransactions)
+	book.POST("/transactions", th.CreateTransaction, editor)
+	book.PUT("/transactions/:id", th.UpdateTransaction, editor)
+	book.DELETE("/transactions/:id", th.DeleteTransaction, editor)
+	return e
+}
+
+// doJSON は token を Bearer トークンとしてリクエストを送り、レスポンスを返します。
+func doJSON(e *echo.Echo, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+// registerUser はユーザーを登録してセッショントークンを返します。
+func registerUser(t *testing.T, e *echo.Echo, email string) string {
+	t.Helper()
+	rec := doJSON(e, http.MethodPost, "/api/auth/register", "", `{"email":"`+email+`","password":"password123","name":"テスト"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Register: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var res domain.AuthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("Register: invalid JSON: %v", err)
+	}
+	if res.Token == "" || res.User.Email != email {
+		t.Fatalf("Register: expected token and user %s, got %+v", email, res)
+	}
+	return res.Token
+}
+
+// findCategoryByName はログイン中のユーザーが利用している家計簿から、name のカテゴリを探します。
+func findCategoryByName(t *testing.T, e *echo.Echo, token, name string) domain.Category {
+	t.Helper()
+	rec := doJSON(e, http.MethodGet, "/api/categories", token, "")
+	var categories []domain.Category
+	if err := json.Unmarshal(rec.Body.Bytes(), &categories); err != nil {
+		t.Fatalf("GetCategories: invalid JSON: %v", err)
+	}
+	for _, c := range categories {
+		if c.Name == name {
+			return c
+		}
+		for _, child := range c.Children {
+			if child.Name == name {
+				return child
+			}
+		}
+	}
+	t.Fatalf("GetCategories: category %s not found in %+v", name, categories)
+	return domain.Category{}
+}
+
+func TestRegister_Validation(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	registerUser(t, e, "taro@example.com")
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"email":"Taro@Example.com ","password":"password123"}`, http.StatusConflict}, // 大文字・空白は正規化する
+		{`{"email":"hanako","password":"password123"}`, http.StatusBadRequest},
+		{`{"email":"hanako@example.com","password":"short"}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		rec := doJSON(e, http.MethodPost, "/api/auth/register", "", tc.body)
+		if rec.Code != tc.want {
+			t.Errorf("Register(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	registerUser(t, e, "taro@example.com")
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"email":"taro@example.com","password":"password123"}`, http.StatusOK},
+		{`{"email":"taro@example.com","password":"wrong-password"}`, http.StatusUnauthorized},
+		{`{"email":"hanako@example.com","password":"password123"}`, http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		rec := doJSON(e, http.MethodPost, "/api/auth/login", "", tc.body)
+		if rec.Code != tc.want {
+			t.Errorf("Login(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	token := registerUser(t, e, "taro@example.com")
+
+	if rec := doJSON(e, http.MethodGet, "/api/transactions", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: expected status 401, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodGet, "/api/transactions", "invalid", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("invalid token: expected status 401, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodGet, "/api/auth/me", token, ""); rec.Code != http.StatusOK {
+		t.Errorf("Me: expected status 200, got %d", rec.Code)
+	}
+
+	// Cookie でも認証できる
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+	req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: token})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("cookie: expected status 200, got %d", rec.Code)
+	}
+
+	// ログアウト後はトークンが使えない
+	if rec := doJSON(e, http.MethodPost, "/api/auth/logout", token, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Logout: expected status 200, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodGet, "/api/auth/me", token, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: expected status 401, got %d", rec.Code)
+	}
+}
+
+func TestTransactions_PerUser(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	// 認証導入前に登録されたサンプルデータ
+	if err := repo.Save(&domain.Transaction{Type: "expense", CategoryId: 1, AccountId: 1, Amount: -500}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	e := newAuthTestServer(repository.NewUserRepository(), repo)
+	taro := registerUser(t, e, "taro@example.com")
+	hanako := registerUser(t, e, "hanako@example.com")
+
+	// 2人目のユーザーの家計簿には初期カテゴリのコピーがあり、最初の家計簿のカテゴリは使えない
+	if rec := doJSON(e, http.MethodPost, "/api/transactions", hanako,
+		`{"date":"2025-01-10","type":"expense","category_id":12,"amount":800}`); rec.Code == http.StatusCreated {
+		t.Errorf("CreateTransaction(other household's category): expected error, got status %d", rec.Code)
+	}
+	food := findCategoryByName(t, e, hanako, "食材")
+	rec := doJSON(e, http.MethodPost, "/api/transactions", hanako,
+		`{"date":"2025-01-10","type":"expense","category_id":`+strconv.Itoa(food.ID)+`,"amount":800}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// 最初のユーザーはサンプルデータを引き継ぎ、2人目のユーザーの収支は見えない
+	for _, tc := range []struct {
+		name, token string
+		amount      int
+	}{
+		{"taro", taro, -500},
+		{"hanako", hanako, -800},
+	} {
+		rec := doJSON(e, http.MethodGet, "/api/transactions", tc.token, "")
+		var result struct {
+			Transactions []domain.Transaction `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("GetTransactions: invalid JSON: %v", err)
+		}
+		if len(result.Transactions) != 1 || result.Transactions[0].Amount != tc.amount {
+			t.Errorf("GetTransactions(%s): expected one transaction of %d, got %+v", tc.name, tc.amount, result.Transactions)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// userContextKey はログイン中のユーザーを echo.Context に保存するキーです。
+const userContextKey = "user"
+
+// RequireAuth はログインしていないリクエストを 401 で拒否するミドルウェアです。
+// セッショントークンは Authorization: Bearer ヘッダー、なければ Cookie から読み取ります。
+// 有効なセッションであればユーザーを echo.Context に保存します。
+func RequireAuth(users repository.UserRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := sessionToken(c)
+			if token == "" {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "ログインが必要です",
+				})
+			}
+
+			session, err := users.FindSession(hashSessionToken(token), time.Now())
+			if errors.Is(err, repository.ErrSessionNotFound) {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "セッションが無効です。再度ログインしてください",
+				})
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "セッションの確認に失敗しました: " + err.Error(),
+				})
+			}
+			user, err := users.FindById(session.UserId)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "セッションが無効です。再度ログインしてください",
+				})
+			}
+
+			c.Set(userContextKey, user)
+			return next(c)
+		}
+	}
+}
+
+// sessionToken はリクエストからセッショントークンを取り出します。見つからない場合は空文字を返します。
+func sessionToken(c echo.Context) string {
+	if auth := c.Request().Header.Get(echo.HeaderAuthorization); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if cookie, err := c.Cookie(sessionCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// currentUser は RequireAuth が保存したログイン中のユーザーを返します。
+func currentUser(c echo.Context) (domain.User, bool) {
+	user, ok := c.Get(userContextKey).(domain.User)
+	return user, ok
+}
+
+// currentUserId はログイン中のユーザーのIDを返します。RequireAuth を通っていない場合（テストなど）は0を返します。
+func currentUserId(c echo.Context) int {
+	user, _ := currentUser(c)
+	return user.ID
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BudgetHandler は月次予算関連のHTTPリクエストを処理するハンドラです。
+// 実績の集計には TransactionRepository の月次集計を使います。
+type BudgetHandler struct {
+	repo            repository.BudgetRepository
+	transactionRepo repository.TransactionRepository
+	now             func() time.Time
+}
+
+// NewBudgetHandler はBudgetHandlerを生成します。
+func NewBudgetHandler(repo repository.BudgetRepository, transactionRepo repository.TransactionRepository) *BudgetHandler {
+	return &BudgetHandler{repo: repo, transactionRepo: transactionRepo, now: time.Now}
+}
+
+// GetBudgets は予算一覧を取得するGET /api/budgetsのハンドラです。
+// month（YYYY-MM）を指定するとその月の予算に絞り込みます。
+func (h *BudgetHandler) GetBudgets(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	month := c.QueryParam("month")
+	if month != "" {
+		if _, err := time.Parse("2006-01", month); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "monthは YYYY-MM 形式で指定してください",
+			})
+		}
+	}
+
+	budgets, err := repo.FindByMonth(month)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予算の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, budgets)
+}
+
+// GetBudgetStatus は予算と実績を比較するGET /api/budgets/statusのハンドラです。
+// month（YYYY-MM）を省略した場合は当月です。実績は子カテゴリの支出を含みます。
+func (h *BudgetHandler) GetBudgetStatus(c echo.Context) error {
+	transactionRepo := h.transactionRepo.ForHousehold(currentHouseholdId(c))
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	now := h.now()
+	month := c.QueryParam("month")
+	if month == "" {
+		month = now.Format("2006-01")
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "monthは YYYY-MM 形式で指定してください",
+		})
+	}
+
+	budgets, err := repo.FindByMonth(month)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予算の取得に失敗しました: " + err.Error(),
+		})
+	}
+	summary, err := transactionRepo.FindMonthlySummary(start.Year(), int(start.Month()), 0)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "月次集計の取得に失敗しました: " + err.Error(),
+		})
+	}
+	categories, err := transactionRepo.FindAllCategories()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	// 親カテゴリの合計は子カテゴリ分を含むため、親・子どちらの予算にもそのまま使える
+	actual := map[int]int{}
+	for _, total := range summary.Categories {
+		actual[total.CategoryId] = total.Expense
+		for _, child := range total.Children {
+			actual[child.CategoryId] = child.Expense
+		}
+	}
+	names := map[int]string{}
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	report := domain.BudgetStatusReport{Month: month, Items: []domain.BudgetStatus{}}
+	for _, b := range budgets {
+		name, ok := names[b.CategoryId]
+		if !ok {
+			continue // 削除済みカテゴリの予算
+		}
+		report.Items = append(report.Items, domain.NewBudgetStatus(b, name, actual[b.CategoryId], now))
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// CreateBudget は予算を登録するPOST /api/budgetsのハンドラです。
+func (h *BudgetHandler) CreateBudget(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.BudgetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	if err := h.validateBudget(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	budget := domain.Budget{CategoryId: req.CategoryId, Month: req.Month, Amount: req.Amount}
+	if err := repo.Save(&budget); err != nil {
+		if errors.Is(err, repository.ErrBudgetExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予算の保存に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, budget)
+}
+
+// UpdateBudget は予算を更新するPUT /api/budgets/{id}のハンドラです。
+func (h *BudgetHandler) UpdateBudget(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.BudgetRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	if err := h.validateBudget(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	budget := domain.Budget{ID: id, CategoryId: req.CategoryId, Month: req.Month, Amount: req.Amount}
+	if err := repo.Update(&budget); err != nil {
+		if errors.Is(err, repository.ErrBudgetExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予算の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget は予算を削除するDELETE /api/budgets/{id}のハンドラです。
+func (h *BudgetHandler) DeleteBudget(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if err := repo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "予算の削除に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "予算が削除されました",
+	})
+}
+
+// validateBudget は予算のリクエストを検証します。予算は支出に使えるカテゴリにのみ設定できます。
+func (h *BudgetHandler) validateBudget(transactionRepo repository.TransactionRepository, req domain.BudgetRequest) error {
+	if _, err := time.Parse("2006-01", req.Month); err != nil {
+		return errors.New("monthは YYYY-MM 形式で指定してください")
+	}
+	if req.Amount <= 0 {
+		return errors.New("amountは1以上の整数で指定してください")
+	}
+	category, err := transactionRepo.FindCategoryById(req.CategoryId)
+	if err != nil {
+		return errors.New("カテゴリが見つかりません: " + strconv.Itoa(req.CategoryId))
+	}
+	if !category.AllowsType("expense") {
+		return errors.New("予算は支出のカテゴリにのみ設定できます: " + category.Name)
+	}
+	return nil
+}
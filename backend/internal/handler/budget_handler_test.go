@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// budget_handler_test.go は BudgetHandler の HTTP ハンドラテストです。
+
+func TestCreateBudget_Validation(t *testing.T) {
+	h := NewBudgetHandler(repository.NewBudgetRepository(), repository.NewTransactionRepository())
+	e := echo.New()
+
+	// 上から順に登録する（2件目は1件目と同じカテゴリ・月のため重複）
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"category_id":1,"month":"2025-01","amount":40000}`, http.StatusCreated},
+		{`{"category_id":1,"month":"2025-01","amount":30000}`, http.StatusConflict},
+		{`{"category_id":1,"month":"2025/01","amount":40000}`, http.StatusBadRequest},
+		{`{"category_id":1,"month":"2025-02","amount":0}`, http.StatusBadRequest},
+		{`{"category_id":10,"month":"2025-01","amount":1000}`, http.StatusBadRequest}, // 給与は収入用
+		{`{"category_id":999,"month":"2025-01","amount":1000}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/budgets", bytes.NewBufferString(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if err := h.CreateBudget(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateBudget: unexpected error: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("CreateBudget(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestGetBudgetStatus_Success(t *testing.T) {
+	transactionRepo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(transactionRepo, repository.NewCategoryRuleRepository())
+	h := NewBudgetHandler(repository.NewBudgetRepository(), transactionRepo)
+	h.now = func() time.Time { return time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC) }
+	e := echo.New()
+
+	// 食費に30,000円、交通費に5,000円の予算
+	for _, body := range []string{
+		`{"category_id":1,"month":"2025-01","amount":30000}`,
+		`{"category_id":2,"month":"2025-01","amount":5000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/budgets", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_ = h.CreateBudget(e.NewContext(req, httptest.NewRecorder()))
+	}
+	// 1/10時点で 食材 4,000円 + 外食 6,000円、交通費 1,000円
+	for _, body := range []string{
+		`{"date":"2025-01-05","type":"expense","category_id":12,"amount":4000,"memo":"食材"}`,
+		`{"date":"2025-01-08","type":"expense","category_id":11,"amount":6000,"memo":"外食"}`,
+		`{"date":"2025-01-09","type":"expense","category_id":2,"amount":1000,"memo":"電車"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_ = th.CreateTransaction(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/budgets/status?month=2025-01", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetBudgetStatus(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetBudgetStatus: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetBudgetStatus: expected status 200, got %d", rec.Code)
+	}
+
+	var result struct {
+		Month string `json:"month"`
+		Items []struct {
+			CategoryName  string  `json:"category_name"`
+			Budget        int     `json:"budget"`
+			Actual        int     `json:"actual"`
+			Remaining     int     `json:"remaining"`
+			PercentUsed   float64 `json:"percent_used"`
+			Projected     int     `json:"projected"`
+			OverBudget    bool    `json:"over_budget"`
+			ProjectedOver bool    `json:"projected_over"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetBudgetStatus: invalid JSON: %v", err)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("GetBudgetStatus: expected 2 items, got %d", len(result.Items))
+	}
+
+	// 食費: 実績10,000円（外食を含む）、31日/10日経過で見込み31,000円 → 超過見込み
+	food := result.Items[0]
+	if food.CategoryName != "食費" || food.Actual != 10000 || food.Remaining != 20000 || food.PercentUsed != 33.3 {
+		t.Errorf("GetBudgetStatus: unexpected 食費 status: %+v", food)
+	}
+	if food.Projected != 31000 || food.OverBudget || !food.ProjectedOver {
+		t.Errorf("GetBudgetStatus: unexpected 食費 projection: %+v", food)
+	}
+
+	// 交通費: 実績1,000円、見込み3,100円 → 予算内
+	transport := result.Items[1]
+	if transport.Actual != 1000 || transport.Projected != 3100 || transport.ProjectedOver {
+		t.Errorf("GetBudgetStatus: unexpected 交通費 status: %+v", transport)
+	}
+}
+
+func TestGetBudgetStatus_InvalidMonth(t *testing.T) {
+	h := NewBudgetHandler(repository.NewBudgetRepository(), repository.NewTransactionRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/budgets/status?month=2025-13", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetBudgetStatus(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetBudgetStatus: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetBudgetStatus: expected status 400, got %d", rec.Code)
+	}
+}
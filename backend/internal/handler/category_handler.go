@@ -0,0 +1,439 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CategoryHandler はカテゴリ関連のHTTPリクエストを処理するハンドラです。
+// 定期収支ルールのリポジトリは、カテゴリを参照しているルールの確認に使います。
+type CategoryHandler struct {
+	repo      repository.TransactionRepository
+	recurring repository.RecurringRuleRepository
+}
+
+// NewCategoryHandler はCategoryHandlerを生成します。
+func NewCategoryHandler(repo repository.TransactionRepository, recurring repository.RecurringRuleRepository) *CategoryHandler {
+	return &CategoryHandler{repo: repo, recurring: recurring}
+}
+
+// カテゴリ名の最大文字数です（categories.name の VARCHAR(50) に合わせています）。
+const maxCategoryNameLength = 50
+
+// GetCategories はカテゴリ一覧を表示順で取得するGET /api/categoriesのハンドラです。
+// 最上位カテゴリの children に子カテゴリを入れた木構造で返し、flat=true の場合は平らな一覧で返します。
+// アーカイブ済みのカテゴリ（とその子カテゴリ）は include_archived=true を指定した場合のみ含めます。
+// type=income / expense を指定すると、その種別の収支に使えるカテゴリ（both を含む）に絞り込みます。
+func (h *CategoryHandler) GetCategories(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	txType := c.QueryParam("type")
+	if txType != "" && txType != "income" && txType != "expense" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "typeは income または expense を指定してください",
+		})
+	}
+
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	includeArchived := c.QueryParam("include_archived") == "true"
+	archived := map[int]bool{}
+	for _, category := range categories {
+		if category.Archived {
+			archived[category.ID] = true
+		}
+	}
+	result := []domain.Category{}
+	for _, category := range categories {
+		if !includeArchived && (category.Archived || archived[category.ParentId]) {
+			continue
+		}
+		if txType != "" && !category.AllowsType(txType) {
+			continue
+		}
+		result = append(result, category)
+	}
+
+	if c.QueryParam("flat") == "true" {
+		return c.JSON(http.StatusOK, result)
+	}
+	return c.JSON(http.StatusOK, domain.BuildCategoryTree(result))
+}
+
+// CreateCategory はカテゴリを新規作成するPOST /api/categoriesのハンドラです。
+func (h *CategoryHandler) CreateCategory(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+	recurring := h.recurring.ForHousehold(currentHouseholdId(c))
+
+	var req domain.CreateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	name, err := validateCategoryName(repo, req.Name, 0)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if req.DisplayOrder < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "display_orderは0以上の整数で指定してください",
+		})
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = domain.CategoryKindBoth
+		if req.ParentId != 0 {
+			if parent, err := repo.FindCategoryById(req.ParentId); err == nil {
+				kind = parent.Kind
+			}
+		}
+	}
+	if !domain.IsValidCategoryKind(kind) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "kindは income / expense / both のいずれかを指定してください",
+		})
+	}
+
+	category := domain.Category{Name: name, Kind: kind, ParentId: req.ParentId, DisplayOrder: req.DisplayOrder}
+	if err := validateCategoryParent(repo, recurring, category); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := repo.SaveCategory(&category); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの保存に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory はカテゴリの名前・種別・親カテゴリ・表示順の変更とアーカイブを行うPUT /api/categories/{id}のハンドラです。
+// 種別は、子カテゴリや参照している収支・定期収支と合わなくなる場合は変更できません（400）。
+func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+	recurring := h.recurring.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.UpdateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	name, err := validateCategoryName(repo, req.Name, id)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if req.DisplayOrder < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "display_orderは0以上の整数で指定してください",
+		})
+	}
+	if !domain.IsValidCategoryKind(req.Kind) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "kindは income / expense / both のいずれかを指定してください",
+		})
+	}
+
+	category := domain.Category{
+		ID:           id,
+		Name:         name,
+		Kind:         req.Kind,
+		ParentId:     req.ParentId,
+		DisplayOrder: req.DisplayOrder,
+		Archived:     req.Archived,
+	}
+	if err := validateCategoryParent(repo, recurring, category); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if existing, err := repo.FindCategoryById(id); err == nil && existing.Kind != category.Kind {
+		if err := validateCategoryKindChange(repo, recurring, category); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+	if err := repo.UpdateCategory(&category); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+// ReorderCategories はカテゴリの表示順を一括で変更するPUT /api/categories/orderのハンドラです。
+func (h *CategoryHandler) ReorderCategories(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.ReorderCategoriesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	if len(req.IDs) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idsを1件以上指定してください",
+		})
+	}
+	seen := map[int]bool{}
+	for _, id := range req.IDs {
+		if seen[id] {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "idsに同じIDが重複しています: " + strconv.Itoa(id),
+			})
+		}
+		seen[id] = true
+	}
+
+	if err := repo.ReorderCategories(req.IDs); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの並び替えに失敗しました: " + err.Error(),
+		})
+	}
+	return h.GetCategories(c)
+}
+
+// DeleteCategory はカテゴリを削除するDELETE /api/categories/{id}のハンドラです。
+// 子カテゴリがある場合や収支・定期収支から参照されている場合は 409 を返します。
+// reassign_to にカテゴリIDを指定すると、参照している収支・定期収支と予算をそのカテゴリへ付け替えてから削除します。
+// 付け替え先はアーカイブされていない子カテゴリのないカテゴリで、付け替える収支・定期収支の種別に使えるものに限ります（そうでなければ 400）。
+// 付け替え先に同じ月の予算がある場合は 409 を返します。
+func (h *CategoryHandler) DeleteCategory(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+	recurring := h.recurring.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	reassignTo := 0
+	if v := c.QueryParam("reassign_to"); v != "" {
+		reassignTo, err = strconv.Atoi(v)
+		if err != nil || reassignTo <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "reassign_toはカテゴリIDを整数で指定してください",
+			})
+		}
+		if reassignTo == id {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "reassign_toには削除対象以外のカテゴリを指定してください",
+			})
+		}
+		if err := validateReassignTarget(repo, recurring, id, reassignTo); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := repo.DeleteCategory(id, reassignTo); err != nil {
+		if errors.Is(err, repository.ErrCategoryHasChildren) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "子カテゴリがあるため削除できません。先に子カテゴリを削除するか移動してください",
+			})
+		}
+		if errors.Is(err, repository.ErrBudgetExists) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "付け替え先のカテゴリに同じ月の予算があるため削除できません。先にどちらかの予算を削除してください",
+			})
+		}
+		if errors.Is(err, repository.ErrCategoryInUse) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "このカテゴリを使用している収支・定期収支があるため削除できません。reassign_to で付け替え先を指定するか、アーカイブしてください",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの削除に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "カテゴリが削除されました",
+	})
+}
+
+// validateCategoryKindChange はカテゴリの種別を category.Kind に変更できるかを検証します。
+// 種別が both 以外の場合、子カテゴリの種別はすべて同じでなければならず、
+// カテゴリを直接参照している収支（ゴミ箱・内訳を含む）と定期収支ルールの種別にも使えなければなりません。
+func validateCategoryKindChange(repo repository.TransactionRepository, recurring repository.RecurringRuleRepository, category domain.Category) error {
+	if category.Kind == domain.CategoryKindBoth {
+		return nil
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return errors.New("カテゴリの取得に失敗しました: " + err.Error())
+	}
+	for _, c := range categories {
+		if c.ParentId == category.ID && c.Kind != category.Kind {
+			return fmt.Errorf("子カテゴリ「%s」と種別が合わないため、種別を変更できません", c.Name)
+		}
+	}
+
+	types, err := categoryTypesInUse(repo, recurring, category.ID)
+	if err != nil {
+		return err
+	}
+	for _, t := range []string{domain.TransactionTypeIncome, domain.TransactionTypeExpense} {
+		if types[t] && !category.AllowsType(t) {
+			return fmt.Errorf("カテゴリ「%s」を使用している%sの収支・定期収支があるため、種別を変更できません",
+				category.Name, map[string]string{"income": "収入", "expense": "支出"}[t])
+		}
+	}
+	return nil
+}
+
+// validateReassignTarget はカテゴリ id を削除するときの付け替え先 reassignTo を検証します。
+// 付け替え先はアーカイブされておらず子カテゴリを持たないカテゴリで、
+// id を参照している収支・定期収支の種別をすべて使えなければなりません。
+func validateReassignTarget(repo repository.TransactionRepository, recurring repository.RecurringRuleRepository, id, reassignTo int) error {
+	target, err := repo.FindCategoryById(reassignTo)
+	if err != nil {
+		return errors.New("付け替え先のカテゴリが見つかりません: " + strconv.Itoa(reassignTo))
+	}
+	if target.Archived {
+		return fmt.Errorf("アーカイブされたカテゴリ「%s」には付け替えられません", target.Name)
+	}
+	if err := checkLeafCategory(repo, target); err != nil {
+		return err
+	}
+
+	types, err := categoryTypesInUse(repo, recurring, id)
+	if err != nil {
+		return err
+	}
+	for _, t := range []string{domain.TransactionTypeIncome, domain.TransactionTypeExpense} {
+		if types[t] && !target.AllowsType(t) {
+			return errors.New(categoryTypeMismatchMessage(target, t))
+		}
+	}
+	return nil
+}
+
+// categoryTypesInUse はカテゴリ id を直接参照している収支（ゴミ箱・内訳を含む）と定期収支ルールの種別の集合を返します。
+// 参照がなければ空の集合を返します。
+func categoryTypesInUse(repo repository.TransactionRepository, recurring repository.RecurringRuleRepository, id int) (map[string]bool, error) {
+	types := map[string]bool{}
+	transactions, err := repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("収支の取得に失敗しました: %w", err)
+	}
+	trash, err := repo.FindTrash()
+	if err != nil {
+		return nil, fmt.Errorf("収支の取得に失敗しました: %w", err)
+	}
+	for _, t := range append(transactions, trash...) {
+		if t.HasCategory(id) {
+			types[t.Type] = true
+		}
+	}
+	rules, err := recurring.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("定期収支の取得に失敗しました: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.CategoryId == id {
+			types[rule.Type] = true
+		}
+	}
+	return types, nil
+}
+
+// validateCategoryName はカテゴリ名を検証し、前後の空白を除いた名前を返します。
+// excludeId 以外のカテゴリと名前が重複する場合はエラーにします。
+func validateCategoryName(repo repository.TransactionRepository, name string, excludeId int) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("nameを指定してください")
+	}
+	if utf8.RuneCountInString(name) > maxCategoryNameLength {
+		return "", fmt.Errorf("nameは%d文字以内で指定してください", maxCategoryNameLength)
+	}
+
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return "", errors.New("カテゴリの取得に失敗しました: " + err.Error())
+	}
+	for _, category := range categories {
+		if category.ID != excludeId && category.Name == name {
+			return "", errors.New("同じ名前のカテゴリが既に存在します: " + name)
+		}
+	}
+	return name, nil
+}
+
+// validateCategoryParent は親カテゴリの指定を検証します。
+// 親は既存の最上位カテゴリに限り（階層は2段まで）、子を持つカテゴリは子カテゴリにできません。
+// 親の種別が both 以外の場合、子カテゴリの種別は親と同じでなければなりません。
+// 収支・定期収支は子カテゴリのないカテゴリにだけ登録できるため、それらが直接参照しているカテゴリは親にできません。
+func validateCategoryParent(repo repository.TransactionRepository, recurring repository.RecurringRuleRepository, category domain.Category) error {
+	if category.ParentId == 0 {
+		return nil
+	}
+	if category.ParentId < 0 || category.ParentId == category.ID {
+		return errors.New("parent_idが不正です")
+	}
+
+	parent, err := repo.FindCategoryById(category.ParentId)
+	if err != nil {
+		return errors.New("親カテゴリが見つかりません: " + strconv.Itoa(category.ParentId))
+	}
+	if parent.ParentId != 0 {
+		return errors.New("子カテゴリの下にはカテゴリを作成できません（階層は2段までです）")
+	}
+	if parent.Kind != domain.CategoryKindBoth && parent.Kind != category.Kind {
+		return fmt.Errorf("親カテゴリ「%s」と同じ種別（%s）を指定してください", parent.Name, parent.Kind)
+	}
+	types, err := categoryTypesInUse(repo, recurring, parent.ID)
+	if err != nil {
+		return err
+	}
+	if len(types) > 0 {
+		return fmt.Errorf("カテゴリ「%s」は収支・定期収支に使われているため親カテゴリにできません", parent.Name)
+	}
+
+	if category.ID != 0 {
+		categories, err := repo.FindAllCategories()
+		if err != nil {
+			return errors.New("カテゴリの取得に失敗しました: " + err.Error())
+		}
+		for _, c := range categories {
+			if c.ParentId == category.ID {
+				return errors.New("子カテゴリを持つカテゴリは他のカテゴリの子にできません")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// category_handler_test.go は CategoryHandler の HTTP ハンドラテストです。
+
+func TestGetCategories_ExcludesArchived(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 事前に1件アーカイブ
+	updateReq := httptest.NewRequest(http.MethodPut, "/api/categories/8", bytes.NewBufferString(
+		`{"name":"教育費","kind":"expense","display_order":8,"archived":true}`))
+	updateReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	updateC := e.NewContext(updateReq, httptest.NewRecorder())
+	updateC.SetParamNames("id")
+	updateC.SetParamValues("8")
+	_ = h.UpdateCategory(updateC)
+
+	for query, want := range map[string]int{"?flat=true": 14, "?flat=true&include_archived=true": 15} {
+		req := httptest.NewRequest(http.MethodGet, "/api/categories"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := h.GetCategories(c); err != nil {
+			t.Fatalf("GetCategories: unexpected error: %v", err)
+		}
+		var result []map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("GetCategories: invalid JSON: %v", err)
+		}
+		if len(result) != want {
+			t.Errorf("GetCategories(%q): expected %d categories, got %d", query, want, len(result))
+		}
+	}
+}
+
+func TestGetCategories_FilterByType(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories?type=income&flat=true", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.GetCategories(c); err != nil {
+		t.Fatalf("GetCategories: unexpected error: %v", err)
+	}
+	var result []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetCategories: invalid JSON: %v", err)
+	}
+	// 収入に使えるのは その他（both）と 給与（income）
+	if len(result) != 2 || result[0]["name"] != "その他" || result[1]["name"] != "給与" {
+		t.Errorf("GetCategories: expected その他 and 給与, got %v", result)
+	}
+}
+
+func TestGetCategories_Tree(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.GetCategories(c); err != nil {
+		t.Fatalf("GetCategories: unexpected error: %v", err)
+	}
+	var result []struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Children []struct {
+			Name string `json:"name"`
+		} `json:"children"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetCategories: invalid JSON: %v", err)
+	}
+	if len(result) != 10 {
+		t.Fatalf("GetCategories: expected 10 top-level categories, got %d", len(result))
+	}
+	if result[0].Name != "食費" || len(result[0].Children) != 2 || result[0].Children[0].Name != "外食" {
+		t.Errorf("GetCategories: expected 食費 > 外食 / 食材, got %+v", result[0])
+	}
+	if result[3].Name != "光熱費" || len(result[3].Children) != 3 {
+		t.Errorf("GetCategories: expected 光熱費 with 3 children, got %+v", result[3])
+	}
+}
+
+func TestCreateCategory_Subcategory(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 親の種別を引き継ぐ
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(`{"name":"バス","parent_id":2}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateCategory(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateCategory: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created map[string]interface{}
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if created["parent_id"] != float64(2) || created["kind"] != "expense" {
+		t.Errorf("CreateCategory: unexpected response: %v", created)
+	}
+
+	// 3段目・種別の不一致・存在しない親は 400
+	for _, body := range []string{
+		`{"name":"ランチ","parent_id":11}`,
+		`{"name":"ボーナス","kind":"income","parent_id":1}`,
+		`{"name":"不明","parent_id":999}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateCategory(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateCategory: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateCategory(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestCreateCategory_Success(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(`{"name":" 日用品 "}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.CreateCategory(c); err != nil {
+		t.Fatalf("CreateCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateCategory: expected status 201, got %d", rec.Code)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("CreateCategory: invalid JSON: %v", err)
+	}
+	if result["id"] == nil || result["name"] != "日用品" {
+		t.Errorf("CreateCategory: unexpected response: %v", result)
+	}
+}
+
+func TestCreateCategory_InvalidName(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	for _, body := range []string{`{"name":""}`, `{"name":"食費"}`, `{"name":"日用品","kind":"transfer"}`} {
+		req := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := h.CreateCategory(c); err != nil {
+			t.Fatalf("CreateCategory: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateCategory(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestDeleteCategory_HasChildren(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	if err := h.DeleteCategory(c); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("DeleteCategory: expected status 409 for category with children, got %d", rec.Code)
+	}
+}
+
+func TestDeleteCategory_InUse(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 事前に交通費で1件作成
+	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
+		`{"date":"2025-01-15","type":"expense","category_id":2,"amount":1000,"memo":"電車"}`))
+	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	_ = th.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+
+	// 付け替え先なしは 409
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	if err := h.DeleteCategory(c); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("DeleteCategory: expected status 409, got %d", rec.Code)
+	}
+
+	// 付け替え先ありは 200
+	req = httptest.NewRequest(http.MethodDelete, "/api/categories/2?reassign_to=9", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	if err := h.DeleteCategory(c); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("DeleteCategory: expected status 200 with reassign_to, got %d", rec.Code)
+	}
+}
+
+func TestDeleteCategory_BudgetConflict(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	budgets := repository.NewBudgetRepository()
+	repository.LinkMemoryRepositories(repo, nil, nil, budgets)
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	for _, categoryId := range []int{2, 9} {
+		if err := budgets.Save(&domain.Budget{CategoryId: categoryId, Month: "2025-01", Amount: 10000}); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	// 付け替え先に同じ月の予算があるため 409
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/2?reassign_to=9", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	if err := h.DeleteCategory(c); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusConflict {
+		t.Errorf("DeleteCategory: expected status 409 for budget conflict, got %d", rec.Code)
+	}
+	if list, _ := budgets.FindByMonth("2025-01"); len(list) != 2 {
+		t.Errorf("DeleteCategory: expected both budgets to be kept, got %+v", list)
+	}
+}
+
+func TestDeleteCategory_InvalidReassignTarget(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 事前に交通費で支出を1件作成し、教育費をアーカイブ
+	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
+		`{"date":"2025-01-15","type":"expense","category_id":2,"amount":1000,"memo":"電車"}`))
+	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	_ = th.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+	archived, _ := repo.FindCategoryById(8)
+	archived.Archived = true
+	if err := repo.UpdateCategory(&archived); err != nil {
+		t.Fatalf("UpdateCategory: unexpected error: %v", err)
+	}
+
+	// 存在しない・アーカイブ済み・子カテゴリを持つ・支出に使えない（給与）付け替え先は 400
+	for _, reassignTo := range []string{"999", "8", "1", "10"} {
+		req := httptest.NewRequest(http.MethodDelete, "/api/categories/2?reassign_to="+reassignTo, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("2")
+		if err := h.DeleteCategory(c); err != nil {
+			t.Fatalf("DeleteCategory: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("DeleteCategory(reassign_to=%s): expected status 400, got %d", reassignTo, rec.Code)
+		}
+	}
+	if _, err := repo.FindCategoryById(2); err != nil {
+		t.Errorf("DeleteCategory: expected category 2 to be kept, got %v", err)
+	}
+}
+
+func TestUpdateCategory_KindChange(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 事前に交通費で支出を1件作成
+	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
+		`{"date":"2025-01-15","type":"expense","category_id":2,"amount":1000,"memo":"電車"}`))
+	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	_ = th.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+
+	update := func(id, body string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/api/categories/"+id, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		if err := h.UpdateCategory(c); err != nil {
+			t.Fatalf("UpdateCategory: unexpected error: %v", err)
+		}
+		return rec.Code
+	}
+
+	// 支出に使われている交通費は収入用にできない
+	if code := update("2", `{"name":"交通費","kind":"income","display_order":2}`); code != http.StatusBadRequest {
+		t.Errorf("UpdateCategory: expected status 400 for kind used by transactions, got %d", code)
+	}
+	// 子カテゴリ（支出用）を持つ食費は収入用にできない
+	if code := update("1", `{"name":"食費","kind":"income","display_order":1}`); code != http.StatusBadRequest {
+		t.Errorf("UpdateCategory: expected status 400 for kind not matching children, got %d", code)
+	}
+	// 使われていないカテゴリや both への変更はできる
+	if code := update("2", `{"name":"交通費","kind":"both","display_order":2}`); code != http.StatusOK {
+		t.Errorf("UpdateCategory: expected status 200 for both, got %d", code)
+	}
+	if code := update("8", `{"name":"教育費","kind":"income","display_order":8}`); code != http.StatusOK {
+		t.Errorf("UpdateCategory: expected status 200 for unused category, got %d", code)
+	}
+}
+
+func TestCreateCategory_ParentInUse(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	h := NewCategoryHandler(repo, repository.NewRecurringRuleRepository())
+	e := echo.New()
+
+	// 事前に内訳で交通費を使う収支を1件作成
+	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
+		`{"date":"2025-01-15","type":"expense","amount":3000,"splits":[{"category_id":2,"amount":1000},{"category_id":11,"amount":2000}]}`))
+	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	createRec := httptest.NewRecorder()
+	_ = th.CreateTransaction(e.NewContext(createReq, createRec))
+	if createRec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", createRec.Code, createRec.Body.String())
+	}
+
+	// 収支が使っているカテゴリの下には子カテゴリを作れない
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(`{"name":"バス","parent_id":2}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateCategory(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateCategory: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("CreateCategory: expected status 400 for parent used by transactions, got %d", rec.Code)
+	}
+}
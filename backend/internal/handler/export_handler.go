@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/exporter"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ExportTransactions は収支をファイルとして書き出すGET /api/exportのハンドラです。
+// 絞り込み・並び替えは一覧取得（GetTransactions）と同じクエリパラメータで指定し、ページングはしません。
+// 並び順の既定は日付の古い順です。
+//
+//	format    csv（既定） / json / beancount / hledger
+//	encoding  CSV の文字コード: utf-8（既定） / shift_jis（Excel で開く場合）
+//
+// beancount・hledger は複式簿記の仕訳帳で、口座を資産・負債、カテゴリを費用・収益の勘定科目として書き出します（exporter.NewLedgerWriter）。
+// 収支はリポジトリから1件ずつ受け取りながら書き出すため、件数が多くてもまとめてメモリに載せません。
+func (h *TransactionHandler) ExportTransactions(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	filter.Page, filter.Limit = 0, 0
+	if filter.SortOrder == "" {
+		filter.SortOrder = "asc"
+	}
+	if filter.CategoryIds, err = expandCategoryIds(repo, filter.CategoryIds); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = exporter.FormatCSV
+	}
+	encoding := c.QueryParam("encoding")
+	var contentType string
+	switch format {
+	case exporter.FormatCSV:
+		switch encoding {
+		case "", domain.EncodingUTF8:
+			contentType = "text/csv; charset=utf-8"
+		case domain.EncodingShiftJIS:
+			contentType = "text/csv; charset=Shift_JIS"
+		default:
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "encodingは utf-8 / shift_jis のいずれかを指定してください",
+			})
+		}
+	case exporter.FormatJSON:
+		if encoding != "" && encoding != domain.EncodingUTF8 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "JSON は utf-8 でのみ書き出せます",
+			})
+		}
+		contentType = echo.MIMEApplicationJSONCharsetUTF8
+	case exporter.FormatBeancount, exporter.FormatHledger:
+		if encoding != "" && encoding != domain.EncodingUTF8 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "仕訳帳は utf-8 でのみ書き出せます",
+			})
+		}
+		contentType = "text/plain; charset=utf-8"
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "formatは csv / json / beancount / hledger のいずれかを指定してください",
+		})
+	}
+
+	accounts, err := repo.FindAllAccounts()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の取得に失敗しました: " + err.Error(),
+		})
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		})
+	}
+
+	res := c.Response()
+	filename := fmt.Sprintf("transactions-%s.%s", time.Now().Format("20060102"), exportExtensions[format])
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	var w exporter.Writer
+	switch format {
+	case exporter.FormatCSV:
+		accountNames, categoryNames := exportNames(accounts, categories)
+		if w, err = exporter.NewCSVWriter(res, encoding, accountNames, categoryNames); err != nil {
+			return fmt.Errorf("収支の書き出しに失敗しました: %w", err)
+		}
+	case exporter.FormatJSON:
+		w = exporter.NewJSONWriter(res)
+	default:
+		if w, err = exporter.NewLedgerWriter(res, format, accounts, categories); err != nil {
+			return fmt.Errorf("収支の書き出しに失敗しました: %w", err)
+		}
+	}
+
+	// 書き出し始めた後はステータスを変えられないため、途中のエラーはそのまま返してログに残す
+	if err := repo.EachByFilter(filter, w.Write); err != nil {
+		return fmt.Errorf("収支の書き出しに失敗しました: %w", err)
+	}
+	return w.Close()
+}
+
+// exportExtensions は書き出しの形式ごとのファイルの拡張子です。
+var exportExtensions = map[string]string{
+	exporter.FormatCSV:       "csv",
+	exporter.FormatJSON:      "json",
+	exporter.FormatBeancount: "beancount",
+	exporter.FormatHledger:   "journal",
+}
+
+// exportNames は口座・カテゴリのIDと名前の対応を返します。
+func exportNames(accounts []domain.Account, categories []domain.Category) (map[int]string, map[int]string) {
+	accountNames := make(map[int]string, len(accounts))
+	for _, a := range accounts {
+		accountNames[a.ID] = a.Name
+	}
+	categoryNames := make(map[int]string, len(categories))
+	for _, c := range categories {
+		categoryNames[c.ID] = c.Name
+	}
+	return accountNames, categoryNames
+}
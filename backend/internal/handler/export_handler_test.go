@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// export_handler_test.go は収支の書き出し（GET /api/export）の HTTP ハンドラテストです。
+
+func newExportRepo(t *testing.T) repository.TransactionRepository {
+	t.Helper()
+	repo := repository.NewTransactionRepository()
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 8, 25, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, AccountId: 1, Amount: 250000, Memo: "給与"},
+		{Date: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 11, AccountId: 1, Amount: -1200, Memo: "ランチ", Tags: []string{"仕事"}},
+		{Date: time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, AccountId: 1, Amount: -300, Memo: "バス"},
+	} {
+		tx := tx
+		if err := repo.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestExportTransactions_CSV(t *testing.T) {
+	h := NewTransactionHandler(newExportRepo(t), repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 一覧と同じ絞り込み。並び順の既定は日付の古い順
+	req := httptest.NewRequest(http.MethodGet, "/api/export?from=2025-08-01&to=2025-08-31&encoding=shift_jis", nil)
+	rec := httptest.NewRecorder()
+	if err := h.ExportTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ExportTransactions: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ExportTransactions: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); ct != "text/csv; charset=Shift_JIS" {
+		t.Errorf("ExportTransactions: unexpected Content-Type %q", ct)
+	}
+	if cd := rec.Header().Get(echo.HeaderContentDisposition); !strings.HasPrefix(cd, `attachment; filename="transactions-`) {
+		t.Errorf("ExportTransactions: unexpected Content-Disposition %q", cd)
+	}
+
+	text, err := japanese.ShiftJIS.NewDecoder().String(rec.Body.String())
+	if err != nil {
+		t.Fatalf("decode: unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n")
+	want := []string{
+		"ID,日付,種別,カテゴリ,口座,金額,メモ,タグ,内訳",
+		"2,2025-08-01,支出,外食,現金,-1200,ランチ,仕事,",
+		"1,2025-08-25,収入,給与,現金,250000,給与,,",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ExportTransactions: got\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestExportTransactions_JSON(t *testing.T) {
+	h := NewTransactionHandler(newExportRepo(t), repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/export?format=json&type=expense&order=desc", nil)
+	rec := httptest.NewRecorder()
+	if err := h.ExportTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ExportTransactions: unexpected error: %v", err)
+	}
+	var got []domain.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("ExportTransactions: invalid JSON: %v", err)
+	}
+	if len(got) != 2 || got[0].Memo != "バス" || got[1].Memo != "ランチ" || got[1].Tags[0] != "仕事" {
+		t.Errorf("ExportTransactions: unexpected transactions: %+v", got)
+	}
+}
+
+func TestExportTransactions_BadRequest(t *testing.T) {
+	h := NewTransactionHandler(repository.NewTransactionRepository(), repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	for _, query := range []string{
+		"format=xlsx",
+		"encoding=euc-jp",
+		"format=json&encoding=shift_jis",
+		"format=beancount&encoding=shift_jis",
+		"from=2025/08/01",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/export?"+query, nil)
+		rec := httptest.NewRecorder()
+		if err := h.ExportTransactions(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ExportTransactions: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("ExportTransactions(%s): expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// invitationTTL は招待を作成してから受けられなくなるまでの期間です。
+const invitationTTL = 7 * 24 * time.Hour
+
+// maxHouseholdNameLength は家計簿の名前の最大文字数です（households.name の VARCHAR(50) に合わせています）。
+const maxHouseholdNameLength = 50
+
+// householdNameMessage は家計簿の名前が不正な場合のエラーメッセージです。
+var householdNameMessage = "nameは1〜" + strconv.Itoa(maxHouseholdNameLength) + "文字で指定してください"
+
+// householdName は前後の空白を除いた家計簿の名前と、それが1〜50文字に収まっているかを返します。
+func householdName(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	return name, name != "" && utf8.RuneCountInString(name) <= maxHouseholdNameLength
+}
+
+// HouseholdHandler は家計簿・メンバー・招待のHTTPリクエストを処理するハンドラです。
+// /api/household 以下は RequireMembership が決めた利用中の家計簿を対象にします。
+// repo は家計簿を作成するときのカテゴリの用意に使います。
+type HouseholdHandler struct {
+	households repository.HouseholdRepository
+	users      repository.UserRepository
+	repo       repository.TransactionRepository
+	now        func() time.Time
+}
+
+// NewHouseholdHandler はHouseholdHandlerを生成します。
+func NewHouseholdHandler(households repository.HouseholdRepository, users repository.UserRepository, repo repository.TransactionRepository) *HouseholdHandler {
+	return &HouseholdHandler{households: households, users: users, repo: repo, now: time.Now}
+}
+
+// GetHouseholds はログイン中のユーザーが参加している家計簿を役割付きで返すGET /api/householdsのハンドラです。
+func (h *HouseholdHandler) GetHouseholds(c echo.Context) error {
+	memberships, err := h.households.FindByUser(currentUserId(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, memberships)
+}
+
+// CreateHousehold はログイン中のユーザーが所有者の家計簿を作成するPOST /api/householdsのハンドラです。
+// copy_categories_from に参加している家計簿を指定するとそのカテゴリ（アーカイブ済みを含む）をコピーし、
+// 指定しなければ初期カテゴリを用意します。口座は既定の口座（現金）だけを用意します。利用中の家計簿は切り替えません。
+func (h *HouseholdHandler) CreateHousehold(c echo.Context) error {
+	var req domain.CreateHouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	name, ok := householdName(req.Name)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": householdNameMessage,
+		})
+	}
+
+	userId := currentUserId(c)
+	categories := domain.DefaultCategories()
+	if req.CopyCategoriesFrom != 0 {
+		if _, err := h.households.FindMember(req.CopyCategoriesFrom, userId); err != nil {
+			if errors.Is(err, repository.ErrNotMember) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": "コピー元の家計簿のメンバーではありません",
+				})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "家計簿の確認に失敗しました: " + err.Error(),
+			})
+		}
+		var err error
+		categories, err = h.repo.ForHousehold(req.CopyCategoriesFrom).FindAllCategories()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "カテゴリの取得に失敗しました: " + err.Error(),
+			})
+		}
+	}
+
+	household := domain.Household{Name: name}
+	if err := h.households.Save(&household, userId); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の作成に失敗しました: " + err.Error(),
+		})
+	}
+	if err := prepareHousehold(h.repo, household.ID, categories); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリ・口座の作成に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, domain.HouseholdMembership{Household: household, Role: domain.RoleOwner})
+}
+
+// prepareHousehold は新しい家計簿に categories のカテゴリと既定の口座（現金）を用意します。
+func prepareHousehold(repo repository.TransactionRepository, householdId int, categories []domain.Category) error {
+	if err := repo.SaveCategorySet(householdId, categories); err != nil {
+		return err
+	}
+	account := domain.DefaultAccount()
+	return repo.ForHousehold(householdId).SaveAccount(&account)
+}
+
+// SelectHousehold は利用する家計簿を切り替えるPUT /api/households/currentのハンドラです。
+// 参加していない家計簿は選べません。
+func (h *HouseholdHandler) SelectHousehold(c echo.Context) error {
+	var req domain.SelectHouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	userId := currentUserId(c)
+	if _, err := h.households.FindMember(req.HouseholdId, userId); err != nil {
+		if errors.Is(err, repository.ErrNotMember) {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の確認に失敗しました: " + err.Error(),
+		})
+	}
+	if err := h.users.UpdateHousehold(userId, req.HouseholdId); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の切り替えに失敗しました: " + err.Error(),
+		})
+	}
+	return h.GetHouseholds(c)
+}
+
+// GetHousehold は利用中の家計簿と自分の役割・メンバー一覧を返すGET /api/householdのハンドラです。
+func (h *HouseholdHandler) GetHousehold(c echo.Context) error {
+	member, _ := currentMember(c)
+
+	household, err := h.households.FindById(member.HouseholdId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の取得に失敗しました: " + err.Error(),
+		})
+	}
+	members, err := h.findMembers(member.HouseholdId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "メンバーの取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, domain.HouseholdDetail{Household: household, Role: member.Role, Members: members})
+}
+
+// findMembers は家計簿のメンバーを、ユーザーのメールアドレス・名前を補って返します。
+func (h *HouseholdHandler) findMembers(householdId int) ([]domain.HouseholdMember, error) {
+	members, err := h.households.FindMembers(householdId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if user, err := h.users.FindById(members[i].UserId); err == nil {
+			members[i].Email = user.Email
+			members[i].Name = user.Name
+		}
+	}
+	return members, nil
+}
+
+// UpdateHousehold は利用中の家計簿の名前を変更するPUT /api/householdのハンドラです（owner のみ）。
+func (h *HouseholdHandler) UpdateHousehold(c echo.Context) error {
+	var req domain.UpdateHouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	name, ok := householdName(req.Name)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": householdNameMessage,
+		})
+	}
+
+	household := domain.Household{ID: currentHouseholdId(c), Name: name}
+	if err := h.households.Update(&household); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, household)
+}
+
+// UpdateMember はメンバーの役割を変更するPUT /api/household/members/{userId}のハンドラです（owner のみ）。
+// 最後の所有者を所有者以外にすることはできません。
+func (h *HouseholdHandler) UpdateMember(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "userIdは整数で指定してください",
+		})
+	}
+
+	var req domain.UpdateMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	if !domain.IsValidRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "roleは owner / editor / viewer のいずれかを指定してください",
+		})
+	}
+
+	householdId := currentHouseholdId(c)
+	if err := h.households.UpdateMemberRole(householdId, userId, req.Role); err != nil {
+		return memberErrorResponse(c, "メンバーの更新に失敗しました: ", err)
+	}
+	member, err := h.households.FindMember(householdId, userId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "メンバーの取得に失敗しました: " + err.Error(),
+		})
+	}
+	if user, err := h.users.FindById(userId); err == nil {
+		member.Email, member.Name = user.Email, user.Name
+	}
+	return c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember はメンバーを家計簿から外すDELETE /api/household/members/{userId}のハンドラです。
+// 他のメンバーを外せるのは owner だけで、自分自身は役割にかかわらず抜けられます。
+// 最後の所有者は抜けられません。
+func (h *HouseholdHandler) RemoveMember(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "userIdは整数で指定してください",
+		})
+	}
+
+	member, _ := currentMember(c)
+	if userId != member.UserId && member.Role != domain.RoleOwner {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": roleRequiredMessage(domain.RoleOwner),
+		})
+	}
+	if err := h.households.RemoveMember(member.HouseholdId, userId); err != nil {
+		return memberErrorResponse(c, "メンバーの削除に失敗しました: ", err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "メンバーを家計簿から外しました",
+	})
+}
+
+// memberErrorResponse はメンバーの変更に失敗した場合のレスポンスを返します。
+func memberErrorResponse(c echo.Context, prefix string, err error) error {
+	if errors.Is(err, repository.ErrLastOwner) {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": prefix + err.Error(),
+	})
+}
+
+// GetInvitations は利用中の家計簿への招待一覧を返すGET /api/household/invitationsのハンドラです（owner のみ）。
+func (h *HouseholdHandler) GetInvitations(c echo.Context) error {
+	invitations, err := h.households.FindInvitations(currentHouseholdId(c))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "招待の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, invitations)
+}
+
+// CreateInvitation は利用中の家計簿にユーザーを招待するPOST /api/household/invitationsのハンドラです（owner のみ）。
+// 招待のトークンはこのレスポンスでだけ返すため、招待するユーザーに伝えてください。
+func (h *HouseholdHandler) CreateInvitation(c echo.Context) error {
+	var req domain.InvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	email := domain.NormalizeEmail(req.Email)
+	if !strings.Contains(email, "@") || len(email) > 254 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "emailには有効なメールアドレスを指定してください",
+		})
+	}
+	role := req.Role
+	if role == "" {
+		role = domain.RoleEditor
+	}
+	if role != domain.RoleEditor && role != domain.RoleViewer {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "roleは editor または viewer を指定してください",
+		})
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "招待の作成に失敗しました: " + err.Error(),
+		})
+	}
+	invitation := domain.Invitation{
+		HouseholdId: currentHouseholdId(c),
+		Email:       email,
+		Role:        role,
+		InvitedBy:   currentUserId(c),
+		ExpiresAt:   h.now().Add(invitationTTL),
+		Token:       token,
+		TokenHash:   hashSessionToken(token),
+	}
+	if err := h.households.SaveInvitation(&invitation); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "招待の作成に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, invitation)
+}
+
+// DeleteInvitation は招待を取り消すDELETE /api/household/invitations/{id}のハンドラです（owner のみ）。
+func (h *HouseholdHandler) DeleteInvitation(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if err := h.households.DeleteInvitation(currentHouseholdId(c), id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "招待の取り消しに失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "招待を取り消しました",
+	})
+}
+
+// AcceptInvitation は招待を受けて家計簿に参加するPOST /api/invitations/acceptのハンドラです。
+// 招待されたメールアドレスのユーザーだけが参加でき、参加した家計簿がいま利用している家計簿になります。
+func (h *HouseholdHandler) AcceptInvitation(c echo.Context) error {
+	var req domain.AcceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	user, _ := currentUser(c)
+	invitation, err := h.households.FindInvitationByToken(hashSessionToken(strings.TrimSpace(req.Token)), h.now())
+	if errors.Is(err, repository.ErrInvitationNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "招待の取得に失敗しました: " + err.Error(),
+		})
+	}
+	if invitation.Email != user.Email {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "この招待は別のメールアドレス宛てです",
+		})
+	}
+
+	if _, err := h.households.AcceptInvitation(invitation, user.ID); err != nil {
+		if errors.Is(err, repository.ErrAlreadyMember) {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿への参加に失敗しました: " + err.Error(),
+		})
+	}
+	if err := h.users.UpdateHousehold(user.ID, invitation.HouseholdId); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "家計簿の切り替えに失敗しました: " + err.Error(),
+		})
+	}
+	return h.GetHouseholds(c)
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// household_handler_test.go は HouseholdHandler と RequireMembership・RequireRole の HTTP ハンドラテストです。
+// 招待で家計簿を共有し、役割によって変更できる操作が変わることを検証します。
+
+// inviteUser は token のユーザーの家計簿に email のユーザーを role で招待し、招待のトークンを返します。
+func inviteUser(t *testing.T, e *echo.Echo, token, email, role string) string {
+	t.Helper()
+	rec := doJSON(e, http.MethodPost, "/api/household/invitations", token, `{"email":"`+email+`","role":"`+role+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateInvitation: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var inv domain.Invitation
+	if err := json.Unmarshal(rec.Body.Bytes(), &inv); err != nil {
+		t.Fatalf("CreateInvitation: invalid JSON: %v", err)
+	}
+	if inv.Token == "" || inv.Role != role {
+		t.Fatalf("CreateInvitation: expected token and role %s, got %+v", role, inv)
+	}
+	return inv.Token
+}
+
+// getHousehold は token のユーザーが利用している家計簿を返します。
+func getHousehold(t *testing.T, e *echo.Echo, token string) domain.HouseholdDetail {
+	t.Helper()
+	rec := doJSON(e, http.MethodGet, "/api/household", token, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetHousehold: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail domain.HouseholdDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("GetHousehold: invalid JSON: %v", err)
+	}
+	return detail
+}
+
+func TestHousehold_InviteAndShare(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	taro := registerUser(t, e, "taro@example.com")
+	hanako := registerUser(t, e, "hanako@example.com")
+	jiro := registerUser(t, e, "jiro@example.com")
+	household := getHousehold(t, e, taro)
+
+	// 招待を受けられるのは招待されたメールアドレスのユーザーだけ
+	invitation := inviteUser(t, e, taro, "Hanako@Example.com", domain.RoleEditor)
+	if rec := doJSON(e, http.MethodPost, "/api/invitations/accept", jiro, `{"token":"`+invitation+`"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("AcceptInvitation(other user): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/invitations/accept", hanako, `{"token":"invalid"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("AcceptInvitation(invalid token): expected status 404, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/invitations/accept", hanako, `{"token":"`+invitation+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("AcceptInvitation: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/invitations/accept", hanako, `{"token":"`+invitation+`"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("AcceptInvitation(used token): expected status 404, got %d", rec.Code)
+	}
+
+	// 参加した家計簿がいま利用している家計簿になり、メンバーの収支を共有する
+	detail := getHousehold(t, e, hanako)
+	if detail.ID != household.ID || detail.Role != domain.RoleEditor || len(detail.Members) != 2 {
+		t.Fatalf("GetHousehold: expected household %d as editor with 2 members, got %+v", household.ID, detail)
+	}
+	food := findCategoryByName(t, e, hanako, "食材")
+	rec := doJSON(e, http.MethodPost, "/api/transactions", hanako,
+		`{"date":"2025-01-10","type":"expense","category_id":`+strconv.Itoa(food.ID)+`,"amount":800}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doJSON(e, http.MethodGet, "/api/transactions", taro, "")
+	var result struct {
+		Transactions []domain.Transaction `json:"transactions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetTransactions: invalid JSON: %v", err)
+	}
+	var hanakoId int
+	for _, m := range detail.Members {
+		if m.Email == "hanako@example.com" {
+			hanakoId = m.UserId
+		}
+	}
+	if len(result.Transactions) != 1 || result.Transactions[0].CreatedBy != hanakoId {
+		t.Errorf("GetTransactions: expected one transaction created by %d, got %+v", hanakoId, result.Transactions)
+	}
+
+	// 自分の家計簿に切り替えると共有した収支は見えない
+	if rec := doJSON(e, http.MethodGet, "/api/households", hanako, ""); rec.Code != http.StatusOK {
+		t.Fatalf("GetHouseholds: expected status 200, got %d", rec.Code)
+	} else {
+		var memberships []domain.HouseholdMembership
+		if err := json.Unmarshal(rec.Body.Bytes(), &memberships); err != nil || len(memberships) != 2 {
+			t.Fatalf("GetHouseholds: expected 2 households, got %s", rec.Body.String())
+		}
+		own := memberships[0].ID
+		if rec := doJSON(e, http.MethodPut, "/api/households/current", hanako, `{"household_id":`+strconv.Itoa(own)+`}`); rec.Code != http.StatusOK {
+			t.Fatalf("SelectHousehold: expected status 200, got %d", rec.Code)
+		}
+	}
+	if detail := getHousehold(t, e, hanako); detail.ID == household.ID || detail.Role != domain.RoleOwner {
+		t.Errorf("GetHousehold after select: expected own household as owner, got %+v", detail)
+	}
+	if rec := doJSON(e, http.MethodPut, "/api/households/current", jiro, `{"household_id":`+strconv.Itoa(household.ID)+`}`); rec.Code != http.StatusForbidden {
+		t.Errorf("SelectHousehold(not member): expected status 403, got %d", rec.Code)
+	}
+}
+
+func TestHousehold_Roles(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	taro := registerUser(t, e, "taro@example.com")
+	hanako := registerUser(t, e, "hanako@example.com")
+	invitation := inviteUser(t, e, taro, "hanako@example.com", domain.RoleViewer)
+	if rec := doJSON(e, http.MethodPost, "/api/invitations/accept", hanako, `{"token":"`+invitation+`"}`); rec.Code != http.StatusOK {
+		t.Fatalf("AcceptInvitation: expected status 200, got %d", rec.Code)
+	}
+	food := findCategoryByName(t, e, taro, "食材")
+	body := `{"date":"2025-01-10","type":"expense","category_id":` + strconv.Itoa(food.ID) + `,"amount":800}`
+
+	// viewer は閲覧だけでき、変更やメンバー管理はできない
+	if rec := doJSON(e, http.MethodGet, "/api/transactions", hanako, ""); rec.Code != http.StatusOK {
+		t.Errorf("GetTransactions(viewer): expected status 200, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/transactions", hanako, body); rec.Code != http.StatusForbidden {
+		t.Errorf("CreateTransaction(viewer): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodDelete, "/api/transactions/1", hanako, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("DeleteTransaction(viewer): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/household/invitations", hanako, `{"email":"jiro@example.com"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("CreateInvitation(viewer): expected status 403, got %d", rec.Code)
+	}
+
+	// owner が editor にすると変更できるようになる
+	detail := getHousehold(t, e, taro)
+	var taroId, hanakoId int
+	for _, m := range detail.Members {
+		switch m.Email {
+		case "taro@example.com":
+			taroId = m.UserId
+		case "hanako@example.com":
+			hanakoId = m.UserId
+		}
+	}
+	if rec := doJSON(e, http.MethodPut, "/api/household/members/"+strconv.Itoa(hanakoId), taro, `{"role":"admin"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("UpdateMember(invalid role): expected status 400, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPut, "/api/household/members/"+strconv.Itoa(hanakoId), taro, `{"role":"editor"}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdateMember: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/transactions", hanako, body); rec.Code != http.StatusCreated {
+		t.Errorf("CreateTransaction(editor): expected status 201, got %d", rec.Code)
+	}
+
+	// 最後の所有者は役割を変えたり抜けたりできない
+	if rec := doJSON(e, http.MethodPut, "/api/household/members/"+strconv.Itoa(taroId), taro, `{"role":"editor"}`); rec.Code != http.StatusConflict {
+		t.Errorf("UpdateMember(last owner): expected status 409, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodDelete, "/api/household/members/"+strconv.Itoa(taroId), taro, ""); rec.Code != http.StatusConflict {
+		t.Errorf("RemoveMember(last owner): expected status 409, got %d", rec.Code)
+	}
+	// owner 以外は他のメンバーを外せないが、自分は抜けられる
+	if rec := doJSON(e, http.MethodDelete, "/api/household/members/"+strconv.Itoa(taroId), hanako, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("RemoveMember(editor removes owner): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodDelete, "/api/household/members/"+strconv.Itoa(hanakoId), hanako, ""); rec.Code != http.StatusOK {
+		t.Errorf("RemoveMember(leave): expected status 200, got %d", rec.Code)
+	}
+	// 抜けた後は自分の家計簿に戻る
+	if detail := getHousehold(t, e, hanako); detail.ID == getHousehold(t, e, taro).ID {
+		t.Errorf("GetHousehold after leave: expected own household, got %+v", detail)
+	}
+}
+
+func TestHousehold_MultipleBooks(t *testing.T) {
+	e := newAuthTestServer(repository.NewUserRepository(), repository.NewTransactionRepository())
+	taro := registerUser(t, e, "taro@example.com")
+	hanako := registerUser(t, e, "hanako@example.com")
+	personal := getHousehold(t, e, taro)
+
+	// 自分で追加したカテゴリも、コピーして作った家計簿に引き継がれる
+	if rec := doJSON(e, http.MethodPost, "/api/categories", taro, `{"name":"副業経費","kind":"expense"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("CreateCategory: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := doJSON(e, http.MethodPost, "/api/households", taro,
+		`{"name":"副業","copy_categories_from":`+strconv.Itoa(personal.ID)+`}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateHousehold: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var business domain.HouseholdMembership
+	if err := json.Unmarshal(rec.Body.Bytes(), &business); err != nil {
+		t.Fatalf("CreateHousehold: invalid JSON: %v", err)
+	}
+	if business.Role != domain.RoleOwner || business.Name != "副業" {
+		t.Errorf("CreateHousehold: expected owner of 副業, got %+v", business)
+	}
+	// 作成しても利用中の家計簿は変わらない
+	if detail := getHousehold(t, e, taro); detail.ID != personal.ID {
+		t.Errorf("GetHousehold: expected household %d to stay current, got %d", personal.ID, detail.ID)
+	}
+
+	prefix := "/api/households/" + strconv.Itoa(business.ID)
+	rec = doJSON(e, http.MethodGet, prefix+"/categories", taro, "")
+	var copied []domain.Category
+	if err := json.Unmarshal(rec.Body.Bytes(), &copied); err != nil {
+		t.Fatalf("GetCategories: invalid JSON: %v", err)
+	}
+	var expense domain.Category
+	count := 0
+	for _, c := range copied {
+		if c.Name == "副業経費" {
+			expense = c
+		}
+		count += 1 + len(c.Children)
+	}
+	if expense.ID == 0 || count != len(domain.DefaultCategories())+1 {
+		t.Fatalf("GetCategories: expected copied categories including 副業経費, got %+v", copied)
+	}
+
+	// パスで指定した家計簿に登録し、ヘッダーで指定しても同じ家計簿を参照できる
+	rec = doJSON(e, http.MethodPost, prefix+"/transactions", taro,
+		`{"date":"2025-01-10","type":"expense","category_id":`+strconv.Itoa(expense.ID)+`,"amount":3000}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	countTransactions := func(header string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+taro)
+		if header != "" {
+			req.Header.Set(HouseholdIdHeader, header)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var result struct {
+			Transactions []domain.Transaction `json:"transactions"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("GetTransactions: invalid JSON: %v", err)
+		}
+		return len(result.Transactions)
+	}
+	if n := countTransactions(strconv.Itoa(business.ID)); n != 1 {
+		t.Errorf("GetTransactions(header): expected 1 transaction, got %d", n)
+	}
+	if n := countTransactions(""); n != 0 {
+		t.Errorf("GetTransactions(current): expected no transactions in the personal household, got %d", n)
+	}
+
+	// 参加していない家計簿は指定できず、そのカテゴリもコピーできない
+	if rec := doJSON(e, http.MethodGet, prefix+"/transactions", hanako, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("GetTransactions(not member): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/households", hanako,
+		`{"name":"盗用","copy_categories_from":`+strconv.Itoa(business.ID)+`}`); rec.Code != http.StatusForbidden {
+		t.Errorf("CreateHousehold(copy from other): expected status 403, got %d", rec.Code)
+	}
+	if rec := doJSON(e, http.MethodPost, "/api/households", taro, `{"name":" "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("CreateHousehold(empty name): expected status 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// memberContextKey は利用中の家計簿でのメンバー情報を echo.Context に保存するキーです。
+const memberContextKey = "member"
+
+// selectedHouseholdContextKey は URL のパスで指定された家計簿のIDを echo.Context に保存するキーです。
+const selectedHouseholdContextKey = "selectedHousehold"
+
+// HouseholdIdHeader はリクエストごとに家計簿を指定するヘッダーです。
+const HouseholdIdHeader = "X-Household-Id"
+
+// householdPathPattern は /api/households/{id}/… の形式で家計簿を指定するパスです。
+var householdPathPattern = regexp.MustCompile(`^/api/households/(\d+)(/.+)$`)
+
+// SelectHouseholdByPath は /api/households/{id}/transactions のようなパスを /api/transactions に書き換え、
+// 家計簿 {id} を指定したリクエストとして扱うミドルウェアです。ルーティングの前に e.Pre で使います。
+func SelectHouseholdByPath() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if m := householdPathPattern.FindStringSubmatch(req.URL.Path); m != nil {
+				if id, err := strconv.Atoi(m[1]); err == nil {
+					req.URL.Path = "/api" + m[2]
+					req.URL.RawPath = ""
+					c.Set(selectedHouseholdContextKey, id)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// selectedHouseholdId はリクエストで指定された家計簿のIDを返します。
+// パス（SelectHouseholdByPath）、X-Household-Id ヘッダーの順に調べ、指定がない場合は0を返します。
+func selectedHouseholdId(c echo.Context) (int, error) {
+	if id, ok := c.Get(selectedHouseholdContextKey).(int); ok {
+		return id, nil
+	}
+	header := c.Request().Header.Get(HouseholdIdHeader)
+	if header == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(header)
+	if err != nil || id <= 0 {
+		return 0, errors.New(HouseholdIdHeader + "は正の整数で指定してください")
+	}
+	return id, nil
+}
+
+// RequireMembership はリクエストが対象にする家計簿を決め、そのメンバー情報を echo.Context に保存するミドルウェアです。
+// RequireAuth の後に使います。家計簿はパスまたは X-Household-Id ヘッダーで指定でき、
+// 指定がなければユーザーがいま利用している家計簿を使います。
+// 指定した家計簿のメンバーでなければ 403 を返し、利用中の家計簿から外されている場合は参加している最初の家計簿に切り替えます。
+// 各ハンドラはこの家計簿の収支・カテゴリ・予算・定期収支だけを扱います。
+func RequireMembership(households repository.HouseholdRepository, users repository.UserRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := currentUser(c)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "ログインが必要です",
+				})
+			}
+			selected, err := selectedHouseholdId(c)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+			}
+
+			var member domain.HouseholdMember
+			if selected != 0 {
+				member, err = households.FindMember(selected, user.ID)
+				if errors.Is(err, repository.ErrNotMember) {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": err.Error(),
+					})
+				}
+			} else {
+				member, err = households.FindMember(user.HouseholdId, user.ID)
+				if errors.Is(err, repository.ErrNotMember) {
+					member, err = fallbackMembership(households, users, user)
+				}
+				if errors.Is(err, repository.ErrNotMember) {
+					return c.JSON(http.StatusForbidden, map[string]string{
+						"error": "参加している家計簿がありません",
+					})
+				}
+			}
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "家計簿の確認に失敗しました: " + err.Error(),
+				})
+			}
+
+			c.Set(memberContextKey, member)
+			return next(c)
+		}
+	}
+}
+
+// fallbackMembership はユーザーが参加している最初の家計簿を、いま利用している家計簿にします。
+// 参加している家計簿がない場合は ErrNotMember を返します。
+func fallbackMembership(households repository.HouseholdRepository, users repository.UserRepository, user domain.User) (domain.HouseholdMember, error) {
+	memberships, err := households.FindByUser(user.ID)
+	if err != nil {
+		return domain.HouseholdMember{}, err
+	}
+	if len(memberships) == 0 {
+		return domain.HouseholdMember{}, repository.ErrNotMember
+	}
+	if err := users.UpdateHousehold(user.ID, memberships[0].ID); err != nil {
+		return domain.HouseholdMember{}, err
+	}
+	return households.FindMember(memberships[0].ID, user.ID)
+}
+
+// RequireRole は利用中の家計簿での役割が role 以上でないリクエストを 403 で拒否するミドルウェアです。
+// RequireMembership の後に、ルートごとに使います（変更は editor 以上、メンバー管理は owner）。
+func RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			member, ok := currentMember(c)
+			if !ok || !domain.RoleAtLeast(member.Role, role) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error": roleRequiredMessage(role),
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// roleRequiredMessage は役割が足りない場合のエラーメッセージを返します。
+func roleRequiredMessage(role string) string {
+	if role == domain.RoleOwner {
+		return "この操作は家計簿の所有者（owner）だけが実行できます"
+	}
+	return "閲覧者（viewer）は変更できません。編集者（editor）以上の役割が必要です"
+}
+
+// currentMember は RequireMembership が保存した、利用中の家計簿でのメンバー情報を返します。
+func currentMember(c echo.Context) (domain.HouseholdMember, bool) {
+	member, ok := c.Get(memberContextKey).(domain.HouseholdMember)
+	return member, ok
+}
+
+// currentHouseholdId は利用中の家計簿のIDを返します。
+// RequireMembership を通っていない場合（テストなど）は0を返し、リポジトリはすべての家計簿のデータを扱います。
+func currentHouseholdId(c echo.Context) int {
+	member, _ := currentMember(c)
+	return member.HouseholdId
+}
@@ -0,0 +1,651 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/importer"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImportHandler は銀行・カードの明細などのファイルから収支を取り込むHTTPリクエストを処理するハンドラです。
+// 取り込んだ収入・支出の行には、既定で家計簿の仕分けルールを適用します。
+type ImportHandler struct {
+	repo  repository.TransactionRepository
+	rules repository.CategoryRuleRepository
+}
+
+// NewImportHandler はImportHandlerを生成します。
+func NewImportHandler(repo repository.TransactionRepository, rules repository.CategoryRuleRepository) *ImportHandler {
+	return &ImportHandler{repo: repo, rules: rules}
+}
+
+// maxImportFileSize は取り込むファイルの大きさの上限（バイト）です。
+const maxImportFileSize = 5 << 20
+
+// ImportCSV は明細の CSV から収支を取り込むPOST /api/import/csvのハンドラです。
+// multipart/form-data で次の項目を受け付けます。
+//
+//	file     CSV ファイル（UTF-8・BOM 付き UTF-8・Shift_JIS）
+//	profile  列の対応（domain.CSVImportProfile の JSON）
+//	dry_run           true（既定）は登録せずに変換結果を返し、false で登録する
+//	allow_duplicates  true の場合は登録済みの収支と重複する行も登録する（既定 false）
+//	apply_rules       true（既定）は仕分けルールを適用し、一致したルールのカテゴリ・タグ・メモの書き換えで登録する
+//
+// profile でカテゴリを指定しない種別の行は、仕分けルールでカテゴリが決まらなければエラーになります。
+// 登録は1つのトランザクションで行い、エラーのある行は登録せずに結果の rows で理由を返します。
+func (h *ImportHandler) ImportCSV(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	dryRun, err := parseFormBool(c, "dry_run", true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	allowDuplicates, err := parseFormBool(c, "allow_duplicates", false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	applyRules, err := parseFormBool(c, "apply_rules", true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	var profile domain.CSVImportProfile
+	if err := json.Unmarshal([]byte(c.FormValue("profile")), &profile); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "profileの解析に失敗しました: " + err.Error(),
+		})
+	}
+	data, err := readImportFile(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	account, err := resolveAccount(repo, profile.AccountId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if account.Archived {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "アーカイブ済みの口座には登録できません",
+		})
+	}
+
+	rows, err := importer.ParseCSV(data, profile)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	rules, err := h.ruleSet(c, repo, applyRules)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの取得に失敗しました: " + err.Error(),
+		})
+	}
+	result, err := h.importRows(c, repo, rows, account.ID, rules, dryRun, allowDuplicates)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の取り込みに失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// ImportZaim は Zaim の CSV から収支を取り込むPOST /api/import/zaimのハンドラです。
+func (h *ImportHandler) ImportZaim(c echo.Context) error {
+	return h.importApp(c, domain.ImportSourceZaim, importer.ParseZaim)
+}
+
+// ImportMoneyForward は Money Forward ME の CSV から収支を取り込むPOST /api/import/moneyforwardのハンドラです。
+func (h *ImportHandler) ImportMoneyForward(c echo.Context) error {
+	return h.importApp(c, domain.ImportSourceMoneyForward, importer.ParseMoneyForward)
+}
+
+// ImportLedger は beancount・hledger の仕訳帳から収支を取り込むPOST /api/import/ledgerのハンドラです。
+// 口座は仕訳帳の勘定科目名（Assets:・Liabilities: の最下位の名前）と同じ名前の口座に登録し、振替も取り込みます。
+// 口座の勘定科目がない行は account_id の口座に登録します。
+func (h *ImportHandler) ImportLedger(c echo.Context) error {
+	return h.importApp(c, domain.ImportSourceLedger, importer.ParseLedger)
+}
+
+// importApp は他の家計簿アプリの CSV から収支を取り込みます。
+// multipart/form-data で file・dry_run・allow_duplicates・apply_rules（ImportCSV と同じ）と account_id（省略時は家計簿の既定の口座）を受け付けます。
+// 取り込み元のカテゴリ名は、カテゴリの対応（/api/import/mappings）か同じ名前のカテゴリでこの家計簿のカテゴリに変換し、
+// 変換できない行は登録せずに結果の unmapped で返します。
+func (h *ImportHandler) importApp(c echo.Context, source string, parse func([]byte) ([]domain.ImportRow, error)) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	dryRun, err := parseFormBool(c, "dry_run", true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	allowDuplicates, err := parseFormBool(c, "allow_duplicates", false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	applyRules, err := parseFormBool(c, "apply_rules", true)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	accountId := 0
+	if v := c.FormValue("account_id"); v != "" {
+		if accountId, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "account_idは整数で指定してください",
+			})
+		}
+	}
+	data, err := readImportFile(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	account, err := resolveAccount(repo, accountId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if account.Archived {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "アーカイブ済みの口座には登録できません",
+		})
+	}
+
+	rows, err := parse(data)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if err := mapSourceAccounts(repo, rows); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "口座の取得に失敗しました: " + err.Error(),
+		})
+	}
+	unmapped, err := mapSourceCategories(repo, source, rows)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの対応の取得に失敗しました: " + err.Error(),
+		})
+	}
+	rules, err := h.ruleSet(c, repo, applyRules)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの取得に失敗しました: " + err.Error(),
+		})
+	}
+	result, err := h.importRows(c, repo, rows, account.ID, rules, dryRun, allowDuplicates)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の取り込みに失敗しました: " + err.Error(),
+		})
+	}
+	result.Unmapped = unmapped
+	return c.JSON(http.StatusOK, result)
+}
+
+// mapSourceAccounts は取り込み元の口座名（仕訳帳の勘定科目名）を持つ行に、同じ名前のアーカイブされていない口座を設定します。
+// 勘定科目名に使えない文字を置き換えて書き出した名前とも一致するように、importer.LedgerNameKey で比べます。
+func mapSourceAccounts(repo repository.TransactionRepository, rows []domain.ImportRow) error {
+	accounts, err := repo.FindAllAccounts()
+	if err != nil {
+		return err
+	}
+	find := func(source string) int {
+		key := importer.LedgerNameKey(importer.LedgerAccountName(source))
+		for _, a := range accounts {
+			if !a.Archived && importer.LedgerNameKey(a.Name) == key {
+				return a.ID
+			}
+		}
+		return 0
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		for _, target := range []struct {
+			source string
+			id     *int
+		}{{row.SourceAccount, &row.AccountId}, {row.SourceToAccount, &row.ToAccountId}} {
+			if target.source == "" {
+				continue
+			}
+			if *target.id = find(target.source); *target.id == 0 {
+				row.Error = "口座が見つかりません: " + target.source
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// mapSourceCategories は取り込み元のカテゴリ名を持つ行（と内訳）にこの家計簿のカテゴリを設定し、
+// 変換できなかったカテゴリ名を行数の多い順に返します。"大項目/中項目" は次の順に探します。
+//
+//  1. "大項目/中項目" の対応
+//  2. "大項目" の対応
+//  3. 中項目と同じ名前で、行の種別に使えるアーカイブされていない、子カテゴリのないカテゴリ
+//  4. 大項目と同じ名前で、行の種別に使えるアーカイブされていない、子カテゴリのないカテゴリ
+func mapSourceCategories(repo repository.TransactionRepository, source string, rows []domain.ImportRow) ([]domain.UnmappedCategory, error) {
+	mappings, err := repo.FindCategoryMappings(source)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	mapped := make(map[string]int, len(mappings))
+	for _, m := range mappings {
+		mapped[m.Name] = m.CategoryId
+	}
+	parents := map[int]bool{}
+	for _, category := range categories {
+		parents[category.ParentId] = true
+	}
+	byName := func(name, transactionType string) int {
+		for _, category := range categories {
+			if category.Name == name && !category.Archived && !parents[category.ID] && category.AllowsType(transactionType) {
+				return category.ID
+			}
+		}
+		return 0
+	}
+
+	resolve := func(source, transactionType string) int {
+		major, minor, _ := strings.Cut(source, "/")
+		id := mapped[source]
+		if id == 0 {
+			id = mapped[major]
+		}
+		if id == 0 && minor != "" {
+			id = byName(minor, transactionType)
+		}
+		if id == 0 {
+			id = byName(major, transactionType)
+		}
+		return id
+	}
+
+	counts := map[domain.UnmappedCategory]int{}
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		if row.SourceCategory != "" {
+			if row.CategoryId = resolve(row.SourceCategory, row.Type); row.CategoryId == 0 {
+				row.Error = "カテゴリの対応がありません: " + row.SourceCategory
+				counts[domain.UnmappedCategory{Name: row.SourceCategory, Type: row.Type}]++
+			}
+		}
+		for j := range row.Splits {
+			split := &row.Splits[j]
+			if split.CategoryId = resolve(split.SourceCategory, row.Type); split.CategoryId == 0 {
+				if row.Error == "" {
+					row.Error = "カテゴリの対応がありません: " + split.SourceCategory
+				}
+				counts[domain.UnmappedCategory{Name: split.SourceCategory, Type: row.Type}]++
+			}
+		}
+	}
+
+	unmapped := make([]domain.UnmappedCategory, 0, len(counts))
+	for u, n := range counts {
+		u.Count = n
+		unmapped = append(unmapped, u)
+	}
+	sort.Slice(unmapped, func(i, j int) bool {
+		if unmapped[i].Count != unmapped[j].Count {
+			return unmapped[i].Count > unmapped[j].Count
+		}
+		if unmapped[i].Name != unmapped[j].Name {
+			return unmapped[i].Name < unmapped[j].Name
+		}
+		return unmapped[i].Type < unmapped[j].Type
+	})
+	return unmapped, nil
+}
+
+// maxCategoryMappingNameLength はカテゴリの対応の取り込み元のカテゴリ名の長さ（文字数）の上限です。
+const maxCategoryMappingNameLength = 100
+
+// GetCategoryMappings は取り込み元のカテゴリ名の対応を返すGET /api/import/mappings/:sourceのハンドラです。
+// source は zaim / moneyforward / ledger です。
+func (h *ImportHandler) GetCategoryMappings(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	source := c.Param("source")
+	if !domain.IsValidImportSource(source) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "sourceは zaim / moneyforward / ledger のいずれかを指定してください",
+		})
+	}
+	mappings, err := repo.FindCategoryMappings(source)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの対応の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, mappings)
+}
+
+// UpdateCategoryMappings は取り込み元のカテゴリ名の対応を置き換えるPUT /api/import/mappings/:sourceのハンドラです。
+// リクエストボディは {name, category_id} の配列で、category_id が0の対応は削除します。
+func (h *ImportHandler) UpdateCategoryMappings(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	source := c.Param("source")
+	if !domain.IsValidImportSource(source) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "sourceは zaim / moneyforward / ledger のいずれかを指定してください",
+		})
+	}
+	var reqs []domain.CategoryMappingRequest
+	if err := c.Bind(&reqs); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+
+	seen := map[string]bool{}
+	mappings := make([]domain.CategoryMapping, 0, len(reqs))
+	for _, req := range reqs {
+		name := strings.TrimSpace(req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxCategoryMappingNameLength {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("nameは1〜%d文字で指定してください", maxCategoryMappingNameLength),
+			})
+		}
+		if seen[name] {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "同じカテゴリ名の対応が重複しています: " + name,
+			})
+		}
+		seen[name] = true
+		if req.CategoryId == 0 {
+			continue
+		}
+		if _, err := repo.FindCategoryById(req.CategoryId); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("カテゴリが見つかりません: %d", req.CategoryId),
+			})
+		}
+		mappings = append(mappings, domain.CategoryMapping{Name: name, CategoryId: req.CategoryId})
+	}
+
+	if err := repo.SaveCategoryMappings(source, mappings); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの対応の保存に失敗しました: " + err.Error(),
+		})
+	}
+	saved, err := repo.FindCategoryMappings(source)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの対応の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, saved)
+}
+
+// parseFormBool はフォームの name の値を真偽値として解釈します。省略時は def です。
+func parseFormBool(c echo.Context, name string, def bool) (bool, error) {
+	v := c.FormValue(name)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%sは true / false で指定してください", name)
+	}
+	return b, nil
+}
+
+// readImportFile は multipart の file 項目の内容を読み込みます。
+func readImportFile(c echo.Context) ([]byte, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("fileに取り込むファイルを指定してください")
+	}
+	if header.Size > maxImportFileSize {
+		return nil, fmt.Errorf("ファイルは%dMBまでです", maxImportFileSize>>20)
+	}
+	f, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを開けません: %w", err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxImportFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("ファイルを読み込めません: %w", err)
+	}
+	if len(data) > maxImportFileSize {
+		return nil, fmt.Errorf("ファイルは%dMBまでです", maxImportFileSize>>20)
+	}
+	return data, nil
+}
+
+// ruleSet は apply が true の場合にリクエストの家計簿の仕分けルールから domain.RuleSet を作ります。
+// false の場合はどの行にも一致しない空の RuleSet です。
+func (h *ImportHandler) ruleSet(c echo.Context, repo repository.TransactionRepository, apply bool) (*domain.RuleSet, error) {
+	if !apply {
+		return domain.NewRuleSet(nil, nil), nil
+	}
+	rules, err := h.rules.ForHousehold(currentHouseholdId(c)).FindAll()
+	if err != nil {
+		return nil, err
+	}
+	return newRuleSet(repo, rules)
+}
+
+// importRows は変換した行に仕分けルールを適用し、カテゴリと重複を確認して、dryRun でなければエラーのない行を1つのトランザクションで登録します。
+// 口座は行の AccountId（0の場合は accountId）で、振替の行は出金側・入金側の2行の収支として登録します。
+// 登録済みの収支と指紋（domain.Fingerprint）が同じ行は DuplicateOf に相手のIDを設定し、
+// allowDuplicates が false の場合はエラーとして登録しません。
+func (h *ImportHandler) importRows(c echo.Context, repo repository.TransactionRepository, rows []domain.ImportRow, accountId int, rules *domain.RuleSet, dryRun, allowDuplicates bool) (domain.ImportResult, error) {
+	duplicates, err := importDuplicateIndex(repo, rows)
+	if err != nil {
+		return domain.ImportResult{}, err
+	}
+	duplicateCount := 0
+
+	categories := map[int]domain.Category{}
+	var transactions []*domain.Transaction
+	var indexes []int // transactions のそれぞれを変換した行の位置（振替は2件とも同じ行）
+	valid := 0
+	for i := range rows {
+		row := &rows[i]
+		if row.Error != "" {
+			continue
+		}
+
+		date, _ := time.Parse("2006-01-02", row.Date)
+		transaction := &domain.Transaction{
+			Date:      date,
+			Type:      row.Type,
+			AccountId: accountId,
+			Amount:    row.Amount,
+			Memo:      row.Memo,
+			CreatedBy: currentUserId(c),
+		}
+		if row.AccountId != 0 {
+			transaction.AccountId = row.AccountId
+		}
+		var in *domain.Transaction
+		if row.Type == domain.TransactionTypeTransfer {
+			if row.ToAccountId == 0 || row.ToAccountId == transaction.AccountId {
+				row.Error = "振替元と振替先には別の口座を指定してください"
+				continue
+			}
+			transaction.Amount = -row.Amount
+			in = &domain.Transaction{}
+			*in = *transaction
+			in.AccountId, in.Amount = row.ToAccountId, row.Amount
+		} else {
+			if row.Type == domain.TransactionTypeExpense {
+				transaction.Amount = -row.Amount // 支出は負の値で統一
+			}
+			row.Payee = domain.PayeeName(row.Payee)
+			transaction.PayeeName = row.Payee
+			applyImportRules(rules, row, transaction)
+			if err := fillImportCategory(repo, row, transaction, categories); err != nil {
+				row.Error = err.Error()
+				continue
+			}
+		}
+
+		if row.DuplicateOf = duplicates.Take(*transaction); row.DuplicateOf != 0 {
+			duplicateCount++
+			if !allowDuplicates {
+				row.Error = fmt.Sprintf("登録済みの収支と重複しています（ID: %d）", row.DuplicateOf)
+				continue
+			}
+		}
+		transactions = append(transactions, transaction)
+		indexes = append(indexes, i)
+		if in != nil {
+			transactions = append(transactions, in)
+			indexes = append(indexes, i)
+		}
+		valid++
+	}
+
+	result := domain.ImportResult{
+		DryRun:     dryRun,
+		Total:      len(rows),
+		Valid:      valid,
+		Skipped:    len(rows) - valid,
+		Duplicates: duplicateCount,
+		Rows:       rows,
+	}
+	if !dryRun && len(transactions) > 0 {
+		if err := repo.SaveAll(transactions); err != nil {
+			return domain.ImportResult{}, err
+		}
+		for j, t := range transactions {
+			if row := &rows[indexes[j]]; row.TransactionId == 0 {
+				row.TransactionId = t.ID
+			}
+		}
+		result.Imported = valid
+	}
+	return result, nil
+}
+
+// applyImportRules は収入・支出の行に仕分けルールを適用し、一致したルールのカテゴリ・タグ・メモの書き換えを行と transaction に反映します。
+// 内訳のある行のカテゴリは、fillImportCategory で内訳のものになります。
+func applyImportRules(rules *domain.RuleSet, row *domain.ImportRow, transaction *domain.Transaction) {
+	t := *transaction
+	t.CategoryId, t.Tags = row.CategoryId, row.Tags
+	if row.RuleIds = rules.Apply(&t); len(row.RuleIds) == 0 {
+		return
+	}
+	row.CategoryId, row.Tags, row.Memo = t.CategoryId, t.Tags, t.Memo
+	transaction.Memo = t.Memo
+}
+
+// fillImportCategory は収入・支出の行のカテゴリ・内訳・タグを検証して transaction に設定します。
+// categories は確認済みのカテゴリで、行をまたいで使い回します。
+func fillImportCategory(repo repository.TransactionRepository, row *domain.ImportRow, transaction *domain.Transaction, categories map[int]domain.Category) error {
+	if len(row.Splits) > 0 {
+		reqs := make([]domain.SplitRequest, len(row.Splits))
+		for j, split := range row.Splits {
+			reqs[j] = domain.SplitRequest{CategoryId: split.CategoryId, Amount: split.Amount, Memo: split.Memo}
+		}
+		splits, err := buildSplits(repo, row.Type, transaction.Amount, reqs, nil)
+		if err != nil {
+			return err
+		}
+		transaction.Splits = splits
+		row.CategoryId = splits[0].CategoryId
+	}
+	tags, err := buildTags(row.Tags)
+	if err != nil {
+		return err
+	}
+	transaction.Tags = tags
+
+	if row.CategoryId == 0 {
+		return fmt.Errorf("%sのカテゴリが指定されておらず、一致する仕分けルールもありません", map[string]string{"income": "収入", "expense": "支出"}[row.Type])
+	}
+	category, ok := categories[row.CategoryId]
+	if !ok {
+		found, err := repo.FindCategoryById(row.CategoryId)
+		if err != nil {
+			return fmt.Errorf("カテゴリが見つかりません: %d", row.CategoryId)
+		}
+		if err := checkLeafCategory(repo, found); err != nil {
+			return err
+		}
+		category, categories[row.CategoryId] = found, found
+	}
+	if category.Archived {
+		return errors.New("アーカイブ済みのカテゴリには登録できません")
+	}
+	if !category.AllowsType(row.Type) {
+		return errors.New(categoryTypeMismatchMessage(category, row.Type))
+	}
+	transaction.CategoryId = category.ID
+	transaction.Category = category
+	return nil
+}
+
+// importDuplicateIndex は取り込む行の日付の範囲にある登録済みの収支の索引を作ります。
+// 指紋は口座を含むため、行ごとに口座が違う場合（仕訳帳）もすべての口座の収支から作ります。
+func importDuplicateIndex(repo repository.TransactionRepository, rows []domain.ImportRow) (domain.DuplicateIndex, error) {
+	var from, to time.Time
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			continue
+		}
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if to.IsZero() || date.After(to) {
+			to = date
+		}
+	}
+	if from.IsZero() {
+		return domain.DuplicateIndex{}, nil
+	}
+	existing, _, err := repo.FindByFilter(domain.TransactionFilter{From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewDuplicateIndex(existing), nil
+}
@@ -0,0 +1,455 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// import_handler_test.go は ImportHandler（ファイルからの収支の取り込み）の HTTP ハンドラテストです。
+
+// newImportRequest はファイルとフォームの項目を multipart/form-data で送るリクエストを作ります。
+func newImportRequest(t *testing.T, target string, file []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatalf("WriteField: unexpected error: %v", err)
+		}
+	}
+	if file != nil {
+		fw, err := w.CreateFormFile("file", "statement.csv")
+		if err != nil {
+			t.Fatalf("CreateFormFile: unexpected error: %v", err)
+		}
+		fw.Write(file)
+	}
+	w.Close()
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	return req
+}
+
+func TestImportCSV(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 銀行の明細（Shift_JIS）。最後の行は金額がないためエラー
+	data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(
+		"取引日,お引出し,お預入れ,お取り扱い内容\r\n" +
+			"2025/08/01,1200,,ｶｰﾄﾞ ｺﾝﾋﾞﾆ\r\n" +
+			"2025/08/25,,250000,給与\r\n" +
+			"2025/08/26,,,メモのみ\r\n"))
+	if err != nil {
+		t.Fatalf("encode: unexpected error: %v", err)
+	}
+	profile := `{"has_header":true,"date_column":"取引日","debit_column":"お引出し","credit_column":"お預入れ",` +
+		`"memo_columns":["お取り扱い内容"],"expense_category_id":12,"income_category_id":10}`
+
+	// 既定は dry run: 変換結果を返し、登録しない
+	req := newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile})
+	rec := httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportCSV: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if !result.DryRun || result.Total != 3 || result.Valid != 2 || result.Skipped != 1 || result.Imported != 0 {
+		t.Errorf("ImportCSV(dry run): unexpected result: %+v", result)
+	}
+	if result.Rows[0].Memo != "ｶｰﾄﾞ ｺﾝﾋﾞﾆ" || result.Rows[1].Type != "income" || result.Rows[2].Error == "" {
+		t.Errorf("ImportCSV(dry run): unexpected rows: %+v", result.Rows)
+	}
+	if all, _ := repo.FindAll(); len(all) != 0 {
+		t.Fatalf("ImportCSV(dry run): expected no transactions, got %d", len(all))
+	}
+
+	// dry_run=false でエラーのない行を登録する
+	req = newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile, "dry_run": "false"})
+	rec = httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	result = domain.ImportResult{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if result.DryRun || result.Imported != 2 || result.Rows[0].TransactionId == 0 || result.Rows[2].TransactionId != 0 {
+		t.Errorf("ImportCSV(commit): unexpected result: %+v", result)
+	}
+	all, _ := repo.FindAll()
+	if len(all) != 2 || all[0].Amount != -1200 || all[1].Amount != 250000 || all[1].Category.Name == "" {
+		t.Errorf("ImportCSV(commit): unexpected transactions: %+v", all)
+	}
+}
+
+func TestImportCSV_CategoryMismatch(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 収入の行に支出のカテゴリを指定した場合、その行はエラーになり登録しない
+	data := []byte("2025-08-01,-500\n2025-08-02,1000\n")
+	profile := `{"date_column":"1","amount_column":"2","expense_category_id":12,"income_category_id":2}`
+	req := newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile, "dry_run": "false"})
+	rec := httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if result.Imported != 1 || result.Rows[0].Error != "" || result.Rows[1].Error == "" {
+		t.Errorf("ImportCSV: unexpected result: %+v", result)
+	}
+}
+
+func TestImportCSV_Duplicates(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 前回の取り込みで登録済みの収支
+	if err := repo.Save(&domain.Transaction{
+		Date: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -500, Memo: "コンビニ",
+	}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 期間が重なる明細。同じ日に同じ金額のコンビニが2件あり、1件だけが登録済み
+	data := []byte("2025-08-01,-500,ｺﾝﾋﾞﾆ\n2025-08-01,-500,コンビニ\n2025-08-02,-800,書店\n")
+	profile := `{"date_column":"1","amount_column":"2","memo_columns":["3"],"expense_category_id":12}`
+	run := func(fields map[string]string) domain.ImportResult {
+		req := newImportRequest(t, "/api/import/csv", data, fields)
+		rec := httptest.NewRecorder()
+		if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ImportCSV: unexpected error: %v", err)
+		}
+		var result domain.ImportResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("ImportCSV: invalid JSON: %v", err)
+		}
+		return result
+	}
+
+	result := run(map[string]string{"profile": profile})
+	if result.Duplicates != 1 || result.Valid != 2 || result.Rows[0].DuplicateOf != 1 || result.Rows[0].Error == "" || result.Rows[1].DuplicateOf != 0 {
+		t.Errorf("ImportCSV(dry run): unexpected result: %+v", result)
+	}
+
+	// allow_duplicates=true では重複する行も登録する（重複の印は残す）
+	result = run(map[string]string{"profile": profile, "allow_duplicates": "true", "dry_run": "false"})
+	if result.Duplicates != 1 || result.Imported != 3 || result.Rows[0].DuplicateOf != 1 || result.Rows[0].Error != "" {
+		t.Errorf("ImportCSV(allow_duplicates): unexpected result: %+v", result)
+	}
+}
+
+func TestImportCSV_BadRequest(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	data := []byte("2025-08-01,100\n")
+	profile := `{"date_column":"1","amount_column":"2","expense_category_id":12}`
+	for _, tc := range []struct {
+		name   string
+		file   []byte
+		fields map[string]string
+	}{
+		{"no file", nil, map[string]string{"profile": profile}},
+		{"no profile", data, map[string]string{}},
+		{"invalid profile", data, map[string]string{"profile": `{"date_column":"1"}`}},
+		{"invalid dry_run", data, map[string]string{"profile": profile, "dry_run": "maybe"}},
+		{"invalid allow_duplicates", data, map[string]string{"profile": profile, "allow_duplicates": "maybe"}},
+		{"unknown account", data, map[string]string{"profile": `{"date_column":"1","amount_column":"2","account_id":999}`}},
+	} {
+		req := newImportRequest(t, "/api/import/csv", tc.file, tc.fields)
+		rec := httptest.NewRecorder()
+		if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ImportCSV: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("ImportCSV(%s): expected status 400, got %d", tc.name, rec.Code)
+		}
+	}
+}
+
+func TestImportZaim(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	if err := repo.SaveCategoryMappings(domain.ImportSourceZaim, []domain.CategoryMapping{{Name: "趣味・娯楽", CategoryId: 6}}); err != nil {
+		t.Fatalf("SaveCategoryMappings: unexpected error: %v", err)
+	}
+	data := []byte(strings.Join([]string{
+		"日付,方法,カテゴリ,カテゴリの内訳,品目,メモ,お店,収入,支出,集計の設定",
+		"2025-08-01,payment,食費,食料品,,,スーパー,0,2000,常に含める", // 子カテゴリのある大項目（食費）には対応させない
+		"2025-08-02,payment,食費,外食,,,,0,1000,常に含める",      // 中項目と同じ名前のカテゴリ（外食）
+		"2025-08-03,payment,趣味・娯楽,本,,,,0,1500,常に含める",    // 大項目の対応（娯楽費）
+		"2025-08-25,income,給与,-,,,,250000,0,常に含める",      // 同じ名前のカテゴリ（給与）
+		"2025-08-26,payment,交際費,飲み会,,,,0,4000,常に含める",    // 対応なし
+		"2025-08-27,payment,交際費,飲み会,,,,0,3000,常に含める",    // 対応なし
+		"2025-08-28,transfer,-,-,,,,0,0,常に含める",          // 振替
+	}, "\n"))
+
+	req := newImportRequest(t, "/api/import/zaim", data, map[string]string{"dry_run": "false"})
+	rec := httptest.NewRecorder()
+	if err := h.ImportZaim(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportZaim: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportZaim: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportZaim: invalid JSON: %v", err)
+	}
+	if result.Total != 7 || result.Imported != 3 || result.Skipped != 4 {
+		t.Errorf("ImportZaim: unexpected result: %+v", result)
+	}
+	for i, want := range []int{0, 11, 6, 10} {
+		if result.Rows[i].CategoryId != want {
+			t.Errorf("ImportZaim: line %d: expected category %d, got %+v", result.Rows[i].Line, want, result.Rows[i])
+		}
+	}
+	if len(result.Unmapped) != 2 || result.Unmapped[0] != (domain.UnmappedCategory{Name: "交際費/飲み会", Type: "expense", Count: 2}) ||
+		result.Unmapped[1] != (domain.UnmappedCategory{Name: "食費/食料品", Type: "expense", Count: 1}) {
+		t.Errorf("ImportZaim: unexpected unmapped categories: %+v", result.Unmapped)
+	}
+
+	all, _ := repo.FindAll()
+	if len(all) != 3 || all[0].Amount != -1000 || all[2].Amount != 250000 {
+		t.Errorf("ImportZaim: unexpected transactions: %+v", all)
+	}
+
+	// Zaim の CSV ではないファイル
+	req = newImportRequest(t, "/api/import/zaim", []byte("取引日,金額\n2025/08/01,100\n"), nil)
+	rec = httptest.NewRecorder()
+	if err := h.ImportZaim(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportZaim: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ImportZaim: expected status 400 for a non-Zaim CSV, got %d", rec.Code)
+	}
+}
+
+func TestImportMoneyForward(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(
+		`"計算対象","日付","内容","金額（円）","保有金融機関","大項目","中項目","メモ","振替","ID"` + "\r\n" +
+			`"1","2025/08/01","電力会社","-8000","銀行","水道・光熱費","電気代","","0","a1"` + "\r\n" +
+			`"1","2025/08/25","給与","250000","銀行","収入","給与","","0","a2"` + "\r\n"))
+	if err != nil {
+		t.Fatalf("encode: unexpected error: %v", err)
+	}
+	if err := repo.SaveCategoryMappings(domain.ImportSourceMoneyForward, []domain.CategoryMapping{{Name: "水道・光熱費/電気代", CategoryId: 13}}); err != nil {
+		t.Fatalf("SaveCategoryMappings: unexpected error: %v", err)
+	}
+
+	req := newImportRequest(t, "/api/import/moneyforward", data, map[string]string{"account_id": "1"})
+	rec := httptest.NewRecorder()
+	if err := h.ImportMoneyForward(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportMoneyForward: unexpected error: %v", err)
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportMoneyForward: invalid JSON: %v", err)
+	}
+	if !result.DryRun || result.Valid != 2 || result.Rows[0].CategoryId != 13 || result.Rows[1].CategoryId != 10 || len(result.Unmapped) != 0 {
+		t.Errorf("ImportMoneyForward: unexpected result: %+v", result)
+	}
+}
+
+// newLedgerRepo は現金と銀行の口座を持つ家計簿を作ります。
+func newLedgerRepo(t *testing.T) repository.TransactionRepository {
+	t.Helper()
+	repo := repository.NewTransactionRepository()
+	if err := repo.SaveAccount(&domain.Account{Name: "みずほ銀行", Kind: domain.AccountKindBank}); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+	return repo
+}
+
+func TestImportLedger_RoundTrip(t *testing.T) {
+	source := newLedgerRepo(t)
+	date := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	for _, tx := range []domain.Transaction{
+		{Date: date, Type: "expense", CategoryId: 11, AccountId: 1, Amount: -1200, Memo: "ランチ \"駅前\"", Tags: []string{"仕事", "同僚 と"}},
+		{Date: date, Type: "income", CategoryId: 10, AccountId: 2, Amount: 250000, Memo: "給与"},
+		{Date: date, Type: "expense", CategoryId: 12, AccountId: 2, Amount: -4200, Memo: "スーパー", Splits: []domain.Split{
+			{CategoryId: 12, Amount: -3000, Memo: "野菜"},
+			{CategoryId: 9, Amount: -1200},
+		}},
+	} {
+		tx := tx
+		if err := source.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	out := &domain.Transaction{Date: date, Type: "transfer", AccountId: 2, Amount: -30000, Memo: "引き出し"}
+	in := &domain.Transaction{Date: date, Type: "transfer", AccountId: 1, Amount: 30000, Memo: "引き出し"}
+	if err := source.SaveTransfer(out, in); err != nil {
+		t.Fatalf("SaveTransfer: unexpected error: %v", err)
+	}
+	want, _ := source.FindAll()
+
+	e := echo.New()
+	for _, format := range []string{"beancount", "hledger"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/export?format="+format, nil)
+		rec := httptest.NewRecorder()
+		if err := NewTransactionHandler(source, repository.NewCategoryRuleRepository()).ExportTransactions(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ExportTransactions(%s): unexpected error: %v", format, err)
+		}
+		journal := rec.Body.Bytes()
+
+		repo := newLedgerRepo(t)
+		h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+		req = newImportRequest(t, "/api/import/ledger", journal, map[string]string{"dry_run": "false"})
+		rec = httptest.NewRecorder()
+		if err := h.ImportLedger(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ImportLedger(%s): unexpected error: %v", format, err)
+		}
+		var result domain.ImportResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("ImportLedger(%s): invalid JSON: %v", format, err)
+		}
+		if result.Imported != 4 || result.Skipped != 0 {
+			t.Fatalf("ImportLedger(%s): unexpected result: %+v\n%s", format, result, journal)
+		}
+
+		got, _ := repo.FindAll()
+		if len(got) != len(want) {
+			t.Fatalf("ImportLedger(%s): expected %d transactions, got %d", format, len(want), len(got))
+		}
+		for i := range want {
+			w, g := want[i], got[i]
+			if g.Date != w.Date || g.Type != w.Type || g.CategoryId != w.CategoryId || g.AccountId != w.AccountId ||
+				g.Amount != w.Amount || g.Memo != w.Memo || len(g.Splits) != len(w.Splits) || (w.TransferId != 0) != (g.TransferId != 0) {
+				t.Errorf("ImportLedger(%s): transaction %d = %+v, want %+v", format, i, g, w)
+			}
+		}
+		if format == "beancount" && strings.Join(got[0].Tags, ",") != "仕事,同僚 と" {
+			t.Errorf("ImportLedger(%s): unexpected tags %v", format, got[0].Tags)
+		}
+		if got[2].Splits[0].Memo != "野菜" || got[2].Splits[1].CategoryId != 9 {
+			t.Errorf("ImportLedger(%s): unexpected splits %+v", format, got[2].Splits)
+		}
+
+		// 同じ仕訳帳をもう一度取り込むと、振替も含めてすべて重複になる
+		req = newImportRequest(t, "/api/import/ledger", journal, map[string]string{"dry_run": "true"})
+		rec = httptest.NewRecorder()
+		if err := h.ImportLedger(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("ImportLedger(%s): unexpected error: %v", format, err)
+		}
+		result = domain.ImportResult{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("ImportLedger(%s): invalid JSON: %v", format, err)
+		}
+		if result.Duplicates != 4 || result.Valid != 0 {
+			t.Errorf("ImportLedger(%s): expected 4 duplicates, got %+v", format, result)
+		}
+	}
+}
+
+func TestImportLedger_UnknownAccount(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	data := []byte(strings.Join([]string{
+		"2025-08-01 * \"ランチ\"",
+		"  Expenses:外食  1200 JPY",
+		"  Assets:財布  -1200 JPY",
+	}, "\n"))
+	req := newImportRequest(t, "/api/import/ledger", data, nil)
+	rec := httptest.NewRecorder()
+	if err := h.ImportLedger(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportLedger: unexpected error: %v", err)
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportLedger: invalid JSON: %v", err)
+	}
+	if len(result.Rows) != 1 || result.Rows[0].Error != "口座が見つかりません: Assets:財布" {
+		t.Errorf("ImportLedger: unexpected rows %+v", result.Rows)
+	}
+}
+
+func TestCategoryMappings(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	put := func(source, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/import/mappings/"+source, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("source")
+		c.SetParamValues(source)
+		if err := h.UpdateCategoryMappings(c); err != nil {
+			t.Fatalf("UpdateCategoryMappings: unexpected error: %v", err)
+		}
+		return rec
+	}
+
+	// category_id が0の対応は保存しない
+	rec := put("zaim", `[{"name":" 交際費 ","category_id":9},{"name":"日用雑貨","category_id":0}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("UpdateCategoryMappings: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/import/mappings/zaim", nil)
+	rec = httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("source")
+	c.SetParamValues("zaim")
+	if err := h.GetCategoryMappings(c); err != nil {
+		t.Fatalf("GetCategoryMappings: unexpected error: %v", err)
+	}
+	var mappings []domain.CategoryMapping
+	if err := json.Unmarshal(rec.Body.Bytes(), &mappings); err != nil {
+		t.Fatalf("GetCategoryMappings: invalid JSON: %v", err)
+	}
+	if len(mappings) != 1 || mappings[0].Name != "交際費" || mappings[0].CategoryId != 9 {
+		t.Errorf("GetCategoryMappings: unexpected mappings: %+v", mappings)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		source string
+		body   string
+	}{
+		{"unknown source", "mint", `[]`},
+		{"empty name", "zaim", `[{"name":" ","category_id":9}]`},
+		{"duplicate name", "zaim", `[{"name":"交際費","category_id":9},{"name":"交際費","category_id":12}]`},
+		{"unknown category", "zaim", `[{"name":"交際費","category_id":999}]`},
+	} {
+		if rec := put(tc.source, tc.body); rec.Code != http.StatusBadRequest {
+			t.Errorf("UpdateCategoryMappings(%s): expected status 400, got %d", tc.name, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ItemHandler は支出の品目（レシートの1行）の価格の推移のHTTPリクエストを処理するハンドラです。
+// 品目は収支の登録・更新時に items で指定します。
+type ItemHandler struct {
+	repo repository.TransactionRepository
+}
+
+// NewItemHandler はItemHandlerを生成します。
+func NewItemHandler(repo repository.TransactionRepository) *ItemHandler {
+	return &ItemHandler{repo: repo}
+}
+
+// GetPriceHistory は品目の単価の推移を返すGET /api/reports/pricesのハンドラです。
+// item（必須）を品名に含む品目の購入と、月・支払先ごとの単価（数量で重み付けした平均・最安・最高）を返します。
+// 品名は大文字・小文字、全角・半角を区別せずに比べます。
+// from と to（YYYY-MM-DD、両端を含む）を省略した場合は当月までの12か月です。payee_id を指定するとその支払先だけを返します。
+func (h *ItemHandler) GetPriceHistory(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	item := strings.Join(strings.Fields(c.QueryParam("item")), " ")
+	if item == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "itemに品名を指定してください",
+		})
+	}
+	if utf8.RuneCountInString(item) > domain.MaxLineItemNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("itemは%d文字以内で指定してください", domain.MaxLineItemNameLength),
+		})
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if v := c.QueryParam("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "fromは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		from = d
+	}
+	if v := c.QueryParam("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "toは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		to = d
+	}
+	if to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "toにはfrom以降の日付を指定してください",
+		})
+	}
+	payeeId := 0
+	if v := c.QueryParam("payee_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "payee_idは整数で指定してください",
+			})
+		}
+		payeeId = n
+	}
+
+	purchases, err := repo.FindItemPurchases(item, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "品目の購入履歴の取得に失敗しました: " + err.Error(),
+		})
+	}
+	if payeeId != 0 {
+		matched := []domain.ItemPurchase{}
+		for _, p := range purchases {
+			if p.PayeeId == payeeId {
+				matched = append(matched, p)
+			}
+		}
+		purchases = matched
+	}
+	return c.JSON(http.StatusOK, domain.ItemPriceHistory{
+		Item:      item,
+		From:      from.Format("2006-01-02"),
+		To:        to.Format("2006-01-02"),
+		Purchases: purchases,
+		Months:    domain.SummarizeItemPrices(purchases),
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// item_handler_test.go は ItemHandler（品目の価格の推移）と、収支の登録での品目の検証の HTTP ハンドラテストです。
+
+// getPriceHistory は GET /api/reports/prices の結果を返します。
+func getPriceHistory(t *testing.T, h *ItemHandler, e *echo.Echo, query url.Values) (int, domain.ItemPriceHistory) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/prices?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetPriceHistory(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetPriceHistory: unexpected error: %v", err)
+	}
+	var history domain.ItemPriceHistory
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+			t.Fatalf("GetPriceHistory: invalid JSON: %v", err)
+		}
+	}
+	return rec.Code, history
+}
+
+func TestCreateTransaction_Items(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+
+	// 数量の省略は1、品名の空白はまとめる
+	rec := postRuleJSON(t, h.CreateTransaction, "/api/transactions",
+		`{"date":"2025-08-01","type":"expense","category_id":12,"amount":668,"items":[{"name":" 牛乳 ","quantity":2,"unit_price":214},{"name":"卵　10個","unit_price":240}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Transaction
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if len(created.Items) != 2 || created.Items[0].Name != "牛乳" || created.Items[1].Name != "卵 10個" ||
+		created.Items[1].Quantity != 1 || created.Items[0].ID == 0 {
+		t.Errorf("CreateTransaction: unexpected items: %+v", created.Items)
+	}
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"合計が金額と一致しない", `{"date":"2025-08-01","type":"expense","category_id":12,"amount":500,"items":[{"name":"牛乳","unit_price":214}]}`},
+		{"収入", `{"date":"2025-08-01","type":"income","category_id":10,"amount":214,"items":[{"name":"牛乳","unit_price":214}]}`},
+		{"品名なし", `{"date":"2025-08-01","type":"expense","category_id":12,"amount":214,"items":[{"name":"  ","unit_price":214}]}`},
+		{"単価が0", `{"date":"2025-08-01","type":"expense","category_id":12,"amount":0,"items":[{"name":"牛乳","unit_price":0}]}`},
+		{"数量が負", `{"date":"2025-08-01","type":"expense","category_id":12,"amount":214,"items":[{"name":"牛乳","quantity":-1,"unit_price":-214}]}`},
+		{"振替", `{"date":"2025-08-01","type":"transfer","account_id":1,"to_account_id":2,"amount":214,"items":[{"name":"牛乳","unit_price":214}]}`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postRuleJSON(t, h.CreateTransaction, "/api/transactions", tt.body); rec.Code != http.StatusBadRequest {
+				t.Errorf("CreateTransaction: expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetPriceHistory(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ih := NewItemHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-07-03","type":"expense","category_id":12,"amount":428,"payee":"スーパーA","items":[{"name":"牛乳","quantity":2,"unit_price":214}]}`,
+		`{"date":"2025-07-20","type":"expense","category_id":12,"amount":468,"payee":"スーパーA","items":[{"name":"牛乳","unit_price":228},{"name":"卵","unit_price":240}]}`,
+		`{"date":"2025-07-25","type":"expense","category_id":12,"amount":198,"payee":"ドラッグストアB","items":[{"name":"ｷﾞｭｳﾆｭｳ","unit_price":1},{"name":"牛乳","unit_price":197}]}`,
+		`{"date":"2025-08-02","type":"expense","category_id":12,"amount":238,"payee":"スーパーA","items":[{"name":"牛乳","unit_price":238}]}`,
+	)
+
+	code, history := getPriceHistory(t, ih, e, url.Values{"item": {"牛乳"}, "from": {"2025-07-01"}, "to": {"2025-08-31"}})
+	if code != http.StatusOK {
+		t.Fatalf("GetPriceHistory: expected status 200, got %d", code)
+	}
+	if len(history.Purchases) != 4 || history.From != "2025-07-01" || history.To != "2025-08-31" {
+		t.Fatalf("GetPriceHistory: unexpected purchases: %+v", history)
+	}
+	want := []domain.ItemPriceMonth{
+		{Month: "2025-07", PayeeName: "スーパーA", Count: 2, Quantity: 3, AverageUnitPrice: 219, MinUnitPrice: 214, MaxUnitPrice: 228},
+		{Month: "2025-07", PayeeName: "ドラッグストアB", Count: 1, Quantity: 1, AverageUnitPrice: 197, MinUnitPrice: 197, MaxUnitPrice: 197},
+		{Month: "2025-08", PayeeName: "スーパーA", Count: 1, Quantity: 1, AverageUnitPrice: 238, MinUnitPrice: 238, MaxUnitPrice: 238},
+	}
+	if len(history.Months) != len(want) {
+		t.Fatalf("GetPriceHistory: unexpected months: %+v", history.Months)
+	}
+	for i, m := range history.Months {
+		m.PayeeId = 0
+		if m != want[i] {
+			t.Errorf("GetPriceHistory: months[%d] = %+v, want %+v", i, m, want[i])
+		}
+	}
+
+	// payee_id で支払先を絞り込める
+	supermarket := history.Months[0].PayeeId
+	_, history = getPriceHistory(t, ih, e, url.Values{"item": {"牛乳"}, "from": {"2025-07-01"}, "to": {"2025-08-31"}, "payee_id": {strconv.Itoa(supermarket)}})
+	if len(history.Purchases) != 3 || len(history.Months) != 2 {
+		t.Errorf("GetPriceHistory(payee_id): unexpected result: %+v", history)
+	}
+
+	for _, query := range []url.Values{
+		{},
+		{"item": {"牛乳"}, "from": {"2025/07/01"}},
+		{"item": {"牛乳"}, "from": {"2025-08-01"}, "to": {"2025-07-01"}},
+		{"item": {"牛乳"}, "payee_id": {"a"}},
+	} {
+		if code, _ := getPriceHistory(t, ih, e, query); code != http.StatusBadRequest {
+			t.Errorf("GetPriceHistory(%s): expected status 400, got %d", query.Encode(), code)
+		}
+	}
+}
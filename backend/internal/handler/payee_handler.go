@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PayeeHandler は支払先（お店など）と支払先別集計のHTTPリクエストを処理するハンドラです。
+// 支払先は収支の登録・取り込み時に支払先の名前から自動で作られます。
+type PayeeHandler struct {
+	repo repository.TransactionRepository
+}
+
+// NewPayeeHandler はPayeeHandlerを生成します。
+func NewPayeeHandler(repo repository.TransactionRepository) *PayeeHandler {
+	return &PayeeHandler{repo: repo}
+}
+
+// GetPayees は支払先一覧を収支の多い順に取得するGET /api/payeesのハンドラです。
+// 統合された支払先は含めません。q を指定すると名前に q を含む支払先に絞り込みます（大文字・小文字、全角・半角を区別しない）。
+func (h *PayeeHandler) GetPayees(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	payees, err := repo.FindAllPayees()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "支払先の取得に失敗しました: " + err.Error(),
+		})
+	}
+	if q := domain.NormalizeMemo(c.QueryParam("q")); q != "" {
+		matched := []domain.Payee{}
+		for _, p := range payees {
+			if strings.Contains(domain.NormalizeMemo(p.Name), q) {
+				matched = append(matched, p)
+			}
+		}
+		payees = matched
+	}
+	return c.JSON(http.StatusOK, payees)
+}
+
+// UpdatePayee は支払先の名前を変更するPUT /api/payees/{id}のハンドラです。
+// 名前を変えても、以後の取り込みで元の名前と同じ支払先はこの支払先に結び付きます。
+func (h *PayeeHandler) UpdatePayee(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.PayeeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	name := strings.Join(strings.Fields(req.Name), " ")
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "nameを指定してください",
+		})
+	}
+	if utf8.RuneCountInString(name) > domain.MaxPayeeNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("nameは%d文字以内で指定してください", domain.MaxPayeeNameLength),
+		})
+	}
+
+	payee := domain.Payee{ID: id, Name: name}
+	if err := repo.UpdatePayee(&payee); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "支払先の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, payee)
+}
+
+// MergePayees は重複している支払先をまとめるPOST /api/payees/{id}/mergeのハンドラです。
+// payee_ids の支払先の収支をパスで指定した支払先に付け替え、以後の取り込みでもその支払先に結び付けます。
+func (h *PayeeHandler) MergePayees(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.PayeeMergeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	var sourceIds []int
+	seen := map[int]bool{}
+	for _, sourceId := range req.PayeeIds {
+		if sourceId == id {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "統合先の支払先をpayee_idsに含めることはできません",
+			})
+		}
+		if !seen[sourceId] {
+			seen[sourceId] = true
+			sourceIds = append(sourceIds, sourceId)
+		}
+	}
+	if len(sourceIds) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "payee_idsを指定してください",
+		})
+	}
+
+	if err := repo.MergePayees(id, sourceIds); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "支払先の統合に失敗しました: " + err.Error(),
+		})
+	}
+	payee, err := repo.FindPayeeById(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "支払先の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, payee)
+}
+
+// GetPayeeReport は期間内の支払先別の収入・支出の合計と月ごとの件数を返すGET /api/reports/payeesのハンドラです。
+// from と to（YYYY-MM-DD、両端を含む）を省略した場合は当月までの12か月です。振替は含めません。
+// payee_id を指定するとその支払先だけを返します。
+func (h *PayeeHandler) GetPayeeReport(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if v := c.QueryParam("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "fromは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		from = d
+	}
+	if v := c.QueryParam("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "toは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		to = d
+	}
+	if to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "toにはfrom以降の日付を指定してください",
+		})
+	}
+	payeeId := 0
+	if v := c.QueryParam("payee_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "payee_idは整数で指定してください",
+			})
+		}
+		payeeId = n
+	}
+
+	totals, err := repo.FindPayeeTotals(from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "支払先別集計の取得に失敗しました: " + err.Error(),
+		})
+	}
+	if payeeId != 0 {
+		matched := []domain.PayeeTotal{}
+		for _, t := range totals {
+			if t.PayeeId == payeeId {
+				matched = append(matched, t)
+			}
+		}
+		totals = matched
+	}
+	return c.JSON(http.StatusOK, domain.PayeeReport{
+		From:   from.Format("2006-01-02"),
+		To:     to.Format("2006-01-02"),
+		Payees: totals,
+	})
+}
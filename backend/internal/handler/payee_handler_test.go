@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// payee_handler_test.go は PayeeHandler（支払先）と、収支の登録・取り込みでの支払先の設定の HTTP ハンドラテストです。
+
+// getPayees は GET /api/payees の結果を返します。
+func getPayees(t *testing.T, h *PayeeHandler, e *echo.Echo) []domain.Payee {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/payees", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetPayees(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetPayees: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetPayees: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var payees []domain.Payee
+	if err := json.Unmarshal(rec.Body.Bytes(), &payees); err != nil {
+		t.Fatalf("GetPayees: invalid JSON: %v", err)
+	}
+	return payees
+}
+
+// callPayeeById は JSON のリクエストボディとパスの id で fn を呼び出し、レスポンスを返します。
+func callPayeeById(t *testing.T, fn echo.HandlerFunc, e *echo.Echo, method string, id int, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/payees/"+strconv.Itoa(id), bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(id))
+	if err := fn(c); err != nil {
+		t.Fatalf("%s /api/payees/%d: unexpected error: %v", method, id, err)
+	}
+	return rec
+}
+
+func TestCreateTransaction_Payee(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ph := NewPayeeHandler(repo)
+	e := echo.New()
+
+	// 半角カナ・店舗名・法人の種類の表記の揺れは同じ支払先にまとまる
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-01","type":"expense","category_id":12,"amount":300,"payee":"ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店"}`,
+		`{"date":"2025-08-02","type":"expense","category_id":12,"amount":500,"payee":"セブン-イレブン（渋谷駅前店）"}`,
+		`{"date":"2025-08-03","type":"expense","category_id":12,"amount":800,"payee":"株式会社ファミリーマート"}`,
+		`{"date":"2025-08-04","type":"expense","category_id":12,"amount":100}`,
+	)
+	payees := getPayees(t, ph, e)
+	if len(payees) != 2 || payees[0].Name != "セブンイレブン" || payees[0].Count != 2 ||
+		payees[1].Name != "ファミリーマート" || payees[1].Count != 1 {
+		t.Fatalf("GetPayees: unexpected payees: %+v", payees)
+	}
+
+	// payee_id で絞り込める
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions?payee_id="+strconv.Itoa(payees[0].ID), nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTransactions: unexpected error: %v", err)
+	}
+	var page domain.TransactionPage
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("GetTransactions: invalid JSON: %v", err)
+	}
+	if page.Total != 2 || page.Transactions[0].PayeeName != "セブンイレブン" {
+		t.Errorf("GetTransactions(payee_id): unexpected page: %+v", page)
+	}
+
+	// 長すぎる支払先と振替への支払先は 400
+	for _, body := range []string{
+		`{"date":"2025-08-10","type":"expense","category_id":12,"amount":100,"payee":"123456789012345678901234567890123456789012345678901"}`,
+		`{"date":"2025-08-10","type":"transfer","account_id":1,"to_account_id":2,"amount":100,"payee":"銀行"}`,
+	} {
+		if rec := postRuleJSON(t, h.CreateTransaction, "/api/transactions", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestUpdatePayee(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ph := NewPayeeHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e, `{"date":"2025-08-01","type":"expense","category_id":12,"amount":300,"payee":"ｽｰﾊﾟｰ ｱｵｲ"}`)
+	payee := getPayees(t, ph, e)[0]
+
+	if rec := callPayeeById(t, ph.UpdatePayee, e, http.MethodPut, payee.ID, `{"name":"  スーパーあおい  "}`); rec.Code != http.StatusOK {
+		t.Fatalf("UpdatePayee: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	all, _ := repo.FindAll()
+	if all[0].PayeeName != "スーパーあおい" {
+		t.Errorf("UpdatePayee: expected transaction payee name to be updated, got %q", all[0].PayeeName)
+	}
+
+	// 名前を変えても、元の名前で登録した収支は同じ支払先になる
+	createTaggedTransactions(t, h, e, `{"date":"2025-08-02","type":"expense","category_id":12,"amount":300,"payee":"スーパー アオイ"}`)
+	if payees := getPayees(t, ph, e); len(payees) != 1 || payees[0].Count != 2 {
+		t.Errorf("UpdatePayee: expected the original name to keep resolving, got %+v", payees)
+	}
+
+	for _, body := range []string{`{"name":""}`, `{"name":"123456789012345678901234567890123456789012345678901"}`} {
+		if rec := callPayeeById(t, ph.UpdatePayee, e, http.MethodPut, payee.ID, body); rec.Code != http.StatusBadRequest {
+			t.Errorf("UpdatePayee(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestMergePayees(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ph := NewPayeeHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-01","type":"expense","category_id":12,"amount":300,"payee":"ローソン"}`,
+		`{"date":"2025-08-02","type":"expense","category_id":12,"amount":400,"payee":"LAWSON"}`,
+		`{"date":"2025-08-03","type":"expense","category_id":12,"amount":500,"payee":"ﾛｰｿﾝｽﾄｱ100"}`,
+	)
+	payees := getPayees(t, ph, e)
+	if len(payees) != 3 {
+		t.Fatalf("GetPayees: expected 3 payees, got %+v", payees)
+	}
+	target := payees[0].ID
+	body := `{"payee_ids":[` + strconv.Itoa(payees[1].ID) + `,` + strconv.Itoa(payees[2].ID) + `,` + strconv.Itoa(payees[1].ID) + `]}`
+	rec := callPayeeById(t, ph.MergePayees, e, http.MethodPost, target, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("MergePayees: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if payees := getPayees(t, ph, e); len(payees) != 1 || payees[0].ID != target || payees[0].Count != 3 {
+		t.Errorf("MergePayees: unexpected payees after merge: %+v", payees)
+	}
+
+	// 統合元の名前で取り込むと統合先になる
+	createTaggedTransactions(t, h, e, `{"date":"2025-08-04","type":"expense","category_id":12,"amount":600,"payee":"lawson"}`)
+	if payees := getPayees(t, ph, e); len(payees) != 1 || payees[0].Count != 4 {
+		t.Errorf("MergePayees: expected merged name to resolve to the target, got %+v", payees)
+	}
+
+	// 統合先自身・空の指定は 400、統合済みの支払先は失敗する
+	for _, c := range []struct {
+		body string
+		want int
+	}{
+		{`{"payee_ids":[` + strconv.Itoa(target) + `]}`, http.StatusBadRequest},
+		{`{"payee_ids":[]}`, http.StatusBadRequest},
+		{`{"payee_ids":[` + strconv.Itoa(payees[1].ID) + `]}`, http.StatusInternalServerError},
+	} {
+		if rec := callPayeeById(t, ph.MergePayees, e, http.MethodPost, target, c.body); rec.Code != c.want {
+			t.Errorf("MergePayees(%s): expected status %d, got %d", c.body, c.want, rec.Code)
+		}
+	}
+}
+
+func TestGetPayeeReport(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ph := NewPayeeHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-07-01","type":"expense","category_id":12,"amount":1000,"payee":"八百屋"}`,
+		`{"date":"2025-07-11","type":"expense","category_id":12,"amount":2000,"payee":"八百屋"}`,
+		`{"date":"2025-08-10","type":"expense","category_id":12,"amount":3000,"payee":"八百屋"}`,
+		`{"date":"2025-08-25","type":"income","category_id":10,"amount":250000,"payee":"株式会社サンプル"}`,
+		`{"date":"2025-09-01","type":"expense","category_id":12,"amount":9000,"payee":"八百屋"}`,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/payees?from=2025-07-01&to=2025-08-31", nil)
+	rec := httptest.NewRecorder()
+	if err := ph.GetPayeeReport(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetPayeeReport: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetPayeeReport: expected status 200, got %d", rec.Code)
+	}
+	var report domain.PayeeReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GetPayeeReport: invalid JSON: %v", err)
+	}
+	if report.From != "2025-07-01" || report.To != "2025-08-31" || len(report.Payees) != 2 {
+		t.Fatalf("GetPayeeReport: unexpected report: %+v", report)
+	}
+	grocer, salary := report.Payees[0], report.Payees[1]
+	if grocer.PayeeName != "八百屋" || grocer.Expense != 6000 || grocer.Count != 3 || grocer.CountPerMonth != 1.5 ||
+		grocer.AverageInterval == nil || *grocer.AverageInterval != 20 {
+		t.Errorf("GetPayeeReport: unexpected total: %+v", grocer)
+	}
+	if len(grocer.Months) != 2 || grocer.Months[0] != (domain.PayeeMonth{Month: "2025-07", Expense: 3000, Count: 2}) {
+		t.Errorf("GetPayeeReport: unexpected months: %+v", grocer.Months)
+	}
+	if salary.PayeeName != "サンプル" || salary.Income != 250000 || salary.AverageInterval != nil {
+		t.Errorf("GetPayeeReport: unexpected total: %+v", salary)
+	}
+
+	// 期間が逆転している場合は 400
+	req = httptest.NewRequest(http.MethodGet, "/api/reports/payees?from=2025-09-01&to=2025-08-01", nil)
+	rec = httptest.NewRecorder()
+	if err := ph.GetPayeeReport(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetPayeeReport: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetPayeeReport: expected status 400 for reversed range, got %d", rec.Code)
+	}
+}
+
+func TestImportCSV_Payees(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewImportHandler(repo, repository.NewCategoryRuleRepository())
+	ph := NewPayeeHandler(repo)
+	e := echo.New()
+
+	data := []byte("日付,金額,利用店名,備考\n" +
+		"2025/08/01,-480,ｾﾌﾞﾝ-ｲﾚﾌﾞﾝ ｼﾝｼﾞｭｸ3号店,昼食\n" +
+		"2025/08/02,-210,セブンイレブン (池袋店),\n" +
+		"2025/08/03,-1500,(株)マツモトキヨシ,日用品\n")
+	profile := `{"has_header":true,"date_column":"日付","amount_column":"金額","memo_columns":["備考"],` +
+		`"payee_column":"利用店名","expense_category_id":12}`
+	req := newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile, "dry_run": "false"})
+	rec := httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if result.Imported != 3 || result.Rows[0].Payee != "セブン-イレブン" || result.Rows[2].Payee != "マツモトキヨシ" {
+		t.Fatalf("ImportCSV: unexpected result: %+v", result)
+	}
+
+	// 店舗名の有無で分かれず、2つの支払先になる
+	payees := getPayees(t, ph, e)
+	if len(payees) != 2 || payees[0].Name != "セブン-イレブン" || payees[0].Count != 2 {
+		t.Errorf("GetPayees: unexpected payees: %+v", payees)
+	}
+}
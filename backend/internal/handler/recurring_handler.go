@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxRecurringNameLength = 50
+	maxRecurringInterval   = 365
+	defaultUpcomingDays    = 30
+	maxUpcomingDays        = 366
+)
+
+// RecurringHandler は定期収支ルール関連のHTTPリクエストを処理するハンドラです。
+// 予定日が来た収支の登録は scheduler パッケージが行います。
+type RecurringHandler struct {
+	repo            repository.RecurringRuleRepository
+	transactionRepo repository.TransactionRepository
+	now             func() time.Time
+}
+
+// NewRecurringHandler はRecurringHandlerを生成します。
+func NewRecurringHandler(repo repository.RecurringRuleRepository, transactionRepo repository.TransactionRepository) *RecurringHandler {
+	return &RecurringHandler{repo: repo, transactionRepo: transactionRepo, now: time.Now}
+}
+
+// GetRecurringRules は定期収支ルール一覧を取得するGET /api/recurringのハンドラです。
+func (h *RecurringHandler) GetRecurringRules(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	rules, err := repo.FindAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定期収支の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+// GetUpcoming は今後の発生予定を取得するGET /api/recurring/upcomingのハンドラです。
+// days（既定30、最大366）日後までの未登録の発生を登録日順に返します。
+// rule_id を指定するとそのルールの発生だけを返します。
+func (h *RecurringHandler) GetUpcoming(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	days := defaultUpcomingDays
+	if v := c.QueryParam("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxUpcomingDays {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "daysは1〜" + strconv.Itoa(maxUpcomingDays) + "の整数で指定してください",
+			})
+		}
+		days = n
+	}
+	ruleId := 0
+	if v := c.QueryParam("rule_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "rule_idは整数で指定してください",
+			})
+		}
+		ruleId = n
+	}
+
+	rules, err := repo.FindAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定期収支の取得に失敗しました: " + err.Error(),
+		})
+	}
+	categories, err := h.transactionRepo.ForHousehold(currentHouseholdId(c)).FindAllCategories()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		})
+	}
+	names := map[int]string{}
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	until := h.now().AddDate(0, 0, days)
+	occurrences := []domain.Occurrence{}
+	for _, rule := range rules {
+		if ruleId != 0 && rule.ID != ruleId {
+			continue
+		}
+		var after time.Time
+		if rule.PostedThrough != nil {
+			after = *rule.PostedThrough
+		}
+		for _, o := range rule.Occurrences(after, until, maxUpcomingDays) {
+			o.CategoryName = names[o.CategoryId]
+			occurrences = append(occurrences, o)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if !occurrences[i].Date.Equal(occurrences[j].Date) {
+			return occurrences[i].Date.Before(occurrences[j].Date)
+		}
+		return occurrences[i].RuleId < occurrences[j].RuleId
+	})
+	return c.JSON(http.StatusOK, occurrences)
+}
+
+// CreateRecurringRule は定期収支ルールを登録するPOST /api/recurringのハンドラです。
+func (h *RecurringHandler) CreateRecurringRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.RecurringRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	rule, err := h.buildRule(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := repo.Save(&rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定期収支の保存に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRecurringRule は定期収支ルールを更新するPUT /api/recurring/{id}のハンドラです。
+// 登録済みの収支はそのまま残り、まだ登録していない発生から新しい内容で登録します。
+func (h *RecurringHandler) UpdateRecurringRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.RecurringRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	rule, err := h.buildRule(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	rule.ID = id
+	if err := repo.Update(&rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定期収支の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRecurringRule は定期収支ルールを削除するDELETE /api/recurring/{id}のハンドラです。
+// 登録済みの収支は削除しません。
+func (h *RecurringHandler) DeleteRecurringRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if err := repo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "定期収支の削除に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "定期収支が削除されました",
+	})
+}
+
+// buildRule はリクエストを検証し、定期収支ルールを組み立てます。
+// 金額は収支と同じく支出を負の値、収入を正の値にそろえます。
+func (h *RecurringHandler) buildRule(transactionRepo repository.TransactionRepository, req domain.RecurringRuleRequest) (domain.RecurringRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return domain.RecurringRule{}, errors.New("nameを指定してください")
+	}
+	if len([]rune(name)) > maxRecurringNameLength {
+		return domain.RecurringRule{}, errors.New("nameは" + strconv.Itoa(maxRecurringNameLength) + "文字以内で指定してください")
+	}
+	if req.Type != "income" && req.Type != "expense" {
+		return domain.RecurringRule{}, errors.New("typeは income または expense を指定してください")
+	}
+	if req.Amount == 0 {
+		return domain.RecurringRule{}, errors.New("amountは0以外の整数で指定してください")
+	}
+
+	switch req.Frequency {
+	case domain.FrequencyDaily, domain.FrequencyWeekly, domain.FrequencyMonthly, domain.FrequencyYearly:
+	default:
+		return domain.RecurringRule{}, errors.New("frequencyは daily / weekly / monthly / yearly のいずれかを指定してください")
+	}
+	interval := req.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 1 || interval > maxRecurringInterval {
+		return domain.RecurringRule{}, errors.New("intervalは1〜" + strconv.Itoa(maxRecurringInterval) + "の整数で指定してください")
+	}
+	if req.DayOfMonth < 0 || req.DayOfMonth > 31 {
+		return domain.RecurringRule{}, errors.New("day_of_monthは1〜31の整数で指定してください")
+	}
+	adjustment := req.Adjustment
+	if adjustment == "" {
+		adjustment = domain.AdjustmentNone
+	}
+	switch adjustment {
+	case domain.AdjustmentNone, domain.AdjustmentPrevious, domain.AdjustmentNext:
+	default:
+		return domain.RecurringRule{}, errors.New("adjustmentは none / previous / next のいずれかを指定してください")
+	}
+
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return domain.RecurringRule{}, errors.New("start_dateは YYYY-MM-DD 形式で指定してください")
+	}
+	var end *time.Time
+	if req.EndDate != "" {
+		d, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return domain.RecurringRule{}, errors.New("end_dateは YYYY-MM-DD 形式で指定してください")
+		}
+		if d.Before(start) {
+			return domain.RecurringRule{}, errors.New("end_dateは start_date 以降の日付を指定してください")
+		}
+		end = &d
+	}
+
+	category, err := transactionRepo.FindCategoryById(req.CategoryId)
+	if err != nil {
+		return domain.RecurringRule{}, errors.New("カテゴリが見つかりません: " + strconv.Itoa(req.CategoryId))
+	}
+	if category.Archived {
+		return domain.RecurringRule{}, errors.New("アーカイブ済みのカテゴリには登録できません")
+	}
+	if err := checkLeafCategory(transactionRepo, category); err != nil {
+		return domain.RecurringRule{}, err
+	}
+	if !category.AllowsType(req.Type) {
+		return domain.RecurringRule{}, errors.New(categoryTypeMismatchMessage(category, req.Type))
+	}
+
+	account, err := resolveAccount(transactionRepo, req.AccountId)
+	if err != nil {
+		return domain.RecurringRule{}, err
+	}
+	if account.Archived {
+		return domain.RecurringRule{}, errors.New("アーカイブ済みの口座には登録できません")
+	}
+
+	amount := req.Amount
+	if req.Type == "expense" && amount > 0 {
+		amount = -amount // 支出は負の値で統一
+	} else if req.Type == "income" && amount < 0 {
+		amount = -amount // 収入は正の値で統一
+	}
+
+	return domain.RecurringRule{
+		Name:       name,
+		Type:       req.Type,
+		CategoryId: req.CategoryId,
+		AccountId:  account.ID,
+		Amount:     amount,
+		Memo:       req.Memo,
+		Frequency:  req.Frequency,
+		Interval:   interval,
+		DayOfMonth: req.DayOfMonth,
+		EndOfMonth: req.EndOfMonth,
+		Adjustment: adjustment,
+		StartDate:  start,
+		EndDate:    end,
+	}, nil
+}
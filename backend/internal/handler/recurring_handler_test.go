@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// recurring_handler_test.go は RecurringHandler の HTTP ハンドラテストです。
+
+func TestCreateRecurringRule_Validation(t *testing.T) {
+	h := NewRecurringHandler(repository.NewRecurringRuleRepository(), repository.NewTransactionRepository())
+	e := echo.New()
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","start_date":"2025-01-27"}`, http.StatusCreated},
+		{`{"name":"","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","start_date":"2025-01-27"}`, http.StatusBadRequest},
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"hourly","start_date":"2025-01-27"}`, http.StatusBadRequest},
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","interval":-1,"start_date":"2025-01-27"}`, http.StatusBadRequest},
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","day_of_month":32,"start_date":"2025-01-27"}`, http.StatusBadRequest},
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","adjustment":"later","start_date":"2025-01-27"}`, http.StatusBadRequest},
+		{`{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","start_date":"2025-01-27","end_date":"2024-12-31"}`, http.StatusBadRequest},
+		{`{"name":"給与","type":"expense","category_id":10,"amount":250000,"frequency":"monthly","start_date":"2025-01-25"}`, http.StatusBadRequest}, // 給与は収入用
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/api/recurring", bytes.NewBufferString(tc.body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		if err := h.CreateRecurringRule(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateRecurringRule: unexpected error: %v", err)
+		}
+		if rec.Code != tc.want {
+			t.Errorf("CreateRecurringRule(%s): expected status %d, got %d", tc.body, tc.want, rec.Code)
+		}
+	}
+}
+
+func TestCreateRecurringRule_NormalizesAmount(t *testing.T) {
+	h := NewRecurringHandler(repository.NewRecurringRuleRepository(), repository.NewTransactionRepository())
+	e := echo.New()
+
+	body := `{"name":"家賃","type":"expense","category_id":3,"amount":80000,"frequency":"monthly","start_date":"2025-01-27"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/recurring", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := h.CreateRecurringRule(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateRecurringRule: unexpected error: %v", err)
+	}
+	var rule domain.RecurringRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil {
+		t.Fatalf("CreateRecurringRule: failed to unmarshal: %v", err)
+	}
+	if rule.Amount != -80000 || rule.Interval != 1 || rule.Adjustment != domain.AdjustmentNone {
+		t.Errorf("CreateRecurringRule: expected amount -80000, interval 1, adjustment none, got %d, %d, %s",
+			rule.Amount, rule.Interval, rule.Adjustment)
+	}
+}
+
+func TestGetUpcoming_Schedule(t *testing.T) {
+	repo := repository.NewRecurringRuleRepository()
+	h := NewRecurringHandler(repo, repository.NewTransactionRepository())
+	h.now = func() time.Time { return time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC) }
+	e := echo.New()
+
+	// 月末払い: 31日がない月は末日
+	endOfMonth := domain.RecurringRule{
+		Name: "カード", Type: "expense", CategoryId: 9, Amount: -5000,
+		Frequency: domain.FrequencyMonthly, Interval: 1, Adjustment: domain.AdjustmentNone,
+		StartDate: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+	}
+	// 毎月11日・休日なら翌営業日: 1/11(土)→1/14（1/13は成人の日）、2/11(建国記念の日)→2/12
+	nextBusinessDay := domain.RecurringRule{
+		Name: "通信費", Type: "expense", CategoryId: 5, Amount: -3000,
+		Frequency: domain.FrequencyMonthly, Interval: 1, DayOfMonth: 11, Adjustment: domain.AdjustmentNext,
+		StartDate: time.Date(2025, 1, 11, 0, 0, 0, 0, time.UTC),
+	}
+	for _, rule := range []*domain.RecurringRule{&endOfMonth, &nextBusinessDay} {
+		if err := repo.Save(rule); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"?days=100&rule_id=1", []string{"2025-01-31", "2025-02-28", "2025-03-31"}},
+		{"?days=40&rule_id=2", []string{"2025-01-14", "2025-02-12"}},
+		{"?days=40", []string{"2025-01-14", "2025-01-31", "2025-02-12"}},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/recurring/upcoming"+tc.query, nil)
+		rec := httptest.NewRecorder()
+
+		if err := h.GetUpcoming(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("GetUpcoming: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GetUpcoming(%s): expected status 200, got %d", tc.query, rec.Code)
+		}
+		var occurrences []domain.Occurrence
+		if err := json.Unmarshal(rec.Body.Bytes(), &occurrences); err != nil {
+			t.Fatalf("GetUpcoming: failed to unmarshal: %v", err)
+		}
+		var got []string
+		for _, o := range occurrences {
+			got = append(got, o.Date.Format("2006-01-02"))
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("GetUpcoming(%s): expected %v, got %v", tc.query, tc.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("GetUpcoming(%s): expected %v, got %v", tc.query, tc.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestGetUpcoming_InvalidDays(t *testing.T) {
+	h := NewRecurringHandler(repository.NewRecurringRuleRepository(), repository.NewTransactionRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/recurring/upcoming?days=0", nil)
+	rec := httptest.NewRecorder()
+
+	if err := h.GetUpcoming(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetUpcoming: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetUpcoming: expected status 400, got %d", rec.Code)
+	}
+}
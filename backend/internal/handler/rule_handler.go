@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	maxRuleNameLength    = 50
+	maxRulePatternLength = 200
+)
+
+// RuleHandler は仕分けルール関連のHTTPリクエストを処理するハンドラです。
+// 取り込み時と収支の登録時のルールの適用は ImportHandler・TransactionHandler が行います。
+type RuleHandler struct {
+	repo            repository.CategoryRuleRepository
+	transactionRepo repository.TransactionRepository
+}
+
+// NewRuleHandler はRuleHandlerを生成します。
+func NewRuleHandler(repo repository.CategoryRuleRepository, transactionRepo repository.TransactionRepository) *RuleHandler {
+	return &RuleHandler{repo: repo, transactionRepo: transactionRepo}
+}
+
+// GetRules は仕分けルール一覧を優先度の順に取得するGET /api/rulesのハンドラです。
+func (h *RuleHandler) GetRules(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	rules, err := repo.FindAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule は仕分けルールを登録するPOST /api/rulesのハンドラです。
+func (h *RuleHandler) CreateRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.CategoryRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	rule, err := buildCategoryRule(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	if err := repo.Save(&rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの保存に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule は仕分けルールを更新するPUT /api/rules/{id}のハンドラです。
+// 適用済みの収支は変更しません（POST /api/rules/apply で適用し直せます）。
+func (h *RuleHandler) UpdateRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+
+	var req domain.CategoryRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	rule, err := buildCategoryRule(h.transactionRepo.ForHousehold(currentHouseholdId(c)), req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	rule.ID = id
+	if err := repo.Update(&rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule は仕分けルールを削除するDELETE /api/rules/{id}のハンドラです。
+// 適用済みの収支は変更しません。
+func (h *RuleHandler) DeleteRule(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if err := repo.Delete(id); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの削除に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "仕分けルールが削除されました",
+	})
+}
+
+// TestRule は保存前の仕分けルールを登録済みの収支で試すPOST /api/rules/testのハンドラです。
+// リクエストボディはルールの登録と同じで、ほかのルールや enabled にかかわらずそのルールだけを適用した結果を返します。
+// クエリパラメータ from, to（YYYY-MM-DD、両端を含む）で収支の日付の範囲を指定でき、省略時はすべての収支です。
+// 収支は更新せず、結果の changes にはルールに一致した収支だけを返します。
+func (h *RuleHandler) TestRule(c echo.Context) error {
+	transactionRepo := h.transactionRepo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.CategoryRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	rule, err := buildCategoryRule(transactionRepo, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	rule.Enabled = true
+
+	var filter domain.TransactionFilter
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		if v := c.QueryParam(p.name); v != "" {
+			d, err := time.Parse("2006-01-02", v)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": p.name + "は YYYY-MM-DD 形式で指定してください",
+				})
+			}
+			*p.dst = &d
+		}
+	}
+
+	result, err := applyRules(transactionRepo, []domain.CategoryRule{rule}, filter, true)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの試用に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// ApplyRules は仕分けルールを登録済みの収支に適用し直すPOST /api/rules/applyのハンドラです。
+// from〜to（両端を含む）の収入・支出に、rule_ids のルール（省略時は有効なすべてのルール）を優先度の順に適用します。
+// rule_ids で指定したルールは無効でも適用します。dry_run が true の場合は収支を更新せずに結果だけを返します。
+func (h *RuleHandler) ApplyRules(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+	transactionRepo := h.transactionRepo.ForHousehold(currentHouseholdId(c))
+
+	var req domain.RuleApplyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "リクエストボディの解析に失敗しました: " + err.Error(),
+		})
+	}
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "fromは YYYY-MM-DD 形式で指定してください",
+		})
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "toは YYYY-MM-DD 形式で指定してください",
+		})
+	}
+	if to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "toは from 以降の日付を指定してください",
+		})
+	}
+
+	var rules []domain.CategoryRule
+	if len(req.RuleIds) == 0 {
+		if rules, err = repo.FindAll(); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "仕分けルールの取得に失敗しました: " + err.Error(),
+			})
+		}
+	} else {
+		for _, id := range req.RuleIds {
+			rule, err := repo.FindById(id)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+			}
+			rule.Enabled = true
+			rules = append(rules, rule)
+		}
+	}
+
+	result, err := applyRules(transactionRepo, rules, domain.TransactionFilter{From: &from, To: &to}, req.DryRun)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "仕分けルールの適用に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// applyRules は filter に一致する収支に rules を適用し、dryRun でなければカテゴリ・メモ・タグが変わる収支を更新します。
+func applyRules(repo repository.TransactionRepository, rules []domain.CategoryRule, filter domain.TransactionFilter, dryRun bool) (domain.RuleApplyResult, error) {
+	set, err := newRuleSet(repo, rules)
+	if err != nil {
+		return domain.RuleApplyResult{}, err
+	}
+	filter.SortBy, filter.SortOrder = "date", "asc"
+	transactions, _, err := repo.FindByFilter(filter)
+	if err != nil {
+		return domain.RuleApplyResult{}, err
+	}
+
+	result := domain.RuleApplyResult{DryRun: dryRun, Checked: len(transactions), Changes: []domain.RuleChange{}}
+	for _, t := range transactions {
+		change := set.Change(t)
+		if len(change.RuleIds) == 0 {
+			continue
+		}
+		result.Matched++
+		result.Changes = append(result.Changes, change)
+		if !change.Changed {
+			continue
+		}
+		result.Updated++
+		if dryRun {
+			continue
+		}
+		updated := t
+		set.Apply(&updated)
+		if err := repo.Update(&updated); err != nil {
+			return domain.RuleApplyResult{}, err
+		}
+	}
+	return result, nil
+}
+
+// newRuleSet は rules と家計簿のカテゴリから domain.RuleSet を作ります。
+func newRuleSet(repo repository.TransactionRepository, rules []domain.CategoryRule) (*domain.RuleSet, error) {
+	if len(rules) == 0 {
+		return domain.NewRuleSet(nil, nil), nil
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewRuleSet(rules, categories), nil
+}
+
+// buildCategoryRule はリクエストを検証し、仕分けルールを組み立てます。
+// 条件と、カテゴリ・タグ・メモの書き換えのいずれかの動作をそれぞれ1つ以上指定する必要があります。
+func buildCategoryRule(transactionRepo repository.TransactionRepository, req domain.CategoryRuleRequest) (domain.CategoryRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return domain.CategoryRule{}, errors.New("nameを指定してください")
+	}
+	if utf8.RuneCountInString(name) > maxRuleNameLength {
+		return domain.CategoryRule{}, fmt.Errorf("nameは%d文字以内で指定してください", maxRuleNameLength)
+	}
+	if req.Type != "" && req.Type != domain.TransactionTypeIncome && req.Type != domain.TransactionTypeExpense {
+		return domain.CategoryRule{}, errors.New("typeは income または expense を指定してください（省略時はどちらも）")
+	}
+
+	memoContains := strings.TrimSpace(req.MemoContains)
+	if utf8.RuneCountInString(memoContains) > maxRulePatternLength {
+		return domain.CategoryRule{}, fmt.Errorf("memo_containsは%d文字以内で指定してください", maxRulePatternLength)
+	}
+	if utf8.RuneCountInString(req.MemoPattern) > maxRulePatternLength {
+		return domain.CategoryRule{}, fmt.Errorf("memo_patternは%d文字以内で指定してください", maxRulePatternLength)
+	}
+	if req.MemoPattern != "" {
+		if _, err := regexp.Compile(req.MemoPattern); err != nil {
+			return domain.CategoryRule{}, errors.New("memo_patternの正規表現を解釈できません: " + err.Error())
+		}
+	}
+	if (req.MinAmount != nil && *req.MinAmount < 0) || (req.MaxAmount != nil && *req.MaxAmount < 0) {
+		return domain.CategoryRule{}, errors.New("min_amount・max_amountは0以上の整数で指定してください")
+	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return domain.CategoryRule{}, errors.New("max_amountは min_amount 以上の金額を指定してください")
+	}
+
+	accountId := req.AccountId
+	if accountId != 0 {
+		if _, err := transactionRepo.FindAccountById(accountId); err != nil {
+			return domain.CategoryRule{}, errors.New("口座が見つかりません: " + strconv.Itoa(accountId))
+		}
+	}
+
+	var weekdays []int
+	seen := map[int]bool{}
+	for _, d := range req.Weekdays {
+		if d < 0 || d > 6 {
+			return domain.CategoryRule{}, errors.New("weekdaysは0（日曜日）〜6（土曜日）の整数で指定してください")
+		}
+		if !seen[d] {
+			seen[d] = true
+			weekdays = append(weekdays, d)
+		}
+	}
+	sort.Ints(weekdays)
+
+	if req.Type == "" && memoContains == "" && req.MemoPattern == "" && req.MinAmount == nil && req.MaxAmount == nil &&
+		accountId == 0 && len(weekdays) == 0 {
+		return domain.CategoryRule{}, errors.New("条件（type・memo_contains・memo_pattern・min_amount・max_amount・account_id・weekdays）を1つ以上指定してください")
+	}
+
+	if req.CategoryId != 0 {
+		category, err := transactionRepo.FindCategoryById(req.CategoryId)
+		if err != nil {
+			return domain.CategoryRule{}, errors.New("カテゴリが見つかりません: " + strconv.Itoa(req.CategoryId))
+		}
+		if category.Archived {
+			return domain.CategoryRule{}, errors.New("アーカイブ済みのカテゴリは指定できません")
+		}
+		if err := checkLeafCategory(transactionRepo, category); err != nil {
+			return domain.CategoryRule{}, err
+		}
+		if req.Type != "" && !category.AllowsType(req.Type) {
+			return domain.CategoryRule{}, errors.New(categoryTypeMismatchMessage(category, req.Type))
+		}
+	}
+	tags, err := buildTags(req.Tags)
+	if err != nil {
+		return domain.CategoryRule{}, err
+	}
+	if req.CategoryId == 0 && len(tags) == 0 && req.MemoRewrite == "" {
+		return domain.CategoryRule{}, errors.New("category_id・tags・memo_rewriteのいずれかを指定してください")
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return domain.CategoryRule{
+		Name:         name,
+		Priority:     req.Priority,
+		Enabled:      enabled,
+		Type:         req.Type,
+		MemoContains: memoContains,
+		MemoPattern:  req.MemoPattern,
+		MinAmount:    req.MinAmount,
+		MaxAmount:    req.MaxAmount,
+		AccountId:    accountId,
+		Weekdays:     weekdays,
+		CategoryId:   req.CategoryId,
+		Tags:         tags,
+		MemoRewrite:  req.MemoRewrite,
+	}, nil
+}
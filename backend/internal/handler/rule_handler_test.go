@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// rule_handler_test.go は RuleHandler（仕分けルール）と、取り込み・収支登録での仕分けルールの適用の HTTP ハンドラテストです。
+
+// postRuleJSON は JSON のリクエストボディで fn を呼び出し、レスポンスを返します。
+func postRuleJSON(t *testing.T, fn echo.HandlerFunc, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := fn(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatalf("%s: unexpected error: %v", target, err)
+	}
+	return rec
+}
+
+func TestCreateRule_Validation(t *testing.T) {
+	h := NewRuleHandler(repository.NewCategoryRuleRepository(), repository.NewTransactionRepository())
+
+	cases := []struct {
+		body string
+		want int
+	}{
+		{`{"name":"コンビニ","memo_contains":"ｺﾝﾋﾞﾆ","category_id":12}`, http.StatusCreated},
+		{`{"name":"","memo_contains":"ｺﾝﾋﾞﾆ","category_id":12}`, http.StatusBadRequest},
+		{`{"name":"すべて","category_id":12}`, http.StatusBadRequest},                                      // 条件がない
+		{`{"name":"コンビニ","memo_contains":"ｺﾝﾋﾞﾆ"}`, http.StatusBadRequest},                              // 動作がない
+		{`{"name":"正規表現","memo_pattern":"(ｶｰﾄﾞ","category_id":12}`, http.StatusBadRequest},              // 正規表現の誤り
+		{`{"name":"金額","min_amount":1000,"max_amount":500,"category_id":12}`, http.StatusBadRequest},    // 下限が上限より大きい
+		{`{"name":"曜日","weekdays":[7],"category_id":12}`, http.StatusBadRequest},                        // 曜日の範囲外
+		{`{"name":"口座","account_id":99,"category_id":12}`, http.StatusBadRequest},                       // 存在しない口座
+		{`{"name":"給与","type":"expense","memo_contains":"給与","category_id":10}`, http.StatusBadRequest}, // 給与は収入用
+		{`{"name":"種別","type":"transfer","category_id":12}`, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		rec := postRuleJSON(t, h.CreateRule, "/api/rules", tc.body)
+		if rec.Code != tc.want {
+			t.Errorf("CreateRule(%s): expected status %d, got %d: %s", tc.body, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCreateRule_Normalizes(t *testing.T) {
+	h := NewRuleHandler(repository.NewCategoryRuleRepository(), repository.NewTransactionRepository())
+
+	rec := postRuleJSON(t, h.CreateRule, "/api/rules",
+		`{"name":" 週末 ","weekdays":[6,0,6],"tags":[" 週末 ","週末"],"category_id":11}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateRule: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rule domain.CategoryRule
+	if err := json.Unmarshal(rec.Body.Bytes(), &rule); err != nil {
+		t.Fatalf("CreateRule: invalid JSON: %v", err)
+	}
+	if rule.ID == 0 || rule.Name != "週末" || !rule.Enabled || len(rule.Weekdays) != 2 || rule.Weekdays[0] != 0 ||
+		len(rule.Tags) != 1 || rule.Tags[0] != "週末" {
+		t.Errorf("CreateRule: unexpected rule %+v", rule)
+	}
+}
+
+// newRuleTestRepo は仕分けルールの試用・適用を確かめる収支を登録したリポジトリを返します。
+func newRuleTestRepo(t *testing.T) repository.TransactionRepository {
+	t.Helper()
+	repo := repository.NewTransactionRepository()
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 9, AccountId: 1, Amount: -540, Memo: "ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店"}, // 土曜日
+		{Date: time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 9, AccountId: 1, Amount: -3200, Memo: "セブン-イレブン 渋谷店"}, // 月曜日
+		{Date: time.Date(2025, 8, 25, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, AccountId: 1, Amount: 250000, Memo: "給与"},
+		{Date: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 9, AccountId: 1, Amount: -300, Memo: "ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店"},
+	} {
+		tx := tx
+		if err := repo.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestTestRule(t *testing.T) {
+	repo := newRuleTestRepo(t)
+	h := NewRuleHandler(repository.NewCategoryRuleRepository(), repo)
+
+	// 金額の上限を超える収支と期間外の収支には一致しない。メモは正規表現のグループで書き換える
+	rec := postRuleJSON(t, h.TestRule, "/api/rules/test?from=2025-08-01&to=2025-08-31",
+		`{"name":"コンビニ","memo_pattern":"^(\\S+)","max_amount":1000,"category_id":12,"tags":["コンビニ"],"memo_rewrite":"$1"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("TestRule: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result domain.RuleApplyResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("TestRule: invalid JSON: %v", err)
+	}
+	if !result.DryRun || result.Checked != 3 || result.Matched != 1 || result.Updated != 1 || len(result.Changes) != 1 {
+		t.Fatalf("TestRule: unexpected result %+v", result)
+	}
+	change := result.Changes[0]
+	if change.CategoryId != 12 || change.Memo != "ｾﾌﾞﾝｲﾚﾌﾞﾝ" || len(change.Tags) != 1 || change.Transaction.CategoryId != 9 {
+		t.Errorf("TestRule: unexpected change %+v", change)
+	}
+
+	// 試用では収支を更新しない
+	if found, _ := repo.FindById(change.Transaction.ID); found.CategoryId != 9 || found.Memo != "ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店" {
+		t.Errorf("TestRule: expected transaction to be unchanged, got %+v", found)
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	repo := newRuleTestRepo(t)
+	rules := repository.NewCategoryRuleRepository()
+	h := NewRuleHandler(rules, repo)
+
+	for _, rule := range []domain.CategoryRule{
+		{Name: "コンビニ", Priority: 20, Enabled: true, MemoContains: "ｾﾌﾞﾝ", CategoryId: 12, Tags: []string{"コンビニ"}},
+		{Name: "週末", Priority: 10, Enabled: true, Type: "expense", Weekdays: []int{0, 6}, CategoryId: 11, Tags: []string{"週末"}},
+		{Name: "無効", Priority: 0, Enabled: false, Type: "income", CategoryId: 9},
+	} {
+		rule := rule
+		if err := rules.Save(&rule); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	// dry run では更新せず、優先度の順に適用した結果を返す。半角・全角のメモはどちらも「ｾﾌﾞﾝ」に一致する
+	rec := postRuleJSON(t, h.ApplyRules, "/api/rules/apply", `{"from":"2025-08-01","to":"2025-08-31","dry_run":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ApplyRules: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result domain.RuleApplyResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ApplyRules: invalid JSON: %v", err)
+	}
+	if result.Checked != 3 || result.Matched != 2 || result.Updated != 2 {
+		t.Fatalf("ApplyRules(dry run): unexpected result %+v", result)
+	}
+	if saturday := result.Changes[0]; saturday.CategoryId != 11 || len(saturday.RuleIds) != 2 || saturday.RuleIds[0] != 2 ||
+		len(saturday.Tags) != 2 || saturday.Tags[0] != "週末" {
+		t.Errorf("ApplyRules(dry run): unexpected change %+v", saturday)
+	}
+	if all, _ := repo.FindAll(); all[0].CategoryId != 9 {
+		t.Errorf("ApplyRules(dry run): expected transactions to be unchanged")
+	}
+
+	// 更新する。期間外の収支は変えない
+	rec = postRuleJSON(t, h.ApplyRules, "/api/rules/apply", `{"from":"2025-08-01","to":"2025-08-31"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ApplyRules: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	all, _, _ := repo.FindByFilter(domain.TransactionFilter{SortBy: "date", SortOrder: "asc"})
+	if all[0].CategoryId != 11 || all[0].Category.Name != "外食" || len(all[0].Tags) != 2 ||
+		all[1].CategoryId != 12 || all[2].CategoryId != 10 || all[3].CategoryId != 9 {
+		t.Errorf("ApplyRules: unexpected transactions %+v", all)
+	}
+
+	// rule_ids で指定したルールは無効でも適用する
+	rec = postRuleJSON(t, h.ApplyRules, "/api/rules/apply", `{"from":"2025-08-01","to":"2025-08-31","rule_ids":[3]}`)
+	result = domain.RuleApplyResult{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ApplyRules: invalid JSON: %v", err)
+	}
+	if result.Matched != 1 || result.Updated != 1 || result.Changes[0].CategoryId != 9 {
+		t.Errorf("ApplyRules(rule_ids): unexpected result %+v", result)
+	}
+
+	for _, body := range []string{
+		`{"from":"2025-08-31","to":"2025-08-01"}`,
+		`{"from":"2025-08-01"}`,
+		`{"from":"2025-08-01","to":"2025-08-31","rule_ids":[99]}`,
+	} {
+		if rec := postRuleJSON(t, h.ApplyRules, "/api/rules/apply", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("ApplyRules(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestImportCSV_AppliesRules(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	rules := repository.NewCategoryRuleRepository()
+	h := NewImportHandler(repo, rules)
+	e := echo.New()
+
+	rule := domain.CategoryRule{Name: "コンビニ", Enabled: true, MemoContains: "コンビニ", CategoryId: 12, Tags: []string{"コンビニ"}}
+	if err := rules.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 支出のカテゴリを指定しない。ルールに一致しない支出の行はエラー
+	data := []byte("2025-08-01,-500,ｶｰﾄﾞ ｺﾝﾋﾞﾆ\n2025-08-02,-1000,書店\n2025-08-25,250000,給与\n")
+	profile := `{"date_column":"1","amount_column":"2","memo_columns":["3"],"income_category_id":10}`
+	req := newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile, "dry_run": "false"})
+	rec := httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	var result domain.ImportResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if result.Imported != 2 || result.Rows[0].CategoryId != 12 || len(result.Rows[0].RuleIds) != 1 ||
+		result.Rows[1].Error == "" || len(result.Rows[2].RuleIds) != 0 {
+		t.Fatalf("ImportCSV: unexpected result %+v", result)
+	}
+	found, _ := repo.FindById(result.Rows[0].TransactionId)
+	if found.CategoryId != 12 || len(found.Tags) != 1 || found.Tags[0] != "コンビニ" {
+		t.Errorf("ImportCSV: unexpected transaction %+v", found)
+	}
+
+	// apply_rules=false ではルールを適用しない
+	req = newImportRequest(t, "/api/import/csv", data, map[string]string{"profile": profile, "apply_rules": "false"})
+	rec = httptest.NewRecorder()
+	if err := h.ImportCSV(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("ImportCSV: unexpected error: %v", err)
+	}
+	result = domain.ImportResult{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("ImportCSV: invalid JSON: %v", err)
+	}
+	if result.Rows[0].Error == "" || len(result.Rows[0].RuleIds) != 0 {
+		t.Errorf("ImportCSV(apply_rules=false): unexpected rows %+v", result.Rows)
+	}
+}
+
+func TestCreateTransaction_ApplyRules(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	rules := repository.NewCategoryRuleRepository()
+	h := NewTransactionHandler(repo, rules)
+
+	rule := domain.CategoryRule{Name: "電車", Enabled: true, MemoPattern: `(?i)^suica\s*(.*)$`, CategoryId: 2, MemoRewrite: "Suica チャージ $1"}
+	if err := rules.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// apply_rules を付けない場合は category_id が必要
+	body := `{"date":"2025-08-01","type":"expense","amount":3000,"memo":"SUICA 新宿駅"}`
+	if rec := postRuleJSON(t, h.CreateTransaction, "/api/transactions", body); rec.Code != http.StatusBadRequest {
+		t.Errorf("CreateTransaction: expected status 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := postRuleJSON(t, h.CreateTransaction, "/api/transactions?apply_rules=true", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction(apply_rules): expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+	}
+	if created.CategoryId != 2 || created.Category.Name != "交通費" || created.Memo != "Suica チャージ 新宿駅" {
+		t.Errorf("CreateTransaction(apply_rules): unexpected transaction %+v", created)
+	}
+
+	// 指定した category_id はルールのカテゴリより優先する。メモの書き換えは適用する
+	body = `{"date":"2025-08-02","type":"expense","category_id":9,"amount":500,"memo":"SUICA 渋谷駅"}`
+	rec = postRuleJSON(t, h.CreateTransaction, "/api/transactions?apply_rules=true", body)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction(apply_rules): expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	created = domain.Transaction{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+	}
+	if created.CategoryId != 9 || created.Category.Name != "その他" || created.Memo != "Suica チャージ 渋谷駅" {
+		t.Errorf("CreateTransaction(apply_rules): expected the requested category, got %+v", created)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"github.com/labstack/echo/v4"
+)
+
+// 全文検索の検索語の長さ（文字数）の上限と、返す件数の既定値・上限です。
+const (
+	maxSearchQueryLength = 100
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+)
+
+// SearchTransactions は収支を全文検索するGET /api/transactions/searchのハンドラです。
+// メモ・カテゴリ名・内訳・タグを検索し、点数の高い順に一致箇所（ハイライト）とともに返します。
+//
+//	q      検索語（必須。空白で区切ると語ごとに照合し、平均の点数で並べます）
+//	limit  返す件数（既定20、最大100）
+func (h *TransactionHandler) SearchTransactions(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "qに検索語を指定してください",
+		})
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("qは%d文字以内で指定してください", maxSearchQueryLength),
+		})
+	}
+	limit := defaultSearchLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("limitは1〜%dの整数で指定してください", maxSearchLimit),
+			})
+		}
+		limit = n
+	}
+
+	candidates, err := repo.FindSearchCandidates(domain.SearchGrams(query))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の検索に失敗しました: " + err.Error(),
+		})
+	}
+
+	results := []domain.SearchResult{}
+	for _, t := range candidates {
+		if result, ok := domain.SearchTransaction(t, query); ok {
+			results = append(results, result)
+		}
+	}
+	domain.SortSearchResults(results)
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return c.JSON(http.StatusOK, domain.SearchResponse{
+		Query:   query,
+		Results: results,
+		Total:   total,
+	})
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// search_handler_test.go は収支の全文検索の HTTP ハンドラテストです。
+
+// searchTransactions は GET /api/transactions/search を呼び出し、ステータスと結果を返します。
+func searchTransactions(t *testing.T, h *TransactionHandler, e *echo.Echo, query string) (int, domain.SearchResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions/search?"+query, nil)
+	rec := httptest.NewRecorder()
+	if err := h.SearchTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("SearchTransactions: unexpected error: %v", err)
+	}
+	var resp domain.SearchResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("SearchTransactions: invalid JSON: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestSearchTransactions_HalfWidthKana(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-10","type":"expense","category_id":12,"amount":300,"memo":"ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店"}`,
+		`{"date":"2025-08-11","type":"expense","category_id":12,"amount":500,"memo":"セブンイレブン"}`,
+	)
+
+	// 半角カナの濁点は前の文字とまとめて正規化する。一致箇所は元の（半角の）文字列の位置で返す
+	for _, tc := range []struct {
+		query string
+		memo  string
+		rng   domain.SearchRange
+	}{
+		{"セブン", "ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店", domain.SearchRange{Start: 0, End: 4}},
+		{"ｾﾌﾞﾝ", "セブンイレブン", domain.SearchRange{Start: 0, End: 3}},
+	} {
+		_, resp := searchTransactions(t, h, e, "q="+url.QueryEscape(tc.query))
+		var found *domain.SearchResult
+		for i := range resp.Results {
+			if resp.Results[i].Transaction.Memo == tc.memo {
+				found = &resp.Results[i]
+			}
+		}
+		if found == nil {
+			t.Errorf("SearchTransactions(%s): expected %s in results, got %+v", tc.query, tc.memo, resp)
+			continue
+		}
+		if hl := found.Highlights; len(hl) != 1 || hl[0].Ranges[0] != tc.rng {
+			t.Errorf("SearchTransactions(%s): expected range %+v in %s, got %+v", tc.query, tc.rng, tc.memo, hl)
+		}
+	}
+}
+
+func TestSearchTransactions(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-10","type":"expense","category_id":12,"amount":1500,"memo":"駅前のラーメン屋"}`,
+		`{"date":"2025-08-11","type":"expense","category_id":12,"amount":900,"memo":"ラーメン"}`,
+		`{"date":"2025-08-12","type":"expense","category_id":12,"amount":700,"memo":"カップ麺","tags":["ラーメン部"]}`,
+		`{"date":"2025-08-13","type":"expense","category_id":2,"amount":1200,"memo":"ＡＭＡＺＯＮ 日用品"}`,
+		`{"date":"2025-08-14","type":"expense","category_id":12,"amount":500,"memo":"パン"}`,
+	)
+
+	// 語を含む収支が点数の高い順に並ぶ。フィールド全体に占める語の割合が大きいほど点数が高い
+	code, resp := searchTransactions(t, h, e, "q="+url.QueryEscape("ラーメン"))
+	if code != http.StatusOK {
+		t.Fatalf("SearchTransactions: expected status 200, got %d", code)
+	}
+	if resp.Total != 3 || len(resp.Results) != 3 {
+		t.Fatalf("SearchTransactions: expected 3 results, got %+v", resp)
+	}
+	if resp.Results[0].Transaction.Memo != "ラーメン" || resp.Results[2].Transaction.Memo != "駅前のラーメン屋" {
+		t.Errorf("SearchTransactions: unexpected order: %+v", resp.Results)
+	}
+	hl := resp.Results[2].Highlights
+	if len(hl) != 1 || hl[0].Field != domain.SearchFieldMemo || hl[0].Ranges[0] != (domain.SearchRange{Start: 3, End: 7}) {
+		t.Errorf("SearchTransactions: unexpected highlights: %+v", hl)
+	}
+	if tag := resp.Results[1].Highlights; len(tag) != 1 || tag[0].Field != domain.SearchFieldTag {
+		t.Errorf("SearchTransactions: expected tag highlight, got %+v", tag)
+	}
+
+	// 全角・大文字を区別せず、一致箇所は元の文字列の位置で返す
+	_, resp = searchTransactions(t, h, e, "q=amazon")
+	if resp.Total != 1 || resp.Results[0].Highlights[0].Ranges[0] != (domain.SearchRange{Start: 0, End: 6}) {
+		t.Errorf("SearchTransactions(amazon): unexpected results: %+v", resp)
+	}
+
+	// 語の一部だけが一致する収支も、一致度が下限以上なら結果に含める
+	_, resp = searchTransactions(t, h, e, "q="+url.QueryEscape("ラーメン店"))
+	if resp.Total == 0 || resp.Results[0].Transaction.Memo != "ラーメン" {
+		t.Errorf("SearchTransactions(ラーメン店): unexpected results: %+v", resp)
+	}
+
+	// limit で件数を絞っても total は一致件数
+	_, resp = searchTransactions(t, h, e, "q="+url.QueryEscape("ラーメン")+"&limit=1")
+	if resp.Total != 3 || len(resp.Results) != 1 {
+		t.Errorf("SearchTransactions(limit=1): unexpected results: %+v", resp)
+	}
+
+	for _, query := range []string{"q=", "q=%20", "q=a&limit=0"} {
+		if code, _ := searchTransactions(t, h, e, query); code != http.StatusBadRequest {
+			t.Errorf("SearchTransactions(%s): expected status 400, got %d", query, code)
+		}
+	}
+}
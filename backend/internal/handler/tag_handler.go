@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TagHandler は収支に付けたタグの候補とタグ別集計のHTTPリクエストを処理するハンドラです。
+type TagHandler struct {
+	repo repository.TransactionRepository
+}
+
+// NewTagHandler はTagHandlerを生成します。
+func NewTagHandler(repo repository.TransactionRepository) *TagHandler {
+	return &TagHandler{repo: repo}
+}
+
+// タグ候補の件数の既定値と上限です。
+const (
+	defaultTagLimit = 20
+	maxTagLimit     = 100
+)
+
+// GetTags はタグの入力補完に使う候補を返すGET /api/tagsのハンドラです。
+// 付いている収支の多い順に返します。q を指定すると前方一致するタグに絞り込み、limit で件数を指定できます。
+func (h *TagHandler) GetTags(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	limit := defaultTagLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTagLimit {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("limitは1〜%dの整数で指定してください", maxTagLimit),
+			})
+		}
+		limit = n
+	}
+	prefix := strings.TrimLeft(strings.TrimSpace(c.QueryParam("q")), "#＃")
+
+	tags, err := repo.FindTags(prefix, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "タグの取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, tags)
+}
+
+// GetTagReport は期間内のタグ別の収入・支出の合計を返すGET /api/reports/tagsのハンドラです。
+// from と to（YYYY-MM-DD、両端を含む）を省略した場合は当月です。振替は含めません。
+func (h *TagHandler) GetTagReport(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+	if v := c.QueryParam("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "fromは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		from = d
+	}
+	if v := c.QueryParam("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "toは YYYY-MM-DD 形式で指定してください",
+			})
+		}
+		to = d
+	}
+	if to.Before(from) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "toにはfrom以降の日付を指定してください",
+		})
+	}
+
+	totals, err := repo.FindTagTotals(from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "タグ別集計の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, domain.TagReport{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Tags: totals,
+	})
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// tag_handler_test.go は TagHandler と収支のタグの HTTP ハンドラテストです。
+
+// createTaggedTransactions はタグ付きの収支をまとめて登録します。
+func createTaggedTransactions(t *testing.T, h *TransactionHandler, e *echo.Echo, bodies ...string) {
+	t.Helper()
+	for _, body := range bodies {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("CreateTransaction(%s): expected status 201, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestCreateTransaction_Tags(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 前後の空白と先頭の # を取り除き、重複は1つにまとめる
+	body := `{"date":"2025-08-10","type":"expense","category_id":2,"amount":12000,"tags":[" #旅行2025 ","経費精算","旅行2025",""]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateTransaction: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created domain.Transaction
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+	}
+	if len(created.Tags) != 2 || created.Tags[0] != "旅行2025" || created.Tags[1] != "経費精算" {
+		t.Errorf("CreateTransaction: unexpected tags: %v", created.Tags)
+	}
+
+	// 長すぎるタグと振替へのタグは 400
+	for _, body := range []string{
+		`{"date":"2025-08-10","type":"expense","category_id":2,"amount":100,"tags":["1234567890123456789012345678901"]}`,
+		`{"date":"2025-08-10","type":"transfer","account_id":1,"to_account_id":2,"amount":100,"tags":["旅行2025"]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestGetTransactions_FilterByTags(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-10","type":"expense","category_id":2,"amount":12000,"tags":["旅行2025"]}`,
+		`{"date":"2025-08-11","type":"expense","category_id":11,"amount":3000,"tags":["旅行2025","子ども"]}`,
+		`{"date":"2025-09-01","type":"expense","category_id":8,"amount":5000,"tags":["子ども"]}`,
+	)
+
+	for _, tc := range []struct {
+		query string
+		total int
+	}{
+		{"tag=旅行2025,子ども", 3},
+		{"tag=旅行2025&tag=子ども&tag_match=all", 1},
+		{"tag=%23子ども", 2},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions?"+tc.query, nil)
+		rec := httptest.NewRecorder()
+		if err := h.GetTransactions(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("GetTransactions: unexpected error: %v", err)
+		}
+		var page domain.TransactionPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("GetTransactions: invalid JSON: %v", err)
+		}
+		if page.Total != tc.total {
+			t.Errorf("GetTransactions(%s): expected total=%d, got %d", tc.query, tc.total, page.Total)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions?tag=旅行2025&tag_match=some", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTransactions: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetTransactions: expected status 400 for invalid tag_match, got %d", rec.Code)
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	th := NewTagHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-10","type":"expense","category_id":2,"amount":12000,"tags":["旅行2025","経費精算"]}`,
+		`{"date":"2025-08-11","type":"expense","category_id":11,"amount":3000,"tags":["旅行2025"]}`,
+		`{"date":"2025-08-12","type":"expense","category_id":11,"amount":1000,"tags":["旅行2024"]}`,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tags?q=%E6%97%85", nil) // q=旅
+	rec := httptest.NewRecorder()
+	if err := th.GetTags(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTags: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetTags: expected status 200, got %d", rec.Code)
+	}
+	var tags []domain.Tag
+	if err := json.Unmarshal(rec.Body.Bytes(), &tags); err != nil {
+		t.Fatalf("GetTags: invalid JSON: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != (domain.Tag{Name: "旅行2025", Count: 2}) || tags[1].Name != "旅行2024" {
+		t.Errorf("GetTags: unexpected tags: %+v", tags)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/tags?limit=0", nil)
+	rec = httptest.NewRecorder()
+	if err := th.GetTags(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTags: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetTags: expected status 400 for invalid limit, got %d", rec.Code)
+	}
+}
+
+func TestGetTagReport(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	th := NewTagHandler(repo)
+	e := echo.New()
+
+	createTaggedTransactions(t, h, e,
+		`{"date":"2025-08-10","type":"expense","category_id":2,"amount":12000,"tags":["旅行2025","経費精算"]}`,
+		`{"date":"2025-08-20","type":"income","category_id":9,"amount":12000,"tags":["経費精算"]}`,
+		`{"date":"2025-09-01","type":"expense","category_id":8,"amount":5000,"tags":["旅行2025"]}`,
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/tags?from=2025-08-01&to=2025-08-31", nil)
+	rec := httptest.NewRecorder()
+	if err := th.GetTagReport(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTagReport: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetTagReport: expected status 200, got %d", rec.Code)
+	}
+	var report domain.TagReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GetTagReport: invalid JSON: %v", err)
+	}
+	if report.From != "2025-08-01" || report.To != "2025-08-31" || len(report.Tags) != 2 {
+		t.Fatalf("GetTagReport: unexpected report: %+v", report)
+	}
+	if report.Tags[0] != (domain.TagTotal{Tag: "旅行2025", Expense: 12000, Count: 1}) ||
+		report.Tags[1] != (domain.TagTotal{Tag: "経費精算", Income: 12000, Expense: 12000, Count: 2}) {
+		t.Errorf("GetTagReport: unexpected totals: %+v", report.Tags)
+	}
+
+	// 期間が逆転している場合は 400
+	req = httptest.NewRequest(http.MethodGet, "/api/reports/tags?from=2025-09-01&to=2025-08-01", nil)
+	rec = httptest.NewRecorder()
+	if err := th.GetTagReport(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTagReport: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetTagReport: expected status 400 for reversed range, got %d", rec.Code)
+	}
+}
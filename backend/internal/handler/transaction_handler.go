@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"kakeibo-app/backend/internal/domain"
 	"kakeibo-app/backend/internal/repository"
@@ -12,39 +16,255 @@ import (
 )
 
 // TransactionHandler は収支関連のHTTPリクエストを処理するハンドラです。
+// 仕分けルールは ?apply_rules=true を付けて登録する収支にだけ適用します。
 type TransactionHandler struct {
-	repo repository.TransactionRepository
+	repo  repository.TransactionRepository
+	rules repository.CategoryRuleRepository
 }
 
 // NewTransactionHandler はTransactionHandlerを生成します。
-func NewTransactionHandler(repo repository.TransactionRepository) *TransactionHandler {
-	return &TransactionHandler{repo: repo}
+func NewTransactionHandler(repo repository.TransactionRepository, rules repository.CategoryRuleRepository) *TransactionHandler {
+	return &TransactionHandler{repo: repo, rules: rules}
 }
 
-// GetCategories は全カテゴリを取得するGET /api/categoriesのハンドラです。
-func (h *TransactionHandler) GetCategories(c echo.Context) error {
-	categories, err := h.repo.FindAllCategories()
+// 一覧取得時のページサイズの既定値と上限です。
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// GetTransactions は収支データを取得するGET /api/transactionsのハンドラです。
+// クエリパラメータで絞り込み・並び替え・ページングを指定できます。
+//
+//	from, to          日付範囲（YYYY-MM-DD、両端を含む）
+//	type              income / expense / transfer
+//	account_id        口座ID
+//	category_id       カテゴリID（複数指定可: category_id=1&category_id=2 または 1,2。子カテゴリを含む）
+//	min_amount, max_amount  金額（絶対値）の範囲
+//	memo              メモの部分一致
+//	payee_id          支払先ID
+//	tag               タグ（複数指定可: tag=旅行2025&tag=子ども または 旅行2025,子ども）
+//	tag_match         any（いずれかのタグ、既定） / all（すべてのタグ）
+//	sort, order       date / amount / created_at と asc / desc
+//	page, limit       ページ番号（1始まり）と1ページの件数
+func (h *TransactionHandler) GetTransactions(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	filter, err := parseTransactionFilter(c)
 	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if filter.CategoryIds, err = expandCategoryIds(repo, filter.CategoryIds); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "カテゴリの取得に失敗しました: " + err.Error(),
 		})
 	}
-	return c.JSON(http.StatusOK, categories)
-}
 
-// GetTransactions は全収支データを取得するGET /api/transactionsのハンドラです。
-func (h *TransactionHandler) GetTransactions(c echo.Context) error {
-	transactions, err := h.repo.FindAll()
+	transactions, total, err := repo.FindByFilter(filter)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "収支データの取得に失敗しました: " + err.Error(),
 		})
 	}
-	return c.JSON(http.StatusOK, transactions)
+	if transactions == nil {
+		transactions = []domain.Transaction{}
+	}
+	return c.JSON(http.StatusOK, domain.TransactionPage{
+		Transactions: transactions,
+		Total:        total,
+		Page:         filter.Page,
+		Limit:        filter.Limit,
+	})
+}
+
+// expandCategoryIds は親カテゴリでの絞り込みに子カテゴリを含めるため、指定されたIDに子孫のIDを加えます。
+func expandCategoryIds(repo repository.TransactionRepository, ids []int) ([]int, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[int]bool{}
+	var result []int
+	for _, id := range ids {
+		for _, d := range domain.CategoryWithDescendants(categories, id) {
+			if !seen[d] {
+				seen[d] = true
+				result = append(result, d)
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseTransactionFilter はクエリパラメータから一覧の絞り込み条件を組み立てます。
+func parseTransactionFilter(c echo.Context) (domain.TransactionFilter, error) {
+	f := domain.TransactionFilter{Page: 1, Limit: defaultPageLimit}
+
+	if v := c.QueryParam("from"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, errors.New("fromは YYYY-MM-DD 形式で指定してください")
+		}
+		f.From = &d
+	}
+	if v := c.QueryParam("to"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return f, errors.New("toは YYYY-MM-DD 形式で指定してください")
+		}
+		f.To = &d
+	}
+
+	f.Type = c.QueryParam("type")
+	if f.Type != "" && f.Type != "income" && f.Type != "expense" && f.Type != "transfer" {
+		return f, errors.New("typeは income / expense / transfer のいずれかを指定してください")
+	}
+
+	if v := c.QueryParam("account_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, errors.New("account_idは整数で指定してください")
+		}
+		f.AccountId = n
+	}
+
+	for _, v := range c.QueryParams()["category_id"] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				return f, errors.New("category_idは整数で指定してください")
+			}
+			f.CategoryIds = append(f.CategoryIds, id)
+		}
+	}
+
+	if v := c.QueryParam("min_amount"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, errors.New("min_amountは0以上の整数で指定してください")
+		}
+		f.MinAmount = &n
+	}
+	if v := c.QueryParam("max_amount"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, errors.New("max_amountは0以上の整数で指定してください")
+		}
+		f.MaxAmount = &n
+	}
+
+	f.Memo = c.QueryParam("memo")
+
+	if v := c.QueryParam("payee_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, errors.New("payee_idは整数で指定してください")
+		}
+		f.PayeeId = n
+	}
+
+	var tags []string
+	for _, v := range c.QueryParams()["tag"] {
+		tags = append(tags, strings.Split(v, ",")...)
+	}
+	f.Tags = domain.NormalizeTags(tags)
+	f.TagMatch = c.QueryParam("tag_match")
+	if f.TagMatch != "" && f.TagMatch != domain.TagMatchAny && f.TagMatch != domain.TagMatchAll {
+		return f, errors.New("tag_matchは any または all を指定してください")
+	}
+
+	f.SortBy = c.QueryParam("sort")
+	switch f.SortBy {
+	case "", "date", "amount", "created_at":
+	default:
+		return f, errors.New("sortは date / amount / created_at のいずれかを指定してください")
+	}
+	f.SortOrder = c.QueryParam("order")
+	if f.SortOrder != "" && f.SortOrder != "asc" && f.SortOrder != "desc" {
+		return f, errors.New("orderは asc または desc を指定してください")
+	}
+
+	if v := c.QueryParam("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return f, errors.New("pageは1以上の整数で指定してください")
+		}
+		f.Page = n
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return f, fmt.Errorf("limitは1〜%dの整数で指定してください", maxPageLimit)
+		}
+		f.Limit = n
+	}
+
+	return f, nil
+}
+
+// GetMonthlySummary は月次の収支集計を取得するGET /api/summary/monthlyのハンドラです。
+// year と month を省略した場合は当月を集計します。account_id を指定するとその口座の収支だけを集計します。
+func (h *TransactionHandler) GetMonthlySummary(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	now := time.Now()
+	year, month := now.Year(), int(now.Month())
+
+	if v := c.QueryParam("year"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "yearは整数で指定してください",
+			})
+		}
+		year = n
+	}
+	if v := c.QueryParam("month"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 12 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "monthは1〜12の整数で指定してください",
+			})
+		}
+		month = n
+	}
+	accountId := 0
+	if v := c.QueryParam("account_id"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "account_idは整数で指定してください",
+			})
+		}
+		accountId = n
+	}
+
+	summary, err := repo.FindMonthlySummary(year, month, accountId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "月次集計の取得に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, summary)
 }
 
 // CreateTransaction は新規収支を登録するPOST /api/transactionsのハンドラです。
+// type が transfer の場合は口座間の振替として出金・入金の2行を登録し、振替をまとめた形で返します。
+// 日付・金額・メモ・口座が同じ収支（domain.Fingerprint）が登録済みの場合は、登録せずに 409 と重複する収支を返します。
+// ?allow_duplicate=true を付けると重複していても登録します。
+// ?apply_rules=true を付けると仕分けルールを適用し、一致したルールのカテゴリ・タグ・メモの書き換えで登録します。
+// ルールのカテゴリは category_id・splits を省略した場合にだけ使い、指定したカテゴリを置き換えません。
 func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
 	var req domain.CreateTransactionRequest
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -52,9 +272,25 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 		})
 	}
 
+	if req.Type == domain.TransactionTypeTransfer {
+		out, in, err := buildTransfer(repo, req, true)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+		out.CreatedBy, in.CreatedBy = currentUserId(c), currentUserId(c)
+		if err := repo.SaveTransfer(&out, &in); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "振替の保存に失敗しました: " + err.Error(),
+			})
+		}
+		return c.JSON(http.StatusCreated, domain.NewTransfer(out, in))
+	}
+
 	if req.Type != "income" && req.Type != "expense" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "typeは income または expense を指定してください",
+			"error": "typeは income / expense / transfer のいずれかを指定してください",
 		})
 	}
 
@@ -72,24 +308,118 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 		amount = -amount // 収入は正の値で統一
 	}
 
-	categoryId := req.CategoryId
-	category, err := h.repo.FindCategoryById(categoryId)
+	splits, err := buildSplits(repo, req.Type, amount, req.Splits, nil)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	items, err := buildItems(req.Type, amount, req.Items)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	tags, err := buildTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	payee, err := buildPayee(req.Payee)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	account, err := resolveAccount(repo, req.AccountId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if account.Archived {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "アーカイブ済みの口座には登録できません",
 		})
 	}
 
 	transaction := domain.Transaction{
 		Date:       date,
 		Type:       req.Type,
-		CategoryId: categoryId,
+		CategoryId: req.CategoryId,
+		AccountId:  account.ID,
 		Amount:     amount,
 		Memo:       req.Memo,
-		Category:   category,
+		PayeeName:  payee,
+		CreatedBy:  currentUserId(c),
+		Splits:     splits,
+		Items:      items,
+		Tags:       tags,
+	}
+	if len(splits) > 0 {
+		transaction.CategoryId = splits[0].CategoryId
+	}
+
+	if c.QueryParam("apply_rules") == "true" {
+		set, err := h.ruleSet(c, repo)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "仕分けルールの取得に失敗しました: " + err.Error(),
+			})
+		}
+		// 指定されたカテゴリ（内訳を含む）はルールのカテゴリより優先する。タグ・メモの書き換えは適用する
+		categoryId := transaction.CategoryId
+		set.Apply(&transaction)
+		if categoryId != 0 {
+			transaction.CategoryId = categoryId
+		}
+	}
+	if transaction.CategoryId == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "category_idを指定してください",
+		})
 	}
 
-	if err := h.repo.Save(&transaction); err != nil {
+	category, err := repo.FindCategoryById(transaction.CategoryId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("カテゴリが見つかりません: %d", transaction.CategoryId),
+		})
+	}
+	if category.Archived {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "アーカイブ済みのカテゴリには登録できません",
+		})
+	}
+	if err := checkLeafCategory(repo, category); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	if !category.AllowsType(req.Type) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": categoryTypeMismatchMessage(category, req.Type),
+		})
+	}
+	transaction.Category = category
+
+	if c.QueryParam("allow_duplicate") != "true" {
+		duplicates, err := findDuplicates(repo, transaction)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "重複の確認に失敗しました: " + err.Error(),
+			})
+		}
+		if len(duplicates) > 0 {
+			return c.JSON(http.StatusConflict, domain.DuplicateWarning{
+				Error:      "同じ日付・金額・メモ・口座の収支が登録済みです",
+				Duplicates: duplicates,
+			})
+		}
+	}
+
+	if err := repo.Save(&transaction); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "収支の保存に失敗しました: " + err.Error(),
 		})
@@ -98,8 +428,36 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 	return c.JSON(http.StatusCreated, transaction)
 }
 
+// ruleSet はリクエストの家計簿の仕分けルールから domain.RuleSet を作ります。
+func (h *TransactionHandler) ruleSet(c echo.Context, repo repository.TransactionRepository) (*domain.RuleSet, error) {
+	rules, err := h.rules.ForHousehold(currentHouseholdId(c)).FindAll()
+	if err != nil {
+		return nil, err
+	}
+	return newRuleSet(repo, rules)
+}
+
+// findDuplicates は t と指紋が同じ登録済みの収支（振替を除く）を返します。
+func findDuplicates(repo repository.TransactionRepository, t domain.Transaction) ([]domain.Transaction, error) {
+	existing, _, err := repo.FindByFilter(domain.TransactionFilter{From: &t.Date, To: &t.Date, AccountId: t.AccountId})
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := t.Fingerprint()
+	var duplicates []domain.Transaction
+	for _, e := range existing {
+		if e.Type != domain.TransactionTypeTransfer && e.Fingerprint() == fingerprint {
+			duplicates = append(duplicates, e)
+		}
+	}
+	return duplicates, nil
+}
+
 // UpdateTransaction は収支を更新するPUT /api/transactions/{id}のハンドラです。
+// 振替の行を指定した場合は、組になっている2行をまとめて更新します。
 func (h *TransactionHandler) UpdateTransaction(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -114,9 +472,24 @@ func (h *TransactionHandler) UpdateTransaction(c echo.Context) error {
 		})
 	}
 
+	existing, err := repo.FindById(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の更新に失敗しました: " + err.Error(),
+		})
+	}
+	if (existing.TransferId != 0) != (req.Type == domain.TransactionTypeTransfer) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "振替と収入・支出の間で種別は変更できません。削除して登録し直してください",
+		})
+	}
+	if existing.TransferId != 0 {
+		return h.updateTransfer(c, existing.TransferId, req)
+	}
+
 	if req.Type != "income" && req.Type != "expense" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "typeは income または expense を指定してください",
+			"error": "typeは income / expense / transfer のいずれかを指定してください",
 		})
 	}
 
@@ -134,11 +507,63 @@ func (h *TransactionHandler) UpdateTransaction(c echo.Context) error {
 		amount = -amount // 収入は正の値で統一
 	}
 
+	splits, err := buildSplits(repo, req.Type, amount, req.Splits, &existing)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	items, err := buildItems(req.Type, amount, req.Items)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	tags, err := buildTags(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	payee, err := buildPayee(req.Payee)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
 	categoryId := req.CategoryId
-	category, err := h.repo.FindCategoryById(categoryId)
+	if len(splits) > 0 {
+		categoryId = splits[0].CategoryId
+	}
+	if categoryId == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "category_idを指定してください",
+		})
+	}
+	category, err := repo.FindCategoryById(categoryId)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "カテゴリの取得に失敗しました: " + err.Error(),
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("カテゴリが見つかりません: %d", categoryId),
+		})
+	}
+	// 収支が既に使っているカテゴリは、その後に子カテゴリができてもそのまま使える
+	if !existing.HasCategory(categoryId) {
+		if err := checkLeafCategory(repo, category); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+		}
+	}
+	if !category.AllowsType(req.Type) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": categoryTypeMismatchMessage(category, req.Type),
+		})
+	}
+
+	account, err := resolveAccount(repo, req.AccountId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
 		})
 	}
 
@@ -147,12 +572,17 @@ func (h *TransactionHandler) UpdateTransaction(c echo.Context) error {
 		Date:       date,
 		Type:       req.Type,
 		CategoryId: categoryId,
+		AccountId:  account.ID,
 		Amount:     amount,
 		Memo:       req.Memo,
+		PayeeName:  payee,
 		Category:   category,
+		Splits:     splits,
+		Items:      items,
+		Tags:       tags,
 	}
 
-	if err := h.repo.Update(&transaction); err != nil {
+	if err := repo.Update(&transaction); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "収支の更新に失敗しました: " + err.Error(),
 		})
@@ -161,20 +591,264 @@ func (h *TransactionHandler) UpdateTransaction(c echo.Context) error {
 	return c.JSON(http.StatusOK, transaction)
 }
 
-// DeleteTransaction は収支を削除するDELETE /api/transactions/{id}のハンドラです。
+// maxSplits は1件の収支に持たせられる内訳の最大数です。
+const maxSplits = 50
+
+// buildSplits は内訳のリクエストを検証し、親の収支と同じ符号の内訳を組み立てます。
+// 内訳がない場合は nil を返します。内訳は2行以上で、金額の合計が amount と一致する必要があります。
+// アーカイブ済みのカテゴリと子カテゴリのあるカテゴリは、更新前の収支 existing が既に使っている場合を除いて拒否します。
+// 新規登録では existing に nil を渡します。
+func buildSplits(repo repository.TransactionRepository, transactionType string, amount int, reqs []domain.SplitRequest, existing *domain.Transaction) ([]domain.Split, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if len(reqs) < 2 || len(reqs) > maxSplits {
+		return nil, fmt.Errorf("splitsは2〜%d行で指定してください", maxSplits)
+	}
+
+	sign := 1
+	if amount < 0 {
+		sign = -1
+	}
+	splits := make([]domain.Split, 0, len(reqs))
+	sum := 0
+	for i, req := range reqs {
+		if req.Amount <= 0 {
+			return nil, fmt.Errorf("splits[%d]: amountは正の整数で指定してください", i)
+		}
+		category, err := repo.FindCategoryById(req.CategoryId)
+		if err != nil {
+			return nil, fmt.Errorf("splits[%d]: カテゴリが見つかりません: %d", i, req.CategoryId)
+		}
+		if existing == nil || !existing.HasCategory(category.ID) {
+			if category.Archived {
+				return nil, fmt.Errorf("splits[%d]: アーカイブ済みのカテゴリには登録できません", i)
+			}
+			if err := checkLeafCategory(repo, category); err != nil {
+				return nil, fmt.Errorf("splits[%d]: %w", i, err)
+			}
+		}
+		if !category.AllowsType(transactionType) {
+			return nil, fmt.Errorf("splits[%d]: %s", i, categoryTypeMismatchMessage(category, transactionType))
+		}
+		sum += req.Amount
+		splits = append(splits, domain.Split{
+			CategoryId: category.ID,
+			Amount:     sign * req.Amount,
+			Memo:       req.Memo,
+			Category:   category,
+		})
+	}
+	if sum != sign*amount {
+		return nil, fmt.Errorf("splitsの金額の合計（%d）がamount（%d）と一致しません", sum, sign*amount)
+	}
+	return splits, nil
+}
+
+// buildItems は品目のリクエストを検証し、品名を空白をまとめた形にそろえて品目を組み立てます。
+// 品目がない場合は nil を返します。品目は支出だけに指定でき、数量 × 単価の合計が amount の絶対値と一致する必要があります。
+func buildItems(transactionType string, amount int, reqs []domain.LineItemRequest) ([]domain.LineItem, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	if transactionType != domain.TransactionTypeExpense {
+		return nil, errors.New("itemsは支出にだけ指定できます")
+	}
+	if len(reqs) > domain.MaxLineItems {
+		return nil, fmt.Errorf("itemsは%d行以内で指定してください", domain.MaxLineItems)
+	}
+
+	items := make([]domain.LineItem, 0, len(reqs))
+	sum := 0
+	for i, req := range reqs {
+		name := strings.Join(strings.Fields(req.Name), " ")
+		if name == "" {
+			return nil, fmt.Errorf("items[%d]: nameを指定してください", i)
+		}
+		if utf8.RuneCountInString(name) > domain.MaxLineItemNameLength {
+			return nil, fmt.Errorf("items[%d]: nameは%d文字以内で指定してください", i, domain.MaxLineItemNameLength)
+		}
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		if quantity < 0 {
+			return nil, fmt.Errorf("items[%d]: quantityは正の整数で指定してください", i)
+		}
+		if req.UnitPrice <= 0 {
+			return nil, fmt.Errorf("items[%d]: unit_priceは正の整数で指定してください", i)
+		}
+		item := domain.LineItem{Name: name, Quantity: quantity, UnitPrice: req.UnitPrice}
+		sum += item.Total()
+		items = append(items, item)
+	}
+	if sum != -amount {
+		return nil, fmt.Errorf("itemsの金額の合計（%d）がamount（%d）と一致しません", sum, -amount)
+	}
+	return items, nil
+}
+
+// buildTags はタグを正規化し、数と長さを検証します。タグがない場合は nil を返します。
+func buildTags(tags []string) ([]string, error) {
+	tags = domain.NormalizeTags(tags)
+	if len(tags) > domain.MaxTagsPerTransaction {
+		return nil, fmt.Errorf("tagsは%d個以内で指定してください", domain.MaxTagsPerTransaction)
+	}
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > domain.MaxTagLength {
+			return nil, fmt.Errorf("タグは%d文字以内で指定してください: %s", domain.MaxTagLength, tag)
+		}
+	}
+	return tags, nil
+}
+
+// buildPayee は支払先の名前の長さを検証し、domain.PayeeName で表示名にそろえます。指定がない場合は空文字を返します。
+func buildPayee(name string) (string, error) {
+	if utf8.RuneCountInString(strings.TrimSpace(name)) > domain.MaxPayeeNameLength {
+		return "", fmt.Errorf("payeeは%d文字以内で指定してください", domain.MaxPayeeNameLength)
+	}
+	return domain.PayeeName(name), nil
+}
+
+// checkLeafCategory は category に子カテゴリがないことを確認します。収支は子カテゴリのないカテゴリにだけ登録できます。
+func checkLeafCategory(repo repository.TransactionRepository, category domain.Category) error {
+	if category.ParentId != 0 {
+		return nil // 階層は2段までなので、子カテゴリは子を持たない
+	}
+	categories, err := repo.FindAllCategories()
+	if err != nil {
+		return fmt.Errorf("カテゴリの取得に失敗しました: %w", err)
+	}
+	for _, c := range categories {
+		if c.ParentId == category.ID {
+			return fmt.Errorf("カテゴリ「%s」には子カテゴリがあるため登録できません。子カテゴリを指定してください", category.Name)
+		}
+	}
+	return nil
+}
+
+// categoryTypeMismatchMessage はカテゴリの種別と収支の種別が合わない場合のエラーメッセージを返します。
+func categoryTypeMismatchMessage(category domain.Category, transactionType string) string {
+	label := map[string]string{"income": "収入", "expense": "支出"}
+	return fmt.Sprintf("カテゴリ「%s」は%s用のため、%sには使用できません",
+		category.Name, label[category.Kind], label[transactionType])
+}
+
+// updateTransfer は振替の出金・入金の2行をまとめて更新し、振替をまとめた形で返します。
+func (h *TransactionHandler) updateTransfer(c echo.Context, transferId int, req domain.UpdateTransactionRequest) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	out, in, err := buildTransfer(repo, domain.CreateTransactionRequest(req), false)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	pair, err := repo.FindTransfer(transferId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "振替の更新に失敗しました: " + err.Error(),
+		})
+	}
+	out.ID, out.TransferId = pair[0].ID, transferId
+	in.ID, in.TransferId = pair[1].ID, transferId
+
+	if err := repo.UpdateTransfer(&out, &in); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "振替の更新に失敗しました: " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, domain.NewTransfer(out, in))
+}
+
+// buildTransfer は振替のリクエストを検証し、出金側（振替元・負の金額）と入金側（振替先・正の金額）の行を組み立てます。
+// 振替元を省略した場合は既定の口座です。checkArchived が true の場合はアーカイブ済みの口座を拒否します。
+func buildTransfer(repo repository.TransactionRepository, req domain.CreateTransactionRequest, checkArchived bool) (domain.Transaction, domain.Transaction, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("dateは YYYY-MM-DD 形式で指定してください")
+	}
+	amount := req.Amount
+	if amount < 0 {
+		amount = -amount
+	}
+	if amount == 0 {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("amountは0以外の整数で指定してください")
+	}
+	if req.CategoryId != 0 || len(req.Splits) > 0 || len(req.Items) > 0 || len(domain.NormalizeTags(req.Tags)) > 0 || strings.TrimSpace(req.Payee) != "" {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("振替にはカテゴリ・内訳・品目・タグ・支払先を指定できません")
+	}
+	if req.ToAccountId == 0 {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("振替では to_account_id を指定してください")
+	}
+
+	from, err := resolveAccount(repo, req.AccountId)
+	if err != nil {
+		return domain.Transaction{}, domain.Transaction{}, err
+	}
+	to, err := resolveAccount(repo, req.ToAccountId)
+	if err != nil {
+		return domain.Transaction{}, domain.Transaction{}, err
+	}
+	if from.ID == to.ID {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("振替元と振替先には別の口座を指定してください")
+	}
+	if checkArchived && (from.Archived || to.Archived) {
+		return domain.Transaction{}, domain.Transaction{}, errors.New("アーカイブ済みの口座には登録できません")
+	}
+
+	out := domain.Transaction{
+		Date:      date,
+		Type:      domain.TransactionTypeTransfer,
+		AccountId: from.ID,
+		Amount:    -amount,
+		Memo:      req.Memo,
+	}
+	in := out
+	in.AccountId = to.ID
+	in.Amount = amount
+	return out, in, nil
+}
+
+// resolveAccount は収支を登録する口座を repo の家計簿の口座から返します。
+// accountId が0の場合は既定の口座（表示順で先頭のアーカイブしていない口座）です。
+func resolveAccount(repo repository.TransactionRepository, accountId int) (domain.Account, error) {
+	if accountId == 0 {
+		accounts, err := repo.FindAllAccounts()
+		if err != nil {
+			return domain.Account{}, fmt.Errorf("口座の取得に失敗しました: %w", err)
+		}
+		for _, account := range accounts {
+			if !account.Archived {
+				return account, nil
+			}
+		}
+		return domain.Account{}, errors.New("口座がありません。口座を作成してください")
+	}
+	account, err := repo.FindAccountById(accountId)
+	if err != nil {
+		return domain.Account{}, fmt.Errorf("口座が見つかりません: %d", accountId)
+	}
+	return account, nil
+}
+
+// DeleteTransaction は収支をゴミ箱へ移すDELETE /api/transactions/{id}のハンドラです。
+// ゴミ箱の収支は POST /api/transactions/{id}/restore で元に戻せ、保存期間を過ぎると完全に削除されます。
 func (h *TransactionHandler) DeleteTransaction(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "idは整数で指定してください",
 		})
 	}
-	if err := h.repo.Delete(id); err != nil {
+	if err := repo.Delete(id); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "収支の削除に失敗しました: " + err.Error(),
 		})
 	}
 	return c.JSON(http.StatusOK, map[string]string{
-		"message": "収支が削除されました",
+		"message": "収支をゴミ箱に移しました",
 	})
 }
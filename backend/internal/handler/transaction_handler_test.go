@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"kakeibo-app/backend/internal/domain"
 	"kakeibo-app/backend/internal/repository"
 
 	"github.com/labstack/echo/v4"
@@ -17,7 +19,7 @@ import (
 
 func TestGetTransactions_Empty(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
@@ -33,21 +35,182 @@ func TestGetTransactions_Empty(t *testing.T) {
 		t.Errorf("GetTransactions: expected status 200, got %d", rec.Code)
 	}
 
-	var result []interface{}
+	var result struct {
+		Transactions []interface{} `json:"transactions"`
+		Total        int           `json:"total"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetTransactions: invalid JSON: %v", err)
+	}
+	if result.Transactions == nil || len(result.Transactions) != 0 {
+		t.Errorf("GetTransactions: expected empty array, got %v", result.Transactions)
+	}
+	if result.Total != 0 {
+		t.Errorf("GetTransactions: expected total=0, got %d", result.Total)
+	}
+}
+
+func TestGetTransactions_Filter(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 事前に3件作成
+	for _, body := range []string{
+		`{"date":"2025-01-10","type":"expense","category_id":12,"amount":800,"memo":"昼食"}`,
+		`{"date":"2025-01-20","type":"expense","category_id":2,"amount":300,"memo":"電車"}`,
+		`{"date":"2025-02-01","type":"income","category_id":10,"amount":200000,"memo":"給与"}`,
+	} {
+		createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_ = h.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/transactions?from=2025-01-01&to=2025-01-31&type=expense&category_id=1,2&sort=amount&order=asc&limit=1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.GetTransactions(c); err != nil {
+		t.Fatalf("GetTransactions: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetTransactions: expected status 200, got %d", rec.Code)
+	}
+
+	var result struct {
+		Transactions []map[string]interface{} `json:"transactions"`
+		Total        int                      `json:"total"`
+		Page         int                      `json:"page"`
+		Limit        int                      `json:"limit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetTransactions: invalid JSON: %v", err)
+	}
+	if result.Total != 2 {
+		t.Errorf("GetTransactions: expected total=2, got %d", result.Total)
+	}
+	if result.Page != 1 || result.Limit != 1 {
+		t.Errorf("GetTransactions: expected page=1 limit=1, got page=%d limit=%d", result.Page, result.Limit)
+	}
+	if len(result.Transactions) != 1 || result.Transactions[0]["memo"] != "電車" {
+		t.Errorf("GetTransactions: expected only 電車, got %v", result.Transactions)
+	}
+}
+
+func TestGetTransactions_FilterIncludesSubcategories(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 食材・外食（どちらも食費の子）・交通費で1件ずつ作成
+	for _, body := range []string{
+		`{"date":"2025-01-10","type":"expense","category_id":12,"amount":800,"memo":"食材"}`,
+		`{"date":"2025-01-11","type":"expense","category_id":11,"amount":1200,"memo":"外食"}`,
+		`{"date":"2025-01-12","type":"expense","category_id":2,"amount":300,"memo":"電車"}`,
+	} {
+		createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_ = h.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/transactions?category_id=1", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetTransactions(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTransactions: unexpected error: %v", err)
+	}
+
+	var result struct {
+		Total int `json:"total"`
+	}
 	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
 		t.Fatalf("GetTransactions: invalid JSON: %v", err)
 	}
-	if len(result) != 0 {
-		t.Errorf("GetTransactions: expected empty array, got %d items", len(result))
+	if result.Total != 2 {
+		t.Errorf("GetTransactions: expected 食材 and 外食 for category_id=1, got total=%d", result.Total)
+	}
+}
+
+func TestGetTransactions_InvalidQuery(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	for _, query := range []string{"from=2025/01/01", "type=refund", "category_id=abc", "sort=memo", "page=0", "limit=10000"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := h.GetTransactions(c); err != nil {
+			t.Fatalf("GetTransactions(%s): unexpected error: %v", query, err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GetTransactions(%s): expected status 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+func TestGetMonthlySummary_Success(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	for _, body := range []string{
+		`{"date":"2025-02-10","type":"expense","category_id":12,"amount":800,"memo":"昼食"}`,
+		`{"date":"2025-02-25","type":"income","category_id":10,"amount":200000,"memo":"給与"}`,
+		`{"date":"2025-03-01","type":"expense","category_id":12,"amount":500,"memo":"翌月"}`,
+	} {
+		createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		_ = h.CreateTransaction(e.NewContext(createReq, httptest.NewRecorder()))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summary/monthly?year=2025&month=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.GetMonthlySummary(c); err != nil {
+		t.Fatalf("GetMonthlySummary: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetMonthlySummary: expected status 200, got %d", rec.Code)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GetMonthlySummary: invalid JSON: %v", err)
+	}
+	if result["income"] != float64(200000) || result["expense"] != float64(800) || result["balance"] != float64(199200) {
+		t.Errorf("GetMonthlySummary: unexpected totals: %v", result)
+	}
+	if result["count"] != float64(2) {
+		t.Errorf("GetMonthlySummary: expected count=2, got %v", result["count"])
+	}
+}
+
+func TestGetMonthlySummary_InvalidMonth(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/summary/monthly?year=2025&month=13", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	if err := h.GetMonthlySummary(c); err != nil {
+		t.Fatalf("GetMonthlySummary: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GetMonthlySummary: expected status 400 for invalid month, got %d", rec.Code)
 	}
 }
 
 func TestCreateTransaction_Success(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
-	body := `{"date":"2025-01-15","type":"expense","category_id":1,"amount":1500,"memo":"昼食"}`
+	body := `{"date":"2025-01-15","type":"expense","category_id":12,"amount":1500,"memo":"昼食"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -72,14 +235,36 @@ func TestCreateTransaction_Success(t *testing.T) {
 	if result["memo"] != "昼食" {
 		t.Errorf("CreateTransaction: expected memo=昼食, got %v", result["memo"])
 	}
+	// account_id を省略した場合は既定の口座（現金）
+	if result["account_id"] != float64(1) {
+		t.Errorf("CreateTransaction: expected account_id=1, got %v", result["account_id"])
+	}
+}
+
+func TestCreateTransaction_UnknownAccount(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	body := `{"date":"2025-01-15","type":"expense","category_id":12,"account_id":999,"amount":1500}`
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateTransaction: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("CreateTransaction: expected status 400, got %d", rec.Code)
+	}
 }
 
 func TestCreateTransaction_InvalidType(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
-	body := `{"date":"2025-01-15","type":"invalid","category_id":1,"amount":1500,"memo":"テスト"}`
+	body := `{"date":"2025-01-15","type":"invalid","category_id":12,"amount":1500,"memo":"テスト"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -95,12 +280,231 @@ func TestCreateTransaction_InvalidType(t *testing.T) {
 	}
 }
 
+func TestCreateTransaction_CategoryTypeMismatch(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 給与（収入用）を支出に、食材（支出用）を収入に使うと 400
+	for _, body := range []string{
+		`{"date":"2025-01-15","type":"expense","category_id":10,"amount":1500,"memo":"テスト"}`,
+		`{"date":"2025-01-15","type":"income","category_id":12,"amount":1500,"memo":"テスト"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if err := h.CreateTransaction(c); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+
+	// その他（both）はどちらにも使える
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
+		`{"date":"2025-01-15","type":"income","category_id":9,"amount":1500,"memo":"臨時収入"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateTransaction: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("CreateTransaction: expected status 201 for both-kind category, got %d", rec.Code)
+	}
+}
+
+func TestTransaction_LeafCategory(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/transactions/1", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		if err := h.UpdateTransaction(c); err != nil {
+			t.Fatalf("UpdateTransaction: unexpected error: %v", err)
+		}
+		return rec
+	}
+
+	// 子カテゴリのある食費・存在しないカテゴリ・内訳の食費は登録できない
+	for _, body := range []string{
+		`{"date":"2025-01-15","type":"expense","category_id":1,"amount":1500}`,
+		`{"date":"2025-01-15","type":"expense","category_id":99,"amount":1500}`,
+		`{"date":"2025-01-15","type":"expense","amount":1500,"splits":[{"category_id":1,"amount":1000},{"category_id":2,"amount":500}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+
+	// 子カテゴリができる前に食費で登録した収支
+	tx := domain.Transaction{Date: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -1500}
+	if err := repo.Save(&tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 更新でも子カテゴリのある光熱費・存在しないカテゴリは 400
+	for _, body := range []string{
+		`{"date":"2025-01-15","type":"expense","category_id":4,"amount":1500}`,
+		`{"date":"2025-01-15","type":"expense","category_id":99,"amount":1500}`,
+	} {
+		if rec := put(body); rec.Code != http.StatusBadRequest {
+			t.Errorf("UpdateTransaction(%s): expected status 400, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	// 既に使っている食費はそのまま使える
+	if rec := put(`{"date":"2025-01-16","type":"expense","category_id":1,"amount":1500,"memo":"更新"}`); rec.Code != http.StatusOK {
+		t.Errorf("UpdateTransaction: expected status 200 for the current category, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCreateTransaction_Splits(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	// 内訳の合計が金額と合わない・1行だけ・収入用カテゴリを含む場合は 400
+	for _, body := range []string{
+		`{"date":"2025-02-10","type":"expense","amount":5000,"splits":[{"category_id":12,"amount":3000},{"category_id":7,"amount":1000}]}`,
+		`{"date":"2025-02-10","type":"expense","amount":5000,"splits":[{"category_id":12,"amount":5000}]}`,
+		`{"date":"2025-02-10","type":"expense","amount":5000,"splits":[{"category_id":12,"amount":3000},{"category_id":10,"amount":2000}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("CreateTransaction(%s): expected status 400, got %d", body, rec.Code)
+		}
+	}
+
+	body := `{"date":"2025-02-10","type":"expense","amount":5000,"memo":"スーパー","splits":[` +
+		`{"category_id":12,"amount":3000,"memo":"食材"},{"category_id":9,"amount":1200},{"category_id":7,"amount":800,"memo":"薬"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("CreateTransaction: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		CategoryId int `json:"category_id"`
+		Splits     []struct {
+			CategoryId int    `json:"category_id"`
+			Amount     int    `json:"amount"`
+			Memo       string `json:"memo"`
+		} `json:"splits"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+	}
+	// 収支のカテゴリは最初の内訳のカテゴリ、内訳の金額は支出なので負の値
+	if created.CategoryId != 12 || len(created.Splits) != 3 || created.Splits[2].Amount != -800 || created.Splits[2].Memo != "薬" {
+		t.Errorf("CreateTransaction: unexpected splits: %+v", created)
+	}
+
+	// 月次集計は内訳ごとのカテゴリに振り分ける
+	req = httptest.NewRequest(http.MethodGet, "/api/summary/monthly?year=2025&month=2", nil)
+	rec = httptest.NewRecorder()
+	if err := h.GetMonthlySummary(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetMonthlySummary: unexpected error: %v", err)
+	}
+	var summary struct {
+		Expense    int `json:"expense"`
+		Count      int `json:"count"`
+		Categories []struct {
+			CategoryName string `json:"category_name"`
+			Expense      int    `json:"expense"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("GetMonthlySummary: invalid JSON: %v", err)
+	}
+	if summary.Expense != 5000 || summary.Count != 1 || len(summary.Categories) != 3 {
+		t.Fatalf("GetMonthlySummary: unexpected summary: %+v", summary)
+	}
+	for _, ct := range summary.Categories {
+		if want := map[string]int{"食費": 3000, "医療費": 800, "その他": 1200}[ct.CategoryName]; ct.Expense != want {
+			t.Errorf("GetMonthlySummary: expected %s expense=%d, got %d", ct.CategoryName, want, ct.Expense)
+		}
+	}
+}
+
+func TestCreateTransaction_Duplicate(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	e := echo.New()
+
+	create := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		if err := h.CreateTransaction(e.NewContext(req, rec)); err != nil {
+			t.Fatalf("CreateTransaction: unexpected error: %v", err)
+		}
+		return rec
+	}
+
+	if rec := create("/api/transactions", `{"date":"2025-01-15","type":"expense","category_id":12,"amount":1500,"memo":"ランチ 定食"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("CreateTransaction: expected status 201, got %d", rec.Code)
+	}
+
+	// 全角・空白の違いは同じメモとみなし、409 と重複する収支を返す
+	duplicate := `{"date":"2025-01-15","type":"expense","category_id":2,"amount":1500,"memo":"ﾗﾝﾁ  定食"}`
+	rec := create("/api/transactions", duplicate)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("CreateTransaction: expected status 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var warning domain.DuplicateWarning
+	if err := json.Unmarshal(rec.Body.Bytes(), &warning); err != nil {
+		t.Fatalf("CreateTransaction: invalid JSON: %v", err)
+	}
+	if warning.Error == "" || len(warning.Duplicates) != 1 || warning.Duplicates[0].ID != 1 {
+		t.Errorf("CreateTransaction: unexpected warning: %+v", warning)
+	}
+
+	// 日付・金額が違えば重複ではない
+	if rec := create("/api/transactions", `{"date":"2025-01-16","type":"expense","category_id":12,"amount":1500,"memo":"ランチ 定食"}`); rec.Code != http.StatusCreated {
+		t.Errorf("CreateTransaction(another date): expected status 201, got %d", rec.Code)
+	}
+	if rec := create("/api/transactions", `{"date":"2025-01-15","type":"expense","category_id":12,"amount":1600,"memo":"ランチ 定食"}`); rec.Code != http.StatusCreated {
+		t.Errorf("CreateTransaction(another amount): expected status 201, got %d", rec.Code)
+	}
+
+	// allow_duplicate=true で重複していても登録する
+	if rec := create("/api/transactions?allow_duplicate=true", duplicate); rec.Code != http.StatusCreated {
+		t.Errorf("CreateTransaction(allow_duplicate): expected status 201, got %d", rec.Code)
+	}
+	if all, _ := repo.FindAll(); len(all) != 4 {
+		t.Errorf("CreateTransaction: expected 4 transactions, got %d", len(all))
+	}
+}
+
 func TestCreateTransaction_InvalidDate(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
-	body := `{"date":"2025/01/15","type":"expense","category_id":1,"amount":1500,"memo":"テスト"}`
+	body := `{"date":"2025/01/15","type":"expense","category_id":12,"amount":1500,"memo":"テスト"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -118,12 +522,12 @@ func TestCreateTransaction_InvalidDate(t *testing.T) {
 
 func TestUpdateTransaction_Success(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
 	// 事前に1件作成
 	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
-		`{"date":"2025-01-15","type":"expense","category_id":1,"amount":1000,"memo":"元のメモ"}`))
+		`{"date":"2025-01-15","type":"expense","category_id":12,"amount":1000,"memo":"元のメモ"}`))
 	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(createReq, rec)
@@ -158,10 +562,10 @@ func TestUpdateTransaction_Success(t *testing.T) {
 
 func TestUpdateTransaction_InvalidId(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
-	body := `{"date":"2025-01-15","type":"expense","category_id":1,"amount":1500,"memo":"テスト"}`
+	body := `{"date":"2025-01-15","type":"expense","category_id":12,"amount":1500,"memo":"テスト"}`
 	req := httptest.NewRequest(http.MethodPut, "/api/transactions/abc", bytes.NewBufferString(body))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -181,12 +585,12 @@ func TestUpdateTransaction_InvalidId(t *testing.T) {
 
 func TestDeleteTransaction_Success(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
 	// 事前に1件作成
 	createReq := httptest.NewRequest(http.MethodPost, "/api/transactions", bytes.NewBufferString(
-		`{"date":"2025-01-15","type":"expense","category_id":1,"amount":1000,"memo":"削除対象"}`))
+		`{"date":"2025-01-15","type":"expense","category_id":12,"amount":1000,"memo":"削除対象"}`))
 	createReq.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(createReq, rec)
@@ -219,7 +623,7 @@ func TestDeleteTransaction_Success(t *testing.T) {
 
 func TestDeleteTransaction_InvalidId(t *testing.T) {
 	repo := repository.NewTransactionRepository()
-	h := NewTransactionHandler(repo)
+	h := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
 	e := echo.New()
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/transactions/xyz", nil)
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrashHandler はゴミ箱（削除した収支）のHTTPリクエストを処理するハンドラです。
+// 収支は DELETE /api/transactions/{id} でゴミ箱へ移り、保存期間（retentionDays 日）を過ぎると完全に削除されます。
+type TrashHandler struct {
+	repo          repository.TransactionRepository
+	retentionDays int
+}
+
+// NewTrashHandler はTrashHandlerを生成します。
+func NewTrashHandler(repo repository.TransactionRepository, retentionDays int) *TrashHandler {
+	return &TrashHandler{repo: repo, retentionDays: retentionDays}
+}
+
+// GetTrash はゴミ箱の収支を、ゴミ箱へ移した日時の新しい順に完全に削除する日時付きで取得するGET /api/trashのハンドラです。
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	transactions, err := repo.FindTrash()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "ゴミ箱の取得に失敗しました: " + err.Error(),
+		})
+	}
+	trash := domain.Trash{RetentionDays: h.retentionDays, Transactions: []domain.TrashedTransaction{}}
+	for _, t := range transactions {
+		trash.Transactions = append(trash.Transactions, domain.TrashedTransaction{
+			Transaction: t,
+			PurgeAt:     t.DeletedAt.Add(time.Duration(h.retentionDays) * 24 * time.Hour),
+		})
+	}
+	return c.JSON(http.StatusOK, trash)
+}
+
+// RestoreTransaction はゴミ箱の収支を元に戻すPOST /api/transactions/{id}/restoreのハンドラです。
+// 振替の行を指定した場合は組になっているもう1行も戻し、振替をまとめた形で返します。
+func (h *TrashHandler) RestoreTransaction(c echo.Context) error {
+	repo := h.repo.ForHousehold(currentHouseholdId(c))
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "idは整数で指定してください",
+		})
+	}
+	if err := repo.Restore(id); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	t, err := repo.FindById(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "収支の取得に失敗しました: " + err.Error(),
+		})
+	}
+	if t.TransferId != 0 {
+		pair, err := repo.FindTransfer(t.TransferId)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "振替の取得に失敗しました: " + err.Error(),
+			})
+		}
+		return c.JSON(http.StatusOK, domain.NewTransfer(pair[0], pair[1]))
+	}
+	return c.JSON(http.StatusOK, t)
+}
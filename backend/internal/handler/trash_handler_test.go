@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// trash_handler_test.go は TrashHandler（ゴミ箱）と、収支の削除でのゴミ箱への移動の HTTP ハンドラテストです。
+
+// getTrash は GET /api/trash の結果を返します。
+func getTrash(t *testing.T, h *TrashHandler, e *echo.Echo) domain.Trash {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/trash", nil)
+	rec := httptest.NewRecorder()
+	if err := h.GetTrash(e.NewContext(req, rec)); err != nil {
+		t.Fatalf("GetTrash: unexpected error: %v", err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetTrash: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var trash domain.Trash
+	if err := json.Unmarshal(rec.Body.Bytes(), &trash); err != nil {
+		t.Fatalf("GetTrash: invalid JSON: %v", err)
+	}
+	return trash
+}
+
+func TestDeleteTransaction_MovesToTrash(t *testing.T) {
+	repo := repository.NewTransactionRepository()
+	blobs := newTestBlobStore(t)
+	th := NewTransactionHandler(repo, repository.NewCategoryRuleRepository())
+	ah := NewAttachmentHandler(repo, blobs)
+	h := NewTrashHandler(repo, 30)
+	e := echo.New()
+	tx := saveAttachmentTarget(t, repo)
+	other := saveAttachmentTarget(t, repo)
+	uploadAttachment(t, ah, e, tx.ID, "receipt.png", testPNG(t, 10, 10))
+	receipt, _ := repo.FindAttachmentById(1)
+
+	if trash := getTrash(t, h, e); trash.RetentionDays != 30 || len(trash.Transactions) != 0 {
+		t.Fatalf("GetTrash: expected empty trash, got %+v", trash)
+	}
+	if rec := callAttachment(t, th.DeleteTransaction, e, http.MethodDelete, "/api/transactions/1", tx.ID); rec.Code != http.StatusOK {
+		t.Fatalf("DeleteTransaction: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	// ゴミ箱の収支は一覧に含めず、添付ファイルは完全に削除するまで残す
+	if all, _ := repo.FindAll(); len(all) != 1 || all[0].ID != other.ID {
+		t.Errorf("FindAll: expected only the other transaction, got %+v", all)
+	}
+	if f, err := blobs.Open(receipt.BlobKey); err != nil {
+		t.Errorf("DeleteTransaction: expected attachment to remain until purge, got %v", err)
+	} else {
+		f.Close()
+	}
+
+	trash := getTrash(t, h, e)
+	if len(trash.Transactions) != 1 || trash.Transactions[0].ID != tx.ID || trash.Transactions[0].DeletedAt == nil {
+		t.Fatalf("GetTrash: unexpected trash: %+v", trash)
+	}
+	if got := trash.Transactions[0].PurgeAt.Sub(*trash.Transactions[0].DeletedAt); got != 30*24*time.Hour {
+		t.Errorf("GetTrash: expected purge_at 30 days after deleted_at, got %v", got)
+	}
+
+	rec := callAttachment(t, h.RestoreTransaction, e, http.MethodPost, "/api/transactions/1/restore", tx.ID)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("RestoreTransaction: expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var restored domain.Transaction
+	json.Unmarshal(rec.Body.Bytes(), &restored)
+	if restored.ID != tx.ID || restored.DeletedAt != nil {
+		t.Errorf("RestoreTransaction: unexpected transaction: %+v", restored)
+	}
+	if attachments, err := repo.FindAttachments(tx.ID); err != nil || len(attachments) != 1 {
+		t.Errorf("FindAttachments after restore: unexpected result: %+v, %v", attachments, err)
+	}
+	if trash := getTrash(t, h, e); len(trash.Transactions) != 0 {
+		t.Errorf("GetTrash: expected empty trash after restore, got %+v", trash)
+	}
+	if rec := callAttachment(t, h.RestoreTransaction, e, http.MethodPost, "/api/transactions/2/restore", other.ID); rec.Code != http.StatusNotFound {
+		t.Errorf("RestoreTransaction: expected status 404 for a transaction not in the trash, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,218 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// apps.go は他の家計簿アプリ（Zaim・Money Forward ME）が書き出す CSV の変換です。
+// 列は見出し行の列名で探すため、列の並びが変わっても読み込めます。
+// カテゴリは取り込み元のカテゴリ名（ImportRow.SourceCategory）だけを設定し、この家計簿のカテゴリとの対応は呼び出し側で決めます。
+
+// appColumns は見出し行から探した列の位置です。
+type appColumns map[string]int
+
+// findColumns は見出し行から names の列を探します。required の列がない場合はエラーを返し、それ以外の列がない場合は -1 です。
+func findColumns(header []string, app string, required []string, optional ...string) (appColumns, error) {
+	cols := appColumns{}
+	for _, name := range append(append([]string{}, required...), optional...) {
+		cols[name] = -1
+		for i, h := range header {
+			if strings.TrimSpace(h) == name {
+				cols[name] = i
+				break
+			}
+		}
+	}
+	for _, name := range required {
+		if cols[name] < 0 {
+			return nil, fmt.Errorf("%s の CSV ではありません（列が見つかりません: %s）", app, name)
+		}
+	}
+	return cols, nil
+}
+
+// readAppCSV は他の家計簿アプリの CSV を読み込み、見出し行とデータ行に分けます。文字コードは自動で判定します。
+func readAppCSV(data []byte, app string) ([]string, []record, error) {
+	text, err := Decode(data, domain.EncodingAuto)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := readRecords(text, ',', 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%s の CSV ではありません（見出し行がありません）", app)
+	}
+	if len(records)-1 > MaxRows {
+		return nil, nil, fmt.Errorf("取り込めるのは%d行までです", MaxRows)
+	}
+	return records[0].fields, records[1:], nil
+}
+
+// sourceCategory は大項目と中項目から取り込み元のカテゴリ名（"大項目/中項目"）を作ります。
+func sourceCategory(major, minor string) string {
+	if minor == "" || minor == "-" || minor == major {
+		return major
+	}
+	return major + "/" + minor
+}
+
+// joinMemo は空でない値を空白でつなげてメモにします。
+func joinMemo(values ...string) string {
+	var memos []string
+	for _, v := range values {
+		if v != "" && v != "-" {
+			memos = append(memos, v)
+		}
+	}
+	return strings.Join(memos, " ")
+}
+
+// Zaim の CSV の列名です。
+const (
+	zaimDate      = "日付"
+	zaimMethod    = "方法"
+	zaimCategory  = "カテゴリ"
+	zaimSubcat    = "カテゴリの内訳"
+	zaimItem      = "品目"
+	zaimMemo      = "メモ"
+	zaimShop      = "お店"
+	zaimIncome    = "収入"
+	zaimExpense   = "支出"
+	zaimAggregate = "集計の設定"
+)
+
+// ParseZaim は Zaim の「CSV ダウンロード」のファイルを収支の行に変換します。
+// 方法が payment（支出）・income（収入）の行を取り込み、振替・残高調整と集計に含めない設定の行は取り込みません。
+// メモは お店・品目・メモ をつなげたもので、お店を支払先にします。
+func ParseZaim(data []byte) ([]domain.ImportRow, error) {
+	header, records, err := readAppCSV(data, "Zaim")
+	if err != nil {
+		return nil, err
+	}
+	cols, err := findColumns(header, "Zaim",
+		[]string{zaimDate, zaimMethod, zaimCategory, zaimIncome, zaimExpense},
+		zaimSubcat, zaimItem, zaimMemo, zaimShop, zaimAggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.ImportRow, 0, len(records))
+	for _, rec := range records {
+		get := func(name string) string { return field(rec.fields, cols[name]) }
+		row := domain.ImportRow{Line: rec.line}
+
+		var amountColumn string
+		switch get(zaimMethod) {
+		case "payment", "支払", "支出":
+			row.Type, amountColumn = domain.TransactionTypeExpense, zaimExpense
+		case "income", "収入":
+			row.Type, amountColumn = domain.TransactionTypeIncome, zaimIncome
+		case "transfer", "振替":
+			row.Error = "振替は取り込みません"
+		default:
+			row.Error = "取り込まない種類の行です: " + get(zaimMethod)
+		}
+		if row.Error == "" && strings.Contains(get(zaimAggregate), "含めない") {
+			row.Error = "集計に含めない行は取り込みません"
+		}
+		if row.Error != "" {
+			rows = append(rows, row)
+			continue
+		}
+
+		fillAppRow(&row, get(zaimDate), get(amountColumn),
+			sourceCategory(get(zaimCategory), get(zaimSubcat)),
+			joinMemo(get(zaimShop), get(zaimItem), get(zaimMemo)))
+		row.Payee = get(zaimShop)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Money Forward ME の CSV の列名です。
+const (
+	mfTarget   = "計算対象"
+	mfDate     = "日付"
+	mfContent  = "内容"
+	mfAmount   = "金額（円）"
+	mfMajor    = "大項目"
+	mfMinor    = "中項目"
+	mfMemo     = "メモ"
+	mfTransfer = "振替"
+)
+
+// ParseMoneyForward は Money Forward ME の入出金の CSV（Shift_JIS）を収支の行に変換します。
+// 金額の符号で収入・支出を決め、振替と計算対象外の行は取り込みません。メモは 内容・メモ をつなげたもので、内容を支払先にします。
+func ParseMoneyForward(data []byte) ([]domain.ImportRow, error) {
+	header, records, err := readAppCSV(data, "Money Forward ME")
+	if err != nil {
+		return nil, err
+	}
+	cols, err := findColumns(header, "Money Forward ME",
+		[]string{mfDate, mfAmount, mfMajor},
+		mfTarget, mfContent, mfMinor, mfMemo, mfTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.ImportRow, 0, len(records))
+	for _, rec := range records {
+		get := func(name string) string { return field(rec.fields, cols[name]) }
+		row := domain.ImportRow{Line: rec.line}
+
+		switch {
+		case get(mfTransfer) == "1":
+			row.Error = "振替は取り込みません"
+		case get(mfTarget) == "0":
+			row.Error = "計算対象外の行は取り込みません"
+		}
+		if row.Error != "" {
+			rows = append(rows, row)
+			continue
+		}
+
+		fillAppRow(&row, get(mfDate), get(mfAmount),
+			sourceCategory(get(mfMajor), get(mfMinor)),
+			joinMemo(get(mfContent), get(mfMemo)))
+		row.Payee = get(mfContent)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// fillAppRow は日付・金額・カテゴリ名・メモを行に設定します。金額が負の値の場合は支出とし、
+// 種別が決まっていない場合（Money Forward ME）は正の値を収入とします。
+func fillAppRow(row *domain.ImportRow, date, amount, category, memo string) {
+	d, err := parseDate(date, "")
+	if err != nil {
+		row.Error = "日付を読み込めません: " + date
+		return
+	}
+	row.Date = d.Format("2006-01-02")
+
+	n, err := parseAmount(amount)
+	if err != nil {
+		row.Error = err.Error()
+		return
+	}
+	if n < 0 {
+		row.Type, n = domain.TransactionTypeExpense, -n
+	} else if row.Type == "" {
+		row.Type = domain.TransactionTypeIncome
+	}
+	if n == 0 {
+		row.Error = "金額がありません"
+		return
+	}
+	row.Amount = n
+	row.SourceCategory = category
+	row.Memo = memo
+	if category == "" {
+		row.Error = "カテゴリがありません"
+	}
+}
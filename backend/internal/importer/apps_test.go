@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// apps_test.go は他の家計簿アプリ（Zaim・Money Forward ME）の CSV の変換の単体テストです。
+
+func TestParseZaim(t *testing.T) {
+	data := strings.Join([]string{
+		"日付,方法,カテゴリ,カテゴリの内訳,支払元,入金先,品目,メモ,お店,通貨,収入,支出,振替,残高調整,通貨変換前の金額,集計の設定",
+		"2025-08-01,payment,食費,食料品,財布,,牛乳,特売,スーパー,JPY,0,198,0,0,,常に含める",
+		"2025-08-25,income,給与,-,,銀行,,8月分,,JPY,250000,0,0,0,,常に含める",
+		"2025-08-26,transfer,-,-,銀行,財布,,,,JPY,0,0,10000,0,,常に含める",
+		"2025-08-27,payment,交際費,-,財布,,,,,JPY,0,3000,0,0,,集計に含めない",
+		"2025/08/28,payment,日用雑貨,-,財布,,,,,JPY,0,0,0,0,,常に含める",
+	}, "\n")
+	rows, err := ParseZaim([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseZaim: unexpected error: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("ParseZaim: expected 5 rows, got %d", len(rows))
+	}
+	want := domain.ImportRow{Line: 2, Date: "2025-08-01", Type: "expense", Amount: 198, Memo: "スーパー 牛乳 特売", Payee: "スーパー", SourceCategory: "食費/食料品"}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("ParseZaim: row 2 = %+v, want %+v", rows[0], want)
+	}
+	want = domain.ImportRow{Line: 3, Date: "2025-08-25", Type: "income", Amount: 250000, Memo: "8月分", SourceCategory: "給与"}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("ParseZaim: row 3 = %+v, want %+v", rows[1], want)
+	}
+	for i := 2; i < 5; i++ {
+		if rows[i].Error == "" {
+			t.Errorf("ParseZaim: expected error for line %d, got %+v", rows[i].Line, rows[i])
+		}
+	}
+
+	if _, err := ParseZaim([]byte("取引日,金額\n2025/08/01,100\n")); err == nil {
+		t.Error("ParseZaim: expected error for a CSV without Zaim columns")
+	}
+}
+
+func TestParseMoneyForward(t *testing.T) {
+	data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(strings.Join([]string{
+		`"計算対象","日付","内容","金額（円）","保有金融機関","大項目","中項目","メモ","振替","ID"`,
+		`"1","2025/08/01","セブンイレブン","-540","現金","食費","コンビニ","おにぎり","0","a1"`,
+		`"1","2025/08/25","給与 カブシキガイシャ","250000","銀行","収入","給与","","0","a2"`,
+		`"1","2025/08/26","カード引き落とし","-30000","銀行","未分類","未分類","","1","a3"`,
+		`"0","2025/08/27","立替","-1000","現金","交際費","未分類","","0","a4"`,
+	}, "\r\n")))
+	if err != nil {
+		t.Fatalf("encode: unexpected error: %v", err)
+	}
+	rows, err := ParseMoneyForward(data)
+	if err != nil {
+		t.Fatalf("ParseMoneyForward: unexpected error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("ParseMoneyForward: expected 4 rows, got %d", len(rows))
+	}
+	want := domain.ImportRow{Line: 2, Date: "2025-08-01", Type: "expense", Amount: 540, Memo: "セブンイレブン おにぎり", Payee: "セブンイレブン", SourceCategory: "食費/コンビニ"}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("ParseMoneyForward: row 2 = %+v, want %+v", rows[0], want)
+	}
+	want = domain.ImportRow{Line: 3, Date: "2025-08-25", Type: "income", Amount: 250000, Memo: "給与 カブシキガイシャ", Payee: "給与 カブシキガイシャ", SourceCategory: "収入/給与"}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("ParseMoneyForward: row 3 = %+v, want %+v", rows[1], want)
+	}
+	if rows[2].Error == "" || rows[3].Error == "" {
+		t.Errorf("ParseMoneyForward: expected transfer and excluded rows to be skipped: %+v", rows[2:])
+	}
+}
@@ -0,0 +1,369 @@
+// Package importer は銀行・カードの明細や他の家計簿アプリから書き出した CSV を、
+// 登録前の収支の行（domain.ImportRow）に変換します。カテゴリ・口座の確認と登録は呼び出し側で行います。
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxRows は1回の取り込みで読み込むデータ行の上限です。
+const MaxRows = 10000
+
+// utf8BOM は UTF-8 の BOM です。Excel で保存した CSV の先頭に付いています。
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// encodingAliases は文字コードの別名です。
+var encodingAliases = map[string]string{
+	"":            domain.EncodingAuto,
+	"utf8":        domain.EncodingUTF8,
+	"sjis":        domain.EncodingShiftJIS,
+	"cp932":       domain.EncodingShiftJIS,
+	"windows-31j": domain.EncodingShiftJIS,
+}
+
+// Decode は data を encoding に従って UTF-8 の文字列に変換します。先頭の BOM は取り除きます。
+// EncodingAuto の場合は、BOM があるか UTF-8 として正しければ UTF-8、そうでなければ Shift_JIS として読みます。
+func Decode(data []byte, encoding string) (string, error) {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if alias, ok := encodingAliases[encoding]; ok {
+		encoding = alias
+	}
+	if encoding == domain.EncodingAuto {
+		encoding = domain.EncodingShiftJIS
+		if bytes.HasPrefix(data, utf8BOM) || utf8.Valid(data) {
+			encoding = domain.EncodingUTF8
+		}
+	}
+
+	switch encoding {
+	case domain.EncodingUTF8:
+		data = bytes.TrimPrefix(data, utf8BOM)
+		if !utf8.Valid(data) {
+			return "", errors.New("UTF-8 として読み込めません（Shift_JIS のファイルは encoding に shift_jis を指定してください）")
+		}
+		return string(data), nil
+	case domain.EncodingShiftJIS:
+		decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+		if err != nil {
+			return "", fmt.Errorf("Shift_JIS として読み込めません: %w", err)
+		}
+		return string(decoded), nil
+	default:
+		return "", fmt.Errorf("encodingは %s / %s / %s のいずれかを指定してください",
+			domain.EncodingAuto, domain.EncodingUTF8, domain.EncodingShiftJIS)
+	}
+}
+
+// record は CSV の1行と、そのファイル上の行番号（1始まり）です。
+type record struct {
+	line   int
+	fields []string
+}
+
+// readRecords は CSV を読み込み、空の行（すべての列が空の行を含む）を除いて返します。
+// skipRows 行を読み飛ばしてから CSV として解釈し、行番号は読み飛ばした行を含めて数えます。
+func readRecords(text string, delimiter rune, skipRows int) ([]record, error) {
+	for i := 0; i < skipRows && text != ""; i++ {
+		if n := strings.IndexByte(text, '\n'); n >= 0 {
+			text = text[n+1:]
+		} else {
+			text = ""
+		}
+	}
+
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var records []record
+	for {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV を読み込めません: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(fields, "")) == "" {
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		records = append(records, record{line: line + skipRows, fields: fields})
+	}
+	return records, nil
+}
+
+// columnIndex は列の指定（列名か "1" から始まる列番号）を0始まりの列の位置に変換します。
+func columnIndex(ref string, header []string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("列番号は1以上で指定してください: %s", ref)
+		}
+		return n - 1, nil
+	}
+	for i, name := range header {
+		if strings.TrimSpace(name) == ref {
+			return i, nil
+		}
+	}
+	if header == nil {
+		return 0, fmt.Errorf("見出し行がないため列名では指定できません: %s", ref)
+	}
+	return 0, fmt.Errorf("列が見つかりません: %s", ref)
+}
+
+// field は行の i 列目の値を前後の空白を除いて返します。列が足りない場合は空文字です。
+func field(fields []string, i int) string {
+	if i < 0 || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// defaultDateLayouts は日付の形式を省略した場合に順に試す形式です。月・日の0埋めはあってもなくても構いません。
+var defaultDateLayouts = []string{"2006/1/2", "2006-1-2", "2006.1.2", "20060102", "2006年1月2日"}
+
+// dateFormatReplacer は "YYYY/MM/DD" 形式の日付の形式を Go のレイアウトに変換します。
+var dateFormatReplacer = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02", "M", "1", "D", "2")
+
+// parseDate は日付の値を解釈します。format が空の場合は defaultDateLayouts を順に試します。
+// 全角数字を受け付け、"2025/01/05 12:34" のような時刻は無視します。
+func parseDate(value, format string) (time.Time, error) {
+	value = norm.NFKC.String(value)
+	if format != "" {
+		return time.Parse(dateFormatReplacer.Replace(format), value)
+	}
+	if fields := strings.Fields(value); len(fields) > 0 {
+		value = fields[0]
+	}
+	for _, layout := range defaultDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("日付として読み込めません: %s", value)
+}
+
+// amountReplacer は金額の桁区切りや通貨記号を取り除きます。
+var amountReplacer = strings.NewReplacer(",", "", "¥", "", `\`, "", "円", "", " ", "", "+", "")
+
+// parseAmount は金額の値を解釈します。空の場合は0を返します。
+// 全角数字・桁区切り・通貨記号を受け付け、"-"・"△"・"▲"・括弧で囲んだ値は負の値とします。
+func parseAmount(value string) (int, error) {
+	s := norm.NFKC.String(strings.TrimSpace(value))
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	for _, sign := range []string{"-", "−", "△", "▲"} {
+		if strings.HasPrefix(s, sign) {
+			negative = true
+			s = strings.TrimPrefix(s, sign)
+			break
+		}
+	}
+	s = amountReplacer.Replace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+		return 0, fmt.Errorf("金額として読み込めません: %s", value)
+	}
+	if negative {
+		f = -f
+	}
+	return int(f), nil
+}
+
+// csvColumns は CSVImportProfile の列の指定を列の位置に変換したものです。使わない列は -1 です。
+type csvColumns struct {
+	date, amount, debit, credit, payee int
+	memo                               []int
+}
+
+// validateProfile は列の指定と金額の向きを確認し、区切り文字を返します。
+func validateProfile(profile domain.CSVImportProfile) (rune, error) {
+	delimiter := ','
+	if profile.Delimiter != "" {
+		d := strings.ReplaceAll(profile.Delimiter, `\t`, "\t")
+		if utf8.RuneCountInString(d) != 1 {
+			return 0, errors.New("delimiterは1文字で指定してください")
+		}
+		delimiter, _ = utf8.DecodeRuneInString(d)
+	}
+	if profile.SkipRows < 0 {
+		return 0, errors.New("skip_rowsは0以上で指定してください")
+	}
+	if profile.DateColumn == "" {
+		return 0, errors.New("date_columnを指定してください")
+	}
+	hasAmount := profile.AmountColumn != ""
+	hasDebitCredit := profile.DebitColumn != "" || profile.CreditColumn != ""
+	if hasAmount == hasDebitCredit {
+		return 0, errors.New("金額はamount_columnか、debit_column・credit_columnのどちらかで指定してください")
+	}
+	switch profile.AmountPositive {
+	case "", domain.TransactionTypeIncome, domain.TransactionTypeExpense:
+	default:
+		return 0, errors.New("amount_positiveは income / expense のいずれかを指定してください")
+	}
+	return delimiter, nil
+}
+
+// resolveColumns は列の指定を見出し行に照らして列の位置に変換します。
+func resolveColumns(profile domain.CSVImportProfile, header []string) (csvColumns, error) {
+	cols := csvColumns{amount: -1, debit: -1, credit: -1, payee: -1}
+	resolve := func(ref string, dst *int) error {
+		if ref == "" {
+			return nil
+		}
+		i, err := columnIndex(ref, header)
+		*dst = i
+		return err
+	}
+	if err := resolve(profile.DateColumn, &cols.date); err != nil {
+		return cols, err
+	}
+	for _, c := range []struct {
+		ref string
+		dst *int
+	}{{profile.AmountColumn, &cols.amount}, {profile.DebitColumn, &cols.debit}, {profile.CreditColumn, &cols.credit},
+		{profile.PayeeColumn, &cols.payee}} {
+		if err := resolve(c.ref, c.dst); err != nil {
+			return cols, err
+		}
+	}
+	for _, ref := range profile.MemoColumns {
+		i, err := columnIndex(ref, header)
+		if err != nil {
+			return cols, err
+		}
+		cols.memo = append(cols.memo, i)
+	}
+	return cols, nil
+}
+
+// ParseCSV は profile の列の対応に従って明細の CSV を収支の行に変換します。
+// ファイル全体の問題（文字コード・列の指定・行数の超過）はエラーを返し、
+// 行ごとの問題（日付や金額が読めない、カテゴリが未指定など）は ImportRow.Error に入れます。
+func ParseCSV(data []byte, profile domain.CSVImportProfile) ([]domain.ImportRow, error) {
+	delimiter, err := validateProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	text, err := Decode(data, profile.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	records, err := readRecords(text, delimiter, profile.SkipRows)
+	if err != nil {
+		return nil, err
+	}
+	var header []string
+	if profile.HasHeader && len(records) > 0 {
+		header, records = records[0].fields, records[1:]
+	}
+	if len(records) > MaxRows {
+		return nil, fmt.Errorf("取り込めるのは%d行までです", MaxRows)
+	}
+	cols, err := resolveColumns(profile, header)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.ImportRow, 0, len(records))
+	for _, rec := range records {
+		rows = append(rows, parseCSVRecord(rec, cols, profile))
+	}
+	return rows, nil
+}
+
+// parseCSVRecord は CSV の1行を収支の行に変換します。
+func parseCSVRecord(rec record, cols csvColumns, profile domain.CSVImportProfile) domain.ImportRow {
+	row := domain.ImportRow{Line: rec.line}
+
+	date, err := parseDate(field(rec.fields, cols.date), profile.DateFormat)
+	if err != nil {
+		row.Error = "日付を読み込めません: " + field(rec.fields, cols.date)
+		return row
+	}
+	row.Date = date.Format("2006-01-02")
+
+	if cols.amount >= 0 {
+		amount, err := parseAmount(field(rec.fields, cols.amount))
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		positive, negative := domain.TransactionTypeIncome, domain.TransactionTypeExpense
+		if profile.AmountPositive == domain.TransactionTypeExpense {
+			positive, negative = negative, positive
+		}
+		row.Type, row.Amount = positive, amount
+		if amount < 0 {
+			row.Type, row.Amount = negative, -amount
+		}
+	} else {
+		debit, err := parseAmount(field(rec.fields, cols.debit))
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		credit, err := parseAmount(field(rec.fields, cols.credit))
+		if err != nil {
+			row.Error = err.Error()
+			return row
+		}
+		if debit != 0 && credit != 0 {
+			row.Error = "出金と入金の両方に金額があります"
+			return row
+		}
+		row.Type, row.Amount = domain.TransactionTypeExpense, debit
+		if credit != 0 {
+			row.Type, row.Amount = domain.TransactionTypeIncome, credit
+		}
+		if row.Amount < 0 {
+			row.Amount = -row.Amount
+		}
+	}
+	if row.Amount == 0 {
+		row.Error = "金額がありません"
+		return row
+	}
+
+	var memos []string
+	for _, i := range cols.memo {
+		if v := field(rec.fields, i); v != "" {
+			memos = append(memos, v)
+		}
+	}
+	row.Memo = strings.Join(memos, " ")
+	if cols.payee >= 0 {
+		row.Payee = field(rec.fields, cols.payee)
+	}
+
+	row.CategoryId = profile.ExpenseCategoryId
+	if row.Type == domain.TransactionTypeIncome {
+		row.CategoryId = profile.IncomeCategoryId
+	}
+	return row
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// csv_test.go は明細の CSV の変換（文字コード・列の対応・日付と金額の解釈）の単体テストです。
+
+func TestDecode(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("日付,金額\n"))
+	if err != nil {
+		t.Fatalf("encode: unexpected error: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		encoding string
+	}{
+		{"utf-8", []byte("日付,金額\n"), ""},
+		{"utf-8 with BOM", append([]byte{0xEF, 0xBB, 0xBF}, "日付,金額\n"...), domain.EncodingAuto},
+		{"shift_jis (auto)", sjis, ""},
+		{"shift_jis", sjis, "sjis"},
+	} {
+		text, err := Decode(tc.data, tc.encoding)
+		if err != nil {
+			t.Errorf("Decode(%s): unexpected error: %v", tc.name, err)
+			continue
+		}
+		if text != "日付,金額\n" {
+			t.Errorf("Decode(%s): unexpected text %q", tc.name, text)
+		}
+	}
+
+	if _, err := Decode(sjis, domain.EncodingUTF8); err == nil {
+		t.Error("Decode: expected error for Shift_JIS data read as UTF-8")
+	}
+	if _, err := Decode([]byte("a"), "euc-jp"); err == nil {
+		t.Error("Decode: expected error for unsupported encoding")
+	}
+}
+
+func TestParseCSV_DebitCredit(t *testing.T) {
+	// 銀行の明細: 口座名の行、見出し行、出金・入金の2列
+	data := strings.Join([]string{
+		"普通預金 1234567",
+		"取引日,出金金額,入金金額,摘要,摘要内容",
+		`2025/8/1,"1,200",,カード,ＡＭＡＺＯＮ`,
+		"2025/08/25,,\"250,000\",給与,",
+		"2025/08/26,100,100,誤り,",
+		"合計,1300,250000,,",
+	}, "\r\n")
+	profile := domain.CSVImportProfile{
+		SkipRows: 1, HasHeader: true,
+		DateColumn: "取引日", DebitColumn: "出金金額", CreditColumn: "入金金額", MemoColumns: []string{"摘要", "摘要内容"},
+		ExpenseCategoryId: 2, IncomeCategoryId: 10,
+	}
+	rows, err := ParseCSV([]byte(data), profile)
+	if err != nil {
+		t.Fatalf("ParseCSV: unexpected error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("ParseCSV: expected 4 rows, got %+v", rows)
+	}
+	want := []domain.ImportRow{
+		{Line: 3, Date: "2025-08-01", Type: "expense", CategoryId: 2, Amount: 1200, Memo: "カード ＡＭＡＺＯＮ"},
+		{Line: 4, Date: "2025-08-25", Type: "income", CategoryId: 10, Amount: 250000, Memo: "給与"},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(rows[i], want[i]) {
+			t.Errorf("ParseCSV[%d]: expected %+v, got %+v", i, want[i], rows[i])
+		}
+	}
+	if rows[2].Line != 5 || rows[2].Error == "" || rows[3].Line != 6 || rows[3].Error == "" {
+		t.Errorf("ParseCSV: expected errors for lines 5 and 6, got %+v", rows[2:])
+	}
+}
+
+func TestParseCSV_AmountColumn(t *testing.T) {
+	// カードの明細: 見出しなし、列番号で指定、正の値が支出
+	data := "2025年8月3日,スーパー,￥３，４８０\n20250805,返品,△500\n"
+	profile := domain.CSVImportProfile{
+		DateColumn: "1", AmountColumn: "3", AmountPositive: "expense", MemoColumns: []string{"2"},
+		ExpenseCategoryId: 1, IncomeCategoryId: 9,
+	}
+	rows, err := ParseCSV([]byte(data), profile)
+	if err != nil {
+		t.Fatalf("ParseCSV: unexpected error: %v", err)
+	}
+	want := []domain.ImportRow{
+		{Line: 1, Date: "2025-08-03", Type: "expense", CategoryId: 1, Amount: 3480, Memo: "スーパー"},
+		{Line: 2, Date: "2025-08-05", Type: "income", CategoryId: 9, Amount: 500, Memo: "返品"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("ParseCSV: expected %+v, got %+v", want, rows)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(rows[i], want[i]) {
+			t.Errorf("ParseCSV[%d]: expected %+v, got %+v", i, want[i], rows[i])
+		}
+	}
+
+	// 支払先は支払先の列を指定した場合だけ、その列から設定する
+	profile.PayeeColumn = "2"
+	profile.MemoColumns = nil
+	if rows, _ := ParseCSV([]byte(data), profile); rows[0].Payee != "スーパー" || rows[0].Memo != "" {
+		t.Errorf("ParseCSV(payee_column): unexpected row: %+v", rows[0])
+	}
+	profile.PayeeColumn, profile.MemoColumns = "", []string{"2"}
+
+	// 日付の形式を指定した場合はその形式だけを受け付ける
+	profile.DateFormat = "YYYY年M月D日"
+	rows, _ = ParseCSV([]byte(data), profile)
+	if rows[0].Error != "" || rows[1].Error == "" {
+		t.Errorf("ParseCSV(date_format): unexpected rows %+v", rows)
+	}
+
+	// 収入のカテゴリがない場合、収入の行はカテゴリなし（取り込み時に仕分けルールで設定する）
+	profile.DateFormat, profile.IncomeCategoryId = "", 0
+	rows, _ = ParseCSV([]byte(data), profile)
+	if rows[0].Error != "" || rows[1].Error != "" || rows[1].CategoryId != 0 {
+		t.Errorf("ParseCSV(no income category): unexpected rows %+v", rows)
+	}
+}
+
+func TestParseCSV_InvalidProfile(t *testing.T) {
+	data := []byte("日付,金額\n2025-08-01,100\n")
+	for _, profile := range []domain.CSVImportProfile{
+		{AmountColumn: "金額", HasHeader: true},                                      // 日付の列がない
+		{DateColumn: "日付", HasHeader: true},                                        // 金額の列がない
+		{DateColumn: "日付", AmountColumn: "金額", DebitColumn: "出金", HasHeader: true}, // 金額の指定が重複
+		{DateColumn: "日付", AmountColumn: "金額"},                                     // 見出しなしで列名
+		{DateColumn: "日付", AmountColumn: "残高", HasHeader: true},                    // 存在しない列
+		{DateColumn: "1", AmountColumn: "2", AmountPositive: "transfer"},           // 金額の向きが不正
+		{DateColumn: "1", AmountColumn: "2", Delimiter: ",,"},                      // 区切り文字が2文字
+	} {
+		if _, err := ParseCSV(data, profile); err == nil {
+			t.Errorf("ParseCSV(%+v): expected error", profile)
+		}
+	}
+}
@@ -0,0 +1,438 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// ledger.go は複式簿記の仕訳帳（beancount・hledger）の変換です。
+// 取引ごとに口座（Assets:・Liabilities:）とカテゴリ（Expenses:・Income:）の行を読み、収支（振替を含む）の行に変換します。
+// 口座・カテゴリは取り込み元の名前（ImportRow.SourceAccount・SourceCategory）だけを設定し、この家計簿の口座・カテゴリとの対応は呼び出し側で決めます。
+// 書き出し（exporter.NewLedgerWriter）が勘定科目名に付ける "ID-" は取り除きます。
+
+// ledgerEntry は仕訳帳の1件の取引です。
+type ledgerEntry struct {
+	line     int
+	date     string
+	memo     string
+	payee    string
+	tags     []string
+	postings []ledgerPosting
+	err      string
+}
+
+// ledgerPosting は取引の1行（勘定科目と金額）です。
+type ledgerPosting struct {
+	account   string
+	amount    int
+	hasAmount bool
+	memo      string
+}
+
+// beancountDirectives は日付に続く、取引以外の beancount の宣言です。
+var beancountDirectives = map[string]bool{
+	"open": true, "close": true, "commodity": true, "balance": true, "pad": true, "price": true,
+	"note": true, "document": true, "event": true, "query": true, "custom": true,
+}
+
+// ledgerMetadata は beancount のメタデータの行（"key: value"）です。
+var ledgerMetadata = regexp.MustCompile(`^([a-z][A-Za-z0-9_-]*):\s+(.*)$`)
+
+// ledgerIdPrefix は書き出しが勘定科目名の各階層に付ける ID です。
+var ledgerIdPrefix = regexp.MustCompile(`^\d+-`)
+
+// hledgerTag は hledger のコメントの中のタグ（"name:" または "name:value"）の始まりです。
+var hledgerTag = regexp.MustCompile(`(?:^|[\s,])([^\s,:;]+):`)
+
+// ParseLedger は beancount・hledger の仕訳帳を収支の行に変換します。形式はファイルの内容から判別します。
+//
+// 口座の行が1行で、残りがすべて費用（Expenses:）の行なら支出、すべて収益（Income:）の行なら収入とし、
+// カテゴリの行が複数ある取引は内訳にします。カテゴリの行がなく口座の行が2行の取引は振替です。
+// 資本（Equity:）の行を含む取引（開始残高など）と、それ以外の形の取引は取り込みません。
+// タグは beancount の "tags" メタデータ（","区切り）・"#タグ" と hledger のコメントのタグから読みます。
+func ParseLedger(data []byte) ([]domain.ImportRow, error) {
+	text, err := Decode(data, domain.EncodingAuto)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*ledgerEntry
+	var current *ledgerEntry
+	for i, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimRight(raw, " \t\r")
+		if line == "" {
+			current = nil
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			current = nil
+			if line[0] >= '0' && line[0] <= '9' {
+				if entry := parseLedgerHeader(i+1, line); entry != nil {
+					entries = append(entries, entry)
+					current = entry
+				}
+			}
+			// 日付で始まらない行（option・account・コメントなど）は読み飛ばす
+			continue
+		}
+		if current != nil {
+			current.addLine(strings.TrimSpace(line))
+		}
+	}
+
+	// 勘定科目の行のない日付の行は取引とみなさない
+	rows := make([]domain.ImportRow, 0, len(entries))
+	for _, entry := range entries {
+		if len(entry.postings) > 0 {
+			rows = append(rows, entry.row())
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("beancount・hledger の仕訳帳ではありません（取引が見つかりません）")
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("取り込めるのは%d件までです", MaxRows)
+	}
+	return rows, nil
+}
+
+// parseLedgerHeader は取引の1行目を読みます。取引以外の宣言（open など）の場合は nil を返します。
+func parseLedgerHeader(line int, text string) *ledgerEntry {
+	date, rest, _ := strings.Cut(text, " ")
+	date, _, _ = strings.Cut(date, "=") // hledger の2つ目の日付は使わない
+	rest = strings.TrimSpace(rest)
+	keyword, _, _ := strings.Cut(rest, " ")
+	if beancountDirectives[keyword] {
+		return nil
+	}
+
+	entry := &ledgerEntry{line: line}
+	if d, err := time.Parse("2006-1-2", strings.NewReplacer("/", "-", ".", "-").Replace(date)); err != nil {
+		entry.err = "日付を読み込めません: " + date
+	} else {
+		entry.date = d.Format("2006-01-02")
+	}
+
+	// 状態（* / !）・beancount の txn・hledger の取引コード "(...)" を読み飛ばす
+	for _, flag := range []string{"*", "!", "txn"} {
+		if rest == flag || strings.HasPrefix(rest, flag+" ") {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, flag))
+			break
+		}
+	}
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.Index(rest, ")"); end >= 0 {
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+
+	if strings.HasPrefix(rest, `"`) {
+		// beancount: "支払先" "摘要" #タグ ^リンク
+		var texts []string
+		for strings.HasPrefix(rest, `"`) {
+			s, remaining, ok := readBeancountString(rest)
+			if !ok {
+				entry.err = "文字列の引用符が閉じていません"
+				break
+			}
+			texts = append(texts, s)
+			rest = strings.TrimSpace(remaining)
+		}
+		entry.memo = joinMemo(texts...)
+		if len(texts) > 1 {
+			entry.payee = texts[0]
+		}
+		for _, field := range strings.Fields(rest) {
+			if strings.HasPrefix(field, "#") {
+				entry.tags = append(entry.tags, field[1:])
+			}
+		}
+		return entry
+	}
+
+	// hledger: 説明（支払先 | 摘要） ; コメント（タグ）
+	description, comment, _ := strings.Cut(rest, ";")
+	entry.memo = strings.TrimSpace(description)
+	if payee, note, ok := strings.Cut(entry.memo, "|"); ok {
+		entry.payee = strings.TrimSpace(payee)
+		entry.memo = joinMemo(entry.payee, strings.TrimSpace(note))
+	}
+	_, entry.tags = parseHledgerComment(comment)
+	return entry
+}
+
+// readBeancountString は s の先頭の引用符で囲んだ文字列を読み、残りを返します。
+func readBeancountString(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
+
+// parseHledgerComment は hledger のコメントをタグの前の文章とタグ名に分けます。
+func parseHledgerComment(comment string) (string, []string) {
+	loc := hledgerTag.FindStringIndex(comment)
+	if loc == nil {
+		return strings.TrimSpace(comment), nil
+	}
+	var tags []string
+	for _, part := range strings.Split(comment[loc[0]:], ",") {
+		if name, _, ok := strings.Cut(strings.TrimSpace(part), ":"); ok && name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return strings.TrimSpace(comment[:loc[0]]), tags
+}
+
+// addLine は取引の2行目以降（字下げした行）を読みます。
+func (e *ledgerEntry) addLine(text string) {
+	if strings.HasPrefix(text, ";") {
+		memo, tags := parseHledgerComment(strings.TrimLeft(text, "; "))
+		e.tags = append(e.tags, tags...)
+		if n := len(e.postings); n > 0 && memo != "" {
+			e.postings[n-1].memo = joinMemo(e.postings[n-1].memo, memo)
+		}
+		return
+	}
+	if m := ledgerMetadata.FindStringSubmatch(text); m != nil {
+		value := strings.TrimSpace(m[2])
+		if s, _, ok := readBeancountString(value); ok {
+			value = s
+		}
+		switch {
+		case m[1] == "tags":
+			for _, tag := range strings.Split(value, ",") {
+				e.tags = append(e.tags, tag)
+			}
+		case m[1] == "memo" && len(e.postings) > 0:
+			e.postings[len(e.postings)-1].memo = value
+		}
+		return
+	}
+
+	// 勘定科目と金額の行。状態（* / !）と hledger の仮想の行の括弧は読み飛ばす
+	text = strings.TrimSpace(strings.TrimLeft(text, "*! "))
+	text, comment, _ := strings.Cut(text, ";")
+	text = strings.TrimSpace(text)
+	memo, tags := parseHledgerComment(comment)
+	e.tags = append(e.tags, tags...)
+
+	account, amount := splitLedgerPosting(text)
+	account = strings.Trim(account, "()[]")
+	p := ledgerPosting{account: account, memo: memo}
+	if amount != "" {
+		n, err := parseLedgerAmount(amount)
+		if err != nil && e.err == "" {
+			e.err = err.Error()
+		}
+		p.amount, p.hasAmount = n, true
+	}
+	e.postings = append(e.postings, p)
+}
+
+// splitLedgerPosting は行を勘定科目と金額に分けます。hledger の勘定科目は空白を含むことがあるため、
+// 2つ以上続く空白かタブで区切り、見つからない場合は最初の空白のあとが金額として読めるときだけ分けます。
+func splitLedgerPosting(text string) (string, string) {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\t' || (text[i] == ' ' && i+1 < len(text) && text[i+1] == ' ') {
+			return text[:i], strings.TrimSpace(text[i:])
+		}
+	}
+	if account, amount, ok := strings.Cut(text, " "); ok {
+		if _, err := parseLedgerAmount(amount); err == nil {
+			return account, strings.TrimSpace(amount)
+		}
+	}
+	return text, ""
+}
+
+// parseLedgerAmount は "1200 JPY"・"-1,200 JPY"・"¥1200"・"JPY 1200" などの金額を読みます。
+// 価格（@）と残高の確認（=）は読み飛ばし、円以外の通貨はエラーにします。
+func parseLedgerAmount(value string) (int, error) {
+	var parts []string
+	for _, field := range strings.Fields(value) {
+		if strings.HasPrefix(field, "@") || strings.HasPrefix(field, "=") || strings.HasPrefix(field, "{") {
+			break
+		}
+		if strings.IndexFunc(field, unicode.IsLetter) >= 0 && field != "円" {
+			if field != "JPY" {
+				return 0, fmt.Errorf("円（JPY）以外の金額は取り込みません: %s", value)
+			}
+			continue
+		}
+		parts = append(parts, field)
+	}
+	n, err := parseAmount(strings.Join(parts, ""))
+	if err != nil {
+		return 0, fmt.Errorf("金額として読み込めません: %s", value)
+	}
+	return n, nil
+}
+
+// 勘定科目の種類です。
+const (
+	ledgerWallet  = "wallet"
+	ledgerExpense = "expense"
+	ledgerIncome  = "income"
+	ledgerEquity  = "equity"
+)
+
+// ledgerRoots は勘定科目の最上位の名前（小文字）と種類の対応です。
+var ledgerRoots = map[string]string{
+	"assets": ledgerWallet, "asset": ledgerWallet, "liabilities": ledgerWallet, "liability": ledgerWallet,
+	"expenses": ledgerExpense, "expense": ledgerExpense,
+	"income": ledgerIncome, "revenue": ledgerIncome, "revenues": ledgerIncome,
+	"equity": ledgerEquity,
+}
+
+// ledgerNames は勘定科目名を階層ごとに分け、書き出しが付けた "ID-" を取り除きます。
+func ledgerNames(account string) []string {
+	var names []string
+	for _, name := range strings.Split(account, ":") {
+		names = append(names, ledgerIdPrefix.ReplaceAllString(strings.TrimSpace(name), ""))
+	}
+	return names
+}
+
+// LedgerAccountName は仕訳帳の口座の勘定科目名（例: Assets:1-現金）から口座名（現金）を取り出します。
+func LedgerAccountName(account string) string {
+	names := ledgerNames(account)
+	return names[len(names)-1]
+}
+
+// LedgerNameKey は勘定科目名として書き出した名前と元の名前を比べるための形にします。
+// 書き出しは勘定科目名に使えない文字を "-" に置き換えるため、文字・数字以外をすべて "-" にします。
+func LedgerNameKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, name)
+}
+
+// ledgerCategory はカテゴリの勘定科目名（例: Expenses:1-食費:11-外食）から取り込み元のカテゴリ名（食費/外食）を作ります。
+// 3階層以上の場合は最上位と最下位の名前を使います。
+func ledgerCategory(account string) string {
+	names := ledgerNames(account)[1:]
+	if len(names) == 0 {
+		return ""
+	}
+	return sourceCategory(names[0], names[len(names)-1])
+}
+
+// row は取引を収支の行に変換します。
+func (e *ledgerEntry) row() domain.ImportRow {
+	row := domain.ImportRow{Line: e.line, Date: e.date, Memo: e.memo, Payee: e.payee}
+	if e.err != "" {
+		row.Error = e.err
+		return row
+	}
+
+	// 金額を省略した行は、ほかの行の合計と釣り合う金額にする
+	sum, missing := 0, -1
+	for i, p := range e.postings {
+		if p.hasAmount {
+			sum += p.amount
+		} else if missing >= 0 {
+			row.Error = "金額を省略した行が複数あります"
+			return row
+		} else {
+			missing = i
+		}
+	}
+	if missing >= 0 {
+		e.postings[missing].amount, sum = -sum, 0
+	}
+	if sum != 0 {
+		row.Error = fmt.Sprintf("取引の金額の合計が0になりません（%d）", sum)
+		return row
+	}
+
+	var wallets, categories []ledgerPosting
+	kinds := map[string]bool{}
+	for _, p := range e.postings {
+		root, _, _ := strings.Cut(p.account, ":")
+		kind, ok := ledgerRoots[strings.ToLower(root)]
+		if !ok {
+			row.Error = "勘定科目の種類を判別できません: " + p.account
+			return row
+		}
+		if p.amount == 0 {
+			continue
+		}
+		kinds[kind] = true
+		if kind == ledgerWallet {
+			wallets = append(wallets, p)
+		} else {
+			categories = append(categories, p)
+		}
+	}
+
+	switch {
+	case kinds[ledgerEquity]:
+		row.Error = "資本（Equity）の行がある取引（開始残高など）は取り込みません"
+	case len(categories) == 0 && len(wallets) == 2 && wallets[0].amount == -wallets[1].amount:
+		out, in := wallets[0], wallets[1]
+		if out.amount > 0 {
+			out, in = in, out
+		}
+		row.Type, row.Amount = domain.TransactionTypeTransfer, in.amount
+		row.SourceAccount, row.SourceToAccount = out.account, in.account
+	case len(wallets) != 1 || len(categories) == 0:
+		row.Error = "取り込めない形の取引です（口座の行が1行の収入・支出か、口座の行が2行の振替を取り込みます）"
+	case wallets[0].amount < 0 && !kinds[ledgerIncome]:
+		row.Type, row.Amount = domain.TransactionTypeExpense, -wallets[0].amount
+	case wallets[0].amount > 0 && !kinds[ledgerExpense]:
+		row.Type, row.Amount = domain.TransactionTypeIncome, wallets[0].amount
+	default:
+		row.Error = "収入と支出が混ざった取引（返金など）は取り込みません"
+	}
+	if row.Error != "" || row.Type == domain.TransactionTypeTransfer {
+		return row
+	}
+
+	row.SourceAccount = wallets[0].account
+	row.Tags = e.tags
+	sign := 1
+	if row.Type == domain.TransactionTypeIncome {
+		sign = -1
+	}
+	for _, p := range categories {
+		if sign*p.amount <= 0 {
+			row.Error = "収入と支出が混ざった取引（返金など）は取り込みません"
+			return row
+		}
+	}
+	if len(categories) == 1 {
+		row.SourceCategory = ledgerCategory(categories[0].account)
+		if row.Memo == "" {
+			row.Memo = categories[0].memo
+		}
+		return row
+	}
+	for _, p := range categories {
+		row.Splits = append(row.Splits, domain.ImportSplit{
+			SourceCategory: ledgerCategory(p.account),
+			Amount:         sign * p.amount,
+			Memo:           p.memo,
+		})
+	}
+	return row
+}
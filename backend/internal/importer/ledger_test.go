@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// ledger_test.go は仕訳帳（beancount・hledger）の変換の単体テストです。
+
+func TestParseLedger_Beancount(t *testing.T) {
+	data := strings.Join([]string{
+		`option "operating_currency" "JPY"`,
+		`1970-01-01 open Assets:1-現金 JPY`,
+		``,
+		`1970-01-01 * "開始残高"`,
+		`  Assets:1-現金  5000 JPY`,
+		`  Equity:Opening-Balances  -5000 JPY`,
+		``,
+		`2025-08-01 * "スーパー" "夕飯の買い物" #旅行`,
+		`  tags: "子ども,旅行"`,
+		`  Expenses:1-食費:12-食材  3000 JPY`,
+		`    memo: "野菜"`,
+		`  Expenses:9-その他  1,200 JPY`,
+		`  Assets:1-現金`,
+		``,
+		`2025-08-25 txn "給与"`,
+		`  Income:10-給与  -250000 JPY`,
+		`  Assets:2-みずほ-普通  250000 JPY`,
+		``,
+		`2025-08-26 * "カード引き落とし"`,
+		`  Assets:2-みずほ-普通  -30000 JPY`,
+		`  Liabilities:3-楽天カード  30000 JPY`,
+		``,
+		`2025-08-27 * "両替"`,
+		`  Assets:1-現金  -1000 JPY`,
+		`  Assets:Wallet  7 USD`,
+	}, "\n")
+	rows, err := ParseLedger([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseLedger: unexpected error: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("ParseLedger: expected 5 rows, got %d: %+v", len(rows), rows)
+	}
+	if rows[0].Error == "" {
+		t.Errorf("ParseLedger: expected error for opening balance, got %+v", rows[0])
+	}
+	want := domain.ImportRow{
+		Line: 8, Date: "2025-08-01", Type: "expense", Amount: 4200, Memo: "スーパー 夕飯の買い物", Payee: "スーパー",
+		SourceAccount: "Assets:1-現金", Tags: []string{"旅行", "子ども", "旅行"},
+		Splits: []domain.ImportSplit{
+			{SourceCategory: "食費/食材", Amount: 3000, Memo: "野菜"},
+			{SourceCategory: "その他", Amount: 1200},
+		},
+	}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("ParseLedger: row 8 = %+v, want %+v", rows[1], want)
+	}
+	want = domain.ImportRow{Line: 15, Date: "2025-08-25", Type: "income", Amount: 250000, Memo: "給与",
+		SourceCategory: "給与", SourceAccount: "Assets:2-みずほ-普通"}
+	if !reflect.DeepEqual(rows[2], want) {
+		t.Errorf("ParseLedger: row 15 = %+v, want %+v", rows[2], want)
+	}
+	want = domain.ImportRow{Line: 19, Date: "2025-08-26", Type: "transfer", Amount: 30000, Memo: "カード引き落とし",
+		SourceAccount: "Assets:2-みずほ-普通", SourceToAccount: "Liabilities:3-楽天カード"}
+	if !reflect.DeepEqual(rows[3], want) {
+		t.Errorf("ParseLedger: row 19 = %+v, want %+v", rows[3], want)
+	}
+	if !strings.Contains(rows[4].Error, "JPY") {
+		t.Errorf("ParseLedger: expected currency error, got %+v", rows[4])
+	}
+}
+
+func TestParseLedger_Hledger(t *testing.T) {
+	data := strings.Join([]string{
+		`; hledger の仕訳帳`,
+		`account assets:cash`,
+		``,
+		`2025/08/01 * (123) ランチ  ; 仕事:, 同僚 と:`,
+		`    expenses:food:外食        ¥1,200`,
+		`    assets:cash`,
+		``,
+		`2025/08/02 ファミリーマート | コンビニ`,
+		`    expenses:日用品    500 JPY  ; 洗剤`,
+		`    liabilities:楽天カード    -500 JPY`,
+		``,
+		`2025/08/03 返品`,
+		`    expenses:日用品    -500 JPY`,
+		`    expenses:food    300 JPY`,
+		`    assets:cash    200 JPY`,
+		``,
+		`2025/08/04 合わない`,
+		`    expenses:food    300 JPY`,
+		`    assets:cash    -200 JPY`,
+	}, "\n")
+	rows, err := ParseLedger([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseLedger: unexpected error: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("ParseLedger: expected 4 rows, got %d: %+v", len(rows), rows)
+	}
+	want := domain.ImportRow{Line: 4, Date: "2025-08-01", Type: "expense", Amount: 1200, Memo: "ランチ",
+		SourceCategory: "food/外食", SourceAccount: "assets:cash", Tags: []string{"仕事", "同僚 と"}}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("ParseLedger: row 4 = %+v, want %+v", rows[0], want)
+	}
+	want = domain.ImportRow{Line: 8, Date: "2025-08-02", Type: "expense", Amount: 500, Memo: "ファミリーマート コンビニ",
+		Payee: "ファミリーマート", SourceCategory: "日用品", SourceAccount: "liabilities:楽天カード"}
+	if !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("ParseLedger: row 8 = %+v, want %+v", rows[1], want)
+	}
+	for _, row := range rows[2:] {
+		if row.Error == "" {
+			t.Errorf("ParseLedger: expected error for line %d, got %+v", row.Line, row)
+		}
+	}
+}
+
+func TestParseLedger_NoTransactions(t *testing.T) {
+	if _, err := ParseLedger([]byte("日付,金額\n2025-08-01,100\n")); err == nil {
+		t.Error("ParseLedger: expected error for a file without transactions")
+	}
+}
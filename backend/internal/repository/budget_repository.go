@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// BudgetRepository は月次予算の永続化を担当するリポジトリのインターフェースです。
+// 予算は家計簿ごとに分かれており、ForHousehold で得たリポジトリはその家計簿の予算だけを扱います。
+// コンストラクタが返すリポジトリ（ForHousehold(0) と同じ）はすべての家計簿の予算を扱います。
+type BudgetRepository interface {
+	UnownedAssigner
+	ForHousehold(householdId int) BudgetRepository
+	FindByMonth(month string) ([]domain.Budget, error)
+	FindById(id int) (domain.Budget, error)
+	Save(budget *domain.Budget) error
+	Update(budget *domain.Budget) error
+	Delete(id int) error
+}
+
+// ErrBudgetExists は同じ家計簿・カテゴリ・月の予算が既にある場合のエラーです。
+var ErrBudgetExists = errors.New("同じカテゴリ・月の予算が既に登録されています")
+
+// budgetStore はメモリ上のデータ本体で、家計簿ごとのリポジトリの間で共有します。
+type budgetStore struct {
+	mu      sync.RWMutex
+	budgets []domain.Budget
+	nextID  int
+}
+
+// budgetRepository は budgetStore のうち householdId の家計簿の予算を扱います（0 はすべての家計簿）。
+type budgetRepository struct {
+	*budgetStore
+	householdId int
+}
+
+// NewBudgetRepository はメモリベースのBudgetRepositoryを生成します。
+func NewBudgetRepository() BudgetRepository {
+	return &budgetRepository{budgetStore: &budgetStore{
+		budgets: []domain.Budget{},
+		nextID:  1,
+	}}
+}
+
+// ForHousehold は householdId の家計簿の予算だけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *budgetRepository) ForHousehold(householdId int) BudgetRepository {
+	return &budgetRepository{budgetStore: r.budgetStore, householdId: householdId}
+}
+
+// owns は予算がこのリポジトリの扱う家計簿のものかを判定します。
+func (r *budgetRepository) owns(b domain.Budget) bool {
+	return r.householdId == 0 || b.HouseholdId == r.householdId
+}
+
+// AssignUnowned は所有者のいない予算を householdId の家計簿に割り当てます。
+func (r *budgetRepository) AssignUnowned(householdId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.budgets {
+		if r.budgets[i].HouseholdId == 0 {
+			r.budgets[i].HouseholdId = householdId
+		}
+	}
+	return nil
+}
+
+// FindByMonth は指定月（"2006-01"）の予算をカテゴリID順に返します。month が空の場合は全件を返します。
+func (r *budgetRepository) FindByMonth(month string) ([]domain.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.Budget{}
+	for _, b := range r.budgets {
+		if r.owns(b) && (month == "" || b.Month == month) {
+			result = append(result, b)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Month != result[j].Month {
+			return result[i].Month < result[j].Month
+		}
+		return result[i].CategoryId < result[j].CategoryId
+	})
+	return result, nil
+}
+
+func (r *budgetRepository) FindById(id int) (domain.Budget, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, b := range r.budgets {
+		if b.ID == id && r.owns(b) {
+			return b, nil
+		}
+	}
+	return domain.Budget{}, fmt.Errorf("予算が見つかりません: %d", id)
+}
+
+func (r *budgetRepository) Save(b *domain.Budget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		b.HouseholdId = r.householdId
+	}
+	if r.existsLocked(b.HouseholdId, b.CategoryId, b.Month, 0) {
+		return ErrBudgetExists
+	}
+	b.ID = r.nextID
+	b.CreatedAt = time.Now()
+	r.nextID++
+	r.budgets = append(r.budgets, *b)
+	return nil
+}
+
+func (r *budgetRepository) Update(b *domain.Budget) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, budget := range r.budgets {
+		if budget.ID == b.ID && r.owns(budget) {
+			if r.existsLocked(budget.HouseholdId, b.CategoryId, b.Month, b.ID) {
+				return ErrBudgetExists
+			}
+			b.CreatedAt = budget.CreatedAt
+			b.HouseholdId = budget.HouseholdId
+			r.budgets[i] = *b
+			return nil
+		}
+	}
+	return fmt.Errorf("予算が見つかりません: %d", b.ID)
+}
+
+func (r *budgetRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, b := range r.budgets {
+		if b.ID == id && r.owns(b) {
+			r.budgets = append(r.budgets[:i], r.budgets[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("予算が見つかりません: %d", id)
+}
+
+// existsLocked は excludeId 以外に同じ家計簿・カテゴリ・月の予算があるかを判定します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *budgetRepository) existsLocked(householdId, categoryId int, month string, excludeId int) bool {
+	for _, b := range r.budgets {
+		if b.ID != excludeId && b.HouseholdId == householdId && b.CategoryId == categoryId && b.Month == month {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"kakeibo-app/backend/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// postgresBudgetRepository は PostgreSQL 用の BudgetRepository 実装です。
+// month 列は月初日の DATE で保持し、"2006-01" 形式と相互に変換します。
+// householdId が0でない場合は budgets.household_id がその家計簿の行だけを扱います。
+type postgresBudgetRepository struct {
+	db          *sql.DB
+	householdId int
+}
+
+// NewPostgresBudgetRepository は PostgreSQL を使う BudgetRepository を返します。
+func NewPostgresBudgetRepository(db *sql.DB) BudgetRepository {
+	return &postgresBudgetRepository{db: db}
+}
+
+// ForHousehold は householdId の家計簿の予算だけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *postgresBudgetRepository) ForHousehold(householdId int) BudgetRepository {
+	return &postgresBudgetRepository{db: r.db, householdId: householdId}
+}
+
+// AssignUnowned は所有者のいない予算を householdId の家計簿に割り当てます。
+func (r *postgresBudgetRepository) AssignUnowned(householdId int) error {
+	if _, err := r.db.ExecContext(context.Background(),
+		`UPDATE budgets SET household_id = $1 WHERE household_id IS NULL`, householdId,
+	); err != nil {
+		return fmt.Errorf("AssignUnowned: %w", err)
+	}
+	return nil
+}
+
+// FindByMonth は指定月（"2006-01"）の予算をカテゴリID順に返します。month が空の場合は全件を返します。
+func (r *postgresBudgetRepository) FindByMonth(month string) ([]domain.Budget, error) {
+	rows, err := r.db.QueryContext(context.Background(), `
+		SELECT id, category_id, to_char(month, 'YYYY-MM'), amount, created_at, COALESCE(household_id, 0)
+		FROM budgets
+		WHERE ($1 = '' OR month = to_date($1, 'YYYY-MM')) AND ($2 = 0 OR household_id = $2)
+		ORDER BY month, category_id
+	`, month, r.householdId)
+	if err != nil {
+		return nil, fmt.Errorf("FindByMonth: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.Budget{}
+	for rows.Next() {
+		var b domain.Budget
+		if err := rows.Scan(&b.ID, &b.CategoryId, &b.Month, &b.Amount, &b.CreatedAt, &b.HouseholdId); err != nil {
+			return nil, fmt.Errorf("FindByMonth scan: %w", err)
+		}
+		result = append(result, b)
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresBudgetRepository) FindById(id int) (domain.Budget, error) {
+	var b domain.Budget
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT id, category_id, to_char(month, 'YYYY-MM'), amount, created_at, COALESCE(household_id, 0)
+		FROM budgets WHERE id = $1 AND ($2 = 0 OR household_id = $2)
+	`, id, r.householdId).Scan(&b.ID, &b.CategoryId, &b.Month, &b.Amount, &b.CreatedAt, &b.HouseholdId)
+	if err == sql.ErrNoRows {
+		return domain.Budget{}, fmt.Errorf("予算が見つかりません: %d", id)
+	}
+	if err != nil {
+		return domain.Budget{}, fmt.Errorf("FindById: %w", err)
+	}
+	return b, nil
+}
+
+func (r *postgresBudgetRepository) Save(b *domain.Budget) error {
+	if r.householdId != 0 {
+		b.HouseholdId = r.householdId
+	}
+	err := r.db.QueryRowContext(context.Background(), `
+		INSERT INTO budgets (category_id, month, amount, household_id)
+		VALUES ($1, to_date($2, 'YYYY-MM'), $3, NULLIF($4, 0))
+		RETURNING id, created_at
+	`, b.CategoryId, b.Month, b.Amount, b.HouseholdId).Scan(&b.ID, &b.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrBudgetExists
+	}
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresBudgetRepository) Update(b *domain.Budget) error {
+	err := r.db.QueryRowContext(context.Background(), `
+		UPDATE budgets
+		SET category_id = $1, month = to_date($2, 'YYYY-MM'), amount = $3
+		WHERE id = $4 AND ($5 = 0 OR household_id = $5)
+		RETURNING created_at, COALESCE(household_id, 0)
+	`, b.CategoryId, b.Month, b.Amount, b.ID, r.householdId).Scan(&b.CreatedAt, &b.HouseholdId)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("予算が見つかりません: %d", b.ID)
+	}
+	if isUniqueViolation(err) {
+		return ErrBudgetExists
+	}
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresBudgetRepository) Delete(id int) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM budgets WHERE id = $1 AND ($2 = 0 OR household_id = $2)`, id, r.householdId)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("予算が見つかりません: %d", id)
+	}
+	return nil
+}
+
+// isUniqueViolation は err が一意制約違反（SQLSTATE 23505）かを判定します。
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// budget_repository_test.go は BudgetRepository の単体テストです。
+// メモリベースのリポジトリの CRUD 操作を検証します。
+
+func TestBudgetRepository_FindByMonth(t *testing.T) {
+	repo := NewBudgetRepository()
+
+	for _, b := range []domain.Budget{
+		{CategoryId: 2, Month: "2025-01", Amount: 10000},
+		{CategoryId: 1, Month: "2025-01", Amount: 40000},
+		{CategoryId: 1, Month: "2025-02", Amount: 45000},
+	} {
+		if err := repo.Save(&b); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	jan, err := repo.FindByMonth("2025-01")
+	if err != nil {
+		t.Fatalf("FindByMonth: unexpected error: %v", err)
+	}
+	if len(jan) != 2 || jan[0].CategoryId != 1 || jan[1].CategoryId != 2 {
+		t.Errorf("FindByMonth: expected 2 budgets ordered by category, got %+v", jan)
+	}
+
+	all, err := repo.FindByMonth("")
+	if err != nil {
+		t.Fatalf("FindByMonth: unexpected error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("FindByMonth: expected 3 budgets, got %d", len(all))
+	}
+}
+
+func TestBudgetRepository_Save(t *testing.T) {
+	repo := NewBudgetRepository()
+
+	b := &domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 40000}
+	if err := repo.Save(b); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if b.ID != 1 || b.CreatedAt.IsZero() {
+		t.Errorf("Save: expected ID=1 and CreatedAt set, got %+v", b)
+	}
+
+	// 同じカテゴリ・月は重複エラー
+	dup := &domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 30000}
+	if err := repo.Save(dup); !errors.Is(err, ErrBudgetExists) {
+		t.Errorf("Save: expected ErrBudgetExists, got %v", err)
+	}
+}
+
+func TestBudgetRepository_Update(t *testing.T) {
+	repo := NewBudgetRepository()
+
+	a := &domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 40000}
+	b := &domain.Budget{CategoryId: 2, Month: "2025-01", Amount: 10000}
+	_ = repo.Save(a)
+	_ = repo.Save(b)
+
+	a.Amount = 50000
+	if err := repo.Update(a); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	found, _ := repo.FindById(a.ID)
+	if found.Amount != 50000 {
+		t.Errorf("Update: expected Amount=50000, got %d", found.Amount)
+	}
+
+	// 他の予算と同じカテゴリ・月にはできない
+	b.CategoryId = 1
+	if err := repo.Update(b); !errors.Is(err, ErrBudgetExists) {
+		t.Errorf("Update: expected ErrBudgetExists, got %v", err)
+	}
+
+	// 存在しないID
+	if err := repo.Update(&domain.Budget{ID: 999, CategoryId: 3, Month: "2025-01", Amount: 1}); err == nil {
+		t.Error("Update: expected error for non-existent ID")
+	}
+}
+
+func TestBudgetRepository_Delete(t *testing.T) {
+	repo := NewBudgetRepository()
+
+	b := &domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 40000}
+	_ = repo.Save(b)
+
+	if err := repo.Delete(b.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := repo.FindById(b.ID); err == nil {
+		t.Error("Delete: budget should not exist after delete")
+	}
+	if err := repo.Delete(999); err == nil {
+		t.Error("Delete: expected error for non-existent ID")
+	}
+}
+
+func TestBudgetRepository_ForHousehold(t *testing.T) {
+	repo := NewBudgetRepository()
+	alice, bob := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	// 同じカテゴリ・月でも家計簿が違えば登録できる
+	for _, r := range []BudgetRepository{alice, bob} {
+		if err := r.Save(&domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 30000}); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	if err := alice.Save(&domain.Budget{CategoryId: 1, Month: "2025-01", Amount: 10000}); !errors.Is(err, ErrBudgetExists) {
+		t.Errorf("Save: expected ErrBudgetExists, got %v", err)
+	}
+
+	budgets, _ := alice.FindByMonth("2025-01")
+	if len(budgets) != 1 || budgets[0].HouseholdId != 1 {
+		t.Errorf("FindByMonth: expected only household 1's budget, got %+v", budgets)
+	}
+	if err := alice.Delete(2); err == nil {
+		t.Error("Delete: expected error for another household's budget")
+	}
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// HouseholdRepository は家計簿・メンバー・招待の永続化を担当するリポジトリのインターフェースです。
+// メンバーの Email・Name は保持しないため、必要な場合は呼び出し側でユーザーから補います。
+type HouseholdRepository interface {
+	FindById(id int) (domain.Household, error)
+	FindByUser(userId int) ([]domain.HouseholdMembership, error)
+	Count() (int, error)
+	Save(household *domain.Household, ownerId int) error
+	Update(household *domain.Household) error
+	FindMember(householdId, userId int) (domain.HouseholdMember, error)
+	FindMembers(householdId int) ([]domain.HouseholdMember, error)
+	UpdateMemberRole(householdId, userId int, role string) error
+	RemoveMember(householdId, userId int) error
+	FindInvitations(householdId int) ([]domain.Invitation, error)
+	FindInvitationByToken(tokenHash string, now time.Time) (domain.Invitation, error)
+	SaveInvitation(invitation *domain.Invitation) error
+	DeleteInvitation(householdId, id int) error
+	AcceptInvitation(invitation domain.Invitation, userId int) (domain.HouseholdMember, error)
+}
+
+// ErrNotMember はユーザーが家計簿のメンバーではない場合のエラーです。
+var ErrNotMember = errors.New("この家計簿のメンバーではありません")
+
+// ErrAlreadyMember は既にメンバーのユーザーが招待を受けようとした場合のエラーです。
+var ErrAlreadyMember = errors.New("既にこの家計簿のメンバーです")
+
+// ErrLastOwner は最後の所有者を外したり、所有者以外の役割に変えようとした場合のエラーです。
+var ErrLastOwner = errors.New("家計簿には所有者が1人以上必要です")
+
+// ErrInvitationNotFound は招待が存在しないか有効期限切れの場合のエラーです。
+var ErrInvitationNotFound = errors.New("招待が見つからないか、有効期限が切れています")
+
+type householdRepository struct {
+	mu               sync.RWMutex
+	households       []domain.Household
+	members          []domain.HouseholdMember
+	invitations      []domain.Invitation
+	nextID           int
+	nextInvitationID int
+}
+
+// NewHouseholdRepository はメモリベースのHouseholdRepositoryを生成します。
+func NewHouseholdRepository() HouseholdRepository {
+	return &householdRepository{
+		households:       []domain.Household{},
+		members:          []domain.HouseholdMember{},
+		invitations:      []domain.Invitation{},
+		nextID:           1,
+		nextInvitationID: 1,
+	}
+}
+
+func (r *householdRepository) FindById(id int) (domain.Household, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, h := range r.households {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return domain.Household{}, fmt.Errorf("家計簿が見つかりません: %d", id)
+}
+
+// FindByUser はユーザーが参加している家計簿を、参加した順に役割付きで返します。
+func (r *householdRepository) FindByUser(userId int) ([]domain.HouseholdMembership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.HouseholdMembership{}
+	for _, m := range r.members {
+		if m.UserId != userId {
+			continue
+		}
+		for _, h := range r.households {
+			if h.ID == m.HouseholdId {
+				result = append(result, domain.HouseholdMembership{Household: h, Role: m.Role})
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *householdRepository) Count() (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.households), nil
+}
+
+// Save は家計簿を新規作成し、ownerId のユーザーを所有者として登録します。
+func (r *householdRepository) Save(h *domain.Household, ownerId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	h.ID = r.nextID
+	h.CreatedAt = now
+	r.nextID++
+	r.households = append(r.households, *h)
+	r.members = append(r.members, domain.HouseholdMember{
+		HouseholdId: h.ID, UserId: ownerId, Role: domain.RoleOwner, JoinedAt: now,
+	})
+	return nil
+}
+
+// Update は家計簿の名前を更新します。
+func (r *householdRepository) Update(h *domain.Household) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, household := range r.households {
+		if household.ID == h.ID {
+			r.households[i].Name = h.Name
+			h.CreatedAt = household.CreatedAt
+			return nil
+		}
+	}
+	return fmt.Errorf("家計簿が見つかりません: %d", h.ID)
+}
+
+// FindMember はメンバーを返します。メンバーでない場合は ErrNotMember を返します。
+func (r *householdRepository) FindMember(householdId, userId int) (domain.HouseholdMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.memberIndexLocked(householdId, userId); i >= 0 {
+		return r.members[i], nil
+	}
+	return domain.HouseholdMember{}, ErrNotMember
+}
+
+// FindMembers は家計簿のメンバーを参加した順に返します。
+func (r *householdRepository) FindMembers(householdId int) ([]domain.HouseholdMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.HouseholdMember{}
+	for _, m := range r.members {
+		if m.HouseholdId == householdId {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// UpdateMemberRole はメンバーの役割を変更します。
+// 最後の所有者を所有者以外にしようとした場合は ErrLastOwner を返します。
+func (r *householdRepository) UpdateMemberRole(householdId, userId int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.memberIndexLocked(householdId, userId)
+	if i < 0 {
+		return ErrNotMember
+	}
+	if role != domain.RoleOwner && r.isLastOwnerLocked(r.members[i]) {
+		return ErrLastOwner
+	}
+	r.members[i].Role = role
+	return nil
+}
+
+// RemoveMember はメンバーを家計簿から外します。最後の所有者の場合は ErrLastOwner を返します。
+func (r *householdRepository) RemoveMember(householdId, userId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.memberIndexLocked(householdId, userId)
+	if i < 0 {
+		return ErrNotMember
+	}
+	if r.isLastOwnerLocked(r.members[i]) {
+		return ErrLastOwner
+	}
+	r.members = append(r.members[:i], r.members[i+1:]...)
+	return nil
+}
+
+// memberIndexLocked はメンバーのスライス上の位置を返します（見つからない場合は -1）。
+// 呼び出し側でロックを取得している必要があります。
+func (r *householdRepository) memberIndexLocked(householdId, userId int) int {
+	for i, m := range r.members {
+		if m.HouseholdId == householdId && m.UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
+
+// isLastOwnerLocked は m が家計簿のただ1人の所有者かを判定します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *householdRepository) isLastOwnerLocked(m domain.HouseholdMember) bool {
+	if m.Role != domain.RoleOwner {
+		return false
+	}
+	for _, other := range r.members {
+		if other.HouseholdId == m.HouseholdId && other.UserId != m.UserId && other.Role == domain.RoleOwner {
+			return false
+		}
+	}
+	return true
+}
+
+// FindInvitations は家計簿への招待を作成順に返します。
+func (r *householdRepository) FindInvitations(householdId int) ([]domain.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.Invitation{}
+	for _, inv := range r.invitations {
+		if inv.HouseholdId == householdId {
+			result = append(result, inv)
+		}
+	}
+	return result, nil
+}
+
+// FindInvitationByToken は now の時点で有効な招待を返します。
+// 存在しないか有効期限が切れている場合は ErrInvitationNotFound を返します。
+func (r *householdRepository) FindInvitationByToken(tokenHash string, now time.Time) (domain.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, inv := range r.invitations {
+		if inv.TokenHash == tokenHash && now.Before(inv.ExpiresAt) {
+			return inv, nil
+		}
+	}
+	return domain.Invitation{}, ErrInvitationNotFound
+}
+
+// SaveInvitation は招待を保存します。
+func (r *householdRepository) SaveInvitation(inv *domain.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inv.ID = r.nextInvitationID
+	inv.CreatedAt = time.Now()
+	r.nextInvitationID++
+	saved := *inv
+	saved.Token = ""
+	r.invitations = append(r.invitations, saved)
+	return nil
+}
+
+// DeleteInvitation は家計簿への招待を取り消します。
+func (r *householdRepository) DeleteInvitation(householdId, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, inv := range r.invitations {
+		if inv.ID == id && inv.HouseholdId == householdId {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("招待が見つかりません: %d", id)
+}
+
+// AcceptInvitation は招待の役割で userId のユーザーをメンバーに加え、招待を削除します。
+// 既にメンバーの場合は招待だけを削除して ErrAlreadyMember を返します。
+func (r *householdRepository) AcceptInvitation(inv domain.Invitation, userId int) (domain.HouseholdMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, saved := range r.invitations {
+		if saved.ID == inv.ID {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			break
+		}
+	}
+	if r.memberIndexLocked(inv.HouseholdId, userId) >= 0 {
+		return domain.HouseholdMember{}, ErrAlreadyMember
+	}
+	member := domain.HouseholdMember{HouseholdId: inv.HouseholdId, UserId: userId, Role: inv.Role, JoinedAt: time.Now()}
+	r.members = append(r.members, member)
+	return member, nil
+}
@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// postgresHouseholdRepository は PostgreSQL 用の HouseholdRepository 実装です。
+type postgresHouseholdRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresHouseholdRepository は PostgreSQL を使う HouseholdRepository を返します。
+func NewPostgresHouseholdRepository(db *sql.DB) HouseholdRepository {
+	return &postgresHouseholdRepository{db: db}
+}
+
+func (r *postgresHouseholdRepository) FindById(id int) (domain.Household, error) {
+	var h domain.Household
+	err := r.db.QueryRowContext(context.Background(),
+		`SELECT id, name, created_at FROM households WHERE id = $1`, id,
+	).Scan(&h.ID, &h.Name, &h.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.Household{}, fmt.Errorf("家計簿が見つかりません: %d", id)
+	}
+	if err != nil {
+		return domain.Household{}, fmt.Errorf("FindById: %w", err)
+	}
+	return h, nil
+}
+
+// FindByUser はユーザーが参加している家計簿を、参加した順に役割付きで返します。
+func (r *postgresHouseholdRepository) FindByUser(userId int) ([]domain.HouseholdMembership, error) {
+	rows, err := r.db.QueryContext(context.Background(), `
+		SELECT h.id, h.name, h.created_at, m.role
+		FROM household_members m
+		JOIN households h ON h.id = m.household_id
+		WHERE m.user_id = $1
+		ORDER BY m.joined_at, h.id
+	`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("FindByUser: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.HouseholdMembership{}
+	for rows.Next() {
+		var m domain.HouseholdMembership
+		if err := rows.Scan(&m.ID, &m.Name, &m.CreatedAt, &m.Role); err != nil {
+			return nil, fmt.Errorf("FindByUser scan: %w", err)
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresHouseholdRepository) Count() (int, error) {
+	var n int
+	if err := r.db.QueryRowContext(context.Background(), `SELECT COUNT(*) FROM households`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("Count: %w", err)
+	}
+	return n, nil
+}
+
+// Save は家計簿を新規作成し、ownerId のユーザーを所有者として登録します。
+func (r *postgresHouseholdRepository) Save(h *domain.Household, ownerId int) error {
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO households (name) VALUES ($1) RETURNING id, created_at`, h.Name,
+	).Scan(&h.ID, &h.CreatedAt); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)`,
+		h.ID, ownerId, domain.RoleOwner,
+	); err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Save commit: %w", err)
+	}
+	return nil
+}
+
+// Update は家計簿の名前を更新します。
+func (r *postgresHouseholdRepository) Update(h *domain.Household) error {
+	err := r.db.QueryRowContext(context.Background(),
+		`UPDATE households SET name = $1 WHERE id = $2 RETURNING created_at`, h.Name, h.ID,
+	).Scan(&h.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("家計簿が見つかりません: %d", h.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	return nil
+}
+
+// FindMember はメンバーを返します。メンバーでない場合は ErrNotMember を返します。
+func (r *postgresHouseholdRepository) FindMember(householdId, userId int) (domain.HouseholdMember, error) {
+	m := domain.HouseholdMember{HouseholdId: householdId, UserId: userId}
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT role, joined_at FROM household_members WHERE household_id = $1 AND user_id = $2
+	`, householdId, userId).Scan(&m.Role, &m.JoinedAt)
+	if err == sql.ErrNoRows {
+		return domain.HouseholdMember{}, ErrNotMember
+	}
+	if err != nil {
+		return domain.HouseholdMember{}, fmt.Errorf("FindMember: %w", err)
+	}
+	return m, nil
+}
+
+// FindMembers は家計簿のメンバーを参加した順に返します。
+func (r *postgresHouseholdRepository) FindMembers(householdId int) ([]domain.HouseholdMember, error) {
+	rows, err := r.db.QueryContext(context.Background(), `
+		SELECT household_id, user_id, role, joined_at FROM household_members
+		WHERE household_id = $1 ORDER BY joined_at, user_id
+	`, householdId)
+	if err != nil {
+		return nil, fmt.Errorf("FindMembers: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.HouseholdMember{}
+	for rows.Next() {
+		var m domain.HouseholdMember
+		if err := rows.Scan(&m.HouseholdId, &m.UserId, &m.Role, &m.JoinedAt); err != nil {
+			return nil, fmt.Errorf("FindMembers scan: %w", err)
+		}
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// UpdateMemberRole はメンバーの役割を変更します。
+// 最後の所有者を所有者以外にしようとした場合は ErrLastOwner を返します。
+func (r *postgresHouseholdRepository) UpdateMemberRole(householdId, userId int, role string) error {
+	return r.changeMember(householdId, userId, role != domain.RoleOwner, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE household_members SET role = $1 WHERE household_id = $2 AND user_id = $3`, role, householdId, userId)
+		return err
+	})
+}
+
+// RemoveMember はメンバーを家計簿から外します。最後の所有者の場合は ErrLastOwner を返します。
+func (r *postgresHouseholdRepository) RemoveMember(householdId, userId int) error {
+	return r.changeMember(householdId, userId, true, func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`DELETE FROM household_members WHERE household_id = $1 AND user_id = $2`, householdId, userId)
+		return err
+	})
+}
+
+// changeMember はメンバーの行をロックして change を実行します。
+// checkLastOwner が true で、メンバーがただ1人の所有者の場合は ErrLastOwner を返します。
+func (r *postgresHouseholdRepository) changeMember(householdId, userId int, checkLastOwner bool, change func(context.Context, *sql.Tx) error) error {
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("changeMember: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 同じ家計簿のメンバー変更を直列にするため、家計簿の行をロックする
+	if _, err := tx.ExecContext(ctx, `SELECT id FROM households WHERE id = $1 FOR UPDATE`, householdId); err != nil {
+		return fmt.Errorf("changeMember: %w", err)
+	}
+	var role string
+	err = tx.QueryRowContext(ctx,
+		`SELECT role FROM household_members WHERE household_id = $1 AND user_id = $2`, householdId, userId,
+	).Scan(&role)
+	if err == sql.ErrNoRows {
+		return ErrNotMember
+	}
+	if err != nil {
+		return fmt.Errorf("changeMember: %w", err)
+	}
+	if checkLastOwner && role == domain.RoleOwner {
+		var owners int
+		if err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM household_members WHERE household_id = $1 AND role = $2`, householdId, domain.RoleOwner,
+		).Scan(&owners); err != nil {
+			return fmt.Errorf("changeMember: %w", err)
+		}
+		if owners <= 1 {
+			return ErrLastOwner
+		}
+	}
+	if err := change(ctx, tx); err != nil {
+		return fmt.Errorf("changeMember: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("changeMember commit: %w", err)
+	}
+	return nil
+}
+
+// selectInvitations は招待を取得する SELECT 文です。scanInvitation と列の並びを合わせます。
+const selectInvitations = `
+		SELECT id, household_id, email, role, token_hash, invited_by, expires_at, created_at
+		FROM household_invitations`
+
+func scanInvitation(row rowScanner) (domain.Invitation, error) {
+	var inv domain.Invitation
+	err := row.Scan(&inv.ID, &inv.HouseholdId, &inv.Email, &inv.Role, &inv.TokenHash, &inv.InvitedBy, &inv.ExpiresAt, &inv.CreatedAt)
+	return inv, err
+}
+
+// FindInvitations は家計簿への招待を作成順に返します。
+func (r *postgresHouseholdRepository) FindInvitations(householdId int) ([]domain.Invitation, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		selectInvitations+` WHERE household_id = $1 ORDER BY id`, householdId)
+	if err != nil {
+		return nil, fmt.Errorf("FindInvitations: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindInvitations scan: %w", err)
+		}
+		result = append(result, inv)
+	}
+	return result, rows.Err()
+}
+
+// FindInvitationByToken は now の時点で有効な招待を返します。
+// 存在しないか有効期限が切れている場合は ErrInvitationNotFound を返します。
+func (r *postgresHouseholdRepository) FindInvitationByToken(tokenHash string, now time.Time) (domain.Invitation, error) {
+	inv, err := scanInvitation(r.db.QueryRowContext(context.Background(),
+		selectInvitations+` WHERE token_hash = $1 AND expires_at > $2`, tokenHash, now))
+	if err == sql.ErrNoRows {
+		return domain.Invitation{}, ErrInvitationNotFound
+	}
+	if err != nil {
+		return domain.Invitation{}, fmt.Errorf("FindInvitationByToken: %w", err)
+	}
+	return inv, nil
+}
+
+// SaveInvitation は招待を保存します。
+func (r *postgresHouseholdRepository) SaveInvitation(inv *domain.Invitation) error {
+	err := r.db.QueryRowContext(context.Background(), `
+		INSERT INTO household_invitations (household_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, inv.HouseholdId, inv.Email, inv.Role, inv.TokenHash, inv.InvitedBy, inv.ExpiresAt).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("SaveInvitation: %w", err)
+	}
+	return nil
+}
+
+// DeleteInvitation は家計簿への招待を取り消します。
+func (r *postgresHouseholdRepository) DeleteInvitation(householdId, id int) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM household_invitations WHERE id = $1 AND household_id = $2`, id, householdId)
+	if err != nil {
+		return fmt.Errorf("DeleteInvitation: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("招待が見つかりません: %d", id)
+	}
+	return nil
+}
+
+// AcceptInvitation は招待の役割で userId のユーザーをメンバーに加え、招待を削除します。
+// 既にメンバーの場合は招待だけを削除して ErrAlreadyMember を返します。
+func (r *postgresHouseholdRepository) AcceptInvitation(inv domain.Invitation, userId int) (domain.HouseholdMember, error) {
+	ctx := context.Background()
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return domain.HouseholdMember{}, fmt.Errorf("AcceptInvitation: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM household_invitations WHERE id = $1`, inv.ID); err != nil {
+		return domain.HouseholdMember{}, fmt.Errorf("AcceptInvitation: %w", err)
+	}
+	member := domain.HouseholdMember{HouseholdId: inv.HouseholdId, UserId: userId, Role: inv.Role}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO household_members (household_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (household_id, user_id) DO NOTHING
+		RETURNING joined_at
+	`, inv.HouseholdId, userId, inv.Role).Scan(&member.JoinedAt)
+	alreadyMember := err == sql.ErrNoRows
+	if err != nil && !alreadyMember {
+		return domain.HouseholdMember{}, fmt.Errorf("AcceptInvitation: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return domain.HouseholdMember{}, fmt.Errorf("AcceptInvitation commit: %w", err)
+	}
+	if alreadyMember {
+		return domain.HouseholdMember{}, ErrAlreadyMember
+	}
+	return member, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// household_repository_test.go は HouseholdRepository の単体テストです。
+// メモリベースのリポジトリのメンバー管理と招待の有効期限を検証します。
+
+func TestHouseholdRepository_Members(t *testing.T) {
+	repo := NewHouseholdRepository()
+
+	household := &domain.Household{Name: "太郎の家計簿"}
+	if err := repo.Save(household, 1); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if household.ID != 1 || household.CreatedAt.IsZero() {
+		t.Errorf("Save: expected ID=1 and CreatedAt set, got %+v", household)
+	}
+
+	// 作成したユーザーが所有者になる
+	owner, err := repo.FindMember(household.ID, 1)
+	if err != nil || owner.Role != domain.RoleOwner {
+		t.Errorf("FindMember: expected owner, got %+v (err=%v)", owner, err)
+	}
+	if _, err := repo.FindMember(household.ID, 2); !errors.Is(err, ErrNotMember) {
+		t.Errorf("FindMember: expected ErrNotMember, got %v", err)
+	}
+
+	// 最後の所有者は所有者以外にできず、外せない
+	if err := repo.UpdateMemberRole(household.ID, 1, domain.RoleEditor); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("UpdateMemberRole: expected ErrLastOwner, got %v", err)
+	}
+	if err := repo.RemoveMember(household.ID, 1); !errors.Is(err, ErrLastOwner) {
+		t.Errorf("RemoveMember: expected ErrLastOwner, got %v", err)
+	}
+
+	// 所有者が2人いれば片方は役割を変えられる
+	inv := &domain.Invitation{HouseholdId: household.ID, Email: "hanako@example.com", Role: domain.RoleEditor, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.SaveInvitation(inv); err != nil {
+		t.Fatalf("SaveInvitation: unexpected error: %v", err)
+	}
+	if _, err := repo.AcceptInvitation(*inv, 2); err != nil {
+		t.Fatalf("AcceptInvitation: unexpected error: %v", err)
+	}
+	if err := repo.UpdateMemberRole(household.ID, 2, domain.RoleOwner); err != nil {
+		t.Fatalf("UpdateMemberRole: unexpected error: %v", err)
+	}
+	if err := repo.UpdateMemberRole(household.ID, 1, domain.RoleViewer); err != nil {
+		t.Errorf("UpdateMemberRole: unexpected error with another owner: %v", err)
+	}
+
+	memberships, _ := repo.FindByUser(2)
+	if len(memberships) != 1 || memberships[0].ID != household.ID || memberships[0].Role != domain.RoleOwner {
+		t.Errorf("FindByUser: expected owner of household %d, got %+v", household.ID, memberships)
+	}
+	if err := repo.RemoveMember(household.ID, 1); err != nil {
+		t.Fatalf("RemoveMember: unexpected error: %v", err)
+	}
+	if members, _ := repo.FindMembers(household.ID); len(members) != 1 || members[0].UserId != 2 {
+		t.Errorf("FindMembers: expected only user 2, got %+v", members)
+	}
+}
+
+func TestHouseholdRepository_Invitations(t *testing.T) {
+	repo := NewHouseholdRepository()
+	household := &domain.Household{Name: "共有の家計簿"}
+	if err := repo.Save(household, 1); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	now := time.Now()
+
+	inv := &domain.Invitation{
+		HouseholdId: household.ID, Email: "hanako@example.com", Role: domain.RoleViewer,
+		InvitedBy: 1, ExpiresAt: now.Add(time.Hour), Token: "token", TokenHash: "hash",
+	}
+	if err := repo.SaveInvitation(inv); err != nil {
+		t.Fatalf("SaveInvitation: unexpected error: %v", err)
+	}
+	// トークンそのものは保存しない
+	if invitations, _ := repo.FindInvitations(household.ID); len(invitations) != 1 || invitations[0].Token != "" {
+		t.Errorf("FindInvitations: expected one invitation without token, got %+v", invitations)
+	}
+
+	// 有効期限を過ぎた招待は見つからない
+	if _, err := repo.FindInvitationByToken("hash", now.Add(2*time.Hour)); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("FindInvitationByToken: expected ErrInvitationNotFound after expiry, got %v", err)
+	}
+	found, err := repo.FindInvitationByToken("hash", now)
+	if err != nil || found.ID != inv.ID {
+		t.Fatalf("FindInvitationByToken: expected invitation %d, got %+v (err=%v)", inv.ID, found, err)
+	}
+
+	// 招待を受けると招待の役割でメンバーになり、招待は使えなくなる
+	member, err := repo.AcceptInvitation(found, 2)
+	if err != nil || member.Role != domain.RoleViewer {
+		t.Errorf("AcceptInvitation: expected viewer, got %+v (err=%v)", member, err)
+	}
+	if _, err := repo.FindInvitationByToken("hash", now); !errors.Is(err, ErrInvitationNotFound) {
+		t.Errorf("FindInvitationByToken: expected ErrInvitationNotFound after accept, got %v", err)
+	}
+
+	// 既にメンバーのユーザーは招待を受けられない
+	again := &domain.Invitation{HouseholdId: household.ID, Email: "taro@example.com", Role: domain.RoleEditor, ExpiresAt: now.Add(time.Hour)}
+	if err := repo.SaveInvitation(again); err != nil {
+		t.Fatalf("SaveInvitation: unexpected error: %v", err)
+	}
+	if _, err := repo.AcceptInvitation(*again, 1); !errors.Is(err, ErrAlreadyMember) {
+		t.Errorf("AcceptInvitation: expected ErrAlreadyMember, got %v", err)
+	}
+	if err := repo.DeleteInvitation(household.ID, again.ID); err == nil {
+		t.Error("DeleteInvitation: expected error for an accepted invitation")
+	}
+}
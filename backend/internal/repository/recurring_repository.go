@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// RecurringRuleRepository は定期収支ルールの永続化を担当するリポジトリのインターフェースです。
+// ルールは家計簿ごとに分かれており、ForHousehold で得たリポジトリはその家計簿のルールだけを扱います。
+// コンストラクタが返すリポジトリ（ForHousehold(0) と同じ）はすべての家計簿のルールを扱い、定期収支の自動登録に使います。
+type RecurringRuleRepository interface {
+	UnownedAssigner
+	ForHousehold(householdId int) RecurringRuleRepository
+	FindAll() ([]domain.RecurringRule, error)
+	FindById(id int) (domain.RecurringRule, error)
+	Save(rule *domain.RecurringRule) error
+	Update(rule *domain.RecurringRule) error
+	UpdatePostedThrough(id int, postedThrough time.Time) error
+	Delete(id int) error
+}
+
+// recurringRuleStore はメモリ上のデータ本体で、家計簿ごとのリポジトリの間で共有します。
+type recurringRuleStore struct {
+	mu     sync.RWMutex
+	rules  []domain.RecurringRule
+	nextID int
+}
+
+// recurringRuleRepository は recurringRuleStore のうち householdId の家計簿のルールを扱います（0 はすべての家計簿）。
+type recurringRuleRepository struct {
+	*recurringRuleStore
+	householdId int
+}
+
+// NewRecurringRuleRepository はメモリベースのRecurringRuleRepositoryを生成します。
+func NewRecurringRuleRepository() RecurringRuleRepository {
+	return &recurringRuleRepository{recurringRuleStore: &recurringRuleStore{
+		rules:  []domain.RecurringRule{},
+		nextID: 1,
+	}}
+}
+
+// ForHousehold は householdId の家計簿のルールだけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *recurringRuleRepository) ForHousehold(householdId int) RecurringRuleRepository {
+	return &recurringRuleRepository{recurringRuleStore: r.recurringRuleStore, householdId: householdId}
+}
+
+// owns はルールがこのリポジトリの扱う家計簿のものかを判定します。
+func (r *recurringRuleRepository) owns(rule domain.RecurringRule) bool {
+	return r.householdId == 0 || rule.HouseholdId == r.householdId
+}
+
+// AssignUnowned は所有者のいないルールを householdId の家計簿に割り当てます。
+func (r *recurringRuleRepository) AssignUnowned(householdId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		if r.rules[i].HouseholdId == 0 {
+			r.rules[i].HouseholdId = householdId
+		}
+	}
+	return nil
+}
+
+// FindAll は全ルールをID順に返します。
+func (r *recurringRuleRepository) FindAll() ([]domain.RecurringRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []domain.RecurringRule{}
+	for _, rule := range r.rules {
+		if r.owns(rule) {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+func (r *recurringRuleRepository) FindById(id int) (domain.RecurringRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if rule.ID == id && r.owns(rule) {
+			return rule, nil
+		}
+	}
+	return domain.RecurringRule{}, fmt.Errorf("定期収支が見つかりません: %d", id)
+}
+
+func (r *recurringRuleRepository) Save(rule *domain.RecurringRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		rule.HouseholdId = r.householdId
+	}
+	rule.ID = r.nextID
+	rule.CreatedAt = time.Now()
+	r.nextID++
+	r.rules = append(r.rules, *rule)
+	return nil
+}
+
+// Update はルールの内容を更新します。登録日時・登録済みの予定日・所有者は保存済みの値を引き継ぎます。
+func (r *recurringRuleRepository) Update(rule *domain.RecurringRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.rules {
+		if existing.ID == rule.ID && r.owns(existing) {
+			rule.CreatedAt = existing.CreatedAt
+			rule.PostedThrough = existing.PostedThrough
+			rule.HouseholdId = existing.HouseholdId
+			r.rules[i] = *rule
+			return nil
+		}
+	}
+	return fmt.Errorf("定期収支が見つかりません: %d", rule.ID)
+}
+
+// UpdatePostedThrough は登録済みの最後の予定日を更新します。
+func (r *recurringRuleRepository) UpdatePostedThrough(id int, postedThrough time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		if r.rules[i].ID == id && r.owns(r.rules[i]) {
+			r.rules[i].PostedThrough = &postedThrough
+			return nil
+		}
+	}
+	return fmt.Errorf("定期収支が見つかりません: %d", id)
+}
+
+func (r *recurringRuleRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rule := range r.rules {
+		if rule.ID == id && r.owns(rule) {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("定期収支が見つかりません: %d", id)
+}
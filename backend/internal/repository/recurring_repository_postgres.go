@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// postgresRecurringRuleRepository は PostgreSQL 用の RecurringRuleRepository 実装です。
+// householdId が0でない場合は recurring_rules.household_id がその家計簿の行だけを扱います。
+type postgresRecurringRuleRepository struct {
+	db          *sql.DB
+	householdId int
+}
+
+// NewPostgresRecurringRuleRepository は PostgreSQL を使う RecurringRuleRepository を返します。
+func NewPostgresRecurringRuleRepository(db *sql.DB) RecurringRuleRepository {
+	return &postgresRecurringRuleRepository{db: db}
+}
+
+// ForHousehold は householdId の家計簿のルールだけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *postgresRecurringRuleRepository) ForHousehold(householdId int) RecurringRuleRepository {
+	return &postgresRecurringRuleRepository{db: r.db, householdId: householdId}
+}
+
+// AssignUnowned は所有者のいないルールを householdId の家計簿に割り当てます。
+func (r *postgresRecurringRuleRepository) AssignUnowned(householdId int) error {
+	if _, err := r.db.ExecContext(context.Background(),
+		`UPDATE recurring_rules SET household_id = $1 WHERE household_id IS NULL`, householdId,
+	); err != nil {
+		return fmt.Errorf("AssignUnowned: %w", err)
+	}
+	return nil
+}
+
+const selectRecurringRules = `
+		SELECT id, name, type, category_id, account_id, amount, memo, frequency, interval_count,
+			day_of_month, end_of_month, adjustment, start_date, end_date, posted_through, created_at,
+			COALESCE(household_id, 0)
+		FROM recurring_rules`
+
+// scanRecurringRule は selectRecurringRules の1行をルールに読み込みます。
+func scanRecurringRule(row rowScanner) (domain.RecurringRule, error) {
+	var rule domain.RecurringRule
+	var endDate, postedThrough sql.NullTime
+	if err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Type, &rule.CategoryId, &rule.AccountId, &rule.Amount, &rule.Memo,
+		&rule.Frequency, &rule.Interval, &rule.DayOfMonth, &rule.EndOfMonth, &rule.Adjustment,
+		&rule.StartDate, &endDate, &postedThrough, &rule.CreatedAt, &rule.HouseholdId,
+	); err != nil {
+		return domain.RecurringRule{}, err
+	}
+	if endDate.Valid {
+		rule.EndDate = &endDate.Time
+	}
+	if postedThrough.Valid {
+		rule.PostedThrough = &postedThrough.Time
+	}
+	return rule, nil
+}
+
+// FindAll は全ルールをID順に返します。
+func (r *postgresRecurringRuleRepository) FindAll() ([]domain.RecurringRule, error) {
+	rows, err := r.db.QueryContext(context.Background(), selectRecurringRules+` WHERE ($1 = 0 OR household_id = $1) ORDER BY id`, r.householdId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAll: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.RecurringRule{}
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindAll scan: %w", err)
+		}
+		result = append(result, rule)
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresRecurringRuleRepository) FindById(id int) (domain.RecurringRule, error) {
+	rule, err := scanRecurringRule(r.db.QueryRowContext(context.Background(),
+		selectRecurringRules+` WHERE id = $1 AND ($2 = 0 OR household_id = $2)`, id, r.householdId))
+	if err == sql.ErrNoRows {
+		return domain.RecurringRule{}, fmt.Errorf("定期収支が見つかりません: %d", id)
+	}
+	if err != nil {
+		return domain.RecurringRule{}, fmt.Errorf("FindById: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *postgresRecurringRuleRepository) Save(rule *domain.RecurringRule) error {
+	if r.householdId != 0 {
+		rule.HouseholdId = r.householdId
+	}
+	err := r.db.QueryRowContext(context.Background(), `
+		INSERT INTO recurring_rules (name, type, category_id, account_id, amount, memo, frequency, interval_count,
+			day_of_month, end_of_month, adjustment, start_date, end_date, household_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, 0))
+		RETURNING id, created_at
+	`, rule.Name, rule.Type, rule.CategoryId, rule.AccountId, rule.Amount, rule.Memo, rule.Frequency, rule.Interval,
+		rule.DayOfMonth, rule.EndOfMonth, rule.Adjustment, rule.StartDate, rule.EndDate, rule.HouseholdId,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// Update はルールの内容を更新します。登録済みの予定日は変更しません。
+func (r *postgresRecurringRuleRepository) Update(rule *domain.RecurringRule) error {
+	result, err := r.db.ExecContext(context.Background(), `
+		UPDATE recurring_rules
+		SET name = $1, type = $2, category_id = $3, account_id = $4, amount = $5, memo = $6, frequency = $7,
+			interval_count = $8, day_of_month = $9, end_of_month = $10, adjustment = $11,
+			start_date = $12, end_date = $13
+		WHERE id = $14 AND ($15 = 0 OR household_id = $15)
+	`, rule.Name, rule.Type, rule.CategoryId, rule.AccountId, rule.Amount, rule.Memo, rule.Frequency,
+		rule.Interval, rule.DayOfMonth, rule.EndOfMonth, rule.Adjustment,
+		rule.StartDate, rule.EndDate, rule.ID, r.householdId)
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("定期収支が見つかりません: %d", rule.ID)
+	}
+	return nil
+}
+
+// UpdatePostedThrough は登録済みの最後の予定日を更新します。
+func (r *postgresRecurringRuleRepository) UpdatePostedThrough(id int, postedThrough time.Time) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`UPDATE recurring_rules SET posted_through = $1 WHERE id = $2 AND ($3 = 0 OR household_id = $3)`,
+		postedThrough, id, r.householdId)
+	if err != nil {
+		return fmt.Errorf("UpdatePostedThrough: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("定期収支が見つかりません: %d", id)
+	}
+	return nil
+}
+
+func (r *postgresRecurringRuleRepository) Delete(id int) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM recurring_rules WHERE id = $1 AND ($2 = 0 OR household_id = $2)`, id, r.householdId)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("定期収支が見つかりません: %d", id)
+	}
+	return nil
+}
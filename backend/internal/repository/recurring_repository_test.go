@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// recurring_repository_test.go は RecurringRuleRepository の単体テストです。
+// メモリベースのリポジトリの CRUD 操作と登録済み予定日の記録を検証します。
+
+func TestRecurringRuleRepository_SaveAndUpdate(t *testing.T) {
+	repo := NewRecurringRuleRepository()
+
+	rule := domain.RecurringRule{
+		Name: "家賃", Type: "expense", CategoryId: 3, Amount: -80000,
+		Frequency: domain.FrequencyMonthly, Interval: 1, Adjustment: domain.AdjustmentNone,
+		StartDate: time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC),
+	}
+	if err := repo.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if rule.ID != 1 {
+		t.Errorf("Save: expected ID 1, got %d", rule.ID)
+	}
+
+	posted := time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC)
+	if err := repo.UpdatePostedThrough(rule.ID, posted); err != nil {
+		t.Fatalf("UpdatePostedThrough: unexpected error: %v", err)
+	}
+
+	// 内容の更新では登録済みの予定日を引き継ぐ
+	updated := rule
+	updated.Amount = -85000
+	updated.PostedThrough = nil
+	if err := repo.Update(&updated); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	found, err := repo.FindById(rule.ID)
+	if err != nil {
+		t.Fatalf("FindById: unexpected error: %v", err)
+	}
+	if found.Amount != -85000 {
+		t.Errorf("Update: expected amount -85000, got %d", found.Amount)
+	}
+	if found.PostedThrough == nil || !found.PostedThrough.Equal(posted) {
+		t.Errorf("Update: expected posted_through %v, got %v", posted, found.PostedThrough)
+	}
+}
+
+func TestRecurringRuleRepository_Delete(t *testing.T) {
+	repo := NewRecurringRuleRepository()
+
+	rule := domain.RecurringRule{Name: "給与", Type: "income", CategoryId: 10, Amount: 250000, Frequency: domain.FrequencyMonthly}
+	if err := repo.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if err := repo.Delete(rule.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := repo.FindById(rule.ID); err == nil {
+		t.Error("FindById: expected error after Delete")
+	}
+	if err := repo.Delete(rule.ID); err == nil {
+		t.Error("Delete: expected error for missing rule")
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// CategoryRuleRepository は仕分けルールの永続化を担当するリポジトリのインターフェースです。
+// ルールは家計簿ごとに分かれており、ForHousehold で得たリポジトリはその家計簿のルールだけを扱います。
+type CategoryRuleRepository interface {
+	UnownedAssigner
+	ForHousehold(householdId int) CategoryRuleRepository
+	FindAll() ([]domain.CategoryRule, error)
+	FindById(id int) (domain.CategoryRule, error)
+	Save(rule *domain.CategoryRule) error
+	Update(rule *domain.CategoryRule) error
+	Delete(id int) error
+}
+
+// categoryRuleStore はメモリ上のデータ本体で、家計簿ごとのリポジトリの間で共有します。
+type categoryRuleStore struct {
+	mu     sync.RWMutex
+	rules  []domain.CategoryRule
+	nextID int
+}
+
+// categoryRuleRepository は categoryRuleStore のうち householdId の家計簿のルールを扱います（0 はすべての家計簿）。
+type categoryRuleRepository struct {
+	*categoryRuleStore
+	householdId int
+}
+
+// NewCategoryRuleRepository はメモリベースのCategoryRuleRepositoryを生成します。
+func NewCategoryRuleRepository() CategoryRuleRepository {
+	return &categoryRuleRepository{categoryRuleStore: &categoryRuleStore{
+		rules:  []domain.CategoryRule{},
+		nextID: 1,
+	}}
+}
+
+// ForHousehold は householdId の家計簿のルールだけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *categoryRuleRepository) ForHousehold(householdId int) CategoryRuleRepository {
+	return &categoryRuleRepository{categoryRuleStore: r.categoryRuleStore, householdId: householdId}
+}
+
+// owns はルールがこのリポジトリの扱う家計簿のものかを判定します。
+func (r *categoryRuleRepository) owns(rule domain.CategoryRule) bool {
+	return r.householdId == 0 || rule.HouseholdId == r.householdId
+}
+
+// AssignUnowned は所有者のいないルールを householdId の家計簿に割り当てます。
+func (r *categoryRuleRepository) AssignUnowned(householdId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		if r.rules[i].HouseholdId == 0 {
+			r.rules[i].HouseholdId = householdId
+		}
+	}
+	return nil
+}
+
+// FindAll は全ルールを優先度（同じ場合はID）の順に返します。
+func (r *categoryRuleRepository) FindAll() ([]domain.CategoryRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []domain.CategoryRule{}
+	for _, rule := range r.rules {
+		if r.owns(rule) {
+			result = append(result, copyCategoryRule(rule))
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Priority != result[j].Priority {
+			return result[i].Priority < result[j].Priority
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *categoryRuleRepository) FindById(id int) (domain.CategoryRule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if rule.ID == id && r.owns(rule) {
+			return copyCategoryRule(rule), nil
+		}
+	}
+	return domain.CategoryRule{}, fmt.Errorf("仕分けルールが見つかりません: %d", id)
+}
+
+func (r *categoryRuleRepository) Save(rule *domain.CategoryRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		rule.HouseholdId = r.householdId
+	}
+	rule.ID = r.nextID
+	rule.CreatedAt = time.Now()
+	r.nextID++
+	r.rules = append(r.rules, copyCategoryRule(*rule))
+	return nil
+}
+
+// Update はルールの内容を更新します。登録日時・所有者は保存済みの値を引き継ぎます。
+func (r *categoryRuleRepository) Update(rule *domain.CategoryRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.rules {
+		if existing.ID == rule.ID && r.owns(existing) {
+			rule.CreatedAt = existing.CreatedAt
+			rule.HouseholdId = existing.HouseholdId
+			r.rules[i] = copyCategoryRule(*rule)
+			return nil
+		}
+	}
+	return fmt.Errorf("仕分けルールが見つかりません: %d", rule.ID)
+}
+
+func (r *categoryRuleRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rule := range r.rules {
+		if rule.ID == id && r.owns(rule) {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("仕分けルールが見つかりません: %d", id)
+}
+
+// copyCategoryRule は呼び出し元と保存済みのルールがスライス・ポインタを共有しないようにコピーします。
+func copyCategoryRule(rule domain.CategoryRule) domain.CategoryRule {
+	rule.Weekdays = append([]int(nil), rule.Weekdays...)
+	rule.Tags = append([]string(nil), rule.Tags...)
+	if rule.MinAmount != nil {
+		v := *rule.MinAmount
+		rule.MinAmount = &v
+	}
+	if rule.MaxAmount != nil {
+		v := *rule.MaxAmount
+		rule.MaxAmount = &v
+	}
+	return rule
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// postgresCategoryRuleRepository は PostgreSQL 用の CategoryRuleRepository 実装です。
+// householdId が0でない場合は category_rules.household_id がその家計簿の行だけを扱います。
+// 曜日は日曜日を1ビット目とするビットの集まり、タグは JSON の配列として保存します。
+type postgresCategoryRuleRepository struct {
+	db          *sql.DB
+	householdId int
+}
+
+// NewPostgresCategoryRuleRepository は PostgreSQL を使う CategoryRuleRepository を返します。
+func NewPostgresCategoryRuleRepository(db *sql.DB) CategoryRuleRepository {
+	return &postgresCategoryRuleRepository{db: db}
+}
+
+// ForHousehold は householdId の家計簿のルールだけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *postgresCategoryRuleRepository) ForHousehold(householdId int) CategoryRuleRepository {
+	return &postgresCategoryRuleRepository{db: r.db, householdId: householdId}
+}
+
+// AssignUnowned は所有者のいないルールを householdId の家計簿に割り当てます。
+func (r *postgresCategoryRuleRepository) AssignUnowned(householdId int) error {
+	if _, err := r.db.ExecContext(context.Background(),
+		`UPDATE category_rules SET household_id = $1 WHERE household_id IS NULL`, householdId,
+	); err != nil {
+		return fmt.Errorf("AssignUnowned: %w", err)
+	}
+	return nil
+}
+
+const selectCategoryRules = `
+		SELECT id, name, priority, enabled, type, memo_contains, memo_pattern, min_amount, max_amount,
+			COALESCE(account_id, 0), weekdays, COALESCE(category_id, 0), tags, memo_rewrite, created_at,
+			COALESCE(household_id, 0)
+		FROM category_rules`
+
+// scanCategoryRule は selectCategoryRules の1行をルールに読み込みます。
+func scanCategoryRule(row rowScanner) (domain.CategoryRule, error) {
+	var rule domain.CategoryRule
+	var minAmount, maxAmount sql.NullInt64
+	var weekdays int
+	var tags []byte
+	if err := row.Scan(
+		&rule.ID, &rule.Name, &rule.Priority, &rule.Enabled, &rule.Type, &rule.MemoContains, &rule.MemoPattern,
+		&minAmount, &maxAmount, &rule.AccountId, &weekdays, &rule.CategoryId, &tags, &rule.MemoRewrite,
+		&rule.CreatedAt, &rule.HouseholdId,
+	); err != nil {
+		return domain.CategoryRule{}, err
+	}
+	if minAmount.Valid {
+		v := int(minAmount.Int64)
+		rule.MinAmount = &v
+	}
+	if maxAmount.Valid {
+		v := int(maxAmount.Int64)
+		rule.MaxAmount = &v
+	}
+	for d := 0; d < 7; d++ {
+		if weekdays&(1<<d) != 0 {
+			rule.Weekdays = append(rule.Weekdays, d)
+		}
+	}
+	if err := json.Unmarshal(tags, &rule.Tags); err != nil {
+		return domain.CategoryRule{}, err
+	}
+	return rule, nil
+}
+
+// categoryRuleColumns はルールを保存する列の値（曜日のビットとタグの JSON）を返します。
+func categoryRuleColumns(rule *domain.CategoryRule) (int, string, error) {
+	weekdays := 0
+	for _, d := range rule.Weekdays {
+		weekdays |= 1 << d
+	}
+	tags := rule.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	data, err := json.Marshal(tags)
+	if err != nil {
+		return 0, "", err
+	}
+	return weekdays, string(data), nil
+}
+
+// FindAll は全ルールを優先度（同じ場合はID）の順に返します。
+func (r *postgresCategoryRuleRepository) FindAll() ([]domain.CategoryRule, error) {
+	rows, err := r.db.QueryContext(context.Background(),
+		selectCategoryRules+` WHERE ($1 = 0 OR household_id = $1) ORDER BY priority, id`, r.householdId)
+	if err != nil {
+		return nil, fmt.Errorf("FindAll: %w", err)
+	}
+	defer rows.Close()
+
+	result := []domain.CategoryRule{}
+	for rows.Next() {
+		rule, err := scanCategoryRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("FindAll scan: %w", err)
+		}
+		result = append(result, rule)
+	}
+	return result, rows.Err()
+}
+
+func (r *postgresCategoryRuleRepository) FindById(id int) (domain.CategoryRule, error) {
+	rule, err := scanCategoryRule(r.db.QueryRowContext(context.Background(),
+		selectCategoryRules+` WHERE id = $1 AND ($2 = 0 OR household_id = $2)`, id, r.householdId))
+	if err == sql.ErrNoRows {
+		return domain.CategoryRule{}, fmt.Errorf("仕分けルールが見つかりません: %d", id)
+	}
+	if err != nil {
+		return domain.CategoryRule{}, fmt.Errorf("FindById: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *postgresCategoryRuleRepository) Save(rule *domain.CategoryRule) error {
+	if r.householdId != 0 {
+		rule.HouseholdId = r.householdId
+	}
+	weekdays, tags, err := categoryRuleColumns(rule)
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	err = r.db.QueryRowContext(context.Background(), `
+		INSERT INTO category_rules (name, priority, enabled, type, memo_contains, memo_pattern, min_amount, max_amount,
+			account_id, weekdays, category_id, tags, memo_rewrite, household_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), $10, NULLIF($11, 0), $12, $13, NULLIF($14, 0))
+		RETURNING id, created_at
+	`, rule.Name, rule.Priority, rule.Enabled, rule.Type, rule.MemoContains, rule.MemoPattern, rule.MinAmount, rule.MaxAmount,
+		rule.AccountId, weekdays, rule.CategoryId, tags, rule.MemoRewrite, rule.HouseholdId,
+	).Scan(&rule.ID, &rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// Update はルールの内容を更新します。
+func (r *postgresCategoryRuleRepository) Update(rule *domain.CategoryRule) error {
+	weekdays, tags, err := categoryRuleColumns(rule)
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	result, err := r.db.ExecContext(context.Background(), `
+		UPDATE category_rules
+		SET name = $1, priority = $2, enabled = $3, type = $4, memo_contains = $5, memo_pattern = $6,
+			min_amount = $7, max_amount = $8, account_id = NULLIF($9, 0), weekdays = $10,
+			category_id = NULLIF($11, 0), tags = $12, memo_rewrite = $13
+		WHERE id = $14 AND ($15 = 0 OR household_id = $15)
+	`, rule.Name, rule.Priority, rule.Enabled, rule.Type, rule.MemoContains, rule.MemoPattern,
+		rule.MinAmount, rule.MaxAmount, rule.AccountId, weekdays,
+		rule.CategoryId, tags, rule.MemoRewrite, rule.ID, r.householdId)
+	if err != nil {
+		return fmt.Errorf("Update: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("仕分けルールが見つかりません: %d", rule.ID)
+	}
+	return nil
+}
+
+func (r *postgresCategoryRuleRepository) Delete(id int) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM category_rules WHERE id = $1 AND ($2 = 0 OR household_id = $2)`, id, r.householdId)
+	if err != nil {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("仕分けルールが見つかりません: %d", id)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// rule_repository_test.go は CategoryRuleRepository の単体テストです。
+// メモリベースのリポジトリの CRUD 操作と優先度の順の並びを検証します。
+
+func TestCategoryRuleRepository_FindAllOrder(t *testing.T) {
+	repo := NewCategoryRuleRepository()
+
+	for _, rule := range []domain.CategoryRule{
+		{Name: "コンビニ", Priority: 20, Enabled: true, MemoContains: "コンビニ", CategoryId: 1},
+		{Name: "電車", Priority: 10, Enabled: true, MemoContains: "Suica", CategoryId: 2},
+		{Name: "スーパー", Priority: 20, Enabled: true, MemoContains: "スーパー", CategoryId: 12},
+	} {
+		if err := repo.Save(&rule); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	rules, err := repo.FindAll()
+	if err != nil {
+		t.Fatalf("FindAll: unexpected error: %v", err)
+	}
+	var names []string
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	if len(names) != 3 || names[0] != "電車" || names[1] != "コンビニ" || names[2] != "スーパー" {
+		t.Errorf("FindAll: expected priority then ID order, got %v", names)
+	}
+}
+
+func TestCategoryRuleRepository_UpdateAndDelete(t *testing.T) {
+	repo := NewCategoryRuleRepository()
+	other := repo.ForHousehold(2)
+
+	minAmount := 1000
+	rule := domain.CategoryRule{Name: "家賃", Enabled: true, MinAmount: &minAmount, Weekdays: []int{1}, Tags: []string{"固定費"}}
+	if err := repo.ForHousehold(1).Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 呼び出し元の値を書き換えても保存済みのルールは変わらない
+	minAmount = 0
+	rule.Tags[0] = "変更"
+	found, err := repo.FindById(rule.ID)
+	if err != nil {
+		t.Fatalf("FindById: unexpected error: %v", err)
+	}
+	if *found.MinAmount != 1000 || found.Tags[0] != "固定費" {
+		t.Errorf("FindById: expected stored copy, got min %d, tags %v", *found.MinAmount, found.Tags)
+	}
+
+	// 別の家計簿のルールは更新・削除できない
+	updated := found
+	updated.Name = "家賃・管理費"
+	if err := other.Update(&updated); err == nil {
+		t.Error("Update: expected error for another household's rule")
+	}
+	if err := repo.ForHousehold(1).Update(&updated); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	if updated.HouseholdId != 1 || !updated.CreatedAt.Equal(found.CreatedAt) {
+		t.Errorf("Update: expected household and created_at to be kept, got %+v", updated)
+	}
+
+	if err := other.Delete(rule.ID); err == nil {
+		t.Error("Delete: expected error for another household's rule")
+	}
+	if err := repo.ForHousehold(1).Delete(rule.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := repo.FindById(rule.ID); err == nil {
+		t.Error("FindById: expected error after Delete")
+	}
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,66 +13,780 @@ import (
 
 // TransactionRepository は収支データの永続化を担当するリポジトリのインターフェースです。
 // 最小限のAPIのためメモリ上に保持します（後でPostgreSQLへ拡張可能）。
+//
+// 収支（振替・月次集計・口座残高を含む）は家計簿ごとに分かれています。
+// ForHousehold で得たリポジトリはその家計簿の収支だけを読み書きし、登録した収支をその家計簿のものにします。
+// コンストラクタが返すリポジトリ（ForHousehold(0) と同じ）はすべての家計簿の収支を扱います。
+// カテゴリと口座も家計簿ごとに分かれています。
 type TransactionRepository interface {
+	UnownedAssigner
+	ForHousehold(householdId int) TransactionRepository
 	FindAll() ([]domain.Transaction, error)
+	FindByFilter(filter domain.TransactionFilter) ([]domain.Transaction, int, error)
+	EachByFilter(filter domain.TransactionFilter, fn func(domain.Transaction) error) error
 	FindById(id int) (domain.Transaction, error)
+	FindMonthlySummary(year, month, accountId int) (domain.MonthlySummary, error)
+	FindTags(prefix string, limit int) ([]domain.Tag, error)
+	FindTagTotals(from, to time.Time) ([]domain.TagTotal, error)
+	FindAllPayees() ([]domain.Payee, error)
+	FindPayeeById(id int) (domain.Payee, error)
+	UpdatePayee(payee *domain.Payee) error
+	MergePayees(targetId int, sourceIds []int) error
+	FindPayeeTotals(from, to time.Time) ([]domain.PayeeTotal, error)
+	FindItemPurchases(name string, from, to time.Time) ([]domain.ItemPurchase, error)
+	FindSearchCandidates(grams []string) ([]domain.Transaction, error)
 	FindAllCategories() ([]domain.Category, error)
 	FindCategoryById(id int) (domain.Category, error)
+	SaveCategory(category *domain.Category) error
+	UpdateCategory(category *domain.Category) error
+	ReorderCategories(ids []int) error
+	DeleteCategory(id, reassignTo int) error
+	SaveCategorySet(householdId int, categories []domain.Category) error
+	FindCategoryMappings(source string) ([]domain.CategoryMapping, error)
+	SaveCategoryMappings(source string, mappings []domain.CategoryMapping) error
+	FindAllAccounts() ([]domain.Account, error)
+	FindAccountById(id int) (domain.Account, error)
+	FindAccountBalances() ([]domain.AccountBalance, error)
+	SaveAccount(account *domain.Account) error
+	UpdateAccount(account *domain.Account) error
+	DeleteAccount(id int) error
 	Save(transaction *domain.Transaction) error
+	SaveAll(transactions []*domain.Transaction) error
 	Update(transaction *domain.Transaction) error
 	Delete(id int) error
+	FindTrash() ([]domain.Transaction, error)
+	Restore(id int) error
+	PurgeTrash(before time.Time) (int, []domain.Attachment, error)
+	FindTransfer(transferId int) ([]domain.Transaction, error)
+	SaveTransfer(out, in *domain.Transaction) error
+	UpdateTransfer(out, in *domain.Transaction) error
+	FindAttachments(transactionId int) ([]domain.Attachment, error)
+	FindAttachmentById(id int) (domain.Attachment, error)
+	SaveAttachment(attachment *domain.Attachment) error
+	DeleteAttachment(id int) error
 }
 
+// ErrCategoryInUse は収支か定期収支ルールから参照されているカテゴリを付け替え先なしで削除しようとした場合のエラーです。
+// ゴミ箱の収支から参照されている場合も含みます。
+var ErrCategoryInUse = errors.New("カテゴリは収支・定期収支から参照されています")
+
+// ErrCategoryHasChildren は子カテゴリを持つカテゴリを削除しようとした場合のエラーです。
+var ErrCategoryHasChildren = errors.New("カテゴリに子カテゴリがあります")
+
+// ErrAccountInUse は収支・定期収支ルール・仕分けルールから参照されている口座を削除しようとした場合のエラーです。
+// ゴミ箱の収支から参照されている場合も含みます。
+var ErrAccountInUse = errors.New("口座は収支・定期収支・仕分けルールから参照されています")
+
+// ErrDuplicateOccurrence は同じ定期収支ルール・予定日の収支を二重に登録しようとした場合のエラーです。
+var ErrDuplicateOccurrence = errors.New("この定期収支は登録済みです")
+
+// transactionStore はメモリ上のデータ本体で、家計簿ごとのリポジトリの間で共有します。
+type transactionStore struct {
+	mu               sync.RWMutex
+	transactions     []domain.Transaction
+	categories       []domain.Category
+	accounts         []domain.Account
+	mappings         []domain.CategoryMapping
+	payees           []domain.Payee
+	attachments      []domain.Attachment
+	nextID           int
+	nextCategoryID   int
+	nextAccountID    int
+	nextSplitID      int
+	nextPayeeID      int
+	nextAttachmentID int
+	nextItemID       int
+
+	// 口座・カテゴリを参照するほかのリポジトリのデータ（LinkMemoryRepositories で結び付ける。nil なら参照はない）
+	recurring *recurringRuleStore
+	rules     *categoryRuleStore
+	budgets   *budgetStore
+}
+
+// transactionRepository は transactionStore のうち householdId の家計簿の収支を扱います（0 はすべての家計簿）。
 type transactionRepository struct {
-	mu           sync.RWMutex
-	transactions []domain.Transaction
-	categories   []domain.Category
-	nextID       int
+	*transactionStore
+	householdId int
 }
 
 // NewTransactionRepository はメモリベースのTransactionRepositoryを生成します。
 func NewTransactionRepository() TransactionRepository {
-	return &transactionRepository{
+	return &transactionRepository{transactionStore: &transactionStore{
 		transactions: []domain.Transaction{},
-		categories: []domain.Category{
-			{ID: 1, Name: "食費"},
-			{ID: 2, Name: "交通費"},
-			{ID: 3, Name: "住居費"},
-			{ID: 4, Name: "光熱費"},
-			{ID: 5, Name: "通信費"},
-			{ID: 6, Name: "娯楽費"},
-			{ID: 7, Name: "医療費"},
-			{ID: 8, Name: "教育費"},
-			{ID: 9, Name: "その他"},
-			{ID: 10, Name: "給与"},
+		categories:   domain.DefaultCategories(),
+		accounts: []domain.Account{
+			{ID: domain.DefaultAccountId, Name: "現金", Kind: domain.AccountKindCash, DisplayOrder: 1},
 		},
-		nextID: 1,
+		nextID:           1,
+		nextCategoryID:   16,
+		nextAccountID:    2,
+		nextSplitID:      1,
+		nextPayeeID:      1,
+		nextAttachmentID: 1,
+		nextItemID:       1,
+	}}
+}
+
+// LinkMemoryRepositories はメモリ上の収支のリポジトリに、定期収支・仕分けルール・予算のリポジトリのデータを結び付けます。
+// データベースと同じく、口座・カテゴリの削除時にそれらからの参照を確認・付け替えできるようにします。
+// メモリ上のリポジトリでない引数は無視します。
+func LinkMemoryRepositories(transactions TransactionRepository, recurring RecurringRuleRepository, rules CategoryRuleRepository, budgets BudgetRepository) {
+	t, ok := transactions.(*transactionRepository)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if r, ok := recurring.(*recurringRuleRepository); ok {
+		t.recurring = r.recurringRuleStore
+	}
+	if r, ok := rules.(*categoryRuleRepository); ok {
+		t.rules = r.categoryRuleStore
+	}
+	if b, ok := budgets.(*budgetRepository); ok {
+		t.budgets = b.budgetStore
+	}
+}
+
+// accountReferencedLocked は口座が定期収支ルールか仕分けルールから参照されているかを判定します。
+// 呼び出し側で収支のロックを取得している必要があります。
+func (r *transactionRepository) accountReferencedLocked(id int) bool {
+	if r.recurring != nil {
+		r.recurring.mu.RLock()
+		defer r.recurring.mu.RUnlock()
+		for _, rule := range r.recurring.rules {
+			if rule.AccountId == id {
+				return true
+			}
+		}
 	}
+	if r.rules != nil {
+		r.rules.mu.RLock()
+		defer r.rules.mu.RUnlock()
+		for _, rule := range r.rules.rules {
+			if rule.AccountId == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recurringUsesCategoryLocked はカテゴリが定期収支ルールから参照されているかを判定します。
+// 呼び出し側で収支のロックを取得している必要があります。
+func (r *transactionRepository) recurringUsesCategoryLocked(id int) bool {
+	if r.recurring == nil {
+		return false
+	}
+	r.recurring.mu.RLock()
+	defer r.recurring.mu.RUnlock()
+	for _, rule := range r.recurring.rules {
+		if rule.CategoryId == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ForHousehold は householdId の家計簿の収支だけを扱うリポジトリを返します。0 の場合はすべての家計簿です。
+func (r *transactionRepository) ForHousehold(householdId int) TransactionRepository {
+	return &transactionRepository{transactionStore: r.transactionStore, householdId: householdId}
+}
+
+// owns は収支がこのリポジトリの扱う家計簿のもので、ゴミ箱にないかを判定します。
+func (r *transactionRepository) owns(t domain.Transaction) bool {
+	return t.DeletedAt == nil && r.ownsTrashed(t)
+}
+
+// ownsTrashed は収支がこのリポジトリの扱う家計簿のものかを、ゴミ箱の収支も含めて判定します。
+func (r *transactionRepository) ownsTrashed(t domain.Transaction) bool {
+	return r.householdId == 0 || t.HouseholdId == r.householdId
+}
+
+// ownsCategory はカテゴリがこのリポジトリの扱う家計簿のものかを判定します。
+func (r *transactionRepository) ownsCategory(c domain.Category) bool {
+	return r.householdId == 0 || c.HouseholdId == r.householdId
+}
+
+// ownsAccount は口座がこのリポジトリの扱う家計簿のものかを判定します。
+func (r *transactionRepository) ownsAccount(a domain.Account) bool {
+	return r.householdId == 0 || a.HouseholdId == r.householdId
+}
+
+// AssignUnowned は所有者のいない収支・カテゴリ・口座・カテゴリの対応・支払先を householdId の家計簿に割り当てます。
+func (r *transactionRepository) AssignUnowned(householdId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.transactions {
+		if r.transactions[i].HouseholdId == 0 {
+			r.transactions[i].HouseholdId = householdId
+		}
+	}
+	for i := range r.categories {
+		if r.categories[i].HouseholdId == 0 {
+			r.categories[i].HouseholdId = householdId
+		}
+	}
+	for i := range r.accounts {
+		if r.accounts[i].HouseholdId == 0 {
+			r.accounts[i].HouseholdId = householdId
+		}
+	}
+	for i := range r.mappings {
+		if r.mappings[i].HouseholdId == 0 {
+			r.mappings[i].HouseholdId = householdId
+		}
+	}
+	for i := range r.payees {
+		if r.payees[i].HouseholdId == 0 {
+			r.payees[i].HouseholdId = householdId
+		}
+	}
+	return nil
 }
 
 func (r *transactionRepository) FindAll() ([]domain.Transaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	result := make([]domain.Transaction, len(r.transactions))
-	copy(result, r.transactions)
+	result := []domain.Transaction{}
+	for _, t := range r.transactions {
+		if r.owns(t) {
+			result = append(result, t)
+		}
+	}
 	return result, nil
 }
 
-func (r *transactionRepository) FindAllCategories() ([]domain.Category, error) {
+// EachByFilter は条件に一致する収支を並び替えて1件ずつ fn に渡します。ページングの指定は使いません。
+// fn がエラーを返した場合はそこで止め、そのエラーを返します。
+func (r *transactionRepository) EachByFilter(f domain.TransactionFilter, fn func(domain.Transaction) error) error {
+	f.Page, f.Limit = 0, 0
+	transactions, _, err := r.FindByFilter(f)
+	if err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindByFilter は条件に一致する収支を並び替え・ページングして返します。
+// 2つ目の戻り値はページング前の総件数です。
+func (r *transactionRepository) FindByFilter(f domain.TransactionFilter) ([]domain.Transaction, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []domain.Transaction
+	for _, t := range r.transactions {
+		if r.owns(t) && matchesFilter(t, f) {
+			matched = append(matched, t)
+		}
+	}
+
+	compare := func(a, b domain.Transaction) int {
+		var c int
+		switch f.SortBy {
+		case "amount":
+			c = absInt(a.Amount) - absInt(b.Amount)
+		case "created_at":
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		default:
+			c = a.Date.Compare(b.Date)
+		}
+		if c == 0 {
+			c = a.ID - b.ID
+		}
+		return c
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if f.SortOrder == "asc" {
+			return compare(matched[i], matched[j]) < 0
+		}
+		return compare(matched[i], matched[j]) > 0
+	})
+
+	total := len(matched)
+	if f.Limit > 0 {
+		page := f.Page
+		if page < 1 {
+			page = 1
+		}
+		start := (page - 1) * f.Limit
+		if start > total {
+			start = total
+		}
+		end := start + f.Limit
+		if end > total {
+			end = total
+		}
+		matched = matched[start:end]
+	}
+
+	result := make([]domain.Transaction, len(matched))
+	copy(result, matched)
+	return result, total, nil
+}
+
+// matchesFilter は収支が絞り込み条件をすべて満たすかを判定します。
+func matchesFilter(t domain.Transaction, f domain.TransactionFilter) bool {
+	if f.From != nil && t.Date.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && t.Date.After(*f.To) {
+		return false
+	}
+	if f.Type != "" && t.Type != f.Type {
+		return false
+	}
+	if f.AccountId != 0 && t.AccountId != f.AccountId {
+		return false
+	}
+	if len(f.CategoryIds) > 0 {
+		found := false
+		for _, id := range f.CategoryIds {
+			if t.HasCategory(id) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinAmount != nil && absInt(t.Amount) < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && absInt(t.Amount) > *f.MaxAmount {
+		return false
+	}
+	if f.Memo != "" && !strings.Contains(t.Memo, f.Memo) {
+		return false
+	}
+	if f.PayeeId != 0 && t.PayeeId != f.PayeeId {
+		return false
+	}
+	return t.MatchesTags(f.Tags, f.TagMatch)
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// FindMonthlySummary は指定月の収入・支出合計とカテゴリ別合計を集計します。
+// accountId が0でない場合はその口座の収支だけを集計します。振替は集計に含めません。
+// 内訳のある収支は内訳ごとにそれぞれのカテゴリへ集計し、Count は収支の件数です。
+// カテゴリ別合計は子カテゴリの分を親カテゴリへ集約します。
+func (r *transactionRepository) FindMonthlySummary(year, month, accountId int) (domain.MonthlySummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	summary := domain.MonthlySummary{Year: year, Month: month, Categories: []domain.CategoryTotal{}}
+	index := map[int]int{}
+	for _, t := range r.transactions {
+		if t.Date.Before(from) || !t.Date.Before(to) {
+			continue
+		}
+		if !r.owns(t) || (accountId != 0 && t.AccountId != accountId) {
+			continue
+		}
+		if t.Type == domain.TransactionTypeTransfer {
+			continue
+		}
+		for _, line := range t.CategoryLines() {
+			i, ok := index[line.CategoryId]
+			if !ok {
+				i = len(summary.Categories)
+				index[line.CategoryId] = i
+				summary.Categories = append(summary.Categories, domain.CategoryTotal{
+					CategoryId:   line.CategoryId,
+					CategoryName: r.categoryNameLocked(line.CategoryId),
+				})
+			}
+			ct := &summary.Categories[i]
+			if t.Type == "income" {
+				ct.Income += line.Amount
+			} else {
+				ct.Expense -= line.Amount
+			}
+			ct.Count++
+		}
+		summary.Count++
+	}
+
+	for _, ct := range summary.Categories {
+		summary.Income += ct.Income
+		summary.Expense += ct.Expense
+	}
+	summary.Balance = summary.Income - summary.Expense
+	summary.Categories = domain.RollUpCategoryTotals(summary.Categories, r.sortedCategoriesLocked())
+	return summary, nil
+}
+
+// categoryNameLocked はカテゴリ名を返します。呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) categoryNameLocked(id int) string {
+	if i := r.categoryIndexLocked(id); i >= 0 {
+		return r.categories[i].Name
+	}
+	return ""
+}
+
+// FindTags は収支に付いているタグを、付いている収支の多い順（同数は名前順）に返します。
+// prefix を指定した場合は大文字・小文字を区別せずに前方一致するタグだけを返します。limit が0以下の場合は件数を制限しません。
+func (r *transactionRepository) FindTags(prefix string, limit int) ([]domain.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	counts := map[string]int{}
+	for _, t := range r.transactions {
+		if !r.owns(t) {
+			continue
+		}
+		for _, tag := range t.Tags {
+			if strings.HasPrefix(strings.ToLower(tag), prefix) {
+				counts[tag]++
+			}
+		}
+	}
+
+	result := make([]domain.Tag, 0, len(counts))
+	for name, count := range counts {
+		result = append(result, domain.Tag{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindSearchCandidates は全文検索の候補として、メモ・カテゴリ名・内訳・タグのいずれかに
+// grams（domain.SearchGrams で求めた正規化済みの文字列）のどれかを含む収支を返します。
+// 採点と絞り込みは呼び出し側で domain.SearchTransaction を使って行います。
+func (r *transactionRepository) FindSearchCandidates(grams []string) ([]domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.Transaction{}
+	if len(grams) == 0 {
+		return result, nil
+	}
+	for _, t := range r.transactions {
+		if !r.owns(t) {
+			continue
+		}
+		text := t.SearchString()
+		for _, gram := range grams {
+			if strings.Contains(text, gram) {
+				result = append(result, t)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// FindTagTotals は from から to まで（両端を含む）のタグ別の収入・支出の合計を、支出の多い順（同額は名前順）に返します。
+// 振替は集計に含めません。複数のタグが付いた収支はそれぞれのタグに数えます。
+func (r *transactionRepository) FindTagTotals(from, to time.Time) ([]domain.TagTotal, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	result := make([]domain.Category, len(r.categories))
-	copy(result, r.categories)
+	result := []domain.TagTotal{}
+	index := map[string]int{}
+	for _, t := range r.transactions {
+		if !r.owns(t) || t.Type == domain.TransactionTypeTransfer || t.Date.Before(from) || t.Date.After(to) {
+			continue
+		}
+		for _, tag := range t.Tags {
+			i, ok := index[tag]
+			if !ok {
+				i = len(result)
+				index[tag] = i
+				result = append(result, domain.TagTotal{Tag: tag})
+			}
+			if t.Type == domain.TransactionTypeIncome {
+				result[i].Income += t.Amount
+			} else {
+				result[i].Expense -= t.Amount
+			}
+			result[i].Count++
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Expense != result[j].Expense {
+			return result[i].Expense > result[j].Expense
+		}
+		return result[i].Tag < result[j].Tag
+	})
 	return result, nil
 }
 
+// FindAllPayees は統合されていない支払先を、収支の多い順（同数は名前順）に件数と最後の収支の日付付きで返します。
+func (r *transactionRepository) FindAllPayees() ([]domain.Payee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.Payee{}
+	index := map[int]int{}
+	for _, p := range r.payees {
+		if p.MergedInto == 0 && r.ownsPayee(p) {
+			index[p.ID] = len(result)
+			result = append(result, p)
+		}
+	}
+	for _, t := range r.transactions {
+		i, ok := index[t.PayeeId]
+		if !ok || t.DeletedAt != nil {
+			continue
+		}
+		result[i].Count++
+		if result[i].LastDate == nil || t.Date.After(*result[i].LastDate) {
+			date := t.Date
+			result[i].LastDate = &date
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// FindPayeeById は支払先を返します。統合された支払先も返します。
+func (r *transactionRepository) FindPayeeById(id int) (domain.Payee, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if i := r.payeeIndexLocked(id); i >= 0 && r.ownsPayee(r.payees[i]) {
+		return r.payees[i], nil
+	}
+	return domain.Payee{}, fmt.Errorf("支払先が見つかりません: %d", id)
+}
+
+// UpdatePayee は支払先の名前を変更し、その支払先の収支の支払先名も合わせて更新します。
+// Key は変えないため、以後も元の名前と同じ Key の収支はこの支払先に結び付きます。
+func (r *transactionRepository) UpdatePayee(p *domain.Payee) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.payeeIndexLocked(p.ID)
+	if i < 0 || !r.ownsPayee(r.payees[i]) {
+		return fmt.Errorf("支払先が見つかりません: %d", p.ID)
+	}
+	r.payees[i].Name = p.Name
+	*p = r.payees[i]
+	for j := range r.transactions {
+		if r.transactions[j].PayeeId == p.ID {
+			r.transactions[j].PayeeName = p.Name
+		}
+	}
+	return nil
+}
+
+// MergePayees は sourceIds の支払先を targetId の支払先に統合します。
+// 統合元の収支を統合先に付け替え、統合元は MergedInto に統合先のIDを持って残します（以後その Key の収支は統合先に登録します）。
+// どれかが見つからないか統合済みの場合は何も変更しません。
+func (r *transactionRepository) MergePayees(targetId int, sourceIds []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	target := r.payeeIndexLocked(targetId)
+	if target < 0 || !r.ownsPayee(r.payees[target]) || r.payees[target].MergedInto != 0 {
+		return fmt.Errorf("支払先が見つかりません: %d", targetId)
+	}
+	sources := map[int]bool{}
+	for _, id := range sourceIds {
+		i := r.payeeIndexLocked(id)
+		if id == targetId || i < 0 || r.payees[i].HouseholdId != r.payees[target].HouseholdId || r.payees[i].MergedInto != 0 {
+			return fmt.Errorf("統合する支払先が見つかりません: %d", id)
+		}
+		sources[id] = true
+	}
+
+	for i := range r.payees {
+		if sources[r.payees[i].ID] || sources[r.payees[i].MergedInto] {
+			r.payees[i].MergedInto = targetId
+		}
+	}
+	for j := range r.transactions {
+		if sources[r.transactions[j].PayeeId] {
+			r.transactions[j].PayeeId = targetId
+			r.transactions[j].PayeeName = r.payees[target].Name
+		}
+	}
+	return nil
+}
+
+// FindPayeeTotals は from から to まで（両端を含む）の支払先別の収入・支出の合計と月ごとの内訳を、
+// 支出の多い順（同額は名前順）に返します。振替と支払先のない収支は集計に含めません。
+func (r *transactionRepository) FindPayeeTotals(from, to time.Time) ([]domain.PayeeTotal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []domain.Transaction
+	for _, t := range r.transactions {
+		if !r.owns(t) || t.PayeeId == 0 || t.Type == domain.TransactionTypeTransfer || t.Date.Before(from) || t.Date.After(to) {
+			continue
+		}
+		matched = append(matched, t)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Date.Before(matched[j].Date) })
+
+	result := []domain.PayeeTotal{}
+	index := map[int]int{}
+	for _, t := range matched {
+		i, ok := index[t.PayeeId]
+		if !ok {
+			i = len(result)
+			index[t.PayeeId] = i
+			result = append(result, domain.PayeeTotal{PayeeId: t.PayeeId, PayeeName: t.PayeeName})
+		}
+		result[i].Add(t)
+	}
+	sortPayeeTotals(result)
+	return result, nil
+}
+
+// sortPayeeTotals は支払先別の合計を支出の多い順（同額は名前順）に並べます。
+func sortPayeeTotals(totals []domain.PayeeTotal) {
+	sort.SliceStable(totals, func(i, j int) bool {
+		if totals[i].Expense != totals[j].Expense {
+			return totals[i].Expense > totals[j].Expense
+		}
+		return totals[i].PayeeName < totals[j].PayeeName
+	})
+}
+
+// FindItemPurchases は from から to まで（両端を含む）の支出の品目のうち、品名に name を含むものを日付の古い順に返します。
+// 品名は domain.NormalizeMemo で正規化して比べます（大文字・小文字、全角・半角を区別しない）。
+func (r *transactionRepository) FindItemPurchases(name string, from, to time.Time) ([]domain.ItemPurchase, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query := domain.NormalizeMemo(name)
+	result := []domain.ItemPurchase{}
+	for _, t := range r.transactions {
+		if !r.owns(t) || t.Type != domain.TransactionTypeExpense || t.Date.Before(from) || t.Date.After(to) {
+			continue
+		}
+		for _, item := range t.Items {
+			if strings.Contains(domain.NormalizeMemo(item.Name), query) {
+				result = append(result, domain.ItemPurchase{
+					TransactionId: t.ID, Date: t.Date, PayeeId: t.PayeeId, PayeeName: t.PayeeName,
+					Name: item.Name, Quantity: item.Quantity, UnitPrice: item.UnitPrice,
+				})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result, nil
+}
+
+// ownsPayee は支払先がこのリポジトリの扱う家計簿のものかを判定します。
+func (r *transactionRepository) ownsPayee(p domain.Payee) bool {
+	return r.householdId == 0 || p.HouseholdId == r.householdId
+}
+
+// payeeIndexLocked は支払先のスライス上の位置を返します（見つからない場合は -1）。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) payeeIndexLocked(id int) int {
+	for i, p := range r.payees {
+		if p.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolvePayeeLocked は収支の支払先を決めます。
+// PayeeId が0で PayeeName がある場合は、同じ家計簿で PayeeKey が同じ支払先（統合されていれば統合先）を使い、
+// なければ新しい支払先を作ります。PayeeName は支払先の表示名にそろえます。振替は支払先を持ちません。
+// 呼び出し側で書き込みロックを取得している必要があります。
+func (r *transactionRepository) resolvePayeeLocked(t *domain.Transaction) {
+	if t.Type == domain.TransactionTypeTransfer {
+		t.PayeeId, t.PayeeName = 0, ""
+		return
+	}
+	i := -1
+	if t.PayeeId != 0 {
+		if i = r.payeeIndexLocked(t.PayeeId); i >= 0 && r.payees[i].HouseholdId != t.HouseholdId {
+			i = -1
+		}
+	} else if key := domain.PayeeKey(t.PayeeName); key != "" {
+		for j, p := range r.payees {
+			if p.HouseholdId == t.HouseholdId && p.Key == key {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			r.payees = append(r.payees, domain.Payee{
+				ID:          r.nextPayeeID,
+				Name:        domain.PayeeName(t.PayeeName),
+				Key:         key,
+				CreatedAt:   time.Now(),
+				HouseholdId: t.HouseholdId,
+			})
+			r.nextPayeeID++
+			i = len(r.payees) - 1
+		}
+	}
+	if i >= 0 && r.payees[i].MergedInto != 0 {
+		i = r.payeeIndexLocked(r.payees[i].MergedInto)
+	}
+	if i < 0 {
+		t.PayeeId, t.PayeeName = 0, ""
+		return
+	}
+	t.PayeeId, t.PayeeName = r.payees[i].ID, r.payees[i].Name
+}
+
+func (r *transactionRepository) FindAllCategories() ([]domain.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sortedCategoriesLocked(), nil
+}
+
+// sortedCategoriesLocked はこの家計簿のカテゴリを表示順に並べたコピーを返します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) sortedCategoriesLocked() []domain.Category {
+	result := []domain.Category{}
+	for _, c := range r.categories {
+		if r.ownsCategory(c) {
+			result = append(result, c)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].DisplayOrder != result[j].DisplayOrder {
+			return result[i].DisplayOrder < result[j].DisplayOrder
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
 func (r *transactionRepository) FindById(id int) (domain.Transaction, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for _, transaction := range r.transactions {
-		if transaction.ID == id {
+		if transaction.ID == id && r.owns(transaction) {
 			return transaction, nil
 		}
 	}
@@ -80,30 +797,540 @@ func (r *transactionRepository) FindCategoryById(id int) (domain.Category, error
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	for _, category := range r.categories {
-		if category.ID == id {
+		if category.ID == id && r.ownsCategory(category) {
 			return category, nil
 		}
 	}
 	return domain.Category{}, fmt.Errorf("カテゴリが見つかりません: %d", id)
 }
 
+// SaveCategory はカテゴリを新規作成します。DisplayOrder が0の場合は末尾に追加します。
+func (r *transactionRepository) SaveCategory(c *domain.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		c.HouseholdId = r.householdId
+	}
+	if c.DisplayOrder == 0 {
+		maxOrder := 0
+		for _, category := range r.categories {
+			if category.HouseholdId == c.HouseholdId {
+				maxOrder = max(maxOrder, category.DisplayOrder)
+			}
+		}
+		c.DisplayOrder = maxOrder + 1
+	}
+	c.ID = r.nextCategoryID
+	r.nextCategoryID++
+	r.categories = append(r.categories, *c)
+	return nil
+}
+
+// SaveCategorySet は categories を householdId の家計簿のカテゴリとしてまとめて登録します。
+// categories の ID・ParentId は一覧の中での親子関係を表すだけで、登録時に新しいIDを振り直します。
+// 新しい家計簿に初期カテゴリを用意するときに使います。
+func (r *transactionRepository) SaveCategorySet(householdId int, categories []domain.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 親カテゴリを先に登録し、子カテゴリの ParentId を新しいIDへ付け替える
+	newIds := map[int]int{}
+	for _, parents := range []bool{true, false} {
+		for _, c := range categories {
+			if (c.ParentId == 0) != parents {
+				continue
+			}
+			if !parents {
+				c.ParentId = newIds[c.ParentId]
+			}
+			newIds[c.ID] = r.nextCategoryID
+			c.ID = r.nextCategoryID
+			c.HouseholdId = householdId
+			c.Children = nil
+			r.nextCategoryID++
+			r.categories = append(r.categories, c)
+		}
+	}
+	return nil
+}
+
+// UpdateCategory はカテゴリの名前・種別・表示順・アーカイブ状態を更新します。
+// 保存済みの収支が持つカテゴリ情報も合わせて更新します。
+func (r *transactionRepository) UpdateCategory(c *domain.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, category := range r.categories {
+		if category.ID == c.ID && r.ownsCategory(category) {
+			c.HouseholdId = category.HouseholdId
+			r.categories[i] = *c
+			for j := range r.transactions {
+				if r.transactions[j].HasCategory(c.ID) {
+					r.refreshCategoriesLocked(j)
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("カテゴリが見つかりません: %d", c.ID)
+}
+
+// ReorderCategories は ids の並び順どおりに表示順を振り直します。
+// ids に含まれないカテゴリはその後ろに元の順序で並びます。
+func (r *transactionRepository) ReorderCategories(ids []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order := map[int]int{}
+	for i, id := range ids {
+		order[id] = i + 1
+	}
+	for _, id := range ids {
+		if i := r.categoryIndexLocked(id); i < 0 || !r.ownsCategory(r.categories[i]) {
+			return fmt.Errorf("カテゴリが見つかりません: %d", id)
+		}
+	}
+
+	sort.SliceStable(r.categories, func(i, j int) bool {
+		return r.categories[i].DisplayOrder < r.categories[j].DisplayOrder
+	})
+	next := len(ids) + 1
+	for i := range r.categories {
+		if !r.ownsCategory(r.categories[i]) {
+			continue
+		}
+		if o, ok := order[r.categories[i].ID]; ok {
+			r.categories[i].DisplayOrder = o
+		} else {
+			r.categories[i].DisplayOrder = next
+			next++
+		}
+	}
+	for j := range r.transactions {
+		r.refreshCategoriesLocked(j)
+	}
+	return nil
+}
+
+// refreshCategoriesLocked は j 番目の収支とその内訳が持つカテゴリ情報を、現在のカテゴリで置き換えます。
+// 内訳は返却済みのスライスを書き換えないよう、新しいスライスに作り直します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) refreshCategoriesLocked(j int) {
+	t := &r.transactions[j]
+	if i := r.categoryIndexLocked(t.CategoryId); i >= 0 {
+		t.Category = r.categories[i]
+	}
+	if len(t.Splits) == 0 {
+		return
+	}
+	splits := make([]domain.Split, len(t.Splits))
+	for k, split := range t.Splits {
+		if i := r.categoryIndexLocked(split.CategoryId); i >= 0 {
+			split.Category = r.categories[i]
+		}
+		splits[k] = split
+	}
+	t.Splits = splits
+}
+
+// DeleteCategory はカテゴリを削除します。
+// 子カテゴリがある場合は ErrCategoryHasChildren を返します。
+// 収支か定期収支ルールから参照されている場合、reassignTo が0なら ErrCategoryInUse を返します。
+// reassignTo を指定した場合は、参照している収支・定期収支ルール・予算を reassignTo のカテゴリへ付け替えてから削除します。
+// 付け替え先に同じ月の予算がある場合は ErrBudgetExists を返し、何も変更しません。
+// 付け替えない場合、カテゴリの予算はカテゴリとともに削除します。
+func (r *transactionRepository) DeleteCategory(id, reassignTo int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.categoryIndexLocked(id)
+	if i < 0 || !r.ownsCategory(r.categories[i]) {
+		return fmt.Errorf("カテゴリが見つかりません: %d", id)
+	}
+	for _, c := range r.categories {
+		if c.ParentId == id {
+			return ErrCategoryHasChildren
+		}
+	}
+
+	inUse := r.recurringUsesCategoryLocked(id)
+	for _, t := range r.transactions {
+		if t.HasCategory(id) {
+			inUse = true
+			break
+		}
+	}
+	if inUse && reassignTo == 0 {
+		return ErrCategoryInUse
+	}
+	if reassignTo != 0 {
+		target := r.categoryIndexLocked(reassignTo)
+		if target < 0 || reassignTo == id || r.categories[target].HouseholdId != r.categories[i].HouseholdId {
+			return fmt.Errorf("付け替え先のカテゴリが見つかりません: %d", reassignTo)
+		}
+	}
+
+	if r.budgets != nil {
+		r.budgets.mu.Lock()
+		defer r.budgets.mu.Unlock()
+		if reassignTo != 0 {
+			months := map[string]bool{}
+			for _, b := range r.budgets.budgets {
+				if b.CategoryId == reassignTo {
+					months[b.Month] = true
+				}
+			}
+			for _, b := range r.budgets.budgets {
+				if b.CategoryId == id && months[b.Month] {
+					return ErrBudgetExists
+				}
+			}
+		}
+		budgets := make([]domain.Budget, 0, len(r.budgets.budgets))
+		for _, b := range r.budgets.budgets {
+			if b.CategoryId == id {
+				if reassignTo == 0 {
+					continue
+				}
+				b.CategoryId = reassignTo
+			}
+			budgets = append(budgets, b)
+		}
+		r.budgets.budgets = budgets
+	}
+
+	if reassignTo != 0 {
+		for j := range r.transactions {
+			t := &r.transactions[j]
+			if !t.HasCategory(id) {
+				continue
+			}
+			if t.CategoryId == id {
+				t.CategoryId = reassignTo
+			}
+			splits := make([]domain.Split, len(t.Splits))
+			for k, split := range t.Splits {
+				if split.CategoryId == id {
+					split.CategoryId = reassignTo
+				}
+				splits[k] = split
+			}
+			if len(splits) > 0 {
+				t.Splits = splits
+			}
+			r.refreshCategoriesLocked(j)
+		}
+		if r.recurring != nil {
+			r.recurring.mu.Lock()
+			for j := range r.recurring.rules {
+				if r.recurring.rules[j].CategoryId == id {
+					r.recurring.rules[j].CategoryId = reassignTo
+				}
+			}
+			r.recurring.mu.Unlock()
+		}
+	}
+
+	// 取り込み時のカテゴリの対応は、付け替える場合は付け替え先へ移し、そうでなければ削除する
+	mappings := make([]domain.CategoryMapping, 0, len(r.mappings))
+	for _, m := range r.mappings {
+		if m.CategoryId == id {
+			if reassignTo == 0 {
+				continue
+			}
+			m.CategoryId = reassignTo
+		}
+		mappings = append(mappings, m)
+	}
+	r.mappings = mappings
+
+	r.categories = append(r.categories[:i], r.categories[i+1:]...)
+	return nil
+}
+
+// FindCategoryMappings は取り込み元 source のカテゴリの対応を名前順に返します。
+func (r *transactionRepository) FindCategoryMappings(source string) ([]domain.CategoryMapping, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.CategoryMapping{}
+	for _, m := range r.mappings {
+		if m.Source == source && (r.householdId == 0 || m.HouseholdId == r.householdId) {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// SaveCategoryMappings は取り込み元 source のカテゴリの対応を mappings で置き換えます。
+func (r *transactionRepository) SaveCategoryMappings(source string, mappings []domain.CategoryMapping) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]domain.CategoryMapping, 0, len(r.mappings)+len(mappings))
+	for _, m := range r.mappings {
+		if m.Source != source || m.HouseholdId != r.householdId {
+			result = append(result, m)
+		}
+	}
+	for _, m := range mappings {
+		m.Source, m.HouseholdId = source, r.householdId
+		result = append(result, m)
+	}
+	r.mappings = result
+	return nil
+}
+
+// categoryIndexLocked はカテゴリのスライス上の位置を返します（見つからない場合は -1）。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) categoryIndexLocked(id int) int {
+	for i, c := range r.categories {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindAllAccounts はこの家計簿の口座を表示順に返します。
+func (r *transactionRepository) FindAllAccounts() ([]domain.Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sortedAccountsLocked(), nil
+}
+
+// sortedAccountsLocked はこの家計簿の口座を表示順に並べたコピーを返します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) sortedAccountsLocked() []domain.Account {
+	result := []domain.Account{}
+	for _, a := range r.accounts {
+		if r.ownsAccount(a) {
+			result = append(result, a)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].DisplayOrder != result[j].DisplayOrder {
+			return result[i].DisplayOrder < result[j].DisplayOrder
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (r *transactionRepository) FindAccountById(id int) (domain.Account, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, account := range r.accounts {
+		if account.ID == id && r.ownsAccount(account) {
+			return account, nil
+		}
+	}
+	return domain.Account{}, fmt.Errorf("口座が見つかりません: %d", id)
+}
+
+// FindAccountBalances は口座ごとの現在の残高（開始残高＋収支の合計）を表示順に返します。
+func (r *transactionRepository) FindAccountBalances() ([]domain.AccountBalance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := map[int]int{}
+	for _, t := range r.transactions {
+		if r.owns(t) {
+			totals[t.AccountId] += t.Amount
+		}
+	}
+	result := []domain.AccountBalance{}
+	for _, a := range r.sortedAccountsLocked() {
+		result = append(result, domain.AccountBalance{Account: a, Balance: a.OpeningBalance + totals[a.ID]})
+	}
+	return result, nil
+}
+
+// SaveAccount は口座をこの家計簿に新規作成します。DisplayOrder が0の場合は末尾に追加します。
+func (r *transactionRepository) SaveAccount(a *domain.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		a.HouseholdId = r.householdId
+	}
+	if a.DisplayOrder == 0 {
+		maxOrder := 0
+		for _, account := range r.accounts {
+			if account.HouseholdId == a.HouseholdId {
+				maxOrder = max(maxOrder, account.DisplayOrder)
+			}
+		}
+		a.DisplayOrder = maxOrder + 1
+	}
+	a.ID = r.nextAccountID
+	r.nextAccountID++
+	r.accounts = append(r.accounts, *a)
+	return nil
+}
+
+// UpdateAccount は口座の名前・種別・開始残高・表示順・アーカイブ状態を更新します。
+func (r *transactionRepository) UpdateAccount(a *domain.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, account := range r.accounts {
+		if account.ID == a.ID && r.ownsAccount(account) {
+			a.HouseholdId = account.HouseholdId
+			r.accounts[i] = *a
+			return nil
+		}
+	}
+	return fmt.Errorf("口座が見つかりません: %d", a.ID)
+}
+
+// DeleteAccount は口座を削除します。収支・定期収支ルール・仕分けルールから参照されている場合は ErrAccountInUse を返します。
+func (r *transactionRepository) DeleteAccount(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := -1
+	for j, account := range r.accounts {
+		if account.ID == id && r.ownsAccount(account) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return fmt.Errorf("口座が見つかりません: %d", id)
+	}
+	for _, t := range r.transactions {
+		if t.AccountId == id {
+			return ErrAccountInUse
+		}
+	}
+	if r.accountReferencedLocked(id) {
+		return ErrAccountInUse
+	}
+	r.accounts = append(r.accounts[:i], r.accounts[i+1:]...)
+	return nil
+}
+
+// Save は収支を新規登録します。
+// 同じ定期収支ルール・予定日の収支が登録済みの場合は ErrDuplicateOccurrence を返します。
 func (r *transactionRepository) Save(t *domain.Transaction) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if t.RecurringRuleId != 0 && t.RecurringDate != nil {
+		for _, transaction := range r.transactions {
+			if transaction.RecurringRuleId == t.RecurringRuleId &&
+				transaction.RecurringDate != nil && transaction.RecurringDate.Equal(*t.RecurringDate) {
+				return ErrDuplicateOccurrence
+			}
+		}
+	}
+	r.saveLocked(t)
+	return nil
+}
+
+// SaveAll は複数の収支をまとめて登録します（CSV の取り込みなど）。
+// 振替は出金側・入金側の2行を続けて並べ、SaveTransfer と同じく組として登録します。
+// 途中で失敗した場合はどの収支も登録しません。
+func (r *transactionRepository) SaveAll(transactions []*domain.Transaction) error {
+	if err := checkTransferPairs(transactions); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := 0; i < len(transactions); i++ {
+		t := transactions[i]
+		if t.Type != domain.TransactionTypeTransfer {
+			r.saveLocked(t)
+			continue
+		}
+		in := transactions[i+1]
+		t.TransferId, in.TransferId = r.nextID, r.nextID
+		r.saveLocked(t)
+		r.saveLocked(in)
+		i++
+	}
+	return nil
+}
+
+// checkTransferPairs は SaveAll に渡された振替が出金側・入金側の2行ずつ続いているかを確認します。
+func checkTransferPairs(transactions []*domain.Transaction) error {
+	for i := 0; i < len(transactions); i++ {
+		if transactions[i].Type != domain.TransactionTypeTransfer {
+			continue
+		}
+		if i+1 >= len(transactions) || transactions[i+1].Type != domain.TransactionTypeTransfer ||
+			transactions[i].Amount >= 0 || transactions[i+1].Amount <= 0 {
+			return fmt.Errorf("振替は出金側・入金側の2行を続けて指定してください")
+		}
+		i++
+	}
+	return nil
+}
+
+// saveLocked は収支に ID を採番して登録します。呼び出し側で書き込みロックを取得している必要があります。
+func (r *transactionRepository) saveLocked(t *domain.Transaction) {
+	if r.householdId != 0 {
+		t.HouseholdId = r.householdId
+	}
 	t.ID = r.nextID
 	t.CreatedAt = time.Now()
+	t.Splits = r.numberSplitsLocked(t.Splits)
+	t.Items = r.numberItemsLocked(t.Items)
+	t.Tags = append([]string(nil), t.Tags...)
+	r.resolvePayeeLocked(t)
 	r.nextID++
 	r.transactions = append(r.transactions, *t)
-	return nil
 }
 
+// numberSplitsLocked は内訳に新しいIDを振ったコピーを返します。内訳がない場合は nil を返します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) numberSplitsLocked(splits []domain.Split) []domain.Split {
+	if len(splits) == 0 {
+		return nil
+	}
+	result := make([]domain.Split, len(splits))
+	for i, split := range splits {
+		split.ID = r.nextSplitID
+		r.nextSplitID++
+		result[i] = split
+	}
+	return result
+}
+
+// numberItemsLocked は品目に新しいIDを振ったコピーを返します。品目がない場合は nil を返します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) numberItemsLocked(items []domain.LineItem) []domain.LineItem {
+	if len(items) == 0 {
+		return nil
+	}
+	result := make([]domain.LineItem, len(items))
+	for i, item := range items {
+		item.ID = r.nextItemID
+		r.nextItemID++
+		result[i] = item
+	}
+	return result
+}
+
+// Update は収支の日付・種別・カテゴリ・金額・メモ・支払先・内訳・品目・タグを更新します。内訳・品目・タグは t の内容で置き換えます。
+// 登録日時と定期収支・振替・家計簿・登録者の情報は保存済みの値を引き継ぎます。
 func (r *transactionRepository) Update(t *domain.Transaction) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	for i, transaction := range r.transactions {
-		if transaction.ID == t.ID {
+		if transaction.ID == t.ID && r.owns(transaction) {
 			t.CreatedAt = transaction.CreatedAt
+			t.RecurringRuleId = transaction.RecurringRuleId
+			t.RecurringDate = transaction.RecurringDate
+			t.TransferId = transaction.TransferId
+			t.HouseholdId = transaction.HouseholdId
+			t.CreatedBy = transaction.CreatedBy
+			t.Splits = r.numberSplitsLocked(t.Splits)
+			t.Items = r.numberItemsLocked(t.Items)
+			t.Tags = append([]string(nil), t.Tags...)
+			r.resolvePayeeLocked(t)
 			r.transactions[i] = *t
 			return nil
 		}
@@ -111,14 +1338,222 @@ func (r *transactionRepository) Update(t *domain.Transaction) error {
 	return fmt.Errorf("収支が見つかりません: %d", t.ID)
 }
 
+// Delete は収支をゴミ箱へ移します（DeletedAt に現在の日時を設定します）。振替の場合は組になっているもう1行も移します。
+// 内訳・品目・タグ・添付ファイルは PurgeTrash で完全に削除するまで残します。
 func (r *transactionRepository) Delete(id int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	for i, transaction := range r.transactions {
-		if transaction.ID == id {
-			r.transactions = append(r.transactions[:i], r.transactions[i+1:]...)
-			return nil
+	for _, transaction := range r.transactions {
+		if transaction.ID != id || !r.owns(transaction) {
+			continue
+		}
+		now := time.Now()
+		for i := range r.transactions {
+			t := &r.transactions[i]
+			if t.ID == id || (transaction.TransferId != 0 && t.TransferId == transaction.TransferId) {
+				t.DeletedAt = &now
+			}
 		}
+		return nil
 	}
 	return fmt.Errorf("収支が見つかりません: %d", id)
 }
+
+// FindTrash はゴミ箱の収支を、ゴミ箱へ移した日時の新しい順に返します。
+func (r *transactionRepository) FindTrash() ([]domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []domain.Transaction{}
+	for _, t := range r.transactions {
+		if t.DeletedAt != nil && r.ownsTrashed(t) {
+			result = append(result, t)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].DeletedAt.Equal(*result[j].DeletedAt) {
+			return result[i].DeletedAt.After(*result[j].DeletedAt)
+		}
+		return result[i].ID > result[j].ID
+	})
+	return result, nil
+}
+
+// Restore はゴミ箱の収支を元に戻します。振替の場合は組になっているもう1行も戻します。
+func (r *transactionRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, transaction := range r.transactions {
+		if transaction.ID != id || transaction.DeletedAt == nil || !r.ownsTrashed(transaction) {
+			continue
+		}
+		for i := range r.transactions {
+			t := &r.transactions[i]
+			if t.ID == id || (transaction.TransferId != 0 && t.TransferId == transaction.TransferId) {
+				t.DeletedAt = nil
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("ゴミ箱に収支が見つかりません: %d", id)
+}
+
+// PurgeTrash は before より前にゴミ箱へ移した収支を、内訳・品目・タグ・添付ファイルの情報とともに完全に削除します。
+// 削除した収支の件数と、削除した添付ファイルを返します（ファイル置き場のファイルは呼び出し側で削除します）。
+func (r *transactionRepository) PurgeTrash(before time.Time) (int, []domain.Attachment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := map[int]bool{}
+	kept := r.transactions[:0]
+	for _, t := range r.transactions {
+		if t.DeletedAt != nil && t.DeletedAt.Before(before) && r.ownsTrashed(t) {
+			purged[t.ID] = true
+			continue
+		}
+		kept = append(kept, t)
+	}
+	r.transactions = kept
+	var attachments []domain.Attachment
+	keptAttachments := r.attachments[:0]
+	for _, a := range r.attachments {
+		if purged[a.TransactionId] {
+			attachments = append(attachments, a)
+			continue
+		}
+		keptAttachments = append(keptAttachments, a)
+	}
+	r.attachments = keptAttachments
+	return len(purged), attachments, nil
+}
+
+// FindTransfer は振替の出金側・入金側の2行をこの順で返します。
+func (r *transactionRepository) FindTransfer(transferId int) ([]domain.Transaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []domain.Transaction
+	for _, t := range r.transactions {
+		if t.TransferId == transferId && r.owns(t) {
+			result = append(result, t)
+		}
+	}
+	if len(result) != 2 {
+		return nil, fmt.Errorf("振替が見つかりません: %d", transferId)
+	}
+	if result[0].Amount > result[1].Amount {
+		result[0], result[1] = result[1], result[0]
+	}
+	return result, nil
+}
+
+// SaveTransfer は振替の出金側・入金側の2行をまとめて登録します。
+// 出金側のIDを両方の TransferId に設定します。
+func (r *transactionRepository) SaveTransfer(out, in *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.householdId != 0 {
+		out.HouseholdId, in.HouseholdId = r.householdId, r.householdId
+	}
+	now := time.Now()
+	out.ID, in.ID = r.nextID, r.nextID+1
+	out.TransferId, in.TransferId = out.ID, out.ID
+	out.CreatedAt, in.CreatedAt = now, now
+	r.nextID += 2
+	r.transactions = append(r.transactions, *out, *in)
+	return nil
+}
+
+// UpdateTransfer は振替の出金側・入金側の2行をまとめて更新します。
+// どちらかが見つからない場合はどちらも更新しません。
+func (r *transactionRepository) UpdateTransfer(out, in *domain.Transaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	indexes := make([]int, 2)
+	for n, t := range []*domain.Transaction{out, in} {
+		indexes[n] = -1
+		for i, transaction := range r.transactions {
+			if transaction.ID == t.ID && transaction.TransferId != 0 && transaction.TransferId == t.TransferId && r.owns(transaction) {
+				indexes[n] = i
+			}
+		}
+		if indexes[n] < 0 {
+			return fmt.Errorf("振替が見つかりません: %d", t.TransferId)
+		}
+	}
+	for n, t := range []*domain.Transaction{out, in} {
+		t.CreatedAt = r.transactions[indexes[n]].CreatedAt
+		t.HouseholdId = r.transactions[indexes[n]].HouseholdId
+		t.CreatedBy = r.transactions[indexes[n]].CreatedBy
+		r.transactions[indexes[n]] = *t
+	}
+	return nil
+}
+
+// FindAttachments は収支の添付ファイルを添付した順に返します。収支が見つからない場合はエラーを返します。
+func (r *transactionRepository) FindAttachments(transactionId int) ([]domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.ownsTransactionLocked(transactionId) {
+		return nil, fmt.Errorf("収支が見つかりません: %d", transactionId)
+	}
+	result := []domain.Attachment{}
+	for _, a := range r.attachments {
+		if a.TransactionId == transactionId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// FindAttachmentById は添付ファイルを返します。
+func (r *transactionRepository) FindAttachmentById(id int) (domain.Attachment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.attachments {
+		if a.ID == id && r.ownsTransactionLocked(a.TransactionId) {
+			return a, nil
+		}
+	}
+	return domain.Attachment{}, fmt.Errorf("添付ファイルが見つかりません: %d", id)
+}
+
+// SaveAttachment は収支に添付ファイルを登録します。収支が見つからない場合はエラーを返します。
+func (r *transactionRepository) SaveAttachment(a *domain.Attachment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.ownsTransactionLocked(a.TransactionId) {
+		return fmt.Errorf("収支が見つかりません: %d", a.TransactionId)
+	}
+	a.ID = r.nextAttachmentID
+	r.nextAttachmentID++
+	a.CreatedAt = time.Now()
+	r.attachments = append(r.attachments, *a)
+	return nil
+}
+
+// DeleteAttachment は添付ファイルの情報を削除します（ファイル置き場のファイルは呼び出し側で削除します）。
+func (r *transactionRepository) DeleteAttachment(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, a := range r.attachments {
+		if a.ID == id && r.ownsTransactionLocked(a.TransactionId) {
+			r.attachments = append(r.attachments[:i], r.attachments[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("添付ファイルが見つかりません: %d", id)
+}
+
+// ownsTransactionLocked は収支が存在し、このリポジトリの扱う家計簿のもので、ゴミ箱にないかを判定します。
+// 呼び出し側でロックを取得している必要があります。
+func (r *transactionRepository) ownsTransactionLocked(id int) bool {
+	for _, t := range r.transactions {
+		if t.ID == id {
+			return r.owns(t)
+		}
+	}
+	return false
+}
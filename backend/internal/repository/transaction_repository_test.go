@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -46,6 +47,634 @@ func TestTransactionRepository_FindAll(t *testing.T) {
 	}
 }
 
+func TestTransactionRepository_FindByFilter(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -800, Memo: "スーパーで食材"},
+		{Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, Amount: -300, Memo: "電車"},
+		{Date: time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -2500, Memo: "スーパーでまとめ買い"},
+		{Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, Amount: 200000, Memo: "給与"},
+	} {
+		if err := repo.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	// 条件なしは日付の降順
+	all, total, err := repo.FindByFilter(domain.TransactionFilter{})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 4 || len(all) != 4 || all[0].Memo != "給与" {
+		t.Errorf("FindByFilter: expected 4 transactions newest first, got total=%d %+v", total, all)
+	}
+
+	// 日付・種別・カテゴリ・金額・メモの複合条件
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	minAmount := 500
+	found, total, err := repo.FindByFilter(domain.TransactionFilter{
+		From: &from, To: &to, Type: "expense", CategoryIds: []int{1, 9}, MinAmount: &minAmount, Memo: "スーパー",
+		SortBy: "amount", SortOrder: "asc",
+	})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 2 || len(found) != 2 {
+		t.Fatalf("FindByFilter: expected 2 transactions, got total=%d len=%d", total, len(found))
+	}
+	if found[0].Amount != -800 || found[1].Amount != -2500 {
+		t.Errorf("FindByFilter: expected ascending by absolute amount, got %d, %d", found[0].Amount, found[1].Amount)
+	}
+
+	// ページング: 総件数はページに関係なく全体の件数
+	page, total, err := repo.FindByFilter(domain.TransactionFilter{Page: 2, Limit: 3})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 4 || len(page) != 1 || page[0].Memo != "スーパーで食材" {
+		t.Errorf("FindByFilter: expected last page with 1 item, got total=%d %+v", total, page)
+	}
+
+	// 範囲外のページは空
+	empty, total, err := repo.FindByFilter(domain.TransactionFilter{Page: 5, Limit: 3})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 4 || len(empty) != 0 {
+		t.Errorf("FindByFilter: expected empty page, got total=%d len=%d", total, len(empty))
+	}
+}
+
+func TestTransactionRepository_FindMonthlySummary(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -999, Memo: "前月"},
+		{Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -800, Memo: "昼食"},
+		{Date: time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 11, Amount: -1200, Memo: "夕食"},
+		{Date: time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, Amount: -300, Memo: "電車"},
+		{Date: time.Date(2025, 2, 25, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, Amount: 200000, Memo: "給与"},
+		{Date: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, Amount: -999, Memo: "翌月"},
+	} {
+		if err := repo.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	summary, err := repo.FindMonthlySummary(2025, 2, 0)
+	if err != nil {
+		t.Fatalf("FindMonthlySummary: unexpected error: %v", err)
+	}
+	if summary.Income != 200000 || summary.Expense != 2300 || summary.Balance != 197700 || summary.Count != 4 {
+		t.Errorf("FindMonthlySummary: unexpected totals: %+v", summary)
+	}
+	if len(summary.Categories) != 3 {
+		t.Fatalf("FindMonthlySummary: expected 3 categories, got %+v", summary.Categories)
+	}
+	// 外食（食費の子カテゴリ）の分は食費に集約される
+	food := summary.Categories[0]
+	if food.CategoryId != 1 || food.CategoryName != "食費" || food.Expense != 2000 || food.Count != 2 {
+		t.Errorf("FindMonthlySummary: unexpected 食費 total: %+v", food)
+	}
+	if len(food.Children) != 1 || food.Children[0].CategoryName != "外食" || food.Children[0].Expense != 1200 {
+		t.Errorf("FindMonthlySummary: expected 外食 breakdown under 食費, got %+v", food.Children)
+	}
+
+	// データのない月は0件
+	empty, err := repo.FindMonthlySummary(2024, 12, 0)
+	if err != nil {
+		t.Fatalf("FindMonthlySummary: unexpected error: %v", err)
+	}
+	if empty.Count != 0 || empty.Categories == nil || len(empty.Categories) != 0 {
+		t.Errorf("FindMonthlySummary: expected empty summary, got %+v", empty)
+	}
+}
+
+func TestTransactionRepository_Splits(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	receipt := &domain.Transaction{
+		Date:       time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+		Type:       "expense",
+		CategoryId: 12,
+		Amount:     -5000,
+		Memo:       "スーパー",
+		Splits: []domain.Split{
+			{CategoryId: 12, Amount: -3000, Memo: "食材"},
+			{CategoryId: 9, Amount: -1200, Memo: "日用品"},
+			{CategoryId: 7, Amount: -800, Memo: "薬"},
+		},
+	}
+	if err := repo.Save(receipt); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if err := repo.Save(&domain.Transaction{
+		Date: time.Date(2025, 2, 12, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -700,
+	}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	found, err := repo.FindById(receipt.ID)
+	if err != nil {
+		t.Fatalf("FindById: unexpected error: %v", err)
+	}
+	if len(found.Splits) != 3 || found.Splits[0].ID == 0 || found.Splits[2].CategoryId != 7 {
+		t.Fatalf("FindById: unexpected splits: %+v", found.Splits)
+	}
+
+	// 内訳はそれぞれのカテゴリに集計し、件数は収支の件数
+	summary, err := repo.FindMonthlySummary(2025, 2, 0)
+	if err != nil {
+		t.Fatalf("FindMonthlySummary: unexpected error: %v", err)
+	}
+	if summary.Expense != 5700 || summary.Count != 2 {
+		t.Errorf("FindMonthlySummary: unexpected totals: %+v", summary)
+	}
+	expenses := map[string]int{}
+	for _, ct := range summary.Categories {
+		expenses[ct.CategoryName] = ct.Expense
+	}
+	if expenses["食費"] != 3700 || expenses["その他"] != 1200 || expenses["医療費"] != 800 {
+		t.Errorf("FindMonthlySummary: unexpected category totals: %+v", summary.Categories)
+	}
+
+	// カテゴリの絞り込みは内訳のカテゴリにも一致する
+	filtered, total, err := repo.FindByFilter(domain.TransactionFilter{CategoryIds: []int{7}})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 1 || filtered[0].ID != receipt.ID {
+		t.Errorf("FindByFilter: expected only the split transaction, got %+v", filtered)
+	}
+
+	// 内訳だけが参照しているカテゴリも使用中として扱い、付け替えると内訳も移る
+	if err := repo.DeleteCategory(7, 0); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("DeleteCategory: expected ErrCategoryInUse, got %v", err)
+	}
+	if err := repo.DeleteCategory(7, 9); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	found, _ = repo.FindById(receipt.ID)
+	if found.Splits[2].CategoryId != 9 || found.Splits[2].Category.Name != "その他" {
+		t.Errorf("DeleteCategory: expected split to be reassigned to その他, got %+v", found.Splits[2])
+	}
+
+	// 内訳を空にして更新すると内訳のない収支になる
+	found.Splits = nil
+	if err := repo.Update(&found); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	found, _ = repo.FindById(receipt.ID)
+	if len(found.Splits) != 0 {
+		t.Errorf("Update: expected splits to be removed, got %+v", found.Splits)
+	}
+}
+
+func TestTransactionRepository_Tags(t *testing.T) {
+	repo := NewTransactionRepository()
+	book := repo.ForHousehold(1)
+
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, Amount: -12000, Memo: "新幹線", Tags: []string{"旅行2025", "経費精算"}},
+		{Date: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 11, Amount: -3000, Memo: "夕食", Tags: []string{"旅行2025", "子ども"}},
+		{Date: time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 9, Amount: 12000, Memo: "交通費の精算", Tags: []string{"経費精算"}},
+		{Date: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 8, Amount: -5000, Memo: "習い事", Tags: []string{"子ども"}},
+		{Date: time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -800, Memo: "タグなし"},
+	} {
+		if err := book.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	// 別の家計簿のタグは候補にも集計にも含めない
+	if err := repo.ForHousehold(2).Save(&domain.Transaction{
+		Date: time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -999, Tags: []string{"旅行2025"},
+	}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// いずれかのタグ / すべてのタグでの絞り込み
+	matched, total, err := book.FindByFilter(domain.TransactionFilter{Tags: []string{"旅行2025", "子ども"}})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 3 || len(matched) != 3 {
+		t.Errorf("FindByFilter(any): expected 3 transactions, got %+v", matched)
+	}
+	all, total, err := book.FindByFilter(domain.TransactionFilter{Tags: []string{"旅行2025", "子ども"}, TagMatch: domain.TagMatchAll})
+	if err != nil {
+		t.Fatalf("FindByFilter: unexpected error: %v", err)
+	}
+	if total != 1 || all[0].Memo != "夕食" {
+		t.Errorf("FindByFilter(all): expected only 夕食, got %+v", all)
+	}
+
+	// 候補は件数の多い順、同数は名前順。前方一致で絞り込める
+	tags, err := book.FindTags("", 0)
+	if err != nil {
+		t.Fatalf("FindTags: unexpected error: %v", err)
+	}
+	if len(tags) != 3 || tags[0] != (domain.Tag{Name: "子ども", Count: 2}) || tags[2] != (domain.Tag{Name: "経費精算", Count: 2}) {
+		t.Errorf("FindTags: unexpected tags: %+v", tags)
+	}
+	tags, err = book.FindTags("旅", 10)
+	if err != nil {
+		t.Fatalf("FindTags: unexpected error: %v", err)
+	}
+	if len(tags) != 1 || tags[0] != (domain.Tag{Name: "旅行2025", Count: 2}) {
+		t.Errorf("FindTags: expected only 旅行2025 for prefix, got %+v", tags)
+	}
+
+	// 期間内のタグ別合計（複数のタグが付いた収支はそれぞれに数える）
+	totals, err := book.FindTagTotals(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("FindTagTotals: unexpected error: %v", err)
+	}
+	want := []domain.TagTotal{
+		{Tag: "旅行2025", Expense: 15000, Count: 2},
+		{Tag: "経費精算", Income: 12000, Expense: 12000, Count: 2},
+		{Tag: "子ども", Expense: 3000, Count: 1},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("FindTagTotals: expected %+v, got %+v", want, totals)
+	}
+	for i := range want {
+		if totals[i] != want[i] {
+			t.Errorf("FindTagTotals[%d]: expected %+v, got %+v", i, want[i], totals[i])
+		}
+	}
+
+	// 更新でタグを置き換える
+	found, _ := book.FindById(1)
+	found.Tags = []string{"出張"}
+	if err := book.Update(&found); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	if found, _ = book.FindById(1); len(found.Tags) != 1 || found.Tags[0] != "出張" {
+		t.Errorf("Update: expected tags to be replaced, got %v", found.Tags)
+	}
+}
+
+func TestTransactionRepository_Accounts(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	bank := &domain.Account{Name: "銀行", Kind: domain.AccountKindBank, OpeningBalance: 100000}
+	if err := repo.SaveAccount(bank); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+	if bank.ID != 2 || bank.DisplayOrder != 2 {
+		t.Errorf("SaveAccount: expected ID=2 appended at the end, got %+v", bank)
+	}
+
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -800},
+		{Date: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 3, AccountId: bank.ID, Amount: -70000},
+		{Date: time.Date(2025, 2, 25, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, AccountId: bank.ID, Amount: 200000},
+	} {
+		if err := repo.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	balances, err := repo.FindAccountBalances()
+	if err != nil {
+		t.Fatalf("FindAccountBalances: unexpected error: %v", err)
+	}
+	if len(balances) != 2 || balances[0].Balance != -800 || balances[1].Balance != 230000 {
+		t.Errorf("FindAccountBalances: expected balances -800 and 230000, got %+v", balances)
+	}
+
+	// 口座で絞り込んだ月次集計
+	summary, err := repo.FindMonthlySummary(2025, 2, bank.ID)
+	if err != nil {
+		t.Fatalf("FindMonthlySummary: unexpected error: %v", err)
+	}
+	if summary.Income != 200000 || summary.Expense != 70000 || summary.Count != 2 {
+		t.Errorf("FindMonthlySummary: unexpected totals for account %d: %+v", bank.ID, summary)
+	}
+
+	// 収支から参照されている口座は削除できない
+	if err := repo.DeleteAccount(bank.ID); !errors.Is(err, ErrAccountInUse) {
+		t.Errorf("DeleteAccount: expected ErrAccountInUse, got %v", err)
+	}
+}
+
+func TestTransactionRepository_DeleteAccountReferencedByRules(t *testing.T) {
+	repo := NewTransactionRepository()
+	recurring := NewRecurringRuleRepository()
+	rules := NewCategoryRuleRepository()
+	LinkMemoryRepositories(repo, recurring, rules, nil)
+
+	saveAccount := func(name string) *domain.Account {
+		t.Helper()
+		a := &domain.Account{Name: name, Kind: domain.AccountKindBank}
+		if err := repo.SaveAccount(a); err != nil {
+			t.Fatalf("SaveAccount: unexpected error: %v", err)
+		}
+		return a
+	}
+	salary, card, unused := saveAccount("給与口座"), saveAccount("カード"), saveAccount("使っていない口座")
+
+	if err := recurring.Save(&domain.RecurringRule{Name: "給与", Type: "income", CategoryId: 10, AccountId: salary.ID, Amount: 250000}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if err := rules.Save(&domain.CategoryRule{Name: "カード", Enabled: true, AccountId: card.ID, CategoryId: 12}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 定期収支ルール・仕分けルールから参照されている口座は削除できない
+	for _, a := range []*domain.Account{salary, card} {
+		if err := repo.DeleteAccount(a.ID); !errors.Is(err, ErrAccountInUse) {
+			t.Errorf("DeleteAccount(%s): expected ErrAccountInUse, got %v", a.Name, err)
+		}
+	}
+	if err := repo.DeleteAccount(unused.ID); err != nil {
+		t.Errorf("DeleteAccount(%s): unexpected error: %v", unused.Name, err)
+	}
+}
+
+func TestTransactionRepository_DeleteCategoryReferencedByRecurringRule(t *testing.T) {
+	repo := NewTransactionRepository()
+	recurring := NewRecurringRuleRepository()
+	LinkMemoryRepositories(repo, recurring, nil, nil)
+
+	rule := domain.RecurringRule{Name: "外食", Type: "expense", CategoryId: 11, AccountId: 1, Amount: -3000}
+	if err := recurring.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 収支がなくても定期収支ルールから参照されていれば付け替え先が必要
+	if err := repo.DeleteCategory(11, 0); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("DeleteCategory: expected ErrCategoryInUse, got %v", err)
+	}
+	if err := repo.DeleteCategory(11, 12); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	saved, err := recurring.FindById(rule.ID)
+	if err != nil {
+		t.Fatalf("FindById: expected rule to be kept, got %v", err)
+	}
+	if saved.CategoryId != 12 {
+		t.Errorf("DeleteCategory: expected rule to be reassigned to 12, got %d", saved.CategoryId)
+	}
+}
+
+func TestTransactionRepository_DeleteCategoryMovesBudgets(t *testing.T) {
+	repo := NewTransactionRepository()
+	budgets := NewBudgetRepository()
+	LinkMemoryRepositories(repo, nil, nil, budgets)
+
+	save := func(categoryId int, month string) domain.Budget {
+		t.Helper()
+		b := domain.Budget{CategoryId: categoryId, Month: month, Amount: 10000}
+		if err := budgets.Save(&b); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+		return b
+	}
+	save(11, "2025-03")
+	save(11, "2025-04")
+	conflict := save(12, "2025-04")
+	save(13, "2025-03")
+
+	// 付け替え先に同じ月の予算があれば何も変更しない
+	if err := repo.DeleteCategory(11, 12); !errors.Is(err, ErrBudgetExists) {
+		t.Fatalf("DeleteCategory: expected ErrBudgetExists, got %v", err)
+	}
+	if _, err := repo.FindCategoryById(11); err != nil {
+		t.Errorf("DeleteCategory: expected category 11 to be kept, got %v", err)
+	}
+
+	if err := budgets.Delete(conflict.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if err := repo.DeleteCategory(11, 12); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	all, _ := budgets.FindByMonth("")
+	moved := 0
+	for _, b := range all {
+		if b.CategoryId == 11 {
+			t.Errorf("DeleteCategory: expected no budgets left on category 11, got %+v", b)
+		}
+		if b.CategoryId == 12 {
+			moved++
+		}
+	}
+	if moved != 2 {
+		t.Errorf("DeleteCategory: expected 2 budgets moved to category 12, got %d", moved)
+	}
+
+	// 付け替え先を指定しなければ予算もカテゴリとともに削除する
+	if err := repo.DeleteCategory(13, 0); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if march, _ := budgets.FindByMonth("2025-03"); len(march) != 1 || march[0].CategoryId != 12 {
+		t.Errorf("DeleteCategory: expected only the moved 2025-03 budget, got %+v", march)
+	}
+}
+
+func TestTransactionRepository_Transfers(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	bank := &domain.Account{Name: "銀行", Kind: domain.AccountKindBank, OpeningBalance: 100000}
+	if err := repo.SaveAccount(bank); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+
+	// 銀行から現金へ3万円を引き出す
+	date := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	out := &domain.Transaction{Date: date, Type: domain.TransactionTypeTransfer, AccountId: bank.ID, Amount: -30000, Memo: "ATM"}
+	in := &domain.Transaction{Date: date, Type: domain.TransactionTypeTransfer, AccountId: 1, Amount: 30000, Memo: "ATM"}
+	if err := repo.SaveTransfer(out, in); err != nil {
+		t.Fatalf("SaveTransfer: unexpected error: %v", err)
+	}
+	if out.TransferId != out.ID || in.TransferId != out.ID {
+		t.Errorf("SaveTransfer: expected transfer_id=%d on both rows, got %d and %d", out.ID, out.TransferId, in.TransferId)
+	}
+
+	pair, err := repo.FindTransfer(out.TransferId)
+	if err != nil {
+		t.Fatalf("FindTransfer: unexpected error: %v", err)
+	}
+	if len(pair) != 2 || pair[0].ID != out.ID || pair[1].ID != in.ID {
+		t.Errorf("FindTransfer: expected outgoing then incoming, got %+v", pair)
+	}
+
+	// 振替は月次集計に含めず、口座残高には反映する
+	summary, err := repo.FindMonthlySummary(2025, 3, 0)
+	if err != nil {
+		t.Fatalf("FindMonthlySummary: unexpected error: %v", err)
+	}
+	if summary.Income != 0 || summary.Expense != 0 || summary.Count != 0 {
+		t.Errorf("FindMonthlySummary: expected transfers to be excluded, got %+v", summary)
+	}
+	balances, err := repo.FindAccountBalances()
+	if err != nil {
+		t.Fatalf("FindAccountBalances: unexpected error: %v", err)
+	}
+	if balances[0].Balance != 30000 || balances[1].Balance != 70000 {
+		t.Errorf("FindAccountBalances: expected balances 30000 and 70000, got %+v", balances)
+	}
+
+	// 片方を削除すると組の行も削除される
+	if err := repo.Delete(in.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	all, _ := repo.FindAll()
+	if len(all) != 0 {
+		t.Errorf("Delete: expected both rows of the transfer to be deleted, got %+v", all)
+	}
+}
+
+func TestTransactionRepository_ForHousehold(t *testing.T) {
+	repo := NewTransactionRepository()
+	alice, bob := repo.ForHousehold(1), repo.ForHousehold(2)
+	bobCash := domain.DefaultAccount()
+	if err := bob.SaveAccount(&bobCash); err != nil {
+		t.Fatalf("SaveAccount: unexpected error: %v", err)
+	}
+
+	// 家計簿の導入前の収支（所有者なし）
+	legacy := &domain.Transaction{Date: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -300}
+	if err := repo.Save(legacy); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	mine := &domain.Transaction{Date: time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, AccountId: bobCash.ID, Amount: -1000}
+	if err := bob.Save(mine); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if mine.HouseholdId != 2 {
+		t.Errorf("Save: expected HouseholdId=2, got %d", mine.HouseholdId)
+	}
+
+	// 他の家計簿の収支は見えず、更新・削除もできない
+	if all, _ := alice.FindAll(); len(all) != 0 {
+		t.Errorf("FindAll: expected no transactions for household 1, got %+v", all)
+	}
+	if _, err := alice.FindById(mine.ID); err == nil {
+		t.Error("FindById: expected error for another household's transaction")
+	}
+	if err := alice.Update(&domain.Transaction{ID: mine.ID, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -1}); err == nil {
+		t.Error("Update: expected error for another household's transaction")
+	}
+	if err := alice.Delete(mine.ID); err == nil {
+		t.Error("Delete: expected error for another household's transaction")
+	}
+	summary, _ := bob.FindMonthlySummary(2025, 4, 0)
+	if summary.Expense != 1000 || summary.Count != 1 {
+		t.Errorf("FindMonthlySummary: expected only household 2's expense, got %+v", summary)
+	}
+	balances, _ := bob.FindAccountBalances()
+	if len(balances) != 1 || balances[0].ID != bobCash.ID || balances[0].Balance != -1000 {
+		t.Errorf("FindAccountBalances: expected only household 2's account with -1000, got %+v", balances)
+	}
+
+	// 他の家計簿の口座は見えず、更新・削除もできない
+	if _, err := alice.FindAccountById(bobCash.ID); err == nil {
+		t.Error("FindAccountById: expected error for another household's account")
+	}
+	if err := alice.UpdateAccount(&domain.Account{ID: bobCash.ID, Name: "乗っ取り", Kind: domain.AccountKindCash}); err == nil {
+		t.Error("UpdateAccount: expected error for another household's account")
+	}
+	if err := alice.DeleteAccount(bobCash.ID); err == nil {
+		t.Error("DeleteAccount: expected error for another household's account")
+	}
+
+	// 所有者のいない収支を割り当てる
+	if err := repo.AssignUnowned(1); err != nil {
+		t.Fatalf("AssignUnowned: unexpected error: %v", err)
+	}
+	if all, _ := alice.FindAll(); len(all) != 1 || all[0].ID != legacy.ID {
+		t.Errorf("AssignUnowned: expected the legacy transaction for household 1, got %+v", all)
+	}
+	if all, _ := repo.FindAll(); len(all) != 2 {
+		t.Errorf("FindAll: expected all households' transactions without ForHousehold, got %d", len(all))
+	}
+	if accounts, _ := alice.FindAllAccounts(); len(accounts) != 1 || accounts[0].ID != domain.DefaultAccountId {
+		t.Errorf("AssignUnowned: expected the initial account for household 1, got %+v", accounts)
+	}
+}
+
+func TestTransactionRepository_SaveCategorySet(t *testing.T) {
+	repo := NewTransactionRepository()
+	if err := repo.AssignUnowned(1); err != nil {
+		t.Fatalf("AssignUnowned: unexpected error: %v", err)
+	}
+	if err := repo.SaveCategorySet(2, domain.DefaultCategories()); err != nil {
+		t.Fatalf("SaveCategorySet: unexpected error: %v", err)
+	}
+	first, second := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	categories, _ := second.FindAllCategories()
+	if len(categories) != len(domain.DefaultCategories()) {
+		t.Fatalf("FindAllCategories: expected %d categories, got %d", len(domain.DefaultCategories()), len(categories))
+	}
+	byId := map[int]domain.Category{}
+	for _, c := range categories {
+		byId[c.ID] = c
+	}
+	for _, c := range categories {
+		if c.ID <= 15 {
+			t.Errorf("SaveCategorySet: expected new ID, got %+v", c)
+		}
+		// 子カテゴリの親は同じ家計簿の新しいカテゴリを指す
+		if c.ParentId != 0 && byId[c.ParentId].ID == 0 {
+			t.Errorf("SaveCategorySet: parent of %s is not in household 2: %d", c.Name, c.ParentId)
+		}
+	}
+
+	// 他の家計簿のカテゴリは見えず、収支にも使えない
+	if _, err := first.FindCategoryById(categories[0].ID); err == nil {
+		t.Error("FindCategoryById: expected error for another household's category")
+	}
+	if _, err := second.FindCategoryById(1); err == nil {
+		t.Error("FindCategoryById: expected error for another household's category")
+	}
+	if mine, _ := first.FindAllCategories(); len(mine) != len(domain.DefaultCategories()) {
+		t.Errorf("FindAllCategories: expected household 1 to keep %d categories, got %d", len(domain.DefaultCategories()), len(mine))
+	}
+}
+
+func TestTransactionRepository_EachByFilter(t *testing.T) {
+	repo := NewTransactionRepository()
+	for i, amount := range []int{-100, -200, 300} {
+		typ := "expense"
+		if amount > 0 {
+			typ = "income"
+		}
+		tx := &domain.Transaction{Date: time.Date(2025, 8, i+1, 0, 0, 0, 0, time.UTC), Type: typ, CategoryId: 9, Amount: amount}
+		if err := repo.Save(tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+
+	// ページングの指定は使わず、条件に一致するすべての収支を順に渡す
+	var ids []int
+	err := repo.EachByFilter(domain.TransactionFilter{Type: "expense", SortOrder: "asc", Limit: 1}, func(t domain.Transaction) error {
+		ids = append(ids, t.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("EachByFilter: unexpected error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("EachByFilter: expected IDs [1 2], got %v", ids)
+	}
+
+	// fn がエラーを返すとそこで止める
+	stop := errors.New("stop")
+	count := 0
+	err = repo.EachByFilter(domain.TransactionFilter{}, func(t domain.Transaction) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Errorf("EachByFilter: expected to stop after the first error, got %v (%d calls)", err, count)
+	}
+}
+
 func TestTransactionRepository_FindById(t *testing.T) {
 	repo := NewTransactionRepository()
 
@@ -95,6 +724,126 @@ func TestTransactionRepository_FindCategoryById(t *testing.T) {
 	}
 }
 
+func TestTransactionRepository_SaveCategory(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	cat := &domain.Category{Name: "日用品"}
+	if err := repo.SaveCategory(cat); err != nil {
+		t.Fatalf("SaveCategory: unexpected error: %v", err)
+	}
+	if cat.ID != 16 {
+		t.Errorf("SaveCategory: expected ID=16, got %d", cat.ID)
+	}
+	if cat.DisplayOrder != 16 {
+		t.Errorf("SaveCategory: expected to be appended with DisplayOrder=16, got %d", cat.DisplayOrder)
+	}
+
+	all, _ := repo.FindAllCategories()
+	if len(all) != 16 || all[15].Name != "日用品" {
+		t.Errorf("SaveCategory: expected 日用品 at the end, got %+v", all)
+	}
+}
+
+func TestTransactionRepository_UpdateCategory(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	tx := &domain.Transaction{
+		Date:       time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		Type:       "expense",
+		CategoryId: 1,
+		Amount:     -1000,
+		Category:   domain.Category{ID: 1, Name: "食費"},
+	}
+	if err := repo.Save(tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 名前変更とアーカイブ
+	if err := repo.UpdateCategory(&domain.Category{ID: 1, Name: "食料品", DisplayOrder: 1, Archived: true}); err != nil {
+		t.Fatalf("UpdateCategory: unexpected error: %v", err)
+	}
+	cat, _ := repo.FindCategoryById(1)
+	if cat.Name != "食料品" || !cat.Archived {
+		t.Errorf("UpdateCategory: unexpected category: %+v", cat)
+	}
+
+	// 既存の収支は引き続き参照でき、新しい名前で返る
+	found, err := repo.FindById(1)
+	if err != nil {
+		t.Fatalf("FindById: unexpected error: %v", err)
+	}
+	if found.Category.Name != "食料品" {
+		t.Errorf("UpdateCategory: expected transaction category to be renamed, got %+v", found.Category)
+	}
+
+	// 存在しないカテゴリ
+	if err := repo.UpdateCategory(&domain.Category{ID: 999, Name: "存在しない"}); err == nil {
+		t.Error("UpdateCategory: expected error for non-existent category")
+	}
+}
+
+func TestTransactionRepository_ReorderCategories(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	if err := repo.ReorderCategories([]int{10, 3}); err != nil {
+		t.Fatalf("ReorderCategories: unexpected error: %v", err)
+	}
+	all, _ := repo.FindAllCategories()
+	if all[0].ID != 10 || all[1].ID != 3 || all[2].ID != 1 || all[14].ID != 15 {
+		t.Errorf("ReorderCategories: unexpected order: %+v", all)
+	}
+
+	if err := repo.ReorderCategories([]int{999}); err == nil {
+		t.Error("ReorderCategories: expected error for non-existent category")
+	}
+}
+
+func TestTransactionRepository_DeleteCategory(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	tx := &domain.Transaction{
+		Date:       time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		Type:       "expense",
+		CategoryId: 6,
+		Amount:     -3000,
+	}
+	if err := repo.Save(tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 子カテゴリを持つカテゴリは削除できない
+	if err := repo.DeleteCategory(4, 0); !errors.Is(err, ErrCategoryHasChildren) {
+		t.Errorf("DeleteCategory: expected ErrCategoryHasChildren, got %v", err)
+	}
+
+	// 参照されていないカテゴリは削除できる
+	if err := repo.DeleteCategory(8, 0); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if _, err := repo.FindCategoryById(8); err == nil {
+		t.Error("DeleteCategory: category should not exist after delete")
+	}
+
+	// 参照されているカテゴリは付け替え先がないと削除できない
+	if err := repo.DeleteCategory(6, 0); !errors.Is(err, ErrCategoryInUse) {
+		t.Errorf("DeleteCategory: expected ErrCategoryInUse, got %v", err)
+	}
+
+	// 付け替え先を指定すると収支を移してから削除する
+	if err := repo.DeleteCategory(6, 9); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	found, _ := repo.FindById(1)
+	if found.CategoryId != 9 || found.Category.Name != "その他" {
+		t.Errorf("DeleteCategory: expected transaction to be reassigned to その他, got %+v", found)
+	}
+
+	// 存在しないカテゴリ
+	if err := repo.DeleteCategory(999, 0); err == nil {
+		t.Error("DeleteCategory: expected error for non-existent category")
+	}
+}
+
 func TestTransactionRepository_Save(t *testing.T) {
 	repo := NewTransactionRepository()
 
@@ -123,6 +872,84 @@ func TestTransactionRepository_Save(t *testing.T) {
 	}
 }
 
+func TestTransactionRepository_Save_DuplicateOccurrence(t *testing.T) {
+	repo := NewTransactionRepository()
+
+	scheduled := time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC)
+	newOccurrence := func() *domain.Transaction {
+		return &domain.Transaction{
+			Date:            time.Date(2025, 1, 24, 0, 0, 0, 0, time.UTC),
+			Type:            "income",
+			CategoryId:      10,
+			Amount:          250000,
+			RecurringRuleId: 1,
+			RecurringDate:   &scheduled,
+		}
+	}
+
+	if err := repo.Save(newOccurrence()); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if err := repo.Save(newOccurrence()); !errors.Is(err, ErrDuplicateOccurrence) {
+		t.Errorf("Save: expected ErrDuplicateOccurrence, got %v", err)
+	}
+}
+
+func TestTransactionRepository_SaveAll(t *testing.T) {
+	repo := NewTransactionRepository()
+	book := repo.ForHousehold(1)
+
+	transactions := []*domain.Transaction{
+		{Date: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -500, Memo: "コンビニ"},
+		{Date: time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC), Type: "income", CategoryId: 10, Amount: 250000, Memo: "給与"},
+	}
+	if err := book.SaveAll(transactions); err != nil {
+		t.Fatalf("SaveAll: unexpected error: %v", err)
+	}
+	if transactions[0].ID == 0 || transactions[1].ID != transactions[0].ID+1 {
+		t.Errorf("SaveAll: expected sequential IDs, got %d, %d", transactions[0].ID, transactions[1].ID)
+	}
+	all, _ := book.FindAll()
+	if len(all) != 2 || all[0].HouseholdId != 1 || all[1].HouseholdId != 1 {
+		t.Errorf("SaveAll: expected 2 transactions in household 1, got %+v", all)
+	}
+}
+
+func TestTransactionRepository_SaveAll_Transfer(t *testing.T) {
+	repo := NewTransactionRepository()
+	date := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	transactions := []*domain.Transaction{
+		{Date: date, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -500},
+		{Date: date, Type: "transfer", AccountId: 1, Amount: -30000, Memo: "チャージ"},
+		{Date: date, Type: "transfer", AccountId: 2, Amount: 30000, Memo: "チャージ"},
+	}
+	if err := repo.SaveAll(transactions); err != nil {
+		t.Fatalf("SaveAll: unexpected error: %v", err)
+	}
+	out, in := transactions[1], transactions[2]
+	if out.TransferId != out.ID || in.TransferId != out.ID || transactions[0].TransferId != 0 {
+		t.Errorf("SaveAll: expected transfer pair with transfer_id %d, got %d, %d", out.ID, out.TransferId, in.TransferId)
+	}
+	pair, err := repo.FindTransfer(out.ID)
+	if err != nil || len(pair) != 2 || pair[0].ID != out.ID || pair[1].ID != in.ID {
+		t.Errorf("FindTransfer: expected saved pair, got %+v (err %v)", pair, err)
+	}
+
+	// 組になっていない振替はどの収支も登録しない
+	before, _ := repo.FindAll()
+	err = repo.SaveAll([]*domain.Transaction{
+		{Date: date, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -500},
+		{Date: date, Type: "transfer", AccountId: 1, Amount: -1000},
+	})
+	if err == nil {
+		t.Error("SaveAll: expected error for unpaired transfer")
+	}
+	if after, _ := repo.FindAll(); len(after) != len(before) {
+		t.Errorf("SaveAll: expected nothing saved, got %d -> %d transactions", len(before), len(after))
+	}
+}
+
 func TestTransactionRepository_Update(t *testing.T) {
 	repo := NewTransactionRepository()
 
@@ -199,3 +1026,350 @@ func TestTransactionRepository_Delete(t *testing.T) {
 		t.Error("Delete: expected error for non-existent ID")
 	}
 }
+
+func TestTransactionRepository_FindSearchCandidates(t *testing.T) {
+	repo := NewTransactionRepository()
+	book := repo.ForHousehold(1)
+
+	for _, tx := range []domain.Transaction{
+		{Date: time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 2, Amount: -1200, Memo: "ＡＭＡＺＯＮで日用品"},
+		{Date: time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -800, Memo: "昼食", Tags: []string{"出張"}},
+		{Date: time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -3000, Memo: "スーパー",
+			Splits: []domain.Split{{CategoryId: 1, Amount: -2000, Memo: "食材"}, {CategoryId: 2, Amount: -1000, Memo: "洗剤"}}},
+		{Date: time.Date(2025, 8, 13, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 12, Amount: -500, Memo: "ｾﾌﾞﾝｲﾚﾌﾞﾝ"},
+	} {
+		if err := book.Save(&tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	// 別の家計簿の収支は候補に含めない
+	if err := repo.ForHousehold(2).Save(&domain.Transaction{
+		Date: time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC), Type: "expense", CategoryId: 1, Amount: -999, Memo: "amazon",
+	}); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		memos []string
+	}{
+		{"Amazon", []string{"ＡＭＡＺＯＮで日用品"}}, // 全角・大文字を区別しない
+		{"出張", []string{"昼食"}},             // タグ
+		{"洗剤", []string{"スーパー"}},           // 内訳のメモ
+		{"セブン", []string{"ｾﾌﾞﾝｲﾚﾌﾞﾝ"}},     // 半角カナの濁点を前の文字とまとめる
+		{"存在しない", nil},
+	} {
+		found, err := book.FindSearchCandidates(domain.SearchGrams(tc.query))
+		if err != nil {
+			t.Fatalf("FindSearchCandidates(%s): unexpected error: %v", tc.query, err)
+		}
+		if len(found) != len(tc.memos) {
+			t.Errorf("FindSearchCandidates(%s): expected %v, got %+v", tc.query, tc.memos, found)
+			continue
+		}
+		for i, memo := range tc.memos {
+			if found[i].Memo != memo {
+				t.Errorf("FindSearchCandidates(%s)[%d]: expected %s, got %s", tc.query, i, memo, found[i].Memo)
+			}
+		}
+	}
+}
+
+func TestTransactionRepository_CategoryMappings(t *testing.T) {
+	repo := NewTransactionRepository()
+	book1, book2 := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	if err := book1.SaveCategoryMappings("zaim", []domain.CategoryMapping{
+		{Name: "食費/食料品", CategoryId: 2},
+		{Name: "交際費", CategoryId: 8},
+	}); err != nil {
+		t.Fatalf("SaveCategoryMappings: unexpected error: %v", err)
+	}
+	if err := book2.SaveCategoryMappings("zaim", []domain.CategoryMapping{{Name: "交際費", CategoryId: 9}}); err != nil {
+		t.Fatalf("SaveCategoryMappings: unexpected error: %v", err)
+	}
+
+	found, _ := book1.FindCategoryMappings("zaim")
+	if len(found) != 2 || found[0].Name != "交際費" || found[0].Source != "zaim" || found[1].CategoryId != 2 {
+		t.Errorf("FindCategoryMappings: unexpected mappings: %+v", found)
+	}
+	if found, _ := book1.FindCategoryMappings("moneyforward"); len(found) != 0 {
+		t.Errorf("FindCategoryMappings: expected no mappings for another source, got %+v", found)
+	}
+
+	// 置き換えは他の家計簿の対応に影響しない
+	if err := book1.SaveCategoryMappings("zaim", []domain.CategoryMapping{{Name: "交際費", CategoryId: 8}}); err != nil {
+		t.Fatalf("SaveCategoryMappings: unexpected error: %v", err)
+	}
+	if found, _ := book1.FindCategoryMappings("zaim"); len(found) != 1 {
+		t.Errorf("SaveCategoryMappings: expected mappings to be replaced, got %+v", found)
+	}
+	if found, _ := book2.FindCategoryMappings("zaim"); len(found) != 1 || found[0].CategoryId != 9 {
+		t.Errorf("SaveCategoryMappings: expected household 2 to keep its mapping, got %+v", found)
+	}
+
+	// 参照されていないカテゴリを削除すると対応も削除する
+	if err := repo.DeleteCategory(8, 0); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+	if found, _ := book1.FindCategoryMappings("zaim"); len(found) != 0 {
+		t.Errorf("DeleteCategory: expected mapping to be removed, got %+v", found)
+	}
+}
+
+func TestTransactionRepository_Payees(t *testing.T) {
+	repo := NewTransactionRepository()
+	book1, book2 := repo.ForHousehold(1), repo.ForHousehold(2)
+	save := func(r TransactionRepository, date string, amount int, payee string) domain.Transaction {
+		t.Helper()
+		d, _ := time.Parse("2006-01-02", date)
+		tx := &domain.Transaction{Date: d, Type: "expense", CategoryId: 1, AccountId: 1, Amount: amount, PayeeName: payee}
+		if err := r.Save(tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+		return *tx
+	}
+
+	// 書き方の揺れた名前は同じ支払先になり、家計簿が違えば別の支払先になる
+	seven := save(book1, "2025-08-01", -300, "ｾﾌﾞﾝｲﾚﾌﾞﾝ 新宿店")
+	if seven.PayeeId == 0 || seven.PayeeName != "セブンイレブン" {
+		t.Fatalf("Save: unexpected payee: %d %q", seven.PayeeId, seven.PayeeName)
+	}
+	if again := save(book1, "2025-08-15", -500, "セブン-イレブン 渋谷駅前店"); again.PayeeId != seven.PayeeId {
+		t.Errorf("Save: expected same payee %d, got %d", seven.PayeeId, again.PayeeId)
+	}
+	if other := save(book2, "2025-08-01", -300, "セブンイレブン"); other.PayeeId == seven.PayeeId {
+		t.Errorf("Save: expected another payee for household 2, got %d", other.PayeeId)
+	}
+	lawson := save(book1, "2025-09-03", -200, "LAWSON")
+
+	payees, _ := book1.FindAllPayees()
+	if len(payees) != 2 || payees[0].ID != seven.PayeeId || payees[0].Count != 2 || payees[0].LastDate.Format("2006-01-02") != "2025-08-15" {
+		t.Fatalf("FindAllPayees: unexpected payees: %+v", payees)
+	}
+
+	// 統合すると収支を付け替え、統合元の名前で登録した収支も統合先になる
+	if err := book1.MergePayees(seven.PayeeId, []int{lawson.PayeeId}); err != nil {
+		t.Fatalf("MergePayees: unexpected error: %v", err)
+	}
+	if merged, _ := book1.FindById(lawson.ID); merged.PayeeId != seven.PayeeId || merged.PayeeName != "セブンイレブン" {
+		t.Errorf("MergePayees: expected transaction to be reassigned, got %d %q", merged.PayeeId, merged.PayeeName)
+	}
+	if later := save(book1, "2025-09-20", -100, "ﾛｰｿﾝ"); later.PayeeId == seven.PayeeId {
+		t.Errorf("Save: expected a different name to create a new payee")
+	}
+	if later := save(book1, "2025-09-21", -100, "Lawson"); later.PayeeId != seven.PayeeId {
+		t.Errorf("Save: expected merged key to resolve to %d, got %d", seven.PayeeId, later.PayeeId)
+	}
+	if err := book1.MergePayees(seven.PayeeId, []int{lawson.PayeeId}); err == nil {
+		t.Errorf("MergePayees: expected error for already merged payee")
+	}
+
+	// 名前を変えると収支の支払先名も変わる
+	renamed := domain.Payee{ID: seven.PayeeId, Name: "セブン"}
+	if err := book1.UpdatePayee(&renamed); err != nil {
+		t.Fatalf("UpdatePayee: unexpected error: %v", err)
+	}
+	if found, _ := book1.FindById(seven.ID); found.PayeeName != "セブン" {
+		t.Errorf("UpdatePayee: expected transaction payee name to be updated, got %q", found.PayeeName)
+	}
+	if err := book2.UpdatePayee(&domain.Payee{ID: seven.PayeeId, Name: "x"}); err == nil {
+		t.Errorf("UpdatePayee: expected error for another household's payee")
+	}
+
+	totals, _ := book1.FindPayeeTotals(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC))
+	if len(totals) != 2 || totals[0].PayeeId != seven.PayeeId || totals[0].Expense != 1100 || totals[0].Count != 4 ||
+		len(totals[0].Months) != 2 || totals[0].Months[1] != (domain.PayeeMonth{Month: "2025-09", Expense: 300, Count: 2}) {
+		t.Errorf("FindPayeeTotals: unexpected totals: %+v", totals)
+	}
+}
+
+func TestTransactionRepository_Attachments(t *testing.T) {
+	repo := NewTransactionRepository()
+	book1, book2 := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	tx := &domain.Transaction{Date: time.Now(), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -3000}
+	if err := book1.Save(tx); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	receipt := &domain.Attachment{TransactionId: tx.ID, FileName: "receipt.jpg", ContentType: "image/jpeg", Size: 100, BlobKey: "a/1"}
+	if err := book1.SaveAttachment(receipt); err != nil {
+		t.Fatalf("SaveAttachment: unexpected error: %v", err)
+	}
+	if receipt.ID == 0 || receipt.CreatedAt.IsZero() {
+		t.Errorf("SaveAttachment: expected ID and CreatedAt to be set, got %+v", receipt)
+	}
+	// 他の家計簿の収支には添付できず、添付ファイルも見えない
+	if err := book2.SaveAttachment(&domain.Attachment{TransactionId: tx.ID, BlobKey: "a/2"}); err == nil {
+		t.Errorf("SaveAttachment: expected error for another household's transaction")
+	}
+	if _, err := book2.FindAttachmentById(receipt.ID); err == nil {
+		t.Errorf("FindAttachmentById: expected error for another household")
+	}
+	if _, err := book2.FindAttachments(tx.ID); err == nil {
+		t.Errorf("FindAttachments: expected error for another household")
+	}
+
+	warranty := &domain.Attachment{TransactionId: tx.ID, FileName: "warranty.pdf", ContentType: "application/pdf", Size: 200, BlobKey: "a/3"}
+	if err := book1.SaveAttachment(warranty); err != nil {
+		t.Fatalf("SaveAttachment: unexpected error: %v", err)
+	}
+	attachments, err := book1.FindAttachments(tx.ID)
+	if err != nil || len(attachments) != 2 || attachments[0].ID != receipt.ID || attachments[1].ID != warranty.ID {
+		t.Fatalf("FindAttachments: unexpected result: %+v, %v", attachments, err)
+	}
+
+	if err := book1.DeleteAttachment(receipt.ID); err != nil {
+		t.Fatalf("DeleteAttachment: unexpected error: %v", err)
+	}
+	if _, err := book1.FindAttachmentById(receipt.ID); err == nil {
+		t.Errorf("FindAttachmentById: expected error after DeleteAttachment")
+	}
+
+	// ゴミ箱の収支の添付ファイルは見えず、完全に削除すると添付ファイルの情報も削除する
+	if err := book1.Delete(tx.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := repo.FindAttachmentById(warranty.ID); err == nil {
+		t.Errorf("FindAttachmentById: expected error after the transaction was deleted")
+	}
+	n, purged, err := repo.PurgeTrash(time.Now().Add(time.Second))
+	if err != nil || n != 1 || len(purged) != 1 || purged[0].ID != warranty.ID {
+		t.Errorf("PurgeTrash: unexpected result: %d, %+v, %v", n, purged, err)
+	}
+}
+
+func TestTransactionRepository_Items(t *testing.T) {
+	repo := NewTransactionRepository()
+	book1, book2 := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	day := func(month, d int) time.Time { return time.Date(2025, time.Month(month), d, 0, 0, 0, 0, time.UTC) }
+	shopping := &domain.Transaction{Date: day(3, 10), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -668, PayeeName: "スーパーA",
+		Items: []domain.LineItem{{Name: "牛乳", Quantity: 2, UnitPrice: 214}, {Name: "卵", Quantity: 1, UnitPrice: 240}}}
+	earlier := &domain.Transaction{Date: day(2, 5), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -198,
+		Items: []domain.LineItem{{Name: "低脂肪牛乳", Quantity: 1, UnitPrice: 198}}}
+	other := &domain.Transaction{Date: day(3, 1), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -250,
+		Items: []domain.LineItem{{Name: "牛乳", Quantity: 1, UnitPrice: 250}}}
+	for _, tx := range []*domain.Transaction{shopping, earlier} {
+		if err := book1.Save(tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	if err := book2.Save(other); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if shopping.Items[0].ID == 0 || shopping.Items[0].ID == shopping.Items[1].ID {
+		t.Errorf("Save: expected item IDs to be set, got %+v", shopping.Items)
+	}
+	got, _ := book1.FindById(shopping.ID)
+	if len(got.Items) != 2 || got.Items[1].Name != "卵" || got.Items[0].Total() != 428 {
+		t.Errorf("FindById: unexpected items: %+v", got.Items)
+	}
+
+	// 品名の一部で検索し、日付順に返す。他の家計簿の品目は含めない
+	purchases, err := book1.FindItemPurchases("牛乳", day(1, 1), day(12, 31))
+	if err != nil {
+		t.Fatalf("FindItemPurchases: unexpected error: %v", err)
+	}
+	if len(purchases) != 2 || purchases[0].Name != "低脂肪牛乳" || purchases[1].TransactionId != shopping.ID ||
+		purchases[1].PayeeName != "スーパーA" || purchases[1].Quantity != 2 || purchases[1].UnitPrice != 214 {
+		t.Errorf("FindItemPurchases: unexpected result: %+v", purchases)
+	}
+	if purchases, _ := book1.FindItemPurchases("牛乳", day(3, 1), day(3, 31)); len(purchases) != 1 {
+		t.Errorf("FindItemPurchases(3月): expected 1 purchase, got %+v", purchases)
+	}
+
+	// 更新すると品目を置き換える
+	shopping.Amount = -240
+	shopping.Items = []domain.LineItem{{Name: "卵", Quantity: 1, UnitPrice: 240}}
+	if err := book1.Update(shopping); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	if purchases, _ := book1.FindItemPurchases("牛乳", day(1, 1), day(12, 31)); len(purchases) != 1 {
+		t.Errorf("FindItemPurchases after Update: expected 1 purchase, got %+v", purchases)
+	}
+}
+
+func TestTransactionRepository_Trash(t *testing.T) {
+	repo := NewTransactionRepository()
+	book1, book2 := repo.ForHousehold(1), repo.ForHousehold(2)
+
+	date := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	lunch := &domain.Transaction{Date: date, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -1000, Memo: "昼食", Tags: []string{"外食"}}
+	dinner := &domain.Transaction{Date: date, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -3000, Memo: "夕食"}
+	for _, tx := range []*domain.Transaction{lunch, dinner} {
+		if err := book1.Save(tx); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	out := &domain.Transaction{Date: date, Type: domain.TransactionTypeTransfer, AccountId: 1, Amount: -5000}
+	in := &domain.Transaction{Date: date, Type: domain.TransactionTypeTransfer, AccountId: 2, Amount: 5000}
+	if err := book1.SaveTransfer(out, in); err != nil {
+		t.Fatalf("SaveTransfer: unexpected error: %v", err)
+	}
+
+	// 他の家計簿の収支はゴミ箱へ移せない
+	if err := book2.Delete(lunch.ID); err == nil {
+		t.Errorf("Delete: expected error for another household's transaction")
+	}
+	// ゴミ箱の収支は一覧・集計・タグに含めず、二重にゴミ箱へは移せない
+	if err := book1.Delete(lunch.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if err := book1.Delete(lunch.ID); err == nil {
+		t.Errorf("Delete: expected error for a trashed transaction")
+	}
+	if _, err := book1.FindById(lunch.ID); err == nil {
+		t.Errorf("FindById: expected error for a trashed transaction")
+	}
+	if all, _ := book1.FindAll(); len(all) != 3 {
+		t.Errorf("FindAll: expected 3 transactions, got %d", len(all))
+	}
+	if summary, _ := book1.FindMonthlySummary(2025, 3, 0); summary.Expense != 3000 || summary.Count != 1 {
+		t.Errorf("FindMonthlySummary: expected expense 3000 in 1 transaction, got %+v", summary)
+	}
+	if tags, _ := book1.FindTags("", 0); len(tags) != 0 {
+		t.Errorf("FindTags: expected no tags, got %+v", tags)
+	}
+	if err := book1.Update(&domain.Transaction{ID: lunch.ID, Date: date, Type: "expense", CategoryId: 1, AccountId: 1, Amount: -1}); err == nil {
+		t.Errorf("Update: expected error for a trashed transaction")
+	}
+
+	// 振替は2行まとめてゴミ箱へ移し、まとめて戻す
+	if err := book1.Delete(in.ID); err != nil {
+		t.Fatalf("Delete(transfer): unexpected error: %v", err)
+	}
+	trash, err := book1.FindTrash()
+	if err != nil || len(trash) != 3 || trash[2].ID != lunch.ID || trash[2].DeletedAt == nil || len(trash[2].Tags) != 1 {
+		t.Fatalf("FindTrash: unexpected result: %+v, %v", trash, err)
+	}
+	if trash, _ := book2.FindTrash(); len(trash) != 0 {
+		t.Errorf("FindTrash: expected empty trash for another household, got %+v", trash)
+	}
+	if err := book1.Restore(out.ID); err != nil {
+		t.Fatalf("Restore(transfer): unexpected error: %v", err)
+	}
+	if pair, err := book1.FindTransfer(out.TransferId); err != nil || len(pair) != 2 {
+		t.Errorf("FindTransfer after Restore: unexpected result: %+v, %v", pair, err)
+	}
+	if err := book1.Restore(dinner.ID); err == nil {
+		t.Errorf("Restore: expected error for a transaction not in the trash")
+	}
+	if err := book2.Restore(lunch.ID); err == nil {
+		t.Errorf("Restore: expected error for another household's transaction")
+	}
+
+	// 保存期間を過ぎた収支だけを完全に削除する
+	if n, _, err := book1.PurgeTrash(time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Errorf("PurgeTrash: expected nothing to purge, got %d, %v", n, err)
+	}
+	if n, _, err := book1.PurgeTrash(time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Errorf("PurgeTrash: expected 1 purged transaction, got %d, %v", n, err)
+	}
+	if trash, _ := book1.FindTrash(); len(trash) != 0 {
+		t.Errorf("FindTrash after PurgeTrash: expected empty trash, got %+v", trash)
+	}
+	if err := book1.Restore(lunch.ID); err == nil {
+		t.Errorf("Restore: expected error for a purged transaction")
+	}
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// UserRepository はユーザーとログインセッションの永続化を担当するリポジトリのインターフェースです。
+type UserRepository interface {
+	FindById(id int) (domain.User, error)
+	FindByEmail(email string) (domain.User, error)
+	Save(user *domain.User) error
+	UpdateHousehold(userId, householdId int) error
+	FindSession(tokenHash string, now time.Time) (domain.Session, error)
+	SaveSession(session domain.Session) error
+	DeleteSession(tokenHash string) error
+}
+
+// UnownedAssigner は家計簿ごとにデータを持つリポジトリに共通の操作です。
+type UnownedAssigner interface {
+	// AssignUnowned は所有者のいない（家計簿の導入前に登録された）データを householdId の家計簿に割り当てます。
+	AssignUnowned(householdId int) error
+}
+
+// ErrUserNotFound は指定したメールアドレスのユーザーがいない場合のエラーです。
+var ErrUserNotFound = errors.New("ユーザーが見つかりません")
+
+// ErrEmailTaken は同じメールアドレスのユーザーが既に登録されている場合のエラーです。
+var ErrEmailTaken = errors.New("このメールアドレスは既に登録されています")
+
+// ErrSessionNotFound はセッションが存在しないか有効期限切れの場合のエラーです。
+var ErrSessionNotFound = errors.New("セッションが見つかりません")
+
+type userRepository struct {
+	mu       sync.RWMutex
+	users    []domain.User
+	sessions map[string]domain.Session
+	nextID   int
+}
+
+// NewUserRepository はメモリベースのUserRepositoryを生成します。
+func NewUserRepository() UserRepository {
+	return &userRepository{
+		users:    []domain.User{},
+		sessions: map[string]domain.Session{},
+		nextID:   1,
+	}
+}
+
+func (r *userRepository) FindById(id int) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return domain.User{}, fmt.Errorf("ユーザーが見つかりません: %d", id)
+}
+
+// FindByEmail はメールアドレスでユーザーを探します。見つからない場合は ErrUserNotFound を返します。
+func (r *userRepository) FindByEmail(email string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return domain.User{}, ErrUserNotFound
+}
+
+// Save はユーザーを新規登録します。同じメールアドレスのユーザーがいる場合は ErrEmailTaken を返します。
+func (r *userRepository) Save(u *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == u.Email {
+			return ErrEmailTaken
+		}
+	}
+	u.ID = r.nextID
+	u.CreatedAt = time.Now()
+	r.nextID++
+	r.users = append(r.users, *u)
+	return nil
+}
+
+// UpdateHousehold はユーザーがいま利用している家計簿を変更します。
+func (r *userRepository) UpdateHousehold(userId, householdId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.users {
+		if r.users[i].ID == userId {
+			r.users[i].HouseholdId = householdId
+			return nil
+		}
+	}
+	return fmt.Errorf("ユーザーが見つかりません: %d", userId)
+}
+
+// FindSession は now の時点で有効なセッションを返します。
+// 存在しないか有効期限が切れている場合は ErrSessionNotFound を返します。
+func (r *userRepository) FindSession(tokenHash string, now time.Time) (domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.sessions[tokenHash]
+	if !ok || !now.Before(s.ExpiresAt) {
+		return domain.Session{}, ErrSessionNotFound
+	}
+	return s, nil
+}
+
+// SaveSession はセッションを保存します。同じユーザーの有効期限切れのセッションはこのとき削除します。
+func (r *userRepository) SaveSession(s domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for hash, session := range r.sessions {
+		if session.UserId == s.UserId && !now.Before(session.ExpiresAt) {
+			delete(r.sessions, hash)
+		}
+	}
+	r.sessions[s.TokenHash] = s
+	return nil
+}
+
+// DeleteSession はセッションを削除します。存在しない場合も何もせず成功します。
+func (r *userRepository) DeleteSession(tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, tokenHash)
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// postgresUserRepository は PostgreSQL 用の UserRepository 実装です。
+type postgresUserRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresUserRepository は PostgreSQL を使う UserRepository を返します。
+func NewPostgresUserRepository(db *sql.DB) UserRepository {
+	return &postgresUserRepository{db: db}
+}
+
+func (r *postgresUserRepository) FindById(id int) (domain.User, error) {
+	var u domain.User
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT id, email, name, password_hash, COALESCE(household_id, 0), created_at FROM users WHERE id = $1
+	`, id).Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.HouseholdId, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.User{}, fmt.Errorf("ユーザーが見つかりません: %d", id)
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("FindById: %w", err)
+	}
+	return u, nil
+}
+
+// FindByEmail はメールアドレスでユーザーを探します。見つからない場合は ErrUserNotFound を返します。
+func (r *postgresUserRepository) FindByEmail(email string) (domain.User, error) {
+	var u domain.User
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT id, email, name, password_hash, COALESCE(household_id, 0), created_at FROM users WHERE email = $1
+	`, email).Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.HouseholdId, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.User{}, ErrUserNotFound
+	}
+	if err != nil {
+		return domain.User{}, fmt.Errorf("FindByEmail: %w", err)
+	}
+	return u, nil
+}
+
+// Save はユーザーを新規登録します。同じメールアドレスのユーザーがいる場合は ErrEmailTaken を返します。
+func (r *postgresUserRepository) Save(u *domain.User) error {
+	err := r.db.QueryRowContext(context.Background(), `
+		INSERT INTO users (email, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, u.Email, u.Name, u.PasswordHash).Scan(&u.ID, &u.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("Save: %w", err)
+	}
+	return nil
+}
+
+// UpdateHousehold はユーザーがいま利用している家計簿を変更します。
+func (r *postgresUserRepository) UpdateHousehold(userId, householdId int) error {
+	result, err := r.db.ExecContext(context.Background(),
+		`UPDATE users SET household_id = NULLIF($1, 0) WHERE id = $2`, householdId, userId)
+	if err != nil {
+		return fmt.Errorf("UpdateHousehold: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("ユーザーが見つかりません: %d", userId)
+	}
+	return nil
+}
+
+// FindSession は now の時点で有効なセッションを返します。
+// 存在しないか有効期限が切れている場合は ErrSessionNotFound を返します。
+func (r *postgresUserRepository) FindSession(tokenHash string, now time.Time) (domain.Session, error) {
+	var s domain.Session
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT token_hash, user_id, expires_at FROM sessions
+		WHERE token_hash = $1 AND expires_at > $2
+	`, tokenHash, now).Scan(&s.TokenHash, &s.UserId, &s.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return domain.Session{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return domain.Session{}, fmt.Errorf("FindSession: %w", err)
+	}
+	return s, nil
+}
+
+// SaveSession はセッションを保存します。同じユーザーの有効期限切れのセッションはこのとき削除します。
+func (r *postgresUserRepository) SaveSession(s domain.Session) error {
+	ctx := context.Background()
+	if _, err := r.db.ExecContext(ctx,
+		`DELETE FROM sessions WHERE user_id = $1 AND expires_at <= CURRENT_TIMESTAMP`, s.UserId,
+	); err != nil {
+		return fmt.Errorf("SaveSession: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO sessions (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+	`, s.TokenHash, s.UserId, s.ExpiresAt); err != nil {
+		return fmt.Errorf("SaveSession: %w", err)
+	}
+	return nil
+}
+
+// DeleteSession はセッションを削除します。存在しない場合も何もせず成功します。
+func (r *postgresUserRepository) DeleteSession(tokenHash string) error {
+	if _, err := r.db.ExecContext(context.Background(),
+		`DELETE FROM sessions WHERE token_hash = $1`, tokenHash,
+	); err != nil {
+		return fmt.Errorf("DeleteSession: %w", err)
+	}
+	return nil
+}
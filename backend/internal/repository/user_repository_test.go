@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// user_repository_test.go は UserRepository の単体テストです。
+// メモリベースのリポジトリのユーザー登録とセッションの有効期限を検証します。
+
+func TestUserRepository_Save(t *testing.T) {
+	repo := NewUserRepository()
+
+	user := &domain.User{Email: "taro@example.com", Name: "太郎", PasswordHash: "hash"}
+	if err := repo.Save(user); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	if user.ID != 1 || user.CreatedAt.IsZero() {
+		t.Errorf("Save: expected ID=1 and CreatedAt set, got %+v", user)
+	}
+
+	found, err := repo.FindByEmail("taro@example.com")
+	if err != nil || found.ID != user.ID {
+		t.Errorf("FindByEmail: expected user %d, got %+v (err=%v)", user.ID, found, err)
+	}
+	if _, err := repo.FindByEmail("hanako@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("FindByEmail: expected ErrUserNotFound, got %v", err)
+	}
+
+	// 同じメールアドレスは登録できない
+	if err := repo.Save(&domain.User{Email: "taro@example.com", PasswordHash: "hash"}); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("Save: expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestUserRepository_Sessions(t *testing.T) {
+	repo := NewUserRepository()
+	now := time.Now()
+
+	session := domain.Session{TokenHash: "abc", UserId: 1, ExpiresAt: now.Add(time.Hour)}
+	if err := repo.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession: unexpected error: %v", err)
+	}
+
+	found, err := repo.FindSession("abc", now)
+	if err != nil || found.UserId != 1 {
+		t.Errorf("FindSession: expected session of user 1, got %+v (err=%v)", found, err)
+	}
+
+	// 有効期限を過ぎたセッションは見つからない
+	if _, err := repo.FindSession("abc", now.Add(2*time.Hour)); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("FindSession: expected ErrSessionNotFound after expiry, got %v", err)
+	}
+
+	if err := repo.DeleteSession("abc"); err != nil {
+		t.Fatalf("DeleteSession: unexpected error: %v", err)
+	}
+	if _, err := repo.FindSession("abc", now); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("FindSession: expected ErrSessionNotFound after delete, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Package scheduler は定期収支の自動登録など、バックグラウンドで定期実行する処理を提供します。
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+)
+
+// maxOccurrencesPerRun は1回の実行で1つのルールから登録する収支の上限です。
+// 開始日を大きく過去にしたルールでも1回の実行が長引かないようにします。
+const maxOccurrencesPerRun = 366
+
+// RecurringPoster は定期収支ルールの予定日が来た収支を登録します。
+// すべての家計簿のルールを扱うため、ForHousehold で絞り込んでいないリポジトリを渡します。
+// 登録する収支はルールを持つ家計簿のものになります。
+type RecurringPoster struct {
+	rules        repository.RecurringRuleRepository
+	transactions repository.TransactionRepository
+	now          func() time.Time
+
+	mu sync.Mutex
+	// skipped は登録できずに飛ばしているルールのIDと理由です（同じ理由のログを毎回出さないため）
+	skipped map[int]string
+}
+
+// NewRecurringPoster はRecurringPosterを生成します。
+func NewRecurringPoster(rules repository.RecurringRuleRepository, transactions repository.TransactionRepository) *RecurringPoster {
+	return &RecurringPoster{rules: rules, transactions: transactions, now: time.Now, skipped: map[int]string{}}
+}
+
+// Run は起動直後と interval ごとに PostDue を実行します。ctx が終了するまで戻りません。
+func (p *RecurringPoster) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.PostDue(p.now())
+		if err != nil {
+			log.Printf("定期収支の登録に失敗しました: %v", err)
+		} else if n > 0 {
+			log.Printf("定期収支を%d件登録しました", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PostDue は登録日が asOf 以前で未登録の発生をすべて収支として登録し、登録件数を返します。
+// 登録済みの予定日はルールごとに記録するため、何度実行しても二重に登録しません。
+// カテゴリか口座が削除・アーカイブされたルールはエラーにせず飛ばし、最初の1回だけログに出します。
+// 1つのルールで失敗しても他のルールの登録は続け、最後にまとめてエラーを返します。
+func (p *RecurringPoster) PostDue(asOf time.Time) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rules, err := p.rules.FindAll()
+	if err != nil {
+		return 0, fmt.Errorf("定期収支の取得に失敗しました: %w", err)
+	}
+	categoryList, err := p.transactions.FindAllCategories()
+	if err != nil {
+		return 0, fmt.Errorf("カテゴリの取得に失敗しました: %w", err)
+	}
+	categories := make(map[int]domain.Category, len(categoryList))
+	for _, c := range categoryList {
+		categories[c.ID] = c
+	}
+	accountList, err := p.transactions.FindAllAccounts()
+	if err != nil {
+		return 0, fmt.Errorf("口座の取得に失敗しました: %w", err)
+	}
+	accounts := make(map[int]domain.Account, len(accountList))
+	for _, a := range accountList {
+		accounts[a.ID] = a
+	}
+
+	posted := 0
+	var errs []error
+	for _, rule := range rules {
+		n, err := p.postRule(rule, asOf, categories, accounts)
+		posted += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("定期収支 %d: %w", rule.ID, err))
+		}
+	}
+	return posted, errors.Join(errs...)
+}
+
+// postRule は1つのルールの未登録の発生を古い順に登録します。
+// カテゴリか口座が使えないルールは何も登録せず、登録済みの予定日も進めません。
+func (p *RecurringPoster) postRule(rule domain.RecurringRule, asOf time.Time, categories map[int]domain.Category, accounts map[int]domain.Account) (int, error) {
+	var after time.Time
+	if rule.PostedThrough != nil {
+		after = *rule.PostedThrough
+	}
+	occurrences := rule.Occurrences(after, asOf, maxOccurrencesPerRun)
+	if len(occurrences) == 0 {
+		return 0, nil
+	}
+
+	category, ok := categories[rule.CategoryId]
+	account, accountOk := accounts[rule.AccountId]
+	reason := ""
+	switch {
+	case !ok:
+		reason = fmt.Sprintf("カテゴリ %d が見つかりません", rule.CategoryId)
+	case category.Archived:
+		reason = fmt.Sprintf("カテゴリ %d はアーカイブされています", rule.CategoryId)
+	case !accountOk:
+		reason = fmt.Sprintf("口座 %d が見つかりません", rule.AccountId)
+	case account.Archived:
+		reason = fmt.Sprintf("口座 %d はアーカイブされています", rule.AccountId)
+	}
+	if reason != "" {
+		if p.skipped[rule.ID] != reason {
+			log.Printf("定期収支 %d を登録せずに飛ばします: %s", rule.ID, reason)
+			p.skipped[rule.ID] = reason
+		}
+		return 0, nil
+	}
+	delete(p.skipped, rule.ID)
+
+	posted := 0
+	for _, o := range occurrences {
+		scheduled := o.ScheduledDate
+		transaction := domain.Transaction{
+			Date:            o.Date,
+			Type:            o.Type,
+			CategoryId:      o.CategoryId,
+			AccountId:       o.AccountId,
+			Amount:          o.Amount,
+			Memo:            o.Memo,
+			Category:        category,
+			RecurringRuleId: rule.ID,
+			RecurringDate:   &scheduled,
+			HouseholdId:     rule.HouseholdId,
+		}
+		// 前回の実行が予定日の記録前に中断した場合は登録済みとして扱う
+		if err := p.transactions.Save(&transaction); err != nil && !errors.Is(err, repository.ErrDuplicateOccurrence) {
+			return posted, err
+		} else if err == nil {
+			posted++
+		}
+		if err := p.rules.UpdatePostedThrough(rule.ID, scheduled); err != nil {
+			return posted, err
+		}
+	}
+	return posted, nil
+}
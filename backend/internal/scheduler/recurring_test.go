@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+)
+
+// recurring_test.go は RecurringPoster の単体テストです。
+// メモリベースのリポジトリで、予定日が来た収支の登録と二重登録の防止を検証します。
+
+func TestRecurringPoster_PostDue(t *testing.T) {
+	rules := repository.NewRecurringRuleRepository()
+	transactions := repository.NewTransactionRepository()
+	p := NewRecurringPoster(rules, transactions)
+
+	// 毎月25日の給与。休日なら前営業日（2025-01-25 は土曜 → 1/24）
+	rule := domain.RecurringRule{
+		Name: "給与", Type: "income", CategoryId: 10, AccountId: 1, Amount: 250000,
+		Frequency: domain.FrequencyMonthly, Interval: 1, DayOfMonth: 25, Adjustment: domain.AdjustmentPrevious,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := rules.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	asOf := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	n, err := p.PostDue(asOf)
+	if err != nil {
+		t.Fatalf("PostDue: unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("PostDue: expected 2 transactions, got %d", n)
+	}
+
+	all, _ := transactions.FindAll()
+	if len(all) != 2 {
+		t.Fatalf("PostDue: expected 2 stored transactions, got %d", len(all))
+	}
+	if got := all[0].Date.Format("2006-01-02"); got != "2025-01-24" {
+		t.Errorf("PostDue: expected first date 2025-01-24, got %s", got)
+	}
+	if all[0].RecurringRuleId != rule.ID || all[0].Category.Name != "給与" {
+		t.Errorf("PostDue: expected rule %d and category 給与, got %+v", rule.ID, all[0])
+	}
+
+	// 同じ日に再実行しても二重に登録しない
+	n, err = p.PostDue(asOf)
+	if err != nil {
+		t.Fatalf("PostDue: unexpected error: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("PostDue: expected no new transactions on rerun, got %d", n)
+	}
+
+	saved, _ := rules.FindById(rule.ID)
+	if saved.PostedThrough == nil || saved.PostedThrough.Format("2006-01-02") != "2025-02-25" {
+		t.Errorf("PostDue: expected posted_through 2025-02-25, got %v", saved.PostedThrough)
+	}
+}
+
+func TestRecurringPoster_PostDue_EndDate(t *testing.T) {
+	rules := repository.NewRecurringRuleRepository()
+	transactions := repository.NewTransactionRepository()
+	p := NewRecurringPoster(rules, transactions)
+
+	end := time.Date(2025, 1, 14, 0, 0, 0, 0, time.UTC)
+	rule := domain.RecurringRule{
+		Name: "定期券", Type: "expense", CategoryId: 2, AccountId: 1, Amount: -1000,
+		Frequency: domain.FrequencyWeekly, Interval: 1, Adjustment: domain.AdjustmentNone,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: &end,
+	}
+	if err := rules.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+
+	// 1/1, 1/8 のみ（1/15 は終了日より後）
+	n, err := p.PostDue(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("PostDue: unexpected error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("PostDue: expected 2 transactions, got %d", n)
+	}
+}
+
+func TestRecurringPoster_PostDue_SkipsUnavailableCategory(t *testing.T) {
+	rules := repository.NewRecurringRuleRepository()
+	transactions := repository.NewTransactionRepository()
+	p := NewRecurringPoster(rules, transactions)
+
+	rule := domain.RecurringRule{
+		Name: "外食", Type: "expense", CategoryId: 11, AccountId: 1, Amount: -3000,
+		Frequency: domain.FrequencyMonthly, Interval: 1, DayOfMonth: 10, Adjustment: domain.AdjustmentNone,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if err := rules.Save(&rule); err != nil {
+		t.Fatalf("Save: unexpected error: %v", err)
+	}
+	// 定期収支と結び付けていないため、ルールが参照していてもカテゴリを削除できる
+	if err := transactions.DeleteCategory(11, 0); err != nil {
+		t.Fatalf("DeleteCategory: unexpected error: %v", err)
+	}
+
+	asOf := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		n, err := p.PostDue(asOf)
+		if err != nil {
+			t.Fatalf("PostDue: expected deleted category to be skipped, got %v", err)
+		}
+		if n != 0 {
+			t.Errorf("PostDue: expected no transactions, got %d", n)
+		}
+	}
+	saved, _ := rules.FindById(rule.ID)
+	if saved.PostedThrough != nil {
+		t.Errorf("PostDue: expected posted_through to stay unset, got %v", saved.PostedThrough)
+	}
+
+	// アーカイブされたカテゴリも同じく飛ばす
+	category, _ := transactions.FindCategoryById(2)
+	category.Archived = true
+	if err := transactions.UpdateCategory(&category); err != nil {
+		t.Fatalf("UpdateCategory: unexpected error: %v", err)
+	}
+	saved.CategoryId = 2
+	if err := rules.Update(&saved); err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	if n, err := p.PostDue(asOf); err != nil || n != 0 {
+		t.Errorf("PostDue: expected archived category to be skipped, got %d, %v", n, err)
+	}
+
+	category.Archived = false
+	if err := transactions.UpdateCategory(&category); err != nil {
+		t.Fatalf("UpdateCategory: unexpected error: %v", err)
+	}
+	if n, err := p.PostDue(asOf); err != nil || n != 2 {
+		t.Errorf("PostDue: expected 2 transactions after unarchiving, got %d, %v", n, err)
+	}
+}
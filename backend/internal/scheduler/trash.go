@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"kakeibo-app/backend/internal/repository"
+	"kakeibo-app/backend/internal/storage"
+)
+
+// TrashPurger は保存期間を過ぎたゴミ箱の収支を完全に削除します。
+// 添付ファイルはファイル置き場からも削除します。
+// すべての家計簿の収支を扱うため、ForHousehold で絞り込んでいないリポジトリを渡します。
+type TrashPurger struct {
+	transactions repository.TransactionRepository
+	blobs        storage.BlobStore
+	retention    time.Duration
+	now          func() time.Time
+}
+
+// NewTrashPurger はTrashPurgerを生成します。retention はゴミ箱へ移してから完全に削除するまでの期間です。
+func NewTrashPurger(transactions repository.TransactionRepository, blobs storage.BlobStore, retention time.Duration) *TrashPurger {
+	return &TrashPurger{transactions: transactions, blobs: blobs, retention: retention, now: time.Now}
+}
+
+// Run は起動直後と interval ごとに PurgeExpired を実行します。ctx が終了するまで戻りません。
+func (p *TrashPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := p.PurgeExpired(p.now())
+		if err != nil {
+			log.Printf("ゴミ箱の収支の削除に失敗しました: %v", err)
+		} else if n > 0 {
+			log.Printf("ゴミ箱の収支を%d件削除しました", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired は asOf の時点で保存期間を過ぎたゴミ箱の収支を削除し、削除件数を返します。
+// 添付ファイルの情報は削除済みのため、ファイル置き場からの削除に失敗してもログに残して続けます。
+func (p *TrashPurger) PurgeExpired(asOf time.Time) (int, error) {
+	n, attachments, err := p.transactions.PurgeTrash(asOf.Add(-p.retention))
+	if err != nil {
+		return 0, fmt.Errorf("ゴミ箱の収支の削除に失敗しました: %w", err)
+	}
+	storage.DeleteAttachments(p.blobs, attachments)
+	return n, nil
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"kakeibo-app/backend/internal/domain"
+	"kakeibo-app/backend/internal/repository"
+	"kakeibo-app/backend/internal/storage"
+)
+
+// trash_test.go は TrashPurger の単体テストです。
+// 保存期間を過ぎたゴミ箱の収支だけを削除し、添付ファイルもファイル置き場から削除することを検証します。
+
+func TestTrashPurger_PurgeExpired(t *testing.T) {
+	transactions := repository.NewTransactionRepository()
+	blobs := storage.NewLocalBlobStore(t.TempDir())
+	p := NewTrashPurger(transactions, blobs, 30*24*time.Hour)
+
+	tx := &domain.Transaction{Date: time.Now(), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -1000}
+	kept := &domain.Transaction{Date: time.Now(), Type: "expense", CategoryId: 1, AccountId: 1, Amount: -2000}
+	for _, transaction := range []*domain.Transaction{tx, kept} {
+		if err := transactions.Save(transaction); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+	}
+	receipt := &domain.Attachment{TransactionId: tx.ID, FileName: "receipt.png", ContentType: "image/png", BlobKey: "attachments/1/a", HasThumbnail: true}
+	for _, key := range []string{receipt.BlobKey, receipt.ThumbnailKey()} {
+		if err := blobs.Put(key, strings.NewReader("x")); err != nil {
+			t.Fatalf("Put: unexpected error: %v", err)
+		}
+	}
+	if err := transactions.SaveAttachment(receipt); err != nil {
+		t.Fatalf("SaveAttachment: unexpected error: %v", err)
+	}
+	if err := transactions.Delete(tx.ID); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+
+	// 保存期間内は削除しない
+	if n, err := p.PurgeExpired(time.Now().Add(29 * 24 * time.Hour)); err != nil || n != 0 {
+		t.Fatalf("PurgeExpired: expected nothing to purge, got %d, %v", n, err)
+	}
+	if trash, _ := transactions.FindTrash(); len(trash) != 1 {
+		t.Fatalf("FindTrash: expected 1 transaction, got %d", len(trash))
+	}
+
+	n, err := p.PurgeExpired(time.Now().Add(31 * 24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("PurgeExpired: expected 1 purged transaction, got %d, %v", n, err)
+	}
+	if trash, _ := transactions.FindTrash(); len(trash) != 0 {
+		t.Errorf("FindTrash: expected empty trash, got %+v", trash)
+	}
+	for _, key := range []string{receipt.BlobKey, receipt.ThumbnailKey()} {
+		if _, err := blobs.Open(key); !errors.Is(err, storage.ErrBlobNotFound) {
+			t.Errorf("PurgeExpired: expected %s to be deleted, got %v", key, err)
+		}
+	}
+	if all, _ := transactions.FindAll(); len(all) != 1 || all[0].ID != kept.ID {
+		t.Errorf("FindAll: expected the other transaction to remain, got %+v", all)
+	}
+}
@@ -0,0 +1,127 @@
+// Package storage は添付ファイルなどの中身（blob）を保存するファイル置き場を提供します。
+// 置き場は BlobStore インターフェースで差し替えられ、標準ではローカルのディレクトリに保存します。
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// BlobStore はキーを指定してファイルの中身を保存・取得・削除するファイル置き場です。
+// キーは "/" 区切りの相対パスで、".." や絶対パスは使えません。
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// ErrBlobNotFound はキーのファイルが置き場にない場合のエラーです。
+var ErrBlobNotFound = errors.New("ファイルが見つかりません")
+
+// NewKey は prefix の下に重複しないランダムなキーを作ります。
+func NewKey(prefix string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("NewKey: %w", err)
+	}
+	return prefix + "/" + hex.EncodeToString(b), nil
+}
+
+// LocalBlobStore は dir 以下のファイルに保存する BlobStore です。
+type LocalBlobStore struct {
+	dir string
+}
+
+// NewLocalBlobStore は dir 以下に保存する LocalBlobStore を生成します。dir は最初の保存時に作ります。
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{dir: dir}
+}
+
+// path はキーのファイルのパスを返します。
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("不正なキーです: %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put は r の中身をキーのファイルに保存します。同じキーのファイルは置き換えます。
+// 一時ファイルに書き込んでから名前を変えるため、途中で失敗しても中途半端なファイルは残りません。
+func (s *LocalBlobStore) Put(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("Put: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("Put: %w", err)
+	}
+	defer os.Remove(f.Name()) // 名前を変えた後は何もしない
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("Put: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Put: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("Put: %w", err)
+	}
+	return nil
+}
+
+// Open はキーのファイルを開きます。ない場合は ErrBlobNotFound を返します。
+func (s *LocalBlobStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Open: %w", err)
+	}
+	return f, nil
+}
+
+// Delete はキーのファイルを削除します。ない場合は何もしません。
+func (s *LocalBlobStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Delete: %w", err)
+	}
+	return nil
+}
+
+// DeleteAttachments は添付ファイルの中身とサムネイルを置き場から削除します。
+// 添付ファイルの情報は削除済みのため、失敗してもログに残して続けます。
+func DeleteAttachments(blobs BlobStore, attachments []domain.Attachment) {
+	for _, a := range attachments {
+		keys := []string{a.BlobKey}
+		if a.HasThumbnail {
+			keys = append(keys, a.ThumbnailKey())
+		}
+		for _, key := range keys {
+			if err := blobs.Delete(key); err != nil {
+				log.Printf("添付ファイルの削除に失敗しました（%s）: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"kakeibo-app/backend/internal/domain"
+)
+
+// blob_test.go はローカルのファイル置き場・添付ファイルの削除・サムネイルの単体テストです。
+
+func TestLocalBlobStore(t *testing.T) {
+	s := NewLocalBlobStore(t.TempDir())
+
+	key, err := NewKey("attachments/1")
+	if err != nil {
+		t.Fatalf("NewKey: unexpected error: %v", err)
+	}
+	if err := s.Put(key, strings.NewReader("レシート")); err != nil {
+		t.Fatalf("Put: unexpected error: %v", err)
+	}
+	f, err := s.Open(key)
+	if err != nil {
+		t.Fatalf("Open: unexpected error: %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	f.Close()
+	if string(data) != "レシート" {
+		t.Errorf("Open: got %q, want %q", data, "レシート")
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := s.Open(key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Open after Delete: got %v, want ErrBlobNotFound", err)
+	}
+	// ないファイルの削除はエラーにしない
+	if err := s.Delete(key); err != nil {
+		t.Errorf("Delete again: unexpected error: %v", err)
+	}
+
+	for _, bad := range []string{"../outside", "/etc/passwd", "a/../../b", ""} {
+		if err := s.Put(bad, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q): expected error", bad)
+		}
+	}
+}
+
+func TestDeleteAttachments(t *testing.T) {
+	s := NewLocalBlobStore(t.TempDir())
+
+	attachments := []domain.Attachment{
+		{BlobKey: "attachments/1/receipt", HasThumbnail: true},
+		{BlobKey: "attachments/1/document"},
+	}
+	for _, a := range attachments {
+		keys := []string{a.BlobKey}
+		if a.HasThumbnail {
+			keys = append(keys, a.ThumbnailKey())
+		}
+		for _, key := range keys {
+			if err := s.Put(key, strings.NewReader("x")); err != nil {
+				t.Fatalf("Put(%s): unexpected error: %v", key, err)
+			}
+		}
+	}
+
+	// 中身とサムネイルをすべて削除する
+	DeleteAttachments(s, attachments)
+	for _, key := range []string{"attachments/1/receipt", attachments[0].ThumbnailKey(), "attachments/1/document"} {
+		if _, err := s.Open(key); !errors.Is(err, ErrBlobNotFound) {
+			t.Errorf("Open(%s) after DeleteAttachments: got %v, want ErrBlobNotFound", key, err)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1000, 500))
+	for y := 0; y < 500; y++ {
+		for x := 0; x < 1000; x++ {
+			src.Set(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatalf("png.Encode: unexpected error: %v", err)
+	}
+
+	thumb, err := Thumbnail(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Thumbnail: unexpected error: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("jpeg.Decode: unexpected error: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(ThumbnailSize, ThumbnailSize/2) {
+		t.Errorf("Thumbnail size: got %v, want %v", got, image.Pt(ThumbnailSize, ThumbnailSize/2))
+	}
+	if r, _, _, _ := img.At(10, 10).RGBA(); r>>8 < 180 {
+		t.Errorf("Thumbnail color: got red %d, want about 200", r>>8)
+	}
+
+	if _, err := Thumbnail([]byte("%PDF-1.4")); !errors.Is(err, ErrThumbnailUnsupported) {
+		t.Errorf("Thumbnail(PDF): got %v, want ErrThumbnailUnsupported", err)
+	}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	_ "image/gif" // image.Decode で GIF を読めるようにする
+	_ "image/png" // image.Decode で PNG を読めるようにする
+)
+
+// ThumbnailSize はサムネイルの長い辺の大きさ（ピクセル）です。
+const ThumbnailSize = 320
+
+// maxThumbnailSourcePixels はサムネイルを作る元画像の画素数の上限です（巨大な画像での過大なメモリ使用を防ぎます）。
+const maxThumbnailSourcePixels = 50_000_000
+
+// ErrThumbnailUnsupported はサムネイルを作れない形式の画像の場合のエラーです（WebP など）。
+var ErrThumbnailUnsupported = errors.New("サムネイルを作れない画像形式です")
+
+// Thumbnail は JPEG・PNG・GIF の画像 data から、長い辺を ThumbnailSize 以下に縮小した JPEG を作ります。
+// 元の画像が小さい場合は縮小せずに JPEG にします。透明な部分は白で塗ります。
+func Thumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrThumbnailUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("画像を読み込めません: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxThumbnailSourcePixels {
+		return nil, fmt.Errorf("画像が大きすぎます: %dx%d", config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("画像を読み込めません: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, shrink(src, ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("サムネイルを作れません: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// shrink は src を長い辺が size 以下になるように縮小します。
+// 縮小後の1画素には、元の画像の対応する範囲の画素の平均の色を使います。
+func shrink(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/b.Dx())
+		} else {
+			w, h = max(1, w*size/b.Dy()), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/w)
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					// 白い背景に重ねる
+					a := uint64(c.A)
+					r += (uint64(c.R)*a + 255*(255-a)) / 255
+					g += (uint64(c.G)*a + 255*(255-a)) / 255
+					bl += (uint64(c.B)*a + 255*(255-a)) / 255
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 255})
+		}
+	}
+	return dst
+}
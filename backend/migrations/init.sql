@@ -1,5 +1,6 @@
 -- 家計簿DB初期スキーマ
 -- PostgreSQL コンテナ初回起動時に自動実行されます。
+-- 何度実行しても安全なように書いているため、既存DBへ再実行すると不足している列・テーブルが追加されます。
 
 -- カテゴリテーブル
 CREATE TABLE IF NOT EXISTS categories (
@@ -18,21 +19,461 @@ CREATE TABLE IF NOT EXISTS transactions (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- カテゴリ管理（表示順・アーカイブ）
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS display_order INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- カテゴリ種別（income / expense / both）
+-- 列を追加するときだけ、既存の初期カテゴリに種別を設定します。
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'categories' AND column_name = 'kind'
+    ) THEN
+        ALTER TABLE categories ADD COLUMN kind VARCHAR(10) NOT NULL DEFAULT 'expense'
+            CHECK (kind IN ('income', 'expense', 'both'));
+        UPDATE categories SET kind = 'income' WHERE name = '給与';
+        UPDATE categories SET kind = 'both' WHERE name = 'その他';
+    END IF;
+END $$;
+
+-- 子カテゴリ（階層は2段まで。例: 食費 > 外食）
+-- 列を追加するときだけ、既存の初期カテゴリに子カテゴリを追加します。
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'categories' AND column_name = 'parent_id'
+    ) THEN
+        ALTER TABLE categories ADD COLUMN parent_id INTEGER REFERENCES categories(id);
+        INSERT INTO categories (name, kind, parent_id, display_order)
+        SELECT v.name, p.kind, p.id, (SELECT COALESCE(MAX(display_order), 0) FROM categories) + v.ord
+        FROM (VALUES
+            ('食費', '外食', 1),
+            ('食費', '食材', 2),
+            ('光熱費', '電気', 3),
+            ('光熱費', 'ガス', 4),
+            ('光熱費', '水道', 5)
+        ) AS v(parent_name, name, ord)
+        JOIN categories p ON p.name = v.parent_name;
+    END IF;
+END $$;
+
+-- 月次予算テーブル（month は月初日。付け替え先を指定せずにカテゴリを削除した場合は予算も削除）
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 口座テーブル（現金・銀行口座・クレジットカード・電子マネー）
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('cash', 'bank', 'credit_card', 'e_money')),
+    opening_balance INTEGER NOT NULL DEFAULT 0,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    archived BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- 初期口座（口座が1件もない場合のみ投入。最初に作成された家計簿の口座になる）
+INSERT INTO accounts (id, name, kind, display_order)
+SELECT 1, '現金', 'cash', 1
+WHERE NOT EXISTS (SELECT 1 FROM accounts)
+ON CONFLICT (id) DO NOTHING;
+
+SELECT setval('accounts_id_seq', (SELECT MAX(id) FROM accounts));
+
+-- 定期収支ルールテーブル（ルールから参照されているカテゴリはアプリが付け替え先なしでは削除させない）
+-- posted_through は登録済みの最後の予定日（休日調整前）
+CREATE TABLE IF NOT EXISTS recurring_rules (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('income', 'expense')),
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL,
+    memo TEXT NOT NULL DEFAULT '',
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'yearly')),
+    interval_count INTEGER NOT NULL DEFAULT 1 CHECK (interval_count >= 1),
+    day_of_month INTEGER NOT NULL DEFAULT 0 CHECK (day_of_month BETWEEN 0 AND 31),
+    end_of_month BOOLEAN NOT NULL DEFAULT FALSE,
+    adjustment VARCHAR(10) NOT NULL DEFAULT 'none' CHECK (adjustment IN ('none', 'previous', 'next')),
+    start_date DATE NOT NULL,
+    end_date DATE,
+    posted_through DATE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 定期収支から登録された収支の元ルールと予定日（ルール削除後も収支は残す）
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS recurring_rule_id INTEGER REFERENCES recurring_rules(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS recurring_date DATE;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_recurring
+    ON transactions(recurring_rule_id, recurring_date) WHERE recurring_rule_id IS NOT NULL;
+
+-- 収支・定期収支の口座
+-- 列を追加するときだけ、既存の行を初期口座（現金）に割り当てます。
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'account_id'
+    ) THEN
+        ALTER TABLE transactions ADD COLUMN account_id INTEGER NOT NULL DEFAULT 1 REFERENCES accounts(id);
+        ALTER TABLE transactions ALTER COLUMN account_id DROP DEFAULT;
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'recurring_rules' AND column_name = 'account_id'
+    ) THEN
+        ALTER TABLE recurring_rules ADD COLUMN account_id INTEGER NOT NULL DEFAULT 1 REFERENCES accounts(id);
+        ALTER TABLE recurring_rules ALTER COLUMN account_id DROP DEFAULT;
+    END IF;
+END $$;
+
+-- 口座間の振替（出金・入金の2行を transfer_id で組にする。transfer_id は出金側の行のID）
+-- 振替の行はカテゴリを持たないため category_id は NULL を許可します。
+ALTER TABLE transactions ALTER COLUMN category_id DROP NOT NULL;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+    CHECK (type IN ('income', 'expense', 'transfer'));
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS transfer_id INTEGER;
+
+-- ユーザー（パスワードは bcrypt のハッシュだけを保存）
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(254) NOT NULL UNIQUE,
+    name VARCHAR(50) NOT NULL DEFAULT '',
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ログインセッション（token_hash はトークンの SHA-256。ユーザー削除時はセッションも削除）
+CREATE TABLE IF NOT EXISTS sessions (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 家計簿（収支・カテゴリ・予算・定期収支を持ち、メンバーで共有する。口座は家計簿の間で共有）
+CREATE TABLE IF NOT EXISTS households (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 家計簿のメンバーと役割（owner: 所有者 / editor: 編集者 / viewer: 閲覧者）
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (household_id, user_id)
+);
+
+-- 家計簿への招待（token_hash は招待トークンの SHA-256。受けると削除）
+CREATE TABLE IF NOT EXISTS household_invitations (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    email VARCHAR(254) NOT NULL,
+    role VARCHAR(10) NOT NULL CHECK (role IN ('editor', 'viewer')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ユーザーがいま利用している家計簿
+ALTER TABLE users ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE SET NULL;
+
+-- 収支・カテゴリ・予算・定期収支の家計簿と、収支を登録したメンバー
+-- 家計簿の導入前の行は NULL のままにし、最初に作成された家計簿へアプリが割り当てます。
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE recurring_rules ADD COLUMN IF NOT EXISTS household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS created_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+-- ユーザーごとにデータを持っていたDB（user_id 列がある場合）の移行
+-- ユーザーごとに所有者の家計簿を作り、そのユーザーの行を割り当てます。
+-- カテゴリは最初の家計簿が引き継ぎ、それ以外の家計簿にはコピーを作って参照を付け替えます。
+DO $$
+DECLARE
+    u RECORD;
+    c RECORD;
+    hid INTEGER;
+    first_hid INTEGER;
+    new_cid INTEGER;
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'transactions' AND column_name = 'user_id'
+    ) THEN
+        CREATE TEMP TABLE category_map (old_id INTEGER PRIMARY KEY, new_id INTEGER NOT NULL) ON COMMIT DROP;
+        FOR u IN SELECT id, name FROM users WHERE household_id IS NULL ORDER BY id LOOP
+            INSERT INTO households (name)
+            VALUES (CASE WHEN u.name = '' THEN 'マイ家計簿' ELSE u.name || 'の家計簿' END)
+            RETURNING id INTO hid;
+            INSERT INTO household_members (household_id, user_id, role) VALUES (hid, u.id, 'owner');
+            UPDATE users SET household_id = hid WHERE id = u.id;
+            UPDATE transactions SET household_id = hid, created_by = u.id WHERE user_id = u.id;
+            UPDATE budgets SET household_id = hid WHERE user_id = u.id;
+            UPDATE recurring_rules SET household_id = hid WHERE user_id = u.id;
+
+            IF first_hid IS NULL THEN
+                first_hid := hid;
+                UPDATE categories SET household_id = hid WHERE household_id IS NULL;
+                CONTINUE;
+            END IF;
+
+            DELETE FROM category_map;
+            FOR c IN
+                SELECT * FROM categories WHERE household_id = first_hid ORDER BY parent_id IS NOT NULL, id
+            LOOP
+                INSERT INTO categories (name, kind, parent_id, display_order, archived, household_id)
+                VALUES (c.name, c.kind, (SELECT new_id FROM category_map WHERE old_id = c.parent_id),
+                        c.display_order, c.archived, hid)
+                RETURNING id INTO new_cid;
+                INSERT INTO category_map VALUES (c.id, new_cid);
+            END LOOP;
+            UPDATE transactions t SET category_id = m.new_id
+                FROM category_map m WHERE t.household_id = hid AND t.category_id = m.old_id;
+            UPDATE budgets b SET category_id = m.new_id
+                FROM category_map m WHERE b.household_id = hid AND b.category_id = m.old_id;
+            UPDATE recurring_rules r SET category_id = m.new_id
+                FROM category_map m WHERE r.household_id = hid AND r.category_id = m.old_id;
+        END LOOP;
+
+        ALTER TABLE transactions DROP COLUMN user_id;
+        ALTER TABLE budgets DROP COLUMN IF EXISTS user_id;
+        ALTER TABLE recurring_rules DROP COLUMN IF EXISTS user_id;
+    END IF;
+END $$;
+
+-- 予算は家計簿ごとに同じカテゴリ・月で1件（以前の (category_id, month) の一意制約は削除）
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_category_id_month_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_household_category_month
+    ON budgets (COALESCE(household_id, 0), category_id, month);
+
+-- 収支の内訳（1件の収支を複数のカテゴリに分ける。金額の合計は収支の金額と一致し、符号も同じ）
+-- 内訳のある収支の category_id には最初の内訳のカテゴリを入れます。収支削除時は内訳も削除。
+CREATE TABLE IF NOT EXISTS transaction_splits (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id),
+    amount INTEGER NOT NULL,
+    memo TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+-- タグ（家計簿ごとに同じ名前のタグは1件。収支の登録時に自動で作られる）
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE,
+    name VARCHAR(30) NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_household_name ON tags (COALESCE(household_id, 0), name);
+
+-- 収支とタグの対応（position はタグの並び順。収支・タグの削除時に削除）
+CREATE TABLE IF NOT EXISTS transaction_tags (
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (transaction_id, tag_id)
+);
+
+-- 他の家計簿アプリ（Zaim・Money Forward ME）から取り込むときのカテゴリ名の対応
+-- name は "大項目/中項目"（中項目がなければ大項目だけ）。家計簿・取り込み元・名前ごとに1件。カテゴリの削除時に削除
+CREATE TABLE IF NOT EXISTS category_mappings (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_mappings_household_source_name
+    ON category_mappings (COALESCE(household_id, 0), source, name);
+
+-- 仕分けルール（取り込んだ行などのカテゴリ・タグ・メモを自動で設定する。priority の小さい順に適用）
+-- weekdays は日曜日を1ビット目とする曜日のビット（0 はすべての曜日）、tags はタグの JSON の配列。
+-- カテゴリの削除時はカテゴリを設定しないルールとして残す。ルールから参照されている口座はアプリが削除させない
+CREATE TABLE IF NOT EXISTS category_rules (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    type VARCHAR(10) NOT NULL DEFAULT '',
+    memo_contains TEXT NOT NULL DEFAULT '',
+    memo_pattern TEXT NOT NULL DEFAULT '',
+    min_amount INTEGER,
+    max_amount INTEGER,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    weekdays SMALLINT NOT NULL DEFAULT 0,
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    tags JSONB NOT NULL DEFAULT '[]',
+    memo_rewrite TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 支払先（お店など。key は正規化した名前で、家計簿ごとに同じ key の支払先は1件。収支の登録時に自動で作られる）
+-- 統合した支払先は merged_into に統合先を持って残し、以後その key の収支は統合先に登録します。
+CREATE TABLE IF NOT EXISTS payees (
+    id SERIAL PRIMARY KEY,
+    household_id INTEGER REFERENCES households(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    key VARCHAR(50) NOT NULL,
+    merged_into INTEGER REFERENCES payees(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payees_household_key ON payees (COALESCE(household_id, 0), key);
+
+-- 収支の支払先（振替は持たない）
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payee_id INTEGER REFERENCES payees(id) ON DELETE SET NULL;
+
+-- 収支の添付ファイル（レシート・書類）。中身はファイル置き場の blob_key に保存し、画像はサムネイルも保存する
+-- 収支の削除時に削除（ファイル置き場のファイルはアプリが削除する）
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    file_name VARCHAR(100) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    blob_key VARCHAR(100) NOT NULL UNIQUE,
+    has_thumbnail BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 支出の品目（レシートの1行。数量 × 単価の合計は支出の金額の絶対値と一致する）
+-- position は品目の並び順。収支削除時は品目も削除
+CREATE TABLE IF NOT EXISTS transaction_items (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1,
+    unit_price INTEGER NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+-- 全文検索の索引（収支のメモと内訳のメモを NFKC 正規化・小文字化した文字とバイグラム。空白を含むものは除く）
+-- 収支の登録・更新時にアプリが作り直します。収支削除時に削除
+-- 表を作るときだけ、登録済みの収支の分をまとめて作ります
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.tables WHERE table_name = 'transaction_search_grams'
+    ) THEN
+        RETURN;
+    END IF;
+    CREATE TABLE transaction_search_grams (
+        transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+        gram VARCHAR(2) NOT NULL,
+        PRIMARY KEY (gram, transaction_id)
+    );
+    INSERT INTO transaction_search_grams (transaction_id, gram)
+    SELECT DISTINCT src.transaction_id, substr(src.text, i, n)
+    FROM (
+        SELECT id AS transaction_id, lower(normalize(memo, NFKC)) AS text FROM transactions
+        UNION ALL
+        SELECT transaction_id, lower(normalize(memo, NFKC)) FROM transaction_splits
+    ) src
+    CROSS JOIN generate_series(1, 2) AS n
+    CROSS JOIN LATERAL generate_series(1, length(src.text) - n + 1) AS i
+    WHERE substr(src.text, i, n) !~ '\s';
+END $$;
+
+-- ゴミ箱へ移した日時（ゴミ箱にない収支は NULL）。ゴミ箱の収支は一覧・集計に含めず、保存期間を過ぎると削除する
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- 口座の家計簿（家計簿の導入前の口座は NULL のままにし、最初に作成された家計簿へアプリが割り当てます）
+-- 列を追加するときだけ、家計簿の間で共有していた口座を家計簿ごとに分けます。
+-- 口座は最初の家計簿が引き継ぎ、ほかの家計簿には収支・定期収支・仕分けルールから参照されている口座のコピーを作って参照を付け替え、
+-- 口座が1件もない家計簿には現金の口座を用意します。
+DO $$
+DECLARE
+    a RECORD;
+    hid INTEGER;
+    first_hid INTEGER;
+    new_aid INTEGER;
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'accounts' AND column_name = 'household_id'
+    ) THEN
+        RETURN;
+    END IF;
+    ALTER TABLE accounts ADD COLUMN household_id INTEGER REFERENCES households(id) ON DELETE CASCADE;
+
+    SELECT MIN(id) INTO first_hid FROM households;
+    IF first_hid IS NULL THEN
+        RETURN;
+    END IF;
+    FOR a IN SELECT * FROM accounts WHERE household_id IS NULL ORDER BY id LOOP
+        FOR hid IN
+            SELECT household_id FROM transactions WHERE account_id = a.id AND household_id <> first_hid
+            UNION SELECT household_id FROM recurring_rules WHERE account_id = a.id AND household_id <> first_hid
+            UNION SELECT household_id FROM category_rules WHERE account_id = a.id AND household_id <> first_hid
+        LOOP
+            INSERT INTO accounts (name, kind, opening_balance, display_order, archived, household_id)
+            VALUES (a.name, a.kind, a.opening_balance, a.display_order, a.archived, hid)
+            RETURNING id INTO new_aid;
+            UPDATE transactions SET account_id = new_aid WHERE account_id = a.id AND household_id = hid;
+            UPDATE recurring_rules SET account_id = new_aid WHERE account_id = a.id AND household_id = hid;
+            UPDATE category_rules SET account_id = new_aid WHERE account_id = a.id AND household_id = hid;
+        END LOOP;
+        UPDATE accounts SET household_id = first_hid WHERE id = a.id;
+    END LOOP;
+    INSERT INTO accounts (name, kind, display_order, household_id)
+    SELECT '現金', 'cash', 1, h.id FROM households h
+    WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE household_id = h.id);
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id ON transactions(account_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_transfer_id ON transactions(transfer_id) WHERE transfer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_household_id ON transactions(household_id);
+CREATE INDEX IF NOT EXISTS idx_categories_household_id ON categories(household_id);
+CREATE INDEX IF NOT EXISTS idx_accounts_household_id ON accounts(household_id);
+CREATE INDEX IF NOT EXISTS idx_household_members_user_id ON household_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
 CREATE INDEX IF NOT EXISTS idx_transactions_date ON transactions(date);
 CREATE INDEX IF NOT EXISTS idx_transactions_category_id ON transactions(category_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category_id ON transaction_splits(category_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_tags_tag_id ON transaction_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_category_rules_household_id ON category_rules(household_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_payee_id ON transactions(payee_id) WHERE payee_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_attachments_transaction_id ON attachments(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_items_transaction_id ON transaction_items(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_search_grams_transaction_id ON transaction_search_grams(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions(deleted_at) WHERE deleted_at IS NOT NULL;
 
--- 初期カテゴリデータ
-INSERT INTO categories (id, name) VALUES
-    (1, '食費'),
-    (2, '交通費'),
-    (3, '住居費'),
-    (4, '光熱費'),
-    (5, '通信費'),
-    (6, '娯楽費'),
-    (7, '医療費'),
-    (8, '教育費'),
-    (9, 'その他'),
-    (10, '給与')
+-- 初期カテゴリデータ（カテゴリが1件もない場合のみ投入。削除済みカテゴリを復活させないため）
+INSERT INTO categories (id, name, kind, parent_id)
+SELECT * FROM (VALUES
+    (1, '食費', 'expense', NULL),
+    (2, '交通費', 'expense', NULL),
+    (3, '住居費', 'expense', NULL),
+    (4, '光熱費', 'expense', NULL),
+    (5, '通信費', 'expense', NULL),
+    (6, '娯楽費', 'expense', NULL),
+    (7, '医療費', 'expense', NULL),
+    (8, '教育費', 'expense', NULL),
+    (9, 'その他', 'both', NULL),
+    (10, '給与', 'income', NULL),
+    (11, '外食', 'expense', 1),
+    (12, '食材', 'expense', 1),
+    (13, '電気', 'expense', 4),
+    (14, 'ガス', 'expense', 4),
+    (15, '水道', 'expense', 4)
+) AS v(id, name, kind, parent_id)
+WHERE NOT EXISTS (SELECT 1 FROM categories)
 ON CONFLICT (id) DO NOTHING;
 
+UPDATE categories SET display_order = id WHERE display_order = 0;
+
 SELECT setval('categories_id_seq', (SELECT MAX(id) FROM categories));
@@ -0,0 +1,122 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import {
+  ATTACHMENT_ACCEPT,
+  attachmentThumbnailUrl,
+  attachmentUrl,
+  deleteAttachment,
+  getAttachments,
+  uploadAttachment,
+  type Attachment,
+} from "@/lib/api";
+
+// formatSize はファイルの大きさを KB・MB で表示します。
+function formatSize(size: number): string {
+  if (size >= 1 << 20) return `${(size / (1 << 20)).toFixed(1)}MB`;
+  return `${Math.max(1, Math.round(size / 1024))}KB`;
+}
+
+/**
+ * AttachmentPanel は収支に添付したレシート・書類の一覧（画像はサムネイル付き）と、添付・削除を提供します。
+ */
+export default function AttachmentPanel({ transactionId }: { transactionId: number }) {
+  const [attachments, setAttachments] = useState<Attachment[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [uploading, setUploading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    setLoading(true);
+    setError(null);
+    getAttachments(transactionId)
+      .then(setAttachments)
+      .catch((e) => setError(e instanceof Error ? e.message : "添付ファイルの取得に失敗しました"))
+      .finally(() => setLoading(false));
+  }, [transactionId]);
+
+  const handleUpload = async (files: FileList | null) => {
+    if (!files?.length) return;
+    setUploading(true);
+    setError(null);
+    try {
+      const added: Attachment[] = [];
+      for (const file of Array.from(files)) {
+        added.push(await uploadAttachment(transactionId, file));
+      }
+      setAttachments((prev) => [...prev, ...added]);
+    } catch (e) {
+      setError(e instanceof Error ? e.message : "ファイルの添付に失敗しました");
+    } finally {
+      setUploading(false);
+    }
+  };
+
+  const handleDelete = async (a: Attachment) => {
+    if (!confirm(`「${a.file_name}」を削除しますか？`)) return;
+    setError(null);
+    try {
+      await deleteAttachment(a.id);
+      setAttachments((prev) => prev.filter((x) => x.id !== a.id));
+    } catch (e) {
+      setError(e instanceof Error ? e.message : "添付ファイルの削除に失敗しました");
+    }
+  };
+
+  return (
+    <div className="space-y-2">
+      {error && <p className="text-sm text-red-600">{error}</p>}
+      {loading ? (
+        <p className="text-sm text-slate-500">読み込み中...</p>
+      ) : attachments.length === 0 ? (
+        <p className="text-sm text-slate-500">添付ファイルはありません</p>
+      ) : (
+        <ul className="flex flex-wrap gap-3">
+          {attachments.map((a) => (
+            <li key={a.id} className="w-32 text-xs text-slate-600">
+              <a href={attachmentUrl(a.id)} target="_blank" rel="noopener noreferrer">
+                {a.has_thumbnail ? (
+                  // eslint-disable-next-line @next/next/no-img-element -- 認証付きの API の画像のため最適化しない
+                  <img
+                    src={attachmentThumbnailUrl(a.id)}
+                    alt={a.file_name}
+                    className="h-24 w-32 rounded border border-slate-200 object-cover"
+                  />
+                ) : (
+                  <div className="flex h-24 w-32 items-center justify-center rounded border border-slate-200 bg-slate-50 text-slate-400">
+                    {a.content_type === "application/pdf" ? "PDF" : "画像"}
+                  </div>
+                )}
+              </a>
+              <p className="mt-1 truncate" title={a.file_name}>
+                {a.file_name}
+              </p>
+              <p className="text-slate-400">{formatSize(a.size)}</p>
+              <a href={attachmentUrl(a.id, true)} className="mr-2 text-blue-600 hover:underline">
+                保存
+              </a>
+              <button onClick={() => handleDelete(a)} className="text-red-600 hover:underline">
+                削除
+              </button>
+            </li>
+          ))}
+        </ul>
+      )}
+      <label className="inline-block cursor-pointer rounded border border-slate-300 px-3 py-1 text-sm text-slate-700 hover:bg-slate-50">
+        {uploading ? "添付中..." : "ファイルを添付"}
+        <input
+          type="file"
+          multiple
+          accept={ATTACHMENT_ACCEPT}
+          disabled={uploading}
+          onChange={(e) => {
+            handleUpload(e.target.files);
+            e.target.value = "";
+          }}
+          className="hidden"
+        />
+      </label>
+      <p className="text-xs text-slate-400">画像（JPEG・PNG・GIF・WebP）と PDF、1ファイル 10MB まで</p>
+    </div>
+  );
+}
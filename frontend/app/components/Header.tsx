@@ -1,18 +1,30 @@
 "use client";
 
 import Link from "next/link";
-import { usePathname } from "next/navigation";
+import { usePathname, useRouter } from "next/navigation";
+import { logout } from "@/lib/api";
 
 /**
- * Header は3つの画面（メイン・登録・編集）へ遷移するナビゲーションメニューを提供します。
+ * Header は3つの画面（メイン・登録・編集）と取り込み画面・家計簿画面へ遷移するナビゲーションメニューとログアウトボタンを提供します。
  */
 export default function Header() {
   const pathname = usePathname();
+  const router = useRouter();
+
+  const handleLogout = async () => {
+    try {
+      await logout();
+    } finally {
+      router.push("/login");
+    }
+  };
 
   const navItems = [
     { href: "/", label: "グラフ" },
     { href: "/register", label: "登録" },
     { href: "/transactions", label: "編集" },
+    { href: "/import", label: "取り込み" },
+    { href: "/household", label: "家計簿" },
   ];
 
   return (
@@ -37,6 +49,15 @@ export default function Header() {
             );
           })}
         </div>
+        {pathname !== "/login" && (
+          <button
+            type="button"
+            onClick={handleLogout}
+            className="ml-auto rounded-lg px-4 py-2 text-sm font-medium text-slate-600 transition hover:bg-slate-100 hover:text-slate-800"
+          >
+            ログアウト
+          </button>
+        )}
       </nav>
     </header>
   );
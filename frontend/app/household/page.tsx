@@ -0,0 +1,268 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import {
+  getHouseholds,
+  getHousehold,
+  getMe,
+  selectHousehold,
+  createHousehold,
+  updateMemberRole,
+  removeMember,
+  createInvitation,
+  acceptInvitation,
+  type Household,
+  type HouseholdDetail,
+  type HouseholdRole,
+  type User,
+} from "@/lib/api";
+
+const ROLE_LABELS: Record<HouseholdRole, string> = {
+  owner: "所有者",
+  editor: "編集者",
+  viewer: "閲覧者",
+};
+
+/**
+ * 家計簿画面: 利用する家計簿の切り替えと作成、メンバー・役割の管理、招待の作成と受諾を提供します。
+ */
+export default function HouseholdPage() {
+  const [me, setMe] = useState<User | null>(null);
+  const [households, setHouseholds] = useState<Household[]>([]);
+  const [detail, setDetail] = useState<HouseholdDetail | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string | null>(null);
+  const [actionError, setActionError] = useState<string | null>(null);
+  const [inviteEmail, setInviteEmail] = useState("");
+  const [inviteRole, setInviteRole] = useState<HouseholdRole>("editor");
+  const [inviteToken, setInviteToken] = useState<string | null>(null);
+  const [acceptToken, setAcceptToken] = useState("");
+  const [newName, setNewName] = useState("");
+  const [copyFrom, setCopyFrom] = useState<number>(0);
+
+  const fetchAll = async () => {
+    try {
+      setError(null);
+      const [user, list] = await Promise.all([getMe(), getHouseholds()]);
+      setMe(user);
+      setHouseholds(Array.isArray(list) ? list : []);
+      setDetail(list.length > 0 ? await getHousehold() : null);
+    } catch (e) {
+      setError(e instanceof Error ? e.message : "データの取得に失敗しました");
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    fetchAll();
+  }, []);
+
+  // run は操作を実行し、失敗した場合はエラーを表示、成功した場合は一覧を取り直します。
+  const run = async (action: () => Promise<unknown>) => {
+    setActionError(null);
+    try {
+      await action();
+      await fetchAll();
+    } catch (e) {
+      setActionError(e instanceof Error ? e.message : "操作に失敗しました");
+    }
+  };
+
+  const handleInvite = async (e: React.FormEvent) => {
+    e.preventDefault();
+    await run(async () => {
+      const invitation = await createInvitation(inviteEmail, inviteRole);
+      setInviteToken(invitation.token ?? null);
+      setInviteEmail("");
+    });
+  };
+
+  // 家計簿を作成し、そのまま利用する家計簿に切り替えます。
+  const handleCreate = async (e: React.FormEvent) => {
+    e.preventDefault();
+    await run(async () => {
+      const created = await createHousehold(newName, copyFrom || undefined);
+      await selectHousehold(created.id);
+      setNewName("");
+      setCopyFrom(0);
+    });
+  };
+
+  const handleAccept = async (e: React.FormEvent) => {
+    e.preventDefault();
+    await run(async () => {
+      await acceptInvitation(acceptToken.trim());
+      setAcceptToken("");
+    });
+  };
+
+  if (loading) {
+    return <p className="text-slate-500">読み込み中...</p>;
+  }
+  if (error) {
+    return <p className="text-red-600">{error}</p>;
+  }
+
+  const isOwner = detail?.role === "owner";
+
+  return (
+    <div className="space-y-6">
+      {actionError && <p className="text-sm text-red-600">{actionError}</p>}
+
+      <section className="rounded-lg bg-white p-6 shadow">
+        <h2 className="mb-4 text-xl font-semibold text-slate-700">利用する家計簿</h2>
+        {households.length === 0 ? (
+          <p className="text-sm text-slate-500">参加している家計簿がありません。招待を受けて参加してください。</p>
+        ) : (
+          <select
+            value={detail?.id ?? ""}
+            onChange={(e) => run(() => selectHousehold(Number(e.target.value)))}
+            className="rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+          >
+            {households.map((h) => (
+              <option key={h.id} value={h.id}>
+                {h.name}（{ROLE_LABELS[h.role]}）
+              </option>
+            ))}
+          </select>
+        )}
+      </section>
+
+      <section className="rounded-lg bg-white p-6 shadow">
+        <h2 className="mb-4 text-xl font-semibold text-slate-700">家計簿を作成</h2>
+        <form onSubmit={handleCreate} className="flex flex-wrap items-end gap-3">
+          <input
+            type="text"
+            required
+            maxLength={50}
+            placeholder="名前（例: 副業）"
+            value={newName}
+            onChange={(e) => setNewName(e.target.value)}
+            className="flex-1 rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+          />
+          <select
+            value={copyFrom}
+            onChange={(e) => setCopyFrom(Number(e.target.value))}
+            className="rounded border border-slate-300 px-3 py-2"
+          >
+            <option value={0}>初期カテゴリ</option>
+            {households.map((h) => (
+              <option key={h.id} value={h.id}>
+                {h.name} のカテゴリをコピー
+              </option>
+            ))}
+          </select>
+          <button
+            type="submit"
+            className="rounded bg-blue-600 px-4 py-2 font-medium text-white transition hover:bg-blue-700"
+          >
+            作成
+          </button>
+        </form>
+      </section>
+
+      {detail && (
+        <section className="rounded-lg bg-white p-6 shadow">
+          <h2 className="mb-4 text-xl font-semibold text-slate-700">メンバー</h2>
+          <ul className="divide-y divide-slate-100">
+            {detail.members.map((m) => (
+              <li key={m.user_id} className="flex items-center gap-3 py-2 text-sm">
+                <span className="flex-1 text-slate-700">
+                  {m.name || m.email}
+                  {m.name && <span className="ml-2 text-slate-400">{m.email}</span>}
+                </span>
+                {isOwner ? (
+                  <select
+                    value={m.role}
+                    onChange={(e) =>
+                      run(() => updateMemberRole(m.user_id, e.target.value as HouseholdRole))
+                    }
+                    className="rounded border border-slate-300 px-2 py-1"
+                  >
+                    {(Object.keys(ROLE_LABELS) as HouseholdRole[]).map((role) => (
+                      <option key={role} value={role}>
+                        {ROLE_LABELS[role]}
+                      </option>
+                    ))}
+                  </select>
+                ) : (
+                  <span className="text-slate-500">{ROLE_LABELS[m.role]}</span>
+                )}
+                {(isOwner || m.user_id === me?.id) && (
+                  <button
+                    type="button"
+                    onClick={() => {
+                      const self = m.user_id === me?.id;
+                      if (confirm(self ? "この家計簿から抜けますか？" : `${m.name || m.email} を外しますか？`)) {
+                        run(() => removeMember(m.user_id));
+                      }
+                    }}
+                    className="text-red-600 hover:underline"
+                  >
+                    {m.user_id === me?.id ? "抜ける" : "外す"}
+                  </button>
+                )}
+              </li>
+            ))}
+          </ul>
+        </section>
+      )}
+
+      {isOwner && (
+        <section className="rounded-lg bg-white p-6 shadow">
+          <h2 className="mb-4 text-xl font-semibold text-slate-700">メンバーを招待</h2>
+          <form onSubmit={handleInvite} className="flex flex-wrap items-end gap-3">
+            <input
+              type="email"
+              required
+              placeholder="メールアドレス"
+              value={inviteEmail}
+              onChange={(e) => setInviteEmail(e.target.value)}
+              className="flex-1 rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+            />
+            <select
+              value={inviteRole}
+              onChange={(e) => setInviteRole(e.target.value as HouseholdRole)}
+              className="rounded border border-slate-300 px-3 py-2"
+            >
+              <option value="editor">{ROLE_LABELS.editor}</option>
+              <option value="viewer">{ROLE_LABELS.viewer}</option>
+            </select>
+            <button
+              type="submit"
+              className="rounded bg-blue-600 px-4 py-2 font-medium text-white transition hover:bg-blue-700"
+            >
+              招待
+            </button>
+          </form>
+          {inviteToken && (
+            <p className="mt-3 break-all text-sm text-slate-600">
+              招待トークン（相手に伝えてください。7日間有効）: <code>{inviteToken}</code>
+            </p>
+          )}
+        </section>
+      )}
+
+      <section className="rounded-lg bg-white p-6 shadow">
+        <h2 className="mb-4 text-xl font-semibold text-slate-700">招待を受ける</h2>
+        <form onSubmit={handleAccept} className="flex gap-3">
+          <input
+            type="text"
+            required
+            placeholder="招待トークン"
+            value={acceptToken}
+            onChange={(e) => setAcceptToken(e.target.value)}
+            className="flex-1 rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+          />
+          <button
+            type="submit"
+            className="rounded bg-blue-600 px-4 py-2 font-medium text-white transition hover:bg-blue-700"
+          >
+            参加
+          </button>
+        </form>
+      </section>
+    </div>
+  );
+}
@@ -0,0 +1,542 @@
+"use client";
+
+import { useEffect, useState } from "react";
+import {
+  importCSV,
+  importApp,
+  getCategories,
+  getAccounts,
+  getCategoryMappings,
+  updateCategoryMappings,
+  flattenCategories,
+  type Account,
+  type Category,
+  type CategoryMapping,
+  type CSVImportProfile,
+  type ImportResult,
+  type ImportSource,
+} from "@/lib/api";
+
+// 列の対応はブラウザに保存し、次に同じ銀行・カードの明細を取り込むときに使います。
+const PROFILE_STORAGE_KEY = "kakeibo.csvImportProfile";
+
+const DEFAULT_PROFILE: CSVImportProfile = {
+  encoding: "auto",
+  skip_rows: 0,
+  has_header: true,
+  date_column: "",
+  debit_column: "",
+  credit_column: "",
+  memo_columns: [],
+  expense_category_id: 0,
+  income_category_id: 0,
+  account_id: 1,
+};
+
+// 取り込むファイルの種類。"csv" は列の対応を指定する銀行・カードの明細です。
+const SOURCES: { value: "csv" | ImportSource; label: string }[] = [
+  { value: "csv", label: "銀行・カードの明細 CSV" },
+  { value: "zaim", label: "Zaim" },
+  { value: "moneyforward", label: "Money Forward ME" },
+  { value: "ledger", label: "beancount・hledger の仕訳帳" },
+];
+
+const inputClass =
+  "w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500";
+
+/**
+ * 取り込み画面: 銀行・カードの明細 CSV を列の対応に従って、または Zaim・Money Forward ME の CSV を
+ * カテゴリの対応に従って変換し、プレビューを確認してから登録します。
+ */
+export default function ImportPage() {
+  const [source, setSource] = useState<"csv" | ImportSource>("csv");
+  const [mappings, setMappings] = useState<CategoryMapping[]>([]);
+  const [categories, setCategories] = useState<Category[]>([]);
+  const [accounts, setAccounts] = useState<Account[]>([]);
+  const [profile, setProfile] = useState<CSVImportProfile>(DEFAULT_PROFILE);
+  const [memoColumns, setMemoColumns] = useState("");
+  const [file, setFile] = useState<File | null>(null);
+  const [allowDuplicates, setAllowDuplicates] = useState(false);
+  const [preview, setPreview] = useState<ImportResult | null>(null);
+  const [submitting, setSubmitting] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const [message, setMessage] = useState<string | null>(null);
+
+  useEffect(() => {
+    const saved = window.localStorage.getItem(PROFILE_STORAGE_KEY);
+    if (saved) {
+      try {
+        const p = { ...DEFAULT_PROFILE, ...JSON.parse(saved) } as CSVImportProfile;
+        setProfile(p);
+        setMemoColumns((p.memo_columns ?? []).join(","));
+      } catch {
+        // 壊れた保存内容は無視
+      }
+    }
+    Promise.all([getCategories(), getAccounts()])
+      .then(([data, accountData]) => {
+        setCategories(Array.isArray(data) ? flattenCategories(data) : []);
+        const loaded = Array.isArray(accountData) ? accountData : [];
+        setAccounts(loaded);
+        // 口座は家計簿ごとに分かれているので、保存した設定の口座が家計簿になければ先頭の口座にする
+        setProfile((prev) =>
+          loaded.length === 0 || loaded.some((a) => a.id === prev.account_id)
+            ? prev
+            : { ...prev, account_id: loaded[0].id }
+        );
+      })
+      .catch((e) => setError(e instanceof Error ? e.message : "カテゴリの取得に失敗しました"));
+  }, []);
+
+  useEffect(() => {
+    if (source === "csv") {
+      setMappings([]);
+      return;
+    }
+    getCategoryMappings(source)
+      .then((data) => setMappings(Array.isArray(data) ? data : []))
+      .catch((e) => setError(e instanceof Error ? e.message : "カテゴリの対応の取得に失敗しました"));
+  }, [source]);
+
+  const update = (patch: Partial<CSVImportProfile>) => {
+    setProfile((prev) => ({ ...prev, ...patch }));
+    setPreview(null);
+  };
+
+  const useAmountColumn = profile.amount_column !== undefined;
+
+  const currentProfile = (): CSVImportProfile => ({
+    ...profile,
+    memo_columns: memoColumns
+      .split(",")
+      .map((c) => c.trim())
+      .filter((c) => c !== ""),
+  });
+
+  const run = async (dryRun: boolean) => {
+    if (!file) {
+      setError("CSV ファイルを選択してください");
+      return;
+    }
+    setError(null);
+    setMessage(null);
+    setSubmitting(true);
+    try {
+      let result: ImportResult;
+      if (source === "csv") {
+        const p = currentProfile();
+        result = await importCSV(file, p, dryRun, allowDuplicates);
+        window.localStorage.setItem(PROFILE_STORAGE_KEY, JSON.stringify(p));
+      } else {
+        result = await importApp(source, file, profile.account_id ?? 1, dryRun, allowDuplicates);
+      }
+      if (dryRun) {
+        setPreview(result);
+      } else {
+        setPreview(null);
+        setMessage(`${result.imported}件を登録しました（スキップ ${result.skipped}件）`);
+      }
+    } catch (e) {
+      setError(e instanceof Error ? e.message : "取り込みに失敗しました");
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  const categoryName = (id?: number) => categories.find((c) => c.id === id)?.name ?? "";
+
+  // 保存済みの対応に、プレビューで見つからなかったカテゴリ名を加えて編集できるようにする
+  const editableMappings: CategoryMapping[] = [
+    ...mappings,
+    ...(preview?.unmapped ?? [])
+      .filter((u) => !mappings.some((m) => m.name === u.name))
+      .map((u) => ({ name: u.name, category_id: 0 })),
+  ];
+
+  const setMapping = (name: string, categoryId: number) => {
+    setMappings((prev) =>
+      prev.some((m) => m.name === name)
+        ? prev.map((m) => (m.name === name ? { ...m, category_id: categoryId } : m))
+        : [...prev, { name, category_id: categoryId }]
+    );
+  };
+
+  const saveMappings = async () => {
+    if (source === "csv") return;
+    setError(null);
+    setSubmitting(true);
+    try {
+      const saved = await updateCategoryMappings(
+        source,
+        mappings.filter((m) => m.category_id !== 0)
+      );
+      setMappings(saved);
+    } catch (e) {
+      setError(e instanceof Error ? e.message : "カテゴリの対応の保存に失敗しました");
+      return;
+    } finally {
+      setSubmitting(false);
+    }
+    if (file) {
+      await run(true);
+    }
+  };
+
+  return (
+    <section className="rounded-lg bg-white p-6 shadow">
+      <h2 className="mb-6 text-xl font-semibold text-slate-700">CSV の取り込み</h2>
+
+      <div className="grid gap-4 text-sm sm:grid-cols-2 lg:grid-cols-4">
+        <div>
+          <label className="mb-1 block text-slate-600">取り込み元</label>
+          <select
+            value={source}
+            onChange={(e) => {
+              setSource(e.target.value as "csv" | ImportSource);
+              setPreview(null);
+            }}
+            className={inputClass}
+          >
+            {SOURCES.map((s) => (
+              <option key={s.value} value={s.value}>
+                {s.label}
+              </option>
+            ))}
+          </select>
+        </div>
+        <div>
+          <label className="mb-1 block text-slate-600">口座</label>
+          <select
+            value={profile.account_id ?? 1}
+            onChange={(e) => update({ account_id: parseInt(e.target.value, 10) || 0 })}
+            className={inputClass}
+          >
+            {accounts.map((a) => (
+              <option key={a.id} value={a.id}>
+                {a.name}
+              </option>
+            ))}
+          </select>
+        </div>
+        <div className="sm:col-span-2">
+          <label className="mb-1 block text-slate-600">CSV ファイル</label>
+          <input
+            type="file"
+            accept={source === "ledger" ? ".beancount,.journal,.ledger,.hledger,.txt" : ".csv,text/csv"}
+            onChange={(e) => {
+              setFile(e.target.files?.[0] ?? null);
+              setPreview(null);
+            }}
+            className={inputClass}
+          />
+        </div>
+        {source === "csv" && (
+          <>
+            <div>
+              <label className="mb-1 block text-slate-600">文字コード</label>
+              <select
+                value={profile.encoding}
+                onChange={(e) => update({ encoding: e.target.value as CSVImportProfile["encoding"] })}
+                className={inputClass}
+              >
+                <option value="auto">自動判定</option>
+                <option value="utf-8">UTF-8</option>
+                <option value="shift_jis">Shift_JIS</option>
+              </select>
+            </div>
+            <div>
+              <label className="mb-1 block text-slate-600">先頭で読み飛ばす行数</label>
+              <input
+                type="number"
+                min={0}
+                value={profile.skip_rows ?? 0}
+                onChange={(e) => update({ skip_rows: parseInt(e.target.value, 10) || 0 })}
+                className={inputClass}
+              />
+            </div>
+            <label className="flex items-center gap-2 text-slate-600">
+              <input
+                type="checkbox"
+                checked={profile.has_header ?? false}
+                onChange={(e) => update({ has_header: e.target.checked })}
+              />
+              1行目は見出し行
+            </label>
+            <div>
+              <label className="mb-1 block text-slate-600">日付の列</label>
+              <input
+                type="text"
+                placeholder="取引日 または 1"
+                value={profile.date_column}
+                onChange={(e) => update({ date_column: e.target.value })}
+                className={inputClass}
+              />
+            </div>
+            <div>
+              <label className="mb-1 block text-slate-600">日付の形式（任意）</label>
+              <input
+                type="text"
+                placeholder="YYYY/MM/DD"
+                value={profile.date_format ?? ""}
+                onChange={(e) => update({ date_format: e.target.value })}
+                className={inputClass}
+              />
+            </div>
+            <div>
+              <label className="mb-1 block text-slate-600">金額の列</label>
+              <select
+                value={useAmountColumn ? "amount" : "debit_credit"}
+                onChange={(e) =>
+                  update(
+                    e.target.value === "amount"
+                      ? { amount_column: "", debit_column: undefined, credit_column: undefined }
+                      : { amount_column: undefined, debit_column: "", credit_column: "" }
+                  )
+                }
+                className={inputClass}
+              >
+                <option value="debit_credit">出金・入金の2列</option>
+                <option value="amount">符号付きの1列</option>
+              </select>
+            </div>
+            {useAmountColumn ? (
+              <>
+                <div>
+                  <label className="mb-1 block text-slate-600">金額</label>
+                  <input
+                    type="text"
+                    value={profile.amount_column ?? ""}
+                    onChange={(e) => update({ amount_column: e.target.value })}
+                    className={inputClass}
+                  />
+                </div>
+                <div>
+                  <label className="mb-1 block text-slate-600">正の金額は</label>
+                  <select
+                    value={profile.amount_positive ?? "income"}
+                    onChange={(e) => update({ amount_positive: e.target.value as "income" | "expense" })}
+                    className={inputClass}
+                  >
+                    <option value="income">収入（銀行の明細など）</option>
+                    <option value="expense">支出（カードの明細など）</option>
+                  </select>
+                </div>
+              </>
+            ) : (
+              <>
+                <div>
+                  <label className="mb-1 block text-slate-600">出金</label>
+                  <input
+                    type="text"
+                    value={profile.debit_column ?? ""}
+                    onChange={(e) => update({ debit_column: e.target.value })}
+                    className={inputClass}
+                  />
+                </div>
+                <div>
+                  <label className="mb-1 block text-slate-600">入金</label>
+                  <input
+                    type="text"
+                    value={profile.credit_column ?? ""}
+                    onChange={(e) => update({ credit_column: e.target.value })}
+                    className={inputClass}
+                  />
+                </div>
+              </>
+            )}
+            <div>
+              <label className="mb-1 block text-slate-600">メモの列（カンマ区切り）</label>
+              <input
+                type="text"
+                value={memoColumns}
+                onChange={(e) => {
+                  setMemoColumns(e.target.value);
+                  setPreview(null);
+                }}
+                className={inputClass}
+              />
+            </div>
+            <div>
+              <label className="mb-1 block text-slate-600">支出のカテゴリ</label>
+              <select
+                value={profile.expense_category_id ?? 0}
+                onChange={(e) => update({ expense_category_id: parseInt(e.target.value, 10) || 0 })}
+                className={inputClass}
+              >
+                <option value={0}>選択してください</option>
+                {categories
+                  .filter((c) => c.kind !== "income")
+                  .map((c) => (
+                    <option key={c.id} value={c.id}>
+                      {c.name}
+                    </option>
+                  ))}
+              </select>
+            </div>
+            <div>
+              <label className="mb-1 block text-slate-600">収入のカテゴリ</label>
+              <select
+                value={profile.income_category_id ?? 0}
+                onChange={(e) => update({ income_category_id: parseInt(e.target.value, 10) || 0 })}
+                className={inputClass}
+              >
+                <option value={0}>選択してください</option>
+                {categories
+                  .filter((c) => c.kind !== "expense")
+                  .map((c) => (
+                    <option key={c.id} value={c.id}>
+                      {c.name}
+                    </option>
+                  ))}
+              </select>
+            </div>
+          </>
+        )}
+      </div>
+
+      <div className="mt-6 flex items-center gap-4">
+        <label className="flex items-center gap-2 text-sm text-slate-600">
+          <input
+            type="checkbox"
+            checked={allowDuplicates}
+            onChange={(e) => {
+              setAllowDuplicates(e.target.checked);
+              setPreview(null);
+            }}
+          />
+          登録済みの収支と重複する行も登録する
+        </label>
+        <button
+          type="button"
+          disabled={submitting}
+          onClick={() => run(true)}
+          className="rounded bg-slate-700 px-4 py-2 text-sm text-white hover:bg-slate-800 disabled:opacity-50"
+        >
+          プレビュー
+        </button>
+        <button
+          type="button"
+          disabled={submitting || !preview || preview.valid === 0}
+          onClick={() => run(false)}
+          className="rounded bg-blue-600 px-4 py-2 text-sm text-white hover:bg-blue-700 disabled:opacity-50"
+        >
+          {preview ? `${preview.valid}件を登録` : "登録"}
+        </button>
+      </div>
+
+      {source !== "csv" && editableMappings.length > 0 && (
+        <div className="mt-6 text-sm">
+          <h3 className="mb-2 font-medium text-slate-700">カテゴリの対応</h3>
+          <p className="mb-2 text-slate-500">
+            同じ名前のカテゴリがない取り込み元のカテゴリは、ここで選んだカテゴリにします。「大項目」だけの対応はその大項目のすべての中項目に使います。
+          </p>
+          <table className="w-full text-left">
+            <tbody>
+              {editableMappings.map((m) => {
+                const unmapped = preview?.unmapped?.find((u) => u.name === m.name);
+                return (
+                  <tr key={m.name} className="border-b border-slate-100">
+                    <td className="py-2 pr-4">
+                      {m.name}
+                      {unmapped && (
+                        <span className="ml-2 text-red-600">未対応 {unmapped.count}行</span>
+                      )}
+                    </td>
+                    <td className="py-2">
+                      <select
+                        value={m.category_id}
+                        onChange={(e) => setMapping(m.name, parseInt(e.target.value, 10) || 0)}
+                        className={inputClass}
+                      >
+                        <option value={0}>（対応なし）</option>
+                        {categories
+                          .filter((c) => !unmapped || c.kind === "both" || c.kind === unmapped.type)
+                          .map((c) => (
+                            <option key={c.id} value={c.id}>
+                              {c.name}
+                            </option>
+                          ))}
+                      </select>
+                    </td>
+                  </tr>
+                );
+              })}
+            </tbody>
+          </table>
+          <button
+            type="button"
+            disabled={submitting}
+            onClick={saveMappings}
+            className="mt-2 rounded bg-slate-700 px-4 py-2 text-white hover:bg-slate-800 disabled:opacity-50"
+          >
+            対応を保存
+          </button>
+        </div>
+      )}
+
+      {error && <p className="mt-4 text-sm text-red-600">{error}</p>}
+      {message && <p className="mt-4 text-sm text-green-700">{message}</p>}
+
+      {preview && (
+        <div className="mt-6 overflow-x-auto">
+          <p className="mb-2 text-sm text-slate-600">
+            {preview.total}行中 {preview.valid}行を登録できます（エラー {preview.skipped}行
+            {preview.duplicates > 0 && `、登録済みと重複 ${preview.duplicates}行`}）
+          </p>
+          <table className="w-full text-left text-sm">
+            <thead>
+              <tr className="border-b border-slate-200 text-slate-600">
+                <th className="py-2 pr-4 font-medium">行</th>
+                <th className="py-2 pr-4 font-medium">日付</th>
+                <th className="py-2 pr-4 font-medium">カテゴリ</th>
+                <th className="py-2 pr-4 font-medium">金額</th>
+                <th className="py-2 pr-4 font-medium">メモ</th>
+                <th className="py-2 font-medium">エラー</th>
+              </tr>
+            </thead>
+            <tbody>
+              {preview.rows.map((r) => (
+                <tr
+                  key={r.line}
+                  className={`border-b border-slate-100 ${
+                    r.error ? "bg-red-50" : r.duplicate_of ? "bg-amber-50" : ""
+                  }`}
+                >
+                  <td className="py-2 pr-4 text-slate-500">{r.line}</td>
+                  <td className="py-2 pr-4">{r.date}</td>
+                  <td className="py-2 pr-4">
+                    {r.type === "transfer"
+                      ? `振替 ${r.source_account ?? ""} → ${r.source_to_account ?? ""}`
+                      : r.splits
+                        ? r.splits
+                            .map((s) => (s.category_id ? categoryName(s.category_id) : s.source_category))
+                            .join(" / ")
+                        : r.category_id
+                          ? categoryName(r.category_id)
+                          : r.source_category}
+                  </td>
+                  <td
+                    className={`py-2 pr-4 font-medium ${
+                      r.type === "income"
+                        ? "text-green-600"
+                        : r.type === "transfer"
+                          ? "text-slate-700"
+                          : "text-red-600"
+                    }`}
+                  >
+                    {r.amount !== undefined &&
+                      `${r.type === "income" ? "+" : r.type === "transfer" ? "" : "-"}¥${r.amount.toLocaleString()}`}
+                  </td>
+                  <td className="py-2 pr-4">{r.memo}</td>
+                  <td className="py-2 text-red-600">
+                    {r.error ?? (r.duplicate_of ? `重複（ID: ${r.duplicate_of}）` : "")}
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+    </section>
+  );
+}
@@ -0,0 +1,101 @@
+"use client";
+
+import { useState } from "react";
+import { login, register } from "@/lib/api";
+import { useRouter } from "next/navigation";
+
+/**
+ * ログイン画面: ログインとユーザー登録のフォームを提供します。
+ */
+export default function LoginPage() {
+  const router = useRouter();
+  const [mode, setMode] = useState<"login" | "register">("login");
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [name, setName] = useState("");
+  const [submitting, setSubmitting] = useState(false);
+  const [submitError, setSubmitError] = useState<string | null>(null);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setSubmitError(null);
+    setSubmitting(true);
+    try {
+      if (mode === "login") {
+        await login(email, password);
+      } else {
+        await register(email, password, name);
+      }
+      router.push("/");
+    } catch (e) {
+      setSubmitError(e instanceof Error ? e.message : "ログインに失敗しました");
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  return (
+    <section className="mx-auto max-w-md rounded-lg bg-white p-6 shadow">
+      <h2 className="mb-6 text-xl font-semibold text-slate-700">
+        {mode === "login" ? "ログイン" : "ユーザー登録"}
+      </h2>
+      <form onSubmit={handleSubmit} className="space-y-4">
+        <div>
+          <label className="mb-1 block text-sm text-slate-600">メールアドレス</label>
+          <input
+            type="email"
+            required
+            value={email}
+            onChange={(e) => setEmail(e.target.value)}
+            className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+          />
+        </div>
+        <div>
+          <label className="mb-1 block text-sm text-slate-600">パスワード</label>
+          <input
+            type="password"
+            required
+            minLength={mode === "register" ? 8 : undefined}
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
+            className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+          />
+        </div>
+        {mode === "register" && (
+          <div>
+            <label className="mb-1 block text-sm text-slate-600">名前</label>
+            <input
+              type="text"
+              placeholder="任意"
+              value={name}
+              onChange={(e) => setName(e.target.value)}
+              className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+            />
+          </div>
+        )}
+        {submitError && (
+          <p className="text-sm text-red-600">{submitError}</p>
+        )}
+        <div className="flex items-center gap-4">
+          <button
+            type="submit"
+            disabled={submitting}
+            className="rounded bg-blue-600 px-4 py-2 font-medium text-white transition hover:bg-blue-700 disabled:opacity-50"
+          >
+            {submitting ? "送信中..." : mode === "login" ? "ログイン" : "登録"}
+          </button>
+          <button
+            type="button"
+            onClick={() => {
+              setMode(mode === "login" ? "register" : "login");
+              setSubmitError(null);
+            }}
+            className="text-sm text-blue-600 hover:underline"
+          >
+            {mode === "login" ? "ユーザー登録はこちら" : "ログインはこちら"}
+          </button>
+        </div>
+      </form>
+    </section>
+  );
+}
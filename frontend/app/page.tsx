@@ -11,13 +11,29 @@ import {
   Legend,
   ResponsiveContainer,
 } from "recharts";
-import { getTransactions, type Transaction } from "@/lib/api";
+import {
+  getAccounts,
+  getBudgetStatus,
+  getMonthlySummary,
+  getTagReport,
+  type Account,
+  type BudgetStatus,
+  type MonthlySummary,
+  type TagTotal,
+} from "@/lib/api";
 
 /**
- * メイン画面: カテゴリごとに収入と支出を分けてグラフ表示します。
+ * メイン画面: 選択した月の収入と支出をカテゴリごとにグラフ表示します。
+ * 集計はサーバー側（GET /api/summary/monthly）で行います。
  */
 export default function Home() {
-  const [transactions, setTransactions] = useState<Transaction[]>([]);
+  const now = new Date();
+  const [year, setYear] = useState(now.getFullYear());
+  const [month, setMonth] = useState(now.getMonth() + 1);
+  const [summary, setSummary] = useState<MonthlySummary | null>(null);
+  const [budgets, setBudgets] = useState<BudgetStatus[]>([]);
+  const [accounts, setAccounts] = useState<Account[]>([]);
+  const [tagTotals, setTagTotals] = useState<TagTotal[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
 
@@ -25,8 +41,18 @@ export default function Home() {
     const fetchData = async () => {
       try {
         setError(null);
-        const data = await getTransactions();
-        setTransactions(Array.isArray(data) ? data : []);
+        const monthKey = `${year}-${String(month).padStart(2, "0")}`;
+        const lastDay = new Date(year, month, 0).getDate();
+        const [s, b, a, t] = await Promise.all([
+          getMonthlySummary(year, month),
+          getBudgetStatus(monthKey),
+          getAccounts(),
+          getTagReport(`${monthKey}-01`, `${monthKey}-${String(lastDay).padStart(2, "0")}`),
+        ]);
+        setSummary(s);
+        setBudgets(Array.isArray(b.items) ? b.items : []);
+        setAccounts(Array.isArray(a) ? a : []);
+        setTagTotals(Array.isArray(t.tags) ? t.tags : []);
       } catch (e) {
         setError(e instanceof Error ? e.message : "データの取得に失敗しました");
       } finally {
@@ -34,31 +60,21 @@ export default function Home() {
       }
     };
     fetchData();
-  }, []);
-
-  // カテゴリ別に収入・支出を集計
-  const chartData = (() => {
-    const byCategory: Record<
-      string,
-      { category: string; 収入: number; 支出: number }
-    > = {};
-
-    for (const t of (Array.isArray(transactions) ? transactions : [])) {
-      const name = t.category?.name ?? "その他";
-      if (!byCategory[name]) {
-        byCategory[name] = { category: name, 収入: 0, 支出: 0 };
-      }
-      if (t.type === "income" || t.amount > 0) {
-        byCategory[name].収入 += Math.abs(t.amount);
-      } else {
-        byCategory[name].支出 += Math.abs(t.amount);
-      }
-    }
+  }, [year, month]);
 
-    return Object.values(byCategory).sort(
-      (a, b) => b.収入 + b.支出 - (a.収入 + a.支出)
-    );
-  })();
+  const moveMonth = (delta: number) => {
+    const d = new Date(year, month - 1 + delta, 1);
+    setYear(d.getFullYear());
+    setMonth(d.getMonth() + 1);
+  };
+
+  const chartData = (summary?.categories ?? [])
+    .map((c) => ({
+      category: c.category_name || "その他",
+      収入: c.income,
+      支出: c.expense,
+    }))
+    .sort((a, b) => b.収入 + b.支出 - (a.収入 + a.支出));
 
   const formatYAxis = (value: number) => {
     if (value >= 10000) return `${value / 10000}万`;
@@ -81,15 +97,37 @@ export default function Home() {
     );
   }
 
-  const list = Array.isArray(transactions) ? transactions : [];
-  if (list.length === 0) {
+  const monthNav = (
+    <div className="flex items-center gap-2 text-sm">
+      <button
+        onClick={() => moveMonth(-1)}
+        className="rounded border border-slate-300 px-3 py-1"
+      >
+        前月
+      </button>
+      <span className="font-medium text-slate-700">
+        {year}年{month}月
+      </span>
+      <button
+        onClick={() => moveMonth(1)}
+        className="rounded border border-slate-300 px-3 py-1"
+      >
+        翌月
+      </button>
+    </div>
+  );
+
+  if (!summary || summary.count === 0) {
     return (
       <section className="rounded-lg bg-white p-8 shadow">
-        <h2 className="mb-4 text-xl font-semibold text-slate-700">
-          カテゴリ別 収支グラフ
-        </h2>
+        <div className="mb-4 flex items-center justify-between">
+          <h2 className="text-xl font-semibold text-slate-700">
+            カテゴリ別 収支グラフ
+          </h2>
+          {monthNav}
+        </div>
         <p className="text-slate-500">
-          データがありません。登録画面から収支を追加してください。
+          この月のデータがありません。登録画面から収支を追加してください。
         </p>
       </section>
     );
@@ -97,9 +135,12 @@ export default function Home() {
 
   return (
     <section className="space-y-8">
-      <h2 className="text-xl font-semibold text-slate-700">
-        カテゴリ別 収支グラフ
-      </h2>
+      <div className="flex items-center justify-between">
+        <h2 className="text-xl font-semibold text-slate-700">
+          カテゴリ別 収支グラフ
+        </h2>
+        {monthNav}
+      </div>
 
       <div className="rounded-lg bg-white p-6 shadow">
         <div className="mb-4 flex items-center justify-between">
@@ -134,26 +175,120 @@ export default function Home() {
         </div>
       </div>
 
+      {/* 予算と実績 */}
+      {budgets.length > 0 && (
+        <div className="rounded-lg bg-white p-6 shadow">
+          <h3 className="mb-4 text-lg font-medium text-slate-600">予算と実績</h3>
+          <table className="w-full text-left text-sm">
+            <thead>
+              <tr className="border-b border-slate-200 text-slate-600">
+                <th className="py-2 pr-4 font-medium">カテゴリ</th>
+                <th className="py-2 pr-4 font-medium">予算</th>
+                <th className="py-2 pr-4 font-medium">実績</th>
+                <th className="py-2 pr-4 font-medium">残り</th>
+                <th className="py-2 pr-4 font-medium">使用率</th>
+                <th className="py-2 font-medium">月末見込み</th>
+              </tr>
+            </thead>
+            <tbody>
+              {budgets.map((b) => (
+                <tr key={b.budget_id} className="border-b border-slate-100">
+                  <td className="py-2 pr-4">{b.category_name}</td>
+                  <td className="py-2 pr-4">¥{b.budget.toLocaleString()}</td>
+                  <td className="py-2 pr-4">¥{b.actual.toLocaleString()}</td>
+                  <td
+                    className={`py-2 pr-4 ${
+                      b.over_budget ? "font-medium text-rose-600" : ""
+                    }`}
+                  >
+                    ¥{b.remaining.toLocaleString()}
+                  </td>
+                  <td className="py-2 pr-4">
+                    <div className="h-2 w-24 rounded bg-slate-100">
+                      <div
+                        className={`h-2 rounded ${
+                          b.over_budget ? "bg-rose-500" : "bg-emerald-500"
+                        }`}
+                        style={{ width: `${Math.min(b.percent_used, 100)}%` }}
+                      />
+                    </div>
+                    <span className="text-xs text-slate-500">{b.percent_used}%</span>
+                  </td>
+                  <td
+                    className={`py-2 ${
+                      b.projected_over ? "font-medium text-rose-600" : ""
+                    }`}
+                  >
+                    ¥{b.projected.toLocaleString()}
+                    {b.projected_over && "（超過見込み）"}
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+
+      {/* タグ別集計（複数のタグが付いた収支はそれぞれのタグに数える） */}
+      {tagTotals.length > 0 && (
+        <div className="rounded-lg bg-white p-6 shadow">
+          <h3 className="mb-4 text-lg font-medium text-slate-600">タグ別</h3>
+          <table className="w-full text-left text-sm">
+            <thead>
+              <tr className="border-b border-slate-200 text-slate-600">
+                <th className="py-2 pr-4 font-medium">タグ</th>
+                <th className="py-2 pr-4 font-medium">収入</th>
+                <th className="py-2 pr-4 font-medium">支出</th>
+                <th className="py-2 font-medium">件数</th>
+              </tr>
+            </thead>
+            <tbody>
+              {tagTotals.map((t) => (
+                <tr key={t.tag} className="border-b border-slate-100">
+                  <td className="py-2 pr-4">#{t.tag}</td>
+                  <td className="py-2 pr-4">¥{t.income.toLocaleString()}</td>
+                  <td className="py-2 pr-4">¥{t.expense.toLocaleString()}</td>
+                  <td className="py-2">{t.count}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+
+      {/* 口座残高 */}
+      {accounts.length > 0 && (
+        <div className="rounded-lg bg-white p-6 shadow">
+          <h3 className="mb-4 text-lg font-medium text-slate-600">口座残高</h3>
+          <ul className="grid gap-2 sm:grid-cols-2 lg:grid-cols-4">
+            {accounts.map((a) => (
+              <li key={a.id} className="rounded border border-slate-100 p-3">
+                <p className="text-sm text-slate-500">{a.name}</p>
+                <p
+                  className={`text-lg font-semibold ${
+                    a.balance < 0 ? "text-rose-600" : "text-slate-700"
+                  }`}
+                >
+                  ¥{a.balance.toLocaleString()}
+                </p>
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       {/* サマリー */}
       <div className="grid gap-4 sm:grid-cols-2">
         <div className="rounded-lg bg-emerald-50 p-4 shadow">
           <p className="text-sm font-medium text-emerald-700">収入合計</p>
           <p className="text-2xl font-bold text-emerald-600">
-            ¥
-            {list
-              .filter((t) => t.type === "income" || t.amount > 0)
-              .reduce((sum, t) => sum + Math.abs(t.amount), 0)
-              .toLocaleString()}
+            ¥{summary.income.toLocaleString()}
           </p>
         </div>
         <div className="rounded-lg bg-rose-50 p-4 shadow">
           <p className="text-sm font-medium text-rose-700">支出合計</p>
           <p className="text-2xl font-bold text-rose-600">
-            ¥
-            {list
-              .filter((t) => t.type === "expense" || t.amount < 0)
-              .reduce((sum, t) => sum + Math.abs(t.amount), 0)
-              .toLocaleString()}
+            ¥{summary.expense.toLocaleString()}
           </p>
         </div>
       </div>
@@ -3,9 +3,16 @@
 import { useEffect, useState } from "react";
 import {
   createTransaction,
+  DuplicateTransactionError,
   getCategories,
+  getAccounts,
+  flattenCategories,
+  getTags,
+  parseTags,
+  type Account,
   type Category,
   type CreateTransactionRequest,
+  type SplitRequest,
 } from "@/lib/api";
 import { useRouter } from "next/navigation";
 
@@ -15,6 +22,7 @@ import { useRouter } from "next/navigation";
 export default function RegisterPage() {
   const router = useRouter();
   const [categories, setCategories] = useState<Category[]>([]);
+  const [accounts, setAccounts] = useState<Account[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
 
@@ -22,9 +30,13 @@ export default function RegisterPage() {
     date: new Date().toISOString().slice(0, 10),
     type: "expense",
     category_id: 0,
+    account_id: 1,
     amount: 0,
     memo: "",
   });
+  const [splits, setSplits] = useState<SplitRequest[]>([]);
+  const [tagText, setTagText] = useState("");
+  const [tagOptions, setTagOptions] = useState<string[]>([]);
   const [submitting, setSubmitting] = useState(false);
   const [submitError, setSubmitError] = useState<string | null>(null);
 
@@ -32,8 +44,17 @@ export default function RegisterPage() {
     const fetchCategories = async () => {
       try {
         setError(null);
-        const data = await getCategories();
-        setCategories(Array.isArray(data) ? data : []);
+        const [data, accountData, tags] = await Promise.all([getCategories(), getAccounts(), getTags("", 100)]);
+        setCategories(Array.isArray(data) ? flattenCategories(data) : []);
+        const loaded = Array.isArray(accountData) ? accountData : [];
+        setAccounts(loaded);
+        // 口座は家計簿ごとに分かれているので、家計簿にない口座が選ばれていれば先頭の口座にする
+        setForm((prev) =>
+          loaded.length === 0 || loaded.some((a) => a.id === prev.account_id)
+            ? prev
+            : { ...prev, account_id: loaded[0].id }
+        );
+        setTagOptions(Array.isArray(tags) ? tags.map((t) => t.name) : []);
       } catch (e) {
         setError(e instanceof Error ? e.message : "カテゴリの取得に失敗しました");
       } finally {
@@ -48,11 +69,25 @@ export default function RegisterPage() {
     setSubmitError(null);
     setSubmitting(true);
     try {
-      await createTransaction(form);
+      const data = form.type === "transfer" ? form : { ...form, tags: parseTags(tagText) };
+      const request =
+        data.type !== "transfer" && splits.length > 0 ? { ...data, category_id: 0, splits } : data;
+      try {
+        await createTransaction(request);
+      } catch (e) {
+        // 同じ日付・金額・メモ・口座の収支が登録済みの場合は、確認してから登録する
+        if (!(e instanceof DuplicateTransactionError)) throw e;
+        const memo = e.duplicates[0]?.memo ? `「${e.duplicates[0].memo}」` : "";
+        if (!confirm(`${e.message}${memo}。それでも登録しますか？`)) return;
+        await createTransaction(request, true);
+      }
+      setSplits([]);
+      setTagText("");
       setForm({
         date: new Date().toISOString().slice(0, 10),
         type: "expense",
         category_id: 0,
+        account_id: form.account_id,
         amount: 0,
         memo: "",
       });
@@ -64,6 +99,25 @@ export default function RegisterPage() {
     }
   };
 
+  // 内訳を1行変更します
+  const updateSplit = (index: number, patch: Partial<SplitRequest>) => {
+    setSplits((prev) => prev.map((s, i) => (i === index ? { ...s, ...patch } : s)));
+  };
+
+  // 内訳を使い始めるときは、選択中のカテゴリと金額を1行目にして2行から始める
+  const addSplit = () => {
+    setSplits((prev) =>
+      prev.length > 0
+        ? [...prev, { category_id: 0, amount: 0, memo: "" }]
+        : [
+            { category_id: form.category_id, amount: form.amount, memo: "" },
+            { category_id: 0, amount: 0, memo: "" },
+          ]
+    );
+  };
+
+  const splitTotal = splits.reduce((sum, s) => sum + s.amount, 0);
+
   if (loading) {
     return <p className="text-slate-500">読み込み中...</p>;
   }
@@ -84,7 +138,7 @@ export default function RegisterPage() {
     <section className="rounded-lg bg-white p-6 shadow">
       <h2 className="mb-6 text-xl font-semibold text-slate-700">新規登録</h2>
       <form onSubmit={handleSubmit} className="space-y-4">
-        <div className="grid gap-4 sm:grid-cols-2 lg:grid-cols-4">
+        <div className="grid gap-4 sm:grid-cols-2 lg:grid-cols-5">
           <div>
             <label className="mb-1 block text-sm text-slate-600">日付</label>
             <input
@@ -102,37 +156,89 @@ export default function RegisterPage() {
             <select
               value={form.type}
               onChange={(e) =>
+                // 種別を変えたらカテゴリを選び直す（種別に合わないカテゴリは登録できない）
                 setForm((prev) => ({
                   ...prev,
-                  type: e.target.value as "income" | "expense",
+                  type: e.target.value as "income" | "expense" | "transfer",
+                  category_id: 0,
                 }))
               }
               className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
             >
               <option value="expense">支出</option>
               <option value="income">収入</option>
+              <option value="transfer">振替</option>
             </select>
           </div>
+          {form.type !== "transfer" && splits.length === 0 && (
+            <div>
+              <label className="mb-1 block text-sm text-slate-600">カテゴリ</label>
+              <select
+                value={form.category_id}
+                onChange={(e) =>
+                  setForm((prev) => ({
+                    ...prev,
+                    category_id: parseInt(e.target.value, 10) || 0,
+                  }))
+                }
+                className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+              >
+                <option value={0}>選択してください</option>
+                {categories
+                  .filter((c) => c.kind === "both" || c.kind === form.type)
+                  .map((c) => (
+                  <option key={c.id} value={c.id} disabled={(c.children ?? []).length > 0}>
+                    {c.name}
+                  </option>
+                ))}
+              </select>
+            </div>
+          )}
           <div>
-            <label className="mb-1 block text-sm text-slate-600">カテゴリ</label>
+            <label className="mb-1 block text-sm text-slate-600">
+              {form.type === "transfer" ? "振替元" : "口座"}
+            </label>
             <select
-              value={form.category_id}
+              value={form.account_id}
               onChange={(e) =>
                 setForm((prev) => ({
                   ...prev,
-                  category_id: parseInt(e.target.value, 10) || 0,
+                  account_id: parseInt(e.target.value, 10) || 0,
                 }))
               }
               className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
             >
-              <option value={0}>選択してください</option>
-              {categories.map((c) => (
-                <option key={c.id} value={c.id}>
-                  {c.name}
+              {accounts.map((a) => (
+                <option key={a.id} value={a.id}>
+                  {a.name}
                 </option>
               ))}
             </select>
           </div>
+          {form.type === "transfer" && (
+            <div>
+              <label className="mb-1 block text-sm text-slate-600">振替先</label>
+              <select
+                value={form.to_account_id ?? 0}
+                onChange={(e) =>
+                  setForm((prev) => ({
+                    ...prev,
+                    to_account_id: parseInt(e.target.value, 10) || 0,
+                  }))
+                }
+                className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+              >
+                <option value={0}>選択してください</option>
+                {accounts
+                  .filter((a) => a.id !== form.account_id)
+                  .map((a) => (
+                  <option key={a.id} value={a.id}>
+                    {a.name}
+                  </option>
+                ))}
+              </select>
+            </div>
+          )}
           <div>
             <label className="mb-1 block text-sm text-slate-600">金額（円）</label>
             <input
@@ -162,6 +268,85 @@ export default function RegisterPage() {
             className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
           />
         </div>
+        {form.type !== "transfer" && (
+          <div>
+            <label className="mb-1 block text-sm text-slate-600">タグ</label>
+            <input
+              type="text"
+              list="tag-options"
+              placeholder="任意（カンマ区切り。例: 旅行2025, 子ども）"
+              value={tagText}
+              onChange={(e) => setTagText(e.target.value)}
+              className="w-full rounded border border-slate-300 px-3 py-2 focus:border-blue-500 focus:outline-none focus:ring-1 focus:ring-blue-500"
+            />
+            {/* 入力中のタグ（最後のカンマ以降）を候補で補完する */}
+            <datalist id="tag-options">
+              {tagOptions.map((name) => {
+                const typed = parseTags(tagText);
+                const head = tagText.includes(",") || tagText.includes("、")
+                  ? tagText.slice(0, Math.max(tagText.lastIndexOf(","), tagText.lastIndexOf("、")) + 1) + " "
+                  : "";
+                return typed.includes(name) ? null : <option key={name} value={head + name} />;
+              })}
+            </datalist>
+          </div>
+        )}
+        {form.type !== "transfer" && (
+          <div className="space-y-2">
+            {splits.map((s, i) => (
+              <div key={i} className="flex flex-wrap items-center gap-2">
+                <select
+                  value={s.category_id}
+                  onChange={(e) => updateSplit(i, { category_id: parseInt(e.target.value, 10) || 0 })}
+                  className="rounded border border-slate-300 px-3 py-2"
+                >
+                  <option value={0}>カテゴリ</option>
+                  {categories
+                    .filter((c) => c.kind === "both" || c.kind === form.type)
+                    .map((c) => (
+                    <option key={c.id} value={c.id} disabled={(c.children ?? []).length > 0}>
+                      {c.name}
+                    </option>
+                  ))}
+                </select>
+                <input
+                  type="number"
+                  min="1"
+                  placeholder="金額"
+                  value={s.amount || ""}
+                  onChange={(e) => updateSplit(i, { amount: parseInt(e.target.value, 10) || 0 })}
+                  className="w-32 rounded border border-slate-300 px-3 py-2"
+                />
+                <input
+                  type="text"
+                  placeholder="メモ（任意）"
+                  value={s.memo}
+                  onChange={(e) => updateSplit(i, { memo: e.target.value })}
+                  className="flex-1 rounded border border-slate-300 px-3 py-2"
+                />
+                <button
+                  type="button"
+                  onClick={() => setSplits((prev) => (prev.length <= 2 ? [] : prev.filter((_, j) => j !== i)))}
+                  className="text-sm text-red-600 hover:underline"
+                >
+                  削除
+                </button>
+              </div>
+            ))}
+            {splits.length > 0 && (
+              <p className={`text-sm ${splitTotal === form.amount ? "text-slate-500" : "text-red-600"}`}>
+                内訳の合計: {splitTotal.toLocaleString()}円 / 金額: {form.amount.toLocaleString()}円
+              </p>
+            )}
+            <button
+              type="button"
+              onClick={addSplit}
+              className="text-sm text-blue-600 hover:underline"
+            >
+              ＋ 内訳を追加（複数のカテゴリに分ける）
+            </button>
+          </div>
+        )}
         {submitError && (
           <p className="text-sm text-red-600">{submitError}</p>
         )}
@@ -1,35 +1,137 @@
 "use client";
 
-import { useEffect, useState } from "react";
+import { Fragment, useEffect, useState } from "react";
+import AttachmentPanel from "../components/AttachmentPanel";
 import {
   getTransactions,
+  exportTransactions,
   updateTransaction,
   deleteTransaction,
   getCategories,
+  getAccounts,
+  flattenCategories,
+  parseTags,
+  searchTransactions,
+  type ExportFormat,
+  type SearchHighlight,
+  type SearchResponse,
   type Transaction,
   type CreateTransactionRequest,
   type UpdateTransactionRequest,
   type Category,
+  type Account,
 } from "@/lib/api";
 
+const PAGE_SIZE = 50;
+
+// splitCategoryNames は一覧に表示するカテゴリ名です。内訳がある場合は内訳のカテゴリを並べます。
+function splitCategoryNames(t: Transaction): string {
+  if (t.splits?.length) {
+    return t.splits.map((s) => s.category?.name ?? "").join(" / ");
+  }
+  return t.category?.name ?? "";
+}
+
+// 書き出しの形式ごとのファイルの拡張子です。
+const EXPORT_EXTENSIONS: Record<ExportFormat, string> = {
+  csv: "csv",
+  "csv-sjis": "csv",
+  json: "json",
+  beancount: "beancount",
+  hledger: "journal",
+};
+
+const SEARCH_FIELD_LABELS: Record<SearchHighlight["field"], string> = {
+  memo: "メモ",
+  category: "カテゴリ",
+  split: "内訳",
+  tag: "タグ",
+};
+
+// Highlighted は検索の一致箇所を強調して表示します。ranges は文字（コードポイント）単位の位置です。
+function Highlighted({ highlight }: { highlight: SearchHighlight }) {
+  const chars = Array.from(highlight.text);
+  const parts: JSX.Element[] = [];
+  let pos = 0;
+  highlight.ranges.forEach((r, i) => {
+    parts.push(<span key={`t${i}`}>{chars.slice(pos, r.start).join("")}</span>);
+    parts.push(
+      <mark key={`m${i}`} className="bg-yellow-200">
+        {chars.slice(r.start, r.end).join("")}
+      </mark>
+    );
+    pos = r.end;
+  });
+  parts.push(<span key="rest">{chars.slice(pos).join("")}</span>);
+  return <>{parts}</>;
+}
+
 /**
- * 編集画面: 登録済み収支の一覧表示・編集・削除を提供します。
+ * 編集画面: 登録済み収支の一覧表示・編集・削除・キーワード検索を提供します。
  */
 export default function TransactionsPage() {
   const [transactions, setTransactions] = useState<Transaction[]>([]);
+  const [page, setPage] = useState(1);
+  const [total, setTotal] = useState(0);
   const [categories, setCategories] = useState<Category[]>([]);
+  const [accounts, setAccounts] = useState<Account[]>([]);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
   const [editingId, setEditingId] = useState<number | null>(null);
   const [editForm, setEditForm] = useState<UpdateTransactionRequest | null>(null);
   const [submitting, setSubmitting] = useState(false);
   const [actionError, setActionError] = useState<string | null>(null);
+  const [tagFilter, setTagFilter] = useState("");
+  const [tagMatch, setTagMatch] = useState<"any" | "all">("any");
+  const [editTags, setEditTags] = useState("");
+  const [searchQuery, setSearchQuery] = useState("");
+  const [searchResult, setSearchResult] = useState<SearchResponse | null>(null);
+  const [attachmentsId, setAttachmentsId] = useState<number | null>(null);
 
-  const fetchTransactions = async () => {
+  const handleSearch = async () => {
+    if (searchQuery.trim() === "") {
+      setSearchResult(null);
+      return;
+    }
+    try {
+      setActionError(null);
+      setSearchResult(await searchTransactions(searchQuery.trim()));
+    } catch (e) {
+      setActionError(e instanceof Error ? e.message : "検索に失敗しました");
+    }
+  };
+
+  // 絞り込み中のタグの条件で収支を書き出し、ファイルとして保存します
+  const handleExport = async (format: ExportFormat) => {
+    try {
+      setActionError(null);
+      const blob = await exportTransactions(
+        { tag: parseTags(tagFilter), tag_match: tagMatch },
+        format
+      );
+      const url = URL.createObjectURL(blob);
+      const a = document.createElement("a");
+      a.href = url;
+      a.download = `transactions.${EXPORT_EXTENSIONS[format]}`;
+      a.click();
+      URL.revokeObjectURL(url);
+    } catch (e) {
+      setActionError(e instanceof Error ? e.message : "書き出しに失敗しました");
+    }
+  };
+
+  const fetchTransactions = async (p: number = page) => {
     try {
       setError(null);
-      const data = await getTransactions();
-      setTransactions(Array.isArray(data) ? data : []);
+      const data = await getTransactions({
+        page: p,
+        limit: PAGE_SIZE,
+        tag: parseTags(tagFilter),
+        tag_match: tagMatch,
+      });
+      setTransactions(Array.isArray(data.transactions) ? data.transactions : []);
+      setTotal(data.total ?? 0);
+      setPage(p);
     } catch (e) {
       setError(e instanceof Error ? e.message : "データの取得に失敗しました");
     } finally {
@@ -39,8 +141,13 @@ export default function TransactionsPage() {
 
   const fetchCategories = async () => {
     try {
-      const data = await getCategories();
-      setCategories(Array.isArray(data) ? data : []);
+      // 過去の収支を編集できるよう、アーカイブ済みカテゴリも含めて取得
+      const [data, accountData] = await Promise.all([
+        getCategories(true),
+        getAccounts(true),
+      ]);
+      setCategories(Array.isArray(data) ? flattenCategories(data) : []);
+      setAccounts(Array.isArray(accountData) ? accountData : []);
     } catch {
       // カテゴリ取得失敗は編集に影響
     }
@@ -57,9 +164,17 @@ export default function TransactionsPage() {
       date: new Date(t.date).toISOString().slice(0, 10),
       type: t.type,
       category_id: t.category_id,
+      account_id: t.account_id,
       amount: Math.abs(t.amount),
       memo: t.memo,
+      payee: t.payee_name,
+      // 内訳はそのまま引き継ぐ（金額を変えた場合は内訳の合計と合わず登録できない）
+      splits: t.splits?.map((s) => ({ category_id: s.category_id, amount: Math.abs(s.amount), memo: s.memo })),
+      // 品目もそのまま引き継ぐ（金額を変えた場合は品目の合計と合わず登録できない）
+      items: t.items?.map((i) => ({ name: i.name, quantity: i.quantity, unit_price: i.unit_price })),
+      tags: t.tags,
     });
+    setEditTags((t.tags ?? []).join(", "));
     setActionError(null);
   };
 
@@ -74,7 +189,7 @@ export default function TransactionsPage() {
     setSubmitting(true);
     setActionError(null);
     try {
-      await updateTransaction(editingId, editForm);
+      await updateTransaction(editingId, { ...editForm, tags: parseTags(editTags) });
       await fetchTransactions();
       cancelEdit();
     } catch (e) {
@@ -85,13 +200,14 @@ export default function TransactionsPage() {
   };
 
   const handleDelete = async (id: number) => {
-    if (!confirm("この収支を削除しますか？")) return;
+    if (!confirm("この収支をゴミ箱に移しますか？（保存期間内は元に戻せます）")) return;
     setSubmitting(true);
     setActionError(null);
     try {
       await deleteTransaction(id);
       await fetchTransactions();
       if (editingId === id) cancelEdit();
+      if (attachmentsId === id) setAttachmentsId(null);
     } catch (e) {
       setActionError(e instanceof Error ? e.message : "削除に失敗しました");
     } finally {
@@ -99,6 +215,8 @@ export default function TransactionsPage() {
     }
   };
 
+  const totalPages = Math.max(1, Math.ceil(total / PAGE_SIZE));
+
   const formatAmount = (amount: number) => {
     const abs = Math.abs(amount);
     return `${amount >= 0 ? "+" : "-"}¥${abs.toLocaleString()}`;
@@ -128,6 +246,116 @@ export default function TransactionsPage() {
         <p className="mb-4 text-sm text-red-600">{actionError}</p>
       )}
 
+      <form
+        onSubmit={(e) => {
+          e.preventDefault();
+          handleSearch();
+        }}
+        className="mb-2 flex flex-wrap items-center gap-2 text-sm"
+      >
+        <input
+          type="search"
+          placeholder="メモ・カテゴリ・タグを検索"
+          value={searchQuery}
+          onChange={(e) => setSearchQuery(e.target.value)}
+          className="w-64 rounded border border-slate-300 px-2 py-1"
+        />
+        <button type="submit" className="text-blue-600 hover:underline">
+          検索
+        </button>
+        {searchResult && (
+          <button
+            type="button"
+            onClick={() => {
+              setSearchQuery("");
+              setSearchResult(null);
+            }}
+            className="text-slate-500 hover:underline"
+          >
+            クリア
+          </button>
+        )}
+      </form>
+
+      {searchResult && (
+        <div className="mb-6 rounded border border-slate-200 p-3 text-sm">
+          <p className="mb-2 text-slate-600">
+            「{searchResult.query}」の検索結果: {searchResult.total}件
+            {searchResult.total > searchResult.results.length &&
+              `（上位${searchResult.results.length}件を表示）`}
+          </p>
+          {searchResult.results.length === 0 ? (
+            <p className="text-slate-500">一致する収支はありません。</p>
+          ) : (
+            <ul className="space-y-2">
+              {searchResult.results.map((r) => (
+                <li key={r.transaction.id} className="border-b border-slate-100 pb-2">
+                  <span className="mr-3 text-slate-600">{r.transaction.date.slice(0, 10)}</span>
+                  <span
+                    className={`mr-3 font-medium ${
+                      r.transaction.amount >= 0 ? "text-green-600" : "text-red-600"
+                    }`}
+                  >
+                    {formatAmount(r.transaction.amount)}
+                  </span>
+                  {r.highlights.map((h, i) => (
+                    <span key={i} className="mr-3">
+                      <span className="mr-1 text-xs text-slate-400">
+                        {SEARCH_FIELD_LABELS[h.field]}
+                      </span>
+                      <Highlighted highlight={h} />
+                    </span>
+                  ))}
+                </li>
+              ))}
+            </ul>
+          )}
+        </div>
+      )}
+
+      <form
+        onSubmit={(e) => {
+          e.preventDefault();
+          fetchTransactions(1);
+        }}
+        className="mb-4 flex flex-wrap items-center gap-2 text-sm"
+      >
+        <input
+          type="text"
+          placeholder="タグで絞り込み（カンマ区切り）"
+          value={tagFilter}
+          onChange={(e) => setTagFilter(e.target.value)}
+          className="w-64 rounded border border-slate-300 px-2 py-1"
+        />
+        <select
+          value={tagMatch}
+          onChange={(e) => setTagMatch(e.target.value as "any" | "all")}
+          className="rounded border border-slate-300 px-2 py-1"
+        >
+          <option value="any">いずれかのタグ</option>
+          <option value="all">すべてのタグ</option>
+        </select>
+        <button type="submit" className="text-blue-600 hover:underline">
+          絞り込む
+        </button>
+        <span className="ml-auto text-slate-500">書き出し:</span>
+        <button type="button" onClick={() => handleExport("csv")} className="text-blue-600 hover:underline">
+          CSV
+        </button>
+        <button type="button" onClick={() => handleExport("csv-sjis")} className="text-blue-600 hover:underline">
+          CSV（Excel）
+        </button>
+        <button type="button" onClick={() => handleExport("json")} className="text-blue-600 hover:underline">
+          JSON
+        </button>
+        <button type="button" onClick={() => handleExport("beancount")} className="text-blue-600 hover:underline">
+          beancount
+        </button>
+        <button type="button" onClick={() => handleExport("hledger")} className="text-blue-600 hover:underline">
+          hledger
+        </button>
+      </form>
+
       {(Array.isArray(transactions) ? transactions : []).length === 0 ? (
         <p className="text-slate-500">データがありません。登録画面から追加してください。</p>
       ) : (
@@ -138,6 +366,7 @@ export default function TransactionsPage() {
                 <th className="py-2 pr-4 text-sm font-medium text-slate-600">日付</th>
                 <th className="py-2 pr-4 text-sm font-medium text-slate-600">種別</th>
                 <th className="py-2 pr-4 text-sm font-medium text-slate-600">カテゴリ</th>
+                <th className="py-2 pr-4 text-sm font-medium text-slate-600">口座</th>
                 <th className="py-2 pr-4 text-sm font-medium text-slate-600">金額</th>
                 <th className="py-2 pr-4 text-sm font-medium text-slate-600">メモ</th>
                 <th className="py-2 text-sm font-medium text-slate-600">操作</th>
@@ -145,152 +374,238 @@ export default function TransactionsPage() {
             </thead>
             <tbody>
               {(Array.isArray(transactions) ? transactions : []).map((t) => (
-                <tr key={t.id} className="border-b border-slate-100">
-                  {editingId === t.id && editForm ? (
-                    <>
-                      <td className="py-3 pr-4">
-                        <input
-                          type="date"
-                          value={editForm.date}
-                          onChange={(e) =>
-                            setEditForm((prev) =>
-                              prev ? { ...prev, date: e.target.value } : null
-                            )
-                          }
-                          className="w-full rounded border border-slate-300 px-2 py-1 text-sm"
-                        />
-                      </td>
-                      <td className="py-3 pr-4">
-                        <select
-                          value={editForm.type}
-                          onChange={(e) =>
-                            setEditForm((prev) =>
-                              prev
-                                ? { ...prev, type: e.target.value as "income" | "expense" }
-                                : null
-                            )
-                          }
-                          className="rounded border border-slate-300 px-2 py-1 text-sm"
-                        >
-                          <option value="expense">支出</option>
-                          <option value="income">収入</option>
-                        </select>
-                      </td>
-                      <td className="py-3 pr-4">
-                        <select
-                          value={editForm.category_id}
-                          onChange={(e) =>
-                            setEditForm((prev) =>
-                              prev
-                                ? {
-                                    ...prev,
-                                    category_id: parseInt(e.target.value, 10) || 0,
-                                  }
-                                : null
-                            )
-                          }
-                          className="rounded border border-slate-300 px-2 py-1 text-sm"
+                <Fragment key={t.id}>
+                  <tr className="border-b border-slate-100">
+                    {editingId === t.id && editForm ? (
+                      <>
+                        <td className="py-3 pr-4">
+                          <input
+                            type="date"
+                            value={editForm.date}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev ? { ...prev, date: e.target.value } : null
+                              )
+                            }
+                            className="w-full rounded border border-slate-300 px-2 py-1 text-sm"
+                          />
+                        </td>
+                        <td className="py-3 pr-4">
+                          <select
+                            value={editForm.type}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev
+                                  ? { ...prev, type: e.target.value as "income" | "expense" }
+                                  : null
+                              )
+                            }
+                            className="rounded border border-slate-300 px-2 py-1 text-sm"
+                          >
+                            <option value="expense">支出</option>
+                            <option value="income">収入</option>
+                          </select>
+                        </td>
+                        <td className="py-3 pr-4">
+                          {editForm.splits?.length ? (
+                            <span className="text-sm">{splitCategoryNames(t)}</span>
+                          ) : (
+                          <select
+                            value={editForm.category_id}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev
+                                  ? {
+                                      ...prev,
+                                      category_id: parseInt(e.target.value, 10) || 0,
+                                    }
+                                  : null
+                              )
+                            }
+                            className="rounded border border-slate-300 px-2 py-1 text-sm"
+                          >
+                            {categories
+                              .filter((c) => c.kind === "both" || c.kind === editForm.type)
+                              .map((c) => (
+                              <option key={c.id} value={c.id}>
+                                {c.name}
+                              </option>
+                            ))}
+                          </select>
+                          )}
+                        </td>
+                        <td className="py-3 pr-4">
+                          <select
+                            value={editForm.account_id}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev
+                                  ? {
+                                      ...prev,
+                                      account_id: parseInt(e.target.value, 10) || 0,
+                                    }
+                                  : null
+                              )
+                            }
+                            className="rounded border border-slate-300 px-2 py-1 text-sm"
+                          >
+                            {accounts.map((a) => (
+                              <option key={a.id} value={a.id}>
+                                {a.name}
+                              </option>
+                            ))}
+                          </select>
+                        </td>
+                        <td className="py-3 pr-4">
+                          <input
+                            type="number"
+                            min="1"
+                            value={editForm.amount || ""}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev
+                                  ? {
+                                      ...prev,
+                                      amount: parseInt(e.target.value, 10) || 0,
+                                    }
+                                  : null
+                              )
+                            }
+                            className="w-24 rounded border border-slate-300 px-2 py-1 text-sm"
+                          />
+                        </td>
+                        <td className="py-3 pr-4">
+                          <input
+                            type="text"
+                            value={editForm.memo}
+                            onChange={(e) =>
+                              setEditForm((prev) =>
+                                prev ? { ...prev, memo: e.target.value } : null
+                              )
+                            }
+                            className="w-full rounded border border-slate-300 px-2 py-1 text-sm"
+                          />
+                          <input
+                            type="text"
+                            placeholder="タグ（カンマ区切り）"
+                            value={editTags}
+                            onChange={(e) => setEditTags(e.target.value)}
+                            className="mt-1 w-full rounded border border-slate-300 px-2 py-1 text-sm"
+                          />
+                        </td>
+                        <td className="py-3">
+                          <button
+                            onClick={handleUpdate}
+                            disabled={submitting}
+                            className="mr-2 text-sm text-blue-600 hover:underline disabled:opacity-50"
+                          >
+                            保存
+                          </button>
+                          <button
+                            onClick={cancelEdit}
+                            disabled={submitting}
+                            className="mr-2 text-sm text-slate-600 hover:underline disabled:opacity-50"
+                          >
+                            キャンセル
+                          </button>
+                        </td>
+                      </>
+                    ) : (
+                      <>
+                        <td className="py-3 pr-4">
+                          {new Date(t.date).toLocaleDateString("ja-JP")}
+                        </td>
+                        <td className="py-3 pr-4">
+                          <span
+                            className={
+                              t.type === "income"
+                                ? "text-emerald-600"
+                                : t.type === "transfer"
+                                  ? "text-slate-600"
+                                  : "text-rose-600"
+                            }
+                          >
+                            {t.type === "income" ? "収入" : t.type === "transfer" ? "振替" : "支出"}
+                          </span>
+                        </td>
+                        <td className="py-3 pr-4">{splitCategoryNames(t)}</td>
+                        <td className="py-3 pr-4">
+                          {accounts.find((a) => a.id === t.account_id)?.name ?? ""}
+                        </td>
+                        <td
+                          className={`py-3 pr-4 font-medium ${
+                            t.amount >= 0 ? "text-emerald-600" : "text-rose-600"
+                          }`}
                         >
-                          {categories.map((c) => (
-                            <option key={c.id} value={c.id}>
-                              {c.name}
-                            </option>
+                          {formatAmount(t.amount)}
+                        </td>
+                        <td className="py-3 text-slate-600">
+                          {t.memo}
+                          {t.tags?.map((tag) => (
+                            <span key={tag} className="ml-1 rounded bg-slate-100 px-1.5 py-0.5 text-xs text-slate-500">
+                              #{tag}
+                            </span>
                           ))}
-                        </select>
-                      </td>
-                      <td className="py-3 pr-4">
-                        <input
-                          type="number"
-                          min="1"
-                          value={editForm.amount || ""}
-                          onChange={(e) =>
-                            setEditForm((prev) =>
-                              prev
-                                ? {
-                                    ...prev,
-                                    amount: parseInt(e.target.value, 10) || 0,
-                                  }
-                                : null
-                            )
-                          }
-                          className="w-24 rounded border border-slate-300 px-2 py-1 text-sm"
-                        />
-                      </td>
-                      <td className="py-3 pr-4">
-                        <input
-                          type="text"
-                          value={editForm.memo}
-                          onChange={(e) =>
-                            setEditForm((prev) =>
-                              prev ? { ...prev, memo: e.target.value } : null
-                            )
-                          }
-                          className="w-full rounded border border-slate-300 px-2 py-1 text-sm"
-                        />
-                      </td>
-                      <td className="py-3">
-                        <button
-                          onClick={handleUpdate}
-                          disabled={submitting}
-                          className="mr-2 text-sm text-blue-600 hover:underline disabled:opacity-50"
-                        >
-                          保存
-                        </button>
-                        <button
-                          onClick={cancelEdit}
-                          disabled={submitting}
-                          className="mr-2 text-sm text-slate-600 hover:underline disabled:opacity-50"
-                        >
-                          キャンセル
-                        </button>
-                      </td>
-                    </>
-                  ) : (
-                    <>
-                      <td className="py-3 pr-4">
-                        {new Date(t.date).toLocaleDateString("ja-JP")}
-                      </td>
-                      <td className="py-3 pr-4">
-                        <span
-                          className={
-                            t.type === "income"
-                              ? "text-emerald-600"
-                              : "text-rose-600"
-                          }
-                        >
-                          {t.type === "income" ? "収入" : "支出"}
-                        </span>
-                      </td>
-                      <td className="py-3 pr-4">{t.category?.name ?? ""}</td>
-                      <td
-                        className={`py-3 pr-4 font-medium ${
-                          t.amount >= 0 ? "text-emerald-600" : "text-rose-600"
-                        }`}
-                      >
-                        {formatAmount(t.amount)}
-                      </td>
-                      <td className="py-3 text-slate-600">{t.memo}</td>
-                      <td className="py-3">
-                        <button
-                          onClick={() => startEdit(t)}
-                          className="mr-3 text-sm text-blue-600 hover:underline"
-                        >
-                          編集
-                        </button>
-                        <button
-                          onClick={() => handleDelete(t.id)}
-                          disabled={submitting}
-                          className="text-sm text-red-600 hover:underline disabled:opacity-50"
-                        >
-                          削除
-                        </button>
+                        </td>
+                        <td className="py-3">
+                          {/* 振替は2行をまとめて扱うため、一覧では削除のみ（削除すると組の行も削除） */}
+                          {t.type !== "transfer" && (
+                            <button
+                              onClick={() => startEdit(t)}
+                              className="mr-3 text-sm text-blue-600 hover:underline"
+                            >
+                              編集
+                            </button>
+                          )}
+                          <button
+                            onClick={() => setAttachmentsId(attachmentsId === t.id ? null : t.id)}
+                            className="mr-3 text-sm text-slate-600 hover:underline"
+                          >
+                            添付
+                          </button>
+                          <button
+                            onClick={() => handleDelete(t.id)}
+                            disabled={submitting}
+                            className="text-sm text-red-600 hover:underline disabled:opacity-50"
+                          >
+                            削除
+                          </button>
+                        </td>
+                      </>
+                    )}
+                  </tr>
+                  {attachmentsId === t.id && (
+                    <tr className="border-b border-slate-100 bg-slate-50">
+                      <td colSpan={7} className="px-2 py-3">
+                        <AttachmentPanel transactionId={t.id} />
                       </td>
-                    </>
+                    </tr>
                   )}
-                </tr>
+                </Fragment>
               ))}
             </tbody>
           </table>
+          <div className="mt-4 flex items-center justify-between text-sm text-slate-600">
+            <span>
+              全{total}件（{page} / {totalPages} ページ）
+            </span>
+            <div className="space-x-2">
+              <button
+                onClick={() => fetchTransactions(page - 1)}
+                disabled={page <= 1 || submitting}
+                className="rounded border border-slate-300 px-3 py-1 disabled:opacity-50"
+              >
+                前へ
+              </button>
+              <button
+                onClick={() => fetchTransactions(page + 1)}
+                disabled={page >= totalPages || submitting}
+                className="rounded border border-slate-300 px-3 py-1 disabled:opacity-50"
+              >
+                次へ
+              </button>
+            </div>
+          </div>
         </div>
       )}
     </section>
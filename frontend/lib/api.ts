@@ -7,33 +7,350 @@
 export type Transaction = {
   id: number;
   date: string;
-  type: "income" | "expense";
+  type: "income" | "expense" | "transfer";
   category_id: number;
   category: Category;
+  account_id: number;
   amount: number;
   memo: string;
+  /** 支払先（支払先のない収支は省略） */
+  payee_id?: number;
+  payee_name?: string;
   created_at: string;
+  recurring_rule_id?: number;
+  transfer_id?: number;
+  /** 登録したメンバーのユーザーID */
+  created_by?: number;
+  /** 内訳（複数のカテゴリに分けた収支のみ） */
+  splits?: Split[];
+  tags?: string[];
+  /** 品目（支出のみ。数量 × 単価の合計は金額の絶対値と一致） */
+  items?: LineItem[];
+  /** ゴミ箱へ移した日時（ゴミ箱の収支のみ） */
+  deleted_at?: string;
+};
+
+/** 収支の内訳。金額は収支と同じく支出は負の値 */
+export type Split = {
+  id: number;
+  category_id: number;
+  category: Category;
+  amount: number;
+  memo: string;
+};
+
+/** 内訳の登録・更新リクエスト。金額は正の値 */
+export type SplitRequest = {
+  category_id: number;
+  amount: number;
+  memo: string;
+};
+
+/** 支出の品目（レシートの1行） */
+export type LineItem = {
+  id: number;
+  name: string;
+  quantity: number;
+  /** 税込の単価（正の値） */
+  unit_price: number;
+};
+
+/** 品目の登録・更新リクエスト。quantity の省略時は1 */
+export type LineItemRequest = {
+  name: string;
+  quantity?: number;
+  unit_price: number;
+};
+
+export type TransactionPage = {
+  transactions: Transaction[];
+  total: number;
+  page: number;
+  limit: number;
+};
+
+/** 全文検索で一致したフィールドの断片と一致箇所（文字単位の位置、end は含まない） */
+export type SearchHighlight = {
+  field: "memo" | "category" | "split" | "tag";
+  text: string;
+  ranges: { start: number; end: number }[];
+};
+
+export type SearchResult = {
+  transaction: Transaction;
+  score: number;
+  highlights: SearchHighlight[];
+};
+
+export type SearchResponse = {
+  query: string;
+  results: SearchResult[];
+  total: number;
+};
+
+export type TransactionQuery = {
+  from?: string;
+  to?: string;
+  type?: "income" | "expense" | "transfer";
+  account_id?: number;
+  category_id?: number[];
+  min_amount?: number;
+  max_amount?: number;
+  memo?: string;
+  payee_id?: number;
+  tag?: string[];
+  /** "any": いずれかのタグ（既定） / "all": すべてのタグ */
+  tag_match?: "any" | "all";
+  sort?: "date" | "amount" | "created_at";
+  order?: "asc" | "desc";
+  page?: number;
+  limit?: number;
+};
+
+export type Tag = {
+  name: string;
+  /** タグが付いている収支の件数 */
+  count: number;
+};
+
+export type TagTotal = {
+  tag: string;
+  income: number;
+  expense: number;
+  count: number;
+};
+
+export type TagReport = {
+  from: string;
+  to: string;
+  tags: TagTotal[];
+};
+
+export type Payee = {
+  id: number;
+  name: string;
+  /** 正規化した名前。同じ key の収支は同じ支払先になる */
+  key: string;
+  created_at: string;
+  /** 支払先の収支の件数と最後の収支の日付 */
+  count: number;
+  last_date?: string;
+};
+
+export type PayeeMonth = {
+  month: string;
+  income: number;
+  expense: number;
+  count: number;
+};
+
+export type PayeeTotal = {
+  payee_id: number;
+  payee_name: string;
+  income: number;
+  expense: number;
+  count: number;
+  first_date: string;
+  last_date: string;
+  /** 収支のある月ごとの内訳（古い順） */
+  months: PayeeMonth[];
+  count_per_month: number;
+  /** 収支の間隔の平均日数（2件以上の場合のみ） */
+  average_interval_days?: number;
+};
+
+export type PayeeReport = {
+  from: string;
+  to: string;
+  payees: PayeeTotal[];
+};
+
+export type ItemPurchase = {
+  transaction_id: number;
+  date: string;
+  payee_id?: number;
+  payee_name?: string;
+  name: string;
+  quantity: number;
+  unit_price: number;
+};
+
+/** 品目の1か月・1支払先の単価。average_unit_price は数量で重み付けした平均 */
+export type ItemPriceMonth = {
+  month: string;
+  payee_id?: number;
+  payee_name?: string;
+  count: number;
+  quantity: number;
+  average_unit_price: number;
+  min_unit_price: number;
+  max_unit_price: number;
+};
+
+export type ItemPriceHistory = {
+  item: string;
+  from: string;
+  to: string;
+  /** 購入（日付順） */
+  purchases: ItemPurchase[];
+  /** 月・支払先ごとの単価（月の古い順） */
+  months: ItemPriceMonth[];
+};
+
+export type CategoryTotal = {
+  category_id: number;
+  category_name: string;
+  income: number;
+  expense: number;
+  count: number;
+  children?: CategoryTotal[];
+};
+
+export type MonthlySummary = {
+  year: number;
+  month: number;
+  income: number;
+  expense: number;
+  balance: number;
+  count: number;
+  categories: CategoryTotal[];
+};
+
+export type BudgetStatus = {
+  budget_id: number;
+  category_id: number;
+  category_name: string;
+  month: string;
+  budget: number;
+  actual: number;
+  remaining: number;
+  percent_used: number;
+  projected: number;
+  over_budget: boolean;
+  projected_over: boolean;
+};
+
+export type BudgetStatusReport = {
+  month: string;
+  items: BudgetStatus[];
+};
+
+export type RecurringOccurrence = {
+  rule_id: number;
+  name: string;
+  scheduled_date: string;
+  date: string;
+  type: "income" | "expense";
+  category_id: number;
+  category_name: string;
+  amount: number;
+  memo: string;
 };
 
 export type Category = {
   id: number;
   name: string;
+  kind: "income" | "expense" | "both";
+  parent_id: number;
+  display_order: number;
+  archived: boolean;
+  children?: Category[];
+};
+
+export type Account = {
+  id: number;
+  name: string;
+  kind: "cash" | "bank" | "credit_card" | "e_money";
+  opening_balance: number;
+  display_order: number;
+  archived: boolean;
+  balance: number;
+};
+
+export type User = {
+  id: number;
+  email: string;
+  name: string;
+  /** いま利用している家計簿のID */
+  household_id: number;
+  created_at: string;
+};
+
+export type HouseholdRole = "owner" | "editor" | "viewer";
+
+export type Household = {
+  id: number;
+  name: string;
+  created_at: string;
+  /** この家計簿での自分の役割 */
+  role: HouseholdRole;
+};
+
+export type HouseholdMember = {
+  household_id: number;
+  user_id: number;
+  email: string;
+  name: string;
+  role: HouseholdRole;
+  joined_at: string;
+};
+
+export type HouseholdDetail = Household & {
+  members: HouseholdMember[];
+};
+
+export type Invitation = {
+  id: number;
+  household_id: number;
+  email: string;
+  role: HouseholdRole;
+  invited_by: number;
+  expires_at: string;
+  created_at: string;
+  /** 作成時のレスポンスにだけ含まれる招待トークン */
+  token?: string;
+};
+
+export type AuthResponse = {
+  user: User;
+  token: string;
+  expires_at: string;
 };
 
 export type CreateTransactionRequest = {
   date: string;
-  type: "income" | "expense";
+  type: "income" | "expense" | "transfer";
   category_id: number;
+  account_id: number;
+  /** type が "transfer" のときの振替先の口座ID */
+  to_account_id?: number;
   amount: number;
   memo: string;
+  /** 支払先の名前（振替では指定しない） */
+  payee?: string;
+  tags?: string[];
+  /** 内訳（2行以上、金額の合計は amount と一致） */
+  splits?: SplitRequest[];
+  /** 品目（支出のみ、数量 × 単価の合計は amount と一致） */
+  items?: LineItemRequest[];
 };
 
 export type UpdateTransactionRequest = {
   date: string;
-  type: "income" | "expense";
+  type: "income" | "expense" | "transfer";
   category_id: number;
+  account_id: number;
+  /** type が "transfer" のときの振替先の口座ID */
+  to_account_id?: number;
   amount: number;
   memo: string;
+  /** 支払先の名前（振替では指定しない） */
+  payee?: string;
+  tags?: string[];
+  /** 内訳（2行以上、金額の合計は amount と一致） */
+  splits?: SplitRequest[];
+  /** 品目（支出のみ、数量 × 単価の合計は amount と一致） */
+  items?: LineItemRequest[];
 };
 
 // ブラウザ: アクセス元ホスト＋:8080 でAPIに接続（WiFi/VPNどちらからも同じホストでアクセス可能）
@@ -46,30 +363,277 @@ function getApiBase(): string {
 }
 const API_BASE = getApiBase();
 
-export async function getTransactions(): Promise<Transaction[]> {
-  const res = await fetch(`${API_BASE}/api/transactions`);
+// apiFetch はセッション Cookie を付けてAPIを呼び出します。
+// 401（未ログイン・セッション切れ）の場合はログイン画面へ移動します。
+async function apiFetch(url: string, init: RequestInit = {}): Promise<Response> {
+  const res = await fetch(url, { ...init, credentials: "include" });
+  if (
+    res.status === 401 &&
+    typeof window !== "undefined" &&
+    !url.includes("/api/auth/") &&
+    window.location.pathname !== "/login"
+  ) {
+    window.location.href = "/login";
+  }
+  return res;
+}
+
+function toSearchParams(query: Record<string, unknown>): string {
+  const params = new URLSearchParams();
+  for (const [key, value] of Object.entries(query)) {
+    if (value === undefined || value === "") continue;
+    if (Array.isArray(value)) {
+      for (const v of value) params.append(key, String(v));
+    } else {
+      params.set(key, String(value));
+    }
+  }
+  const s = params.toString();
+  return s ? `?${s}` : "";
+}
+
+export async function getTransactions(
+  query: TransactionQuery = {}
+): Promise<TransactionPage> {
+  const res = await apiFetch(`${API_BASE}/api/transactions${toSearchParams(query)}`);
   if (!res.ok) {
     throw new Error(`収支データの取得に失敗しました: ${res.status}`);
   }
   return res.json();
 }
 
-export async function getCategories(): Promise<Category[]> {
-  const res = await fetch(`${API_BASE}/api/categories`);
+/**
+ * 書き出しの形式。"csv-sjis" は Excel で開ける Shift_JIS の CSV、
+ * "beancount"・"hledger" は複式簿記の仕訳帳です
+ */
+export type ExportFormat = "csv" | "csv-sjis" | "json" | "beancount" | "hledger";
+
+/**
+ * 一覧と同じ絞り込み条件の収支をファイルとして取得します（ページングはせず、日付の古い順）。
+ */
+export async function exportTransactions(
+  query: TransactionQuery,
+  format: ExportFormat
+): Promise<Blob> {
+  const params = toSearchParams({
+    ...query,
+    page: undefined,
+    limit: undefined,
+    format: format === "csv-sjis" ? "csv" : format,
+    encoding: format === "csv-sjis" ? "shift_jis" : undefined,
+  });
+  const res = await apiFetch(`${API_BASE}/api/export${params}`);
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `収支の書き出しに失敗しました: ${res.status}`);
+  }
+  return res.blob();
+}
+
+/** メモ・カテゴリ名・内訳・タグから収支を全文検索し、よく一致した順に取得します */
+export async function searchTransactions(q: string, limit = 20): Promise<SearchResponse> {
+  const res = await apiFetch(
+    `${API_BASE}/api/transactions/search${toSearchParams({ q, limit })}`
+  );
+  if (!res.ok) {
+    throw new Error(`収支の検索に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function getMonthlySummary(
+  year: number,
+  month: number
+): Promise<MonthlySummary> {
+  const res = await apiFetch(
+    `${API_BASE}/api/summary/monthly${toSearchParams({ year, month })}`
+  );
+  if (!res.ok) {
+    throw new Error(`月次集計の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** カンマ（、）区切りで入力されたタグを配列にします */
+export function parseTags(text: string): string[] {
+  return text
+    .split(/[,、，]/)
+    .map((t) => t.trim())
+    .filter((t) => t !== "");
+}
+
+/** タグの入力補完の候補を、付いている収支の多い順に取得します */
+export async function getTags(q = "", limit = 20): Promise<Tag[]> {
+  const res = await apiFetch(`${API_BASE}/api/tags${toSearchParams({ q, limit })}`);
+  if (!res.ok) {
+    throw new Error(`タグの取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** from〜to（YYYY-MM-DD）のタグ別の収入・支出の合計を取得します */
+export async function getTagReport(from: string, to: string): Promise<TagReport> {
+  const res = await apiFetch(`${API_BASE}/api/reports/tags${toSearchParams({ from, to })}`);
+  if (!res.ok) {
+    throw new Error(`タグ別集計の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** 支払先を収支の多い順に取得します。q を指定すると名前に q を含む支払先に絞り込みます */
+export async function getPayees(q = ""): Promise<Payee[]> {
+  const res = await apiFetch(`${API_BASE}/api/payees${toSearchParams({ q })}`);
+  if (!res.ok) {
+    throw new Error(`支払先の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function renamePayee(id: number, name: string): Promise<Payee> {
+  const res = await apiFetch(`${API_BASE}/api/payees/${id}`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `支払先の更新に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** payeeIds の支払先を id の支払先に統合します */
+export async function mergePayees(id: number, payeeIds: number[]): Promise<Payee> {
+  const res = await apiFetch(`${API_BASE}/api/payees/${id}/merge`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ payee_ids: payeeIds }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `支払先の統合に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** from〜to（YYYY-MM-DD、省略時は当月までの12か月）の支払先別の収入・支出の合計と月ごとの件数を取得します */
+export async function getPayeeReport(from = "", to = "", payeeId = 0): Promise<PayeeReport> {
+  const res = await apiFetch(
+    `${API_BASE}/api/reports/payees${toSearchParams({ from, to, payee_id: payeeId || undefined })}`
+  );
+  if (!res.ok) {
+    throw new Error(`支払先別集計の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function getPriceHistory(
+  item: string,
+  from = "",
+  to = "",
+  payeeId = 0
+): Promise<ItemPriceHistory> {
+  const res = await apiFetch(
+    `${API_BASE}/api/reports/prices${toSearchParams({ item, from, to, payee_id: payeeId || undefined })}`
+  );
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `価格の推移の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function getUpcomingRecurring(
+  days = 30
+): Promise<RecurringOccurrence[]> {
+  const res = await apiFetch(
+    `${API_BASE}/api/recurring/upcoming${toSearchParams({ days })}`
+  );
+  if (!res.ok) {
+    throw new Error(`定期収支の予定の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function getBudgetStatus(
+  month: string
+): Promise<BudgetStatusReport> {
+  const res = await apiFetch(
+    `${API_BASE}/api/budgets/status${toSearchParams({ month })}`
+  );
+  if (!res.ok) {
+    throw new Error(`予算実績の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+// カテゴリの木を、親の直後に子が並ぶ平らな一覧に変換します（select 表示用）。
+// 子カテゴリの name には親カテゴリ名を前置します。
+export function flattenCategories(tree: Category[]): Category[] {
+  const result: Category[] = [];
+  for (const parent of tree) {
+    result.push(parent);
+    for (const child of parent.children ?? []) {
+      result.push({ ...child, name: `${parent.name} > ${child.name}` });
+    }
+  }
+  return result;
+}
+
+// カテゴリを木構造（最上位カテゴリの children に子カテゴリ）で取得します。
+export async function getCategories(
+  includeArchived = false
+): Promise<Category[]> {
+  const res = await apiFetch(
+    `${API_BASE}/api/categories${includeArchived ? "?include_archived=true" : ""}`
+  );
   if (!res.ok) {
     throw new Error(`カテゴリの取得に失敗しました: ${res.status}`);
   }
   return res.json();
 }
 
+// 口座を現在の残高付きで取得します。
+export async function getAccounts(
+  includeArchived = false
+): Promise<Account[]> {
+  const res = await apiFetch(
+    `${API_BASE}/api/accounts${includeArchived ? "?include_archived=true" : ""}`
+  );
+  if (!res.ok) {
+    throw new Error(`口座の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** 登録済みの収支と重複する収支を登録しようとしたときのエラー。duplicates は重複する登録済みの収支 */
+export class DuplicateTransactionError extends Error {
+  constructor(message: string, public duplicates: Transaction[]) {
+    super(message);
+  }
+}
+
+/**
+ * 収支を登録します。日付・金額・メモ・口座が同じ収支が登録済みの場合は DuplicateTransactionError を投げ、
+ * allowDuplicate を true にすると重複していても登録します。
+ */
 export async function createTransaction(
-  data: CreateTransactionRequest
+  data: CreateTransactionRequest,
+  allowDuplicate = false
 ): Promise<Transaction> {
-  const res = await fetch(`${API_BASE}/api/transactions`, {
+  const query = allowDuplicate ? "?allow_duplicate=true" : "";
+  const res = await apiFetch(`${API_BASE}/api/transactions${query}`, {
     method: "POST",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(data),
   });
+  if (res.status === 409) {
+    const err = await res.json().catch(() => ({}));
+    throw new DuplicateTransactionError(
+      err.error ?? "同じ収支が登録済みです",
+      Array.isArray(err.duplicates) ? err.duplicates : []
+    );
+  }
   if (!res.ok) {
     const err = await res.json().catch(() => ({}));
     throw new Error(
@@ -83,7 +647,7 @@ export async function updateTransaction(
   id: number,
   data: UpdateTransactionRequest
 ): Promise<Transaction> {
-  const res = await fetch(`${API_BASE}/api/transactions/${id}`, {
+  const res = await apiFetch(`${API_BASE}/api/transactions/${id}`, {
     method: "PUT",
     headers: { "Content-Type": "application/json" },
     body: JSON.stringify(data),
@@ -100,7 +664,7 @@ export async function updateTransaction(
 export async function deleteTransaction(
   id: number
 ): Promise<null> {
-  const res = await fetch(`${API_BASE}/api/transactions/${id}`, {
+  const res = await apiFetch(`${API_BASE}/api/transactions/${id}`, {
     method: "DELETE",
   });
   if (!res.ok) {
@@ -110,4 +674,553 @@ export async function deleteTransaction(
     );
   }
   return null;
-}
\ No newline at end of file
+}
+
+/** ゴミ箱の収支。purge_at を過ぎると完全に削除される */
+export type TrashedTransaction = Transaction & {
+  deleted_at: string;
+  purge_at: string;
+};
+
+export type Trash = {
+  /** ゴミ箱の収支を完全に削除するまでの日数 */
+  retention_days: number;
+  transactions: TrashedTransaction[];
+};
+
+export async function getTrash(): Promise<Trash> {
+  const res = await apiFetch(`${API_BASE}/api/trash`);
+  if (!res.ok) {
+    throw new Error(`ゴミ箱の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** ゴミ箱の収支を元に戻す（振替は2行とも戻る） */
+export async function restoreTransaction(id: number): Promise<Transaction> {
+  const res = await apiFetch(`${API_BASE}/api/transactions/${id}/restore`, {
+    method: "POST",
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `収支の復元に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+/** 収支に添付したレシート・書類（画像または PDF） */
+export type Attachment = {
+  id: number;
+  transaction_id: number;
+  file_name: string;
+  content_type: string;
+  /** バイト数 */
+  size: number;
+  /** JPEG・PNG・GIF はサムネイルがある */
+  has_thumbnail: boolean;
+  created_by?: number;
+  created_at: string;
+};
+
+/** 添付できるファイルの種類（input の accept 用） */
+export const ATTACHMENT_ACCEPT = "image/jpeg,image/png,image/gif,image/webp,application/pdf";
+
+export async function getAttachments(transactionId: number): Promise<Attachment[]> {
+  const res = await apiFetch(`${API_BASE}/api/transactions/${transactionId}/attachments`);
+  if (!res.ok) {
+    throw new Error(`添付ファイルの取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** 収支にファイルを添付します。画像（JPEG・PNG・GIF・WebP）と PDF で 10MB まで */
+export async function uploadAttachment(transactionId: number, file: File): Promise<Attachment> {
+  const form = new FormData();
+  form.append("file", file);
+  const res = await apiFetch(`${API_BASE}/api/transactions/${transactionId}/attachments`, {
+    method: "POST",
+    body: form,
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `ファイルの添付に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function deleteAttachment(id: number): Promise<null> {
+  const res = await apiFetch(`${API_BASE}/api/attachments/${id}`, {
+    method: "DELETE",
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `添付ファイルの削除に失敗しました: ${res.status}`);
+  }
+  return null;
+}
+
+/** 添付ファイルを開く URL（download が true の場合はダウンロードさせる）。ログインのクッキーで認証します */
+export function attachmentUrl(id: number, download = false): string {
+  return `${API_BASE}/api/attachments/${id}${download ? "?download=true" : ""}`;
+}
+
+/** 添付した画像のサムネイルの URL */
+export function attachmentThumbnailUrl(id: number): string {
+  return `${API_BASE}/api/attachments/${id}/thumbnail`;
+}
+
+/** 明細 CSV の列と収支の対応。列は見出しの列名か "1" から始まる列番号 */
+export type CSVImportProfile = {
+  encoding?: "auto" | "utf-8" | "shift_jis";
+  delimiter?: string;
+  skip_rows?: number;
+  has_header?: boolean;
+  date_column: string;
+  date_format?: string;
+  amount_column?: string;
+  amount_positive?: "income" | "expense";
+  debit_column?: string;
+  credit_column?: string;
+  memo_columns?: string[];
+  /** 省略時は支払先を設定しない */
+  payee_column?: string;
+  expense_category_id?: number;
+  income_category_id?: number;
+  account_id?: number;
+};
+
+/** 取り込むファイルの1行を変換した結果。error がある行は登録しない */
+export type ImportRow = {
+  line: number;
+  date?: string;
+  /** "transfer"（振替）は仕訳帳の取り込みのみ */
+  type?: "income" | "expense" | "transfer";
+  category_id?: number;
+  amount?: number;
+  memo?: string;
+  /** 正規化した支払先の名前 */
+  payee?: string;
+  /** 取り込み元のカテゴリ名（Zaim・Money Forward ME・仕訳帳） */
+  source_category?: string;
+  /** 取り込み元の口座名と口座（仕訳帳のみ。振替では振替元） */
+  source_account?: string;
+  account_id?: number;
+  /** 振替先の口座名と口座（仕訳帳の振替のみ） */
+  source_to_account?: string;
+  to_account_id?: number;
+  /** 内訳（仕訳帳で1件の取引に複数のカテゴリがある場合） */
+  splits?: { source_category: string; category_id?: number; amount: number; memo?: string }[];
+  tags?: string[];
+  transaction_id?: number;
+  /** 重複する登録済みの収支のID */
+  duplicate_of?: number;
+  /** 一致した仕分けルールのID（適用した順） */
+  rule_ids?: number[];
+  error?: string;
+};
+
+/** 対応するカテゴリが見つからなかった取り込み元のカテゴリ名と、その行数 */
+export type UnmappedCategory = {
+  name: string;
+  type: "income" | "expense";
+  count: number;
+};
+
+export type ImportResult = {
+  dry_run: boolean;
+  total: number;
+  valid: number;
+  skipped: number;
+  imported: number;
+  duplicates: number;
+  rows: ImportRow[];
+  unmapped?: UnmappedCategory[];
+};
+
+/** 他の家計簿アプリの取り込み元。"ledger" は beancount・hledger の仕訳帳 */
+export type ImportSource = "zaim" | "moneyforward" | "ledger";
+
+/** 取り込み元のカテゴリ名（"大項目/中項目" または "大項目"）と、この家計簿のカテゴリの対応 */
+export type CategoryMapping = {
+  name: string;
+  category_id: number;
+};
+
+/**
+ * 明細の CSV を取り込みます。dryRun が true の場合は登録せずに変換結果だけを返します。
+ * allowDuplicates が false の場合、登録済みの収支と重複する行は登録しません。
+ */
+export async function importCSV(
+  file: File,
+  profile: CSVImportProfile,
+  dryRun: boolean,
+  allowDuplicates = false
+): Promise<ImportResult> {
+  const body = new FormData();
+  body.append("file", file);
+  body.append("profile", JSON.stringify(profile));
+  body.append("dry_run", String(dryRun));
+  body.append("allow_duplicates", String(allowDuplicates));
+  const res = await apiFetch(`${API_BASE}/api/import/csv`, { method: "POST", body });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `CSV の取り込みに失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/**
+ * Zaim・Money Forward ME の CSV、beancount・hledger の仕訳帳を取り込みます。
+ * カテゴリはカテゴリの対応か同じ名前のカテゴリに変換します。仕訳帳の口座は同じ名前の口座に変換します。
+ */
+export async function importApp(
+  source: ImportSource,
+  file: File,
+  accountId: number,
+  dryRun: boolean,
+  allowDuplicates = false
+): Promise<ImportResult> {
+  const body = new FormData();
+  body.append("file", file);
+  body.append("account_id", String(accountId));
+  body.append("dry_run", String(dryRun));
+  body.append("allow_duplicates", String(allowDuplicates));
+  const res = await apiFetch(`${API_BASE}/api/import/${source}`, { method: "POST", body });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `ファイルの取り込みに失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function getCategoryMappings(source: ImportSource): Promise<CategoryMapping[]> {
+  const res = await apiFetch(`${API_BASE}/api/import/mappings/${source}`);
+  if (!res.ok) {
+    throw new Error(`カテゴリの対応の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/**
+ * 取り込み元のカテゴリの対応を mappings で置き換えます。category_id が0の対応は削除されます。
+ */
+export async function updateCategoryMappings(
+  source: ImportSource,
+  mappings: CategoryMapping[]
+): Promise<CategoryMapping[]> {
+  const res = await apiFetch(`${API_BASE}/api/import/mappings/${source}`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(mappings),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `カテゴリの対応の保存に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** 収支のカテゴリ・タグ・メモを自動で設定する仕分けルール。条件はすべて一致した収入・支出に適用する */
+export type CategoryRule = {
+  id: number;
+  name: string;
+  /** 小さいほど先に適用 */
+  priority: number;
+  enabled: boolean;
+  /** 空はどちらも */
+  type: "" | "income" | "expense";
+  memo_contains: string;
+  memo_pattern: string;
+  min_amount: number | null;
+  max_amount: number | null;
+  /** 0 はすべての口座 */
+  account_id: number;
+  /** 0（日曜日）〜6（土曜日）。空はすべての曜日 */
+  weekdays: number[];
+  /** 0 はカテゴリを変更しない */
+  category_id: number;
+  tags: string[];
+  /** 空はメモを変更しない。memo_pattern のグループを $1 などで使える */
+  memo_rewrite: string;
+  created_at: string;
+};
+
+export type CategoryRuleRequest = Partial<Omit<CategoryRule, "id" | "created_at">> & {
+  name: string;
+};
+
+/** 収支に仕分けルールを適用した結果。transaction は適用前の収支 */
+export type RuleChange = {
+  transaction: Transaction;
+  category_id: number;
+  memo: string;
+  tags: string[] | null;
+  rule_ids: number[];
+  changed: boolean;
+};
+
+export type RuleApplyResult = {
+  dry_run: boolean;
+  checked: number;
+  matched: number;
+  updated: number;
+  changes: RuleChange[];
+};
+
+export async function getRules(): Promise<CategoryRule[]> {
+  const res = await apiFetch(`${API_BASE}/api/rules`);
+  if (!res.ok) {
+    throw new Error(`仕分けルールの取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/** id を指定すると更新、省略すると登録します */
+export async function saveRule(data: CategoryRuleRequest, id?: number): Promise<CategoryRule> {
+  const res = await apiFetch(`${API_BASE}/api/rules${id ? `/${id}` : ""}`, {
+    method: id ? "PUT" : "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(data),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `仕分けルールの保存に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+export async function deleteRule(id: number): Promise<null> {
+  const res = await apiFetch(`${API_BASE}/api/rules/${id}`, { method: "DELETE" });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `仕分けルールの削除に失敗しました: ${res.status}`);
+  }
+  return null;
+}
+
+/** 保存前のルールを from〜to（YYYY-MM-DD、省略時はすべて）の収支で試します。収支は更新しません */
+export async function testRule(
+  data: CategoryRuleRequest,
+  from = "",
+  to = ""
+): Promise<RuleApplyResult> {
+  const res = await apiFetch(`${API_BASE}/api/rules/test${toSearchParams({ from, to })}`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(data),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `仕分けルールの試用に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+/**
+ * from〜to の収支に仕分けルールを適用し直します。ruleIds を省略すると有効なすべてのルールを適用し、
+ * dryRun が true の場合は収支を更新せずに結果だけを返します。
+ */
+export async function applyRules(
+  from: string,
+  to: string,
+  dryRun: boolean,
+  ruleIds: number[] = []
+): Promise<RuleApplyResult> {
+  const res = await apiFetch(`${API_BASE}/api/rules/apply`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ from, to, rule_ids: ruleIds, dry_run: dryRun }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(err.error ?? `仕分けルールの適用に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+// ユーザーを登録し、そのままログインします（セッションは Cookie に保存されます）。
+export async function register(
+  email: string,
+  password: string,
+  name: string
+): Promise<AuthResponse> {
+  const res = await apiFetch(`${API_BASE}/api/auth/register`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email, password, name }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `ユーザー登録に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+export async function login(
+  email: string,
+  password: string
+): Promise<AuthResponse> {
+  const res = await apiFetch(`${API_BASE}/api/auth/login`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email, password }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `ログインに失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+export async function logout(): Promise<null> {
+  const res = await apiFetch(`${API_BASE}/api/auth/logout`, {
+    method: "POST",
+  });
+  if (!res.ok && res.status !== 401) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `ログアウトに失敗しました: ${res.status}`
+    );
+  }
+  return null;
+}
+
+// ログイン中のユーザーを返します。未ログインの場合は null を返します。
+export async function getMe(): Promise<User | null> {
+  const res = await apiFetch(`${API_BASE}/api/auth/me`);
+  if (res.status === 401) {
+    return null;
+  }
+  if (!res.ok) {
+    throw new Error(`ユーザーの取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+// 参加している家計簿を役割付きで返します。
+export async function getHouseholds(): Promise<Household[]> {
+  const res = await apiFetch(`${API_BASE}/api/households`);
+  if (!res.ok) {
+    throw new Error(`家計簿の取得に失敗しました: ${res.status}`);
+  }
+  return res.json();
+}
+
+// 家計簿を作成します。copyCategoriesFrom を指定するとその家計簿のカテゴリをコピーします。
+export async function createHousehold(
+  name: string,
+  copyCategoriesFrom?: number
+): Promise<Household> {
+  const res = await apiFetch(`${API_BASE}/api/households`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ name, copy_categories_from: copyCategoriesFrom }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `家計簿の作成に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+// 利用する家計簿を切り替えます。
+export async function selectHousehold(householdId: number): Promise<Household[]> {
+  const res = await apiFetch(`${API_BASE}/api/households/current`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ household_id: householdId }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `家計簿の切り替えに失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+// 利用中の家計簿とメンバー一覧を返します。
+export async function getHousehold(): Promise<HouseholdDetail> {
+  const res = await apiFetch(`${API_BASE}/api/household`);
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `家計簿の取得に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+export async function updateMemberRole(
+  userId: number,
+  role: HouseholdRole
+): Promise<HouseholdMember> {
+  const res = await apiFetch(`${API_BASE}/api/household/members/${userId}`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ role }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `メンバーの更新に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+// メンバーを家計簿から外します。自分のIDを指定すると家計簿から抜けます。
+export async function removeMember(userId: number): Promise<null> {
+  const res = await apiFetch(`${API_BASE}/api/household/members/${userId}`, {
+    method: "DELETE",
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `メンバーの削除に失敗しました: ${res.status}`
+    );
+  }
+  return null;
+}
+
+export async function createInvitation(
+  email: string,
+  role: HouseholdRole
+): Promise<Invitation> {
+  const res = await apiFetch(`${API_BASE}/api/household/invitations`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ email, role }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `招待の作成に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}
+
+// 招待を受けて家計簿に参加します。参加した家計簿がいま利用している家計簿になります。
+export async function acceptInvitation(token: string): Promise<Household[]> {
+  const res = await apiFetch(`${API_BASE}/api/invitations/accept`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ token }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error(
+      err.error ?? `家計簿への参加に失敗しました: ${res.status}`
+    );
+  }
+  return res.json();
+}